@@ -0,0 +1,33 @@
+/* C bindings for srgn, a code surgeon. See README.md for usage. */
+
+#ifndef SRGN_H
+#define SRGN_H
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/*
+ * Process `input` according to `config_json`, returning the result.
+ *
+ * `config_json` may be NULL, meaning the default configuration (which leaves input
+ * unchanged). On error, NULL is returned, and the error message is available via
+ * `srgn_last_error`. A returned string must be released using `srgn_free`.
+ */
+char *srgn_process(const char *input, const char *config_json);
+
+/* Release a string returned by `srgn_process`. Passing NULL is a no-op. */
+void srgn_free(char *s);
+
+/*
+ * The message of the last error which occurred on the calling thread, or NULL if the
+ * last call succeeded. Owned by the library; valid until the next call into it on the
+ * same thread.
+ */
+const char *srgn_last_error(void);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* SRGN_H */
@@ -0,0 +1,115 @@
+//! C bindings for srgn, for embedding from any language with a C FFI.
+//!
+//! See `include/srgn.h` for the interface. Pipelines are configured using JSON, with
+//! keys as in [`PipelineConfig`].
+
+#![warn(clippy::all)]
+#![warn(clippy::pedantic)]
+#![deny(unsafe_op_in_unsafe_fn)]
+
+use srgn_core::pipeline::{Pipeline, PipelineConfig};
+use std::{
+    cell::RefCell,
+    ffi::{c_char, CStr, CString},
+    panic, ptr,
+};
+
+thread_local! {
+    /// Message of the last error which occurred on this thread, if any.
+    static LAST_ERROR: RefCell<Option<CString>> = RefCell::new(None);
+}
+
+/// Process `input` according to `config_json`, returning the result.
+///
+/// `config_json` may be `NULL`, meaning the default configuration (which leaves input
+/// unchanged). On error, `NULL` is returned, and the error message is available via
+/// [`srgn_last_error`]. A returned string must be released using [`srgn_free`].
+///
+/// # Safety
+///
+/// `input` and, if not `NULL`, `config_json` must point to valid, NUL-terminated
+/// strings.
+#[no_mangle]
+pub unsafe extern "C" fn srgn_process(
+    input: *const c_char,
+    config_json: *const c_char,
+) -> *mut c_char {
+    let result = panic::catch_unwind(|| {
+        // SAFETY: upheld by the caller.
+        let input = unsafe { to_str(input, "input")? };
+        let config = unsafe { to_config(config_json)? };
+
+        let pipeline = Pipeline::try_from(&config).map_err(|e| e.to_string())?;
+
+        CString::new(pipeline.run(input)).map_err(|e| format!("Output contains NUL: {e}"))
+    })
+    .unwrap_or_else(|_| Err("Panicked while processing".to_string()));
+
+    match result {
+        Ok(output) => {
+            set_last_error(None);
+            output.into_raw()
+        }
+        Err(e) => {
+            set_last_error(Some(e));
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Release a string returned by [`srgn_process`]. Passing `NULL` is a no-op.
+///
+/// # Safety
+///
+/// `s` must have been returned by [`srgn_process`], and not been released yet.
+#[no_mangle]
+pub unsafe extern "C" fn srgn_free(s: *mut c_char) {
+    if !s.is_null() {
+        // SAFETY: upheld by the caller; the string was created by `CString::into_raw`.
+        drop(unsafe { CString::from_raw(s) });
+    }
+}
+
+/// The message of the last error which occurred on the calling thread, or `NULL` if
+/// the last call succeeded.
+///
+/// The returned string is owned by the library and valid until the next call into it
+/// on the same thread; it must not be released.
+#[no_mangle]
+pub extern "C" fn srgn_last_error() -> *const c_char {
+    LAST_ERROR.with(|e| e.borrow().as_ref().map_or(ptr::null(), |e| e.as_ptr()))
+}
+
+fn set_last_error(message: Option<String>) {
+    let message =
+        message.map(|m| CString::new(m.replace('\0', "\\0")).expect("NUL bytes were replaced"));
+
+    LAST_ERROR.with(|e| *e.borrow_mut() = message);
+}
+
+/// # Safety
+///
+/// `s` must be `NULL`, or point to a valid, NUL-terminated string.
+unsafe fn to_str<'a>(s: *const c_char, name: &str) -> Result<&'a str, String> {
+    if s.is_null() {
+        return Err(format!("{name} is NULL"));
+    }
+
+    // SAFETY: upheld by the caller.
+    unsafe { CStr::from_ptr(s) }
+        .to_str()
+        .map_err(|e| format!("{name} is not valid UTF-8: {e}"))
+}
+
+/// # Safety
+///
+/// `config_json` must be `NULL`, or point to a valid, NUL-terminated string.
+unsafe fn to_config(config_json: *const c_char) -> Result<PipelineConfig, String> {
+    if config_json.is_null() {
+        return Ok(PipelineConfig::default());
+    }
+
+    // SAFETY: upheld by the caller.
+    let json = unsafe { to_str(config_json, "config_json")? };
+    serde_json::from_str(json).map_err(|e| format!("Invalid configuration: {e}"))
+}
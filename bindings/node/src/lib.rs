@@ -0,0 +1,169 @@
+//! Node.js bindings for srgn.
+//!
+//! Mirrors the library's [`Pipeline`]: inputs are processed synchronously, and matches
+//! are reported as plain objects.
+
+#![warn(clippy::all)]
+#![warn(clippy::pedantic)]
+
+use napi::{Error, Result};
+use napi_derive::napi;
+use srgn_core::pipeline::{Pipeline, PipelineConfig};
+
+/// Configuration of a pipeline. Keys correspond to the CLI's options of the same name.
+#[napi(object)]
+#[derive(Debug, Default)]
+pub struct Config {
+    pub language: Option<String>,
+    pub query: Option<String>,
+    pub custom_query: Option<String>,
+    pub scope: Option<String>,
+    pub literal_string: Option<bool>,
+    pub ignore_case: Option<bool>,
+    pub replace: Option<String>,
+    pub squeeze: Option<bool>,
+    pub german: Option<bool>,
+    pub symbols: Option<bool>,
+    pub delete: Option<bool>,
+    pub upper: Option<bool>,
+    pub lower: Option<bool>,
+    pub titlecase: Option<bool>,
+    pub normalize: Option<bool>,
+}
+
+impl From<Config> for PipelineConfig {
+    fn from(config: Config) -> Self {
+        Self {
+            language: config.language,
+            query: config.query,
+            custom_query: config.custom_query,
+            scope: config.scope,
+            literal_string: config.literal_string.unwrap_or_default(),
+            ignore_case: config.ignore_case.unwrap_or_default(),
+            replace: config.replace,
+            squeeze: config.squeeze.unwrap_or_default(),
+            german: config.german.unwrap_or_default(),
+            symbols: config.symbols.unwrap_or_default(),
+            delete: config.delete.unwrap_or_default(),
+            upper: config.upper.unwrap_or_default(),
+            lower: config.lower.unwrap_or_default(),
+            titlecase: config.titlecase.unwrap_or_default(),
+            normalize: config.normalize.unwrap_or_default(),
+        }
+    }
+}
+
+/// A part of some input found to be in scope.
+///
+/// Offsets are in UTF-16 code units, like JavaScript string indices, so
+/// `input.slice(m.start, m.end) === m.text`.
+#[napi(object)]
+#[derive(Debug)]
+pub struct Match {
+    pub start: u32,
+    pub end: u32,
+    pub text: String,
+    /// 1-based.
+    pub line: u32,
+    /// 0-based, from the start of the line.
+    pub column: u32,
+    /// Kind of the syntax node the match was found in, if scoped by a language.
+    pub kind: Option<String>,
+    /// Name of the query capture the match was found in, if scoped by a language.
+    pub capture: Option<String>,
+}
+
+/// Process `input` according to `config`, returning the result.
+#[napi]
+pub fn process(input: String, config: Option<Config>) -> Result<String> {
+    Ok(Srgn::new(config)?.process(input))
+}
+
+/// Check whether anything in `input` is in scope of `config`.
+#[napi]
+pub fn has_any_in_scope(input: String, config: Option<Config>) -> Result<bool> {
+    Ok(Srgn::new(config)?.has_any_in_scope(input))
+}
+
+/// Find all parts of `input` in scope of `config`.
+#[napi]
+pub fn matches(input: String, config: Option<Config>) -> Result<Vec<Match>> {
+    Ok(Srgn::new(config)?.matches(input))
+}
+
+/// A pipeline built once, for processing many inputs without rebuilding it (and
+/// recompiling its patterns and queries) each time.
+#[napi]
+#[derive(Debug)]
+pub struct Srgn {
+    pipeline: Pipeline,
+}
+
+#[napi]
+impl Srgn {
+    /// Build a new pipeline from `config`.
+    #[napi(constructor)]
+    pub fn new(config: Option<Config>) -> Result<Self> {
+        let config = PipelineConfig::from(config.unwrap_or_default());
+
+        Pipeline::try_from(&config)
+            .map(|pipeline| Self { pipeline })
+            .map_err(|e| Error::from_reason(e.to_string()))
+    }
+
+    /// Process `input`, returning the result.
+    #[napi]
+    #[must_use]
+    #[allow(clippy::needless_pass_by_value)] // Strings cross the boundary owned.
+    pub fn process(&self, input: String) -> String {
+        self.pipeline.run(&input)
+    }
+
+    /// Check whether anything in `input` is in scope.
+    #[napi]
+    #[must_use]
+    #[allow(clippy::needless_pass_by_value)]
+    pub fn has_any_in_scope(&self, input: String) -> bool {
+        self.pipeline.has_any_in_scope(&input)
+    }
+
+    /// Find all parts of `input` in scope.
+    #[napi]
+    #[must_use]
+    #[allow(clippy::needless_pass_by_value)]
+    pub fn matches(&self, input: String) -> Vec<Match> {
+        // Convert byte offsets to UTF-16 ones incrementally, as matches are ordered.
+        let mut byte_offset = 0;
+        let mut utf16_offset = 0;
+        let mut to_utf16 = |byte: usize| {
+            utf16_offset += input[byte_offset..byte].encode_utf16().count();
+            byte_offset = byte;
+            to_u32(utf16_offset)
+        };
+
+        self.pipeline
+            .matches(&input)
+            .into_iter()
+            .map(|m| {
+                let line_start = m.range.start - m.column;
+
+                Match {
+                    start: to_utf16(m.range.start),
+                    end: to_utf16(m.range.end),
+                    text: m.text.to_string(),
+                    line: to_u32(m.line),
+                    column: to_u32(input[line_start..m.range.start].encode_utf16().count()),
+                    kind: m.capture.as_ref().map(|c| c.kind.to_string()),
+                    capture: m.capture.map(|c| c.name),
+                }
+            })
+            .collect()
+    }
+}
+
+/// JavaScript strings are limited to less than 2^32 code units, so this cannot
+/// truncate.
+#[allow(clippy::cast_possible_truncation)]
+fn to_u32(n: usize) -> u32 {
+    n as u32
+}
@@ -0,0 +1,76 @@
+//! Python bindings for srgn.
+//!
+//! Pipelines are configured using keyword arguments, with names as in
+//! [`PipelineConfig`], for example:
+//!
+//! ```python
+//! import srgn
+//!
+//! srgn.process("x = 1  # TODO", lang="python", query="comments", scope="TODO", replace="DONE")
+//! ```
+
+#![warn(clippy::all)]
+#![warn(clippy::pedantic)]
+#![forbid(unsafe_code)]
+
+use pyo3::{exceptions::PyValueError, prelude::*, types::PyDict};
+use srgn_core::pipeline::{self, PipelineConfig};
+
+/// Process `src` according to the given keyword arguments, returning the result.
+#[pyfunction]
+#[pyo3(signature = (src, /, **kwargs))]
+fn process(src: &str, kwargs: Option<&PyDict>) -> PyResult<String> {
+    Ok(Pipeline::new(kwargs)?.process(src))
+}
+
+/// Check whether anything in `src` is in scope of the given keyword arguments.
+#[pyfunction]
+#[pyo3(signature = (src, /, **kwargs))]
+fn has_any_in_scope(src: &str, kwargs: Option<&PyDict>) -> PyResult<bool> {
+    Ok(Pipeline::new(kwargs)?.has_any_in_scope(src))
+}
+
+/// A pipeline built once, for processing many inputs without rebuilding it (and
+/// recompiling its patterns and queries) each time.
+#[pyclass(frozen)]
+#[derive(Debug)]
+struct Pipeline(pipeline::Pipeline);
+
+#[pymethods]
+impl Pipeline {
+    #[new]
+    #[pyo3(signature = (**kwargs))]
+    fn new(kwargs: Option<&PyDict>) -> PyResult<Self> {
+        let config: PipelineConfig = match kwargs {
+            Some(kwargs) => {
+                pythonize::depythonize(kwargs).map_err(|e| PyValueError::new_err(e.to_string()))?
+            }
+            None => PipelineConfig::default(),
+        };
+
+        pipeline::Pipeline::try_from(&config)
+            .map(Self)
+            .map_err(|e| PyValueError::new_err(e.to_string()))
+    }
+
+    /// Process `src`, returning the result.
+    fn process(&self, src: &str) -> String {
+        self.0.run(src)
+    }
+
+    /// Check whether anything in `src` is in scope.
+    fn has_any_in_scope(&self, src: &str) -> bool {
+        self.0.has_any_in_scope(src)
+    }
+}
+
+/// A code surgeon for precise text and code transplantation.
+#[pymodule]
+#[pyo3(name = "srgn")]
+fn module(_py: Python<'_>, m: &PyModule) -> PyResult<()> {
+    m.add_function(wrap_pyfunction!(process, m)?)?;
+    m.add_function(wrap_pyfunction!(has_any_in_scope, m)?)?;
+    m.add_class::<Pipeline>()?;
+
+    Ok(())
+}
@@ -0,0 +1,53 @@
+from typing import Optional
+
+def process(
+    src: str,
+    /,
+    *,
+    lang: Optional[str] = None,
+    query: Optional[str] = None,
+    custom_query: Optional[str] = None,
+    scope: Optional[str] = None,
+    literal_string: bool = False,
+    replace: Optional[str] = None,
+    squeeze: bool = False,
+    german: bool = False,
+    symbols: bool = False,
+    delete: bool = False,
+    upper: bool = False,
+    lower: bool = False,
+    titlecase: bool = False,
+    normalize: bool = False,
+) -> str: ...
+def has_any_in_scope(
+    src: str,
+    /,
+    *,
+    lang: Optional[str] = None,
+    query: Optional[str] = None,
+    custom_query: Optional[str] = None,
+    scope: Optional[str] = None,
+    literal_string: bool = False,
+) -> bool: ...
+
+class Pipeline:
+    def __init__(
+        self,
+        *,
+        lang: Optional[str] = None,
+        query: Optional[str] = None,
+        custom_query: Optional[str] = None,
+        scope: Optional[str] = None,
+        literal_string: bool = False,
+        replace: Optional[str] = None,
+        squeeze: bool = False,
+        german: bool = False,
+        symbols: bool = False,
+        delete: bool = False,
+        upper: bool = False,
+        lower: bool = False,
+        titlecase: bool = False,
+        normalize: bool = False,
+    ) -> None: ...
+    def process(self, src: str) -> str: ...
+    def has_any_in_scope(self, src: str) -> bool: ...
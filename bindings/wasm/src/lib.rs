@@ -0,0 +1,84 @@
+//! WebAssembly bindings for srgn, for use from JavaScript (browsers and Node alike).
+//!
+//! Pipelines are configured using plain objects, with keys as in
+//! [`PipelineConfig`], for example:
+//!
+//! ```js
+//! import { process } from "srgn-wasm";
+//!
+//! process("x = 1  # TODO", { language: "python", query: "comments", scope: "TODO", replace: "DONE" });
+//! ```
+
+#![warn(clippy::all)]
+#![warn(clippy::pedantic)]
+#![forbid(unsafe_code)]
+#![warn(missing_docs)]
+
+use srgn_core::pipeline::{Pipeline, PipelineConfig};
+use wasm_bindgen::prelude::*;
+
+/// Process `input` according to `config`, returning the result.
+///
+/// # Errors
+///
+/// Throws if `config` is invalid.
+#[wasm_bindgen]
+pub fn process(input: &str, config: JsValue) -> Result<String, JsError> {
+    Ok(pipeline(config)?.run(input))
+}
+
+/// Check whether anything in `input` is in scope of `config`.
+///
+/// # Errors
+///
+/// Throws if `config` is invalid.
+#[wasm_bindgen(js_name = hasAnyInScope)]
+pub fn has_any_in_scope(input: &str, config: JsValue) -> Result<bool, JsError> {
+    Ok(pipeline(config)?.has_any_in_scope(input))
+}
+
+/// A pipeline built once, for processing many inputs without rebuilding it (and
+/// recompiling its patterns and queries) each time.
+#[wasm_bindgen]
+#[derive(Debug)]
+pub struct Srgn {
+    pipeline: Pipeline,
+}
+
+#[wasm_bindgen]
+impl Srgn {
+    /// Build a new pipeline from `config`.
+    ///
+    /// # Errors
+    ///
+    /// Throws if `config` is invalid.
+    #[wasm_bindgen(constructor)]
+    pub fn new(config: JsValue) -> Result<Srgn, JsError> {
+        Ok(Self {
+            pipeline: pipeline(config)?,
+        })
+    }
+
+    /// Process `input`, returning the result.
+    #[must_use]
+    pub fn process(&self, input: &str) -> String {
+        self.pipeline.run(input)
+    }
+
+    /// Check whether anything in `input` is in scope.
+    #[wasm_bindgen(js_name = hasAnyInScope)]
+    #[must_use]
+    pub fn has_any_in_scope(&self, input: &str) -> bool {
+        self.pipeline.has_any_in_scope(input)
+    }
+}
+
+fn pipeline(config: JsValue) -> Result<Pipeline, JsError> {
+    let config: PipelineConfig = if config.is_undefined() || config.is_null() {
+        PipelineConfig::default()
+    } else {
+        serde_wasm_bindgen::from_value(config)?
+    };
+
+    Ok(Pipeline::try_from(&config)?)
+}
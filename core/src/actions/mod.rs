@@ -0,0 +1,140 @@
+mod deletion;
+#[cfg(feature = "german")]
+mod german;
+mod lower;
+mod normalization;
+mod replace;
+#[cfg(feature = "symbols")]
+mod symbols;
+mod titlecase;
+mod upper;
+
+pub use deletion::Deletion;
+#[cfg(feature = "german")]
+pub use german::German;
+pub use lower::Lower;
+pub use normalization::Normalization;
+pub use replace::{Replacement, ReplacementCreationError};
+#[cfg(feature = "symbols")]
+pub use symbols::{inversion::SymbolsInversion, Symbols};
+pub use titlecase::Titlecase;
+pub use upper::Upper;
+
+use crate::scoping::regex::CaptureGroups;
+
+/// An action in the processing pipeline.
+///
+/// Actions are the core of the text processing pipeline and can be applied in any
+/// order, [any number of times each](https://en.wikipedia.org/wiki/Idempotence) (more
+/// than once being wasted work, though).
+///
+/// Besides the built-in actions, any type may implement this trait, and is then usable
+/// everywhere built-in actions are, e.g. in [`ScopedView::map`] or
+/// [`PipelineBuilder::action`]. Actions are applied to each part [`In`] scope
+/// separately, never seeing parts [`Out`] of scope. Custom actions need not be
+/// idempotent, but then should not be applied repeatedly.
+///
+/// ## Example: a custom action
+///
+/// ```rust
+/// use srgn_core::actions::Action;
+/// use srgn_core::Pipeline;
+///
+/// /// Wraps its input in backticks, as for Markdown inline code.
+/// struct Backticks;
+///
+/// impl Action for Backticks {
+///     fn act(&self, input: &str) -> String {
+///         format!("`{input}`")
+///     }
+/// }
+///
+/// let pipeline = Pipeline::builder()
+///     .regex(r"\w+\(\)")
+///     .unwrap()
+///     .action(Backticks)
+///     .build();
+///
+/// assert_eq!(pipeline.run("Call foo() or bar()."), "Call `foo()` or `bar()`.");
+/// ```
+///
+/// Closures of the fitting signature are actions as well.
+///
+/// [`ScopedView::map`]: crate::scoping::view::ScopedView::map
+/// [`PipelineBuilder::action`]: crate::pipeline::PipelineBuilder::action
+/// [`In`]: crate::scoping::scope::Scope::In
+/// [`Out`]: crate::scoping::scope::Scope::Out
+pub trait Action: Send + Sync {
+    /// Apply this action to the given input.
+    ///
+    /// This is infallible: it cannot fail in the sense of [`Result`]. It can only
+    /// return incorrect results, which would be bugs (please report).
+    fn act(&self, input: &str) -> String;
+
+    /// Apply this action to the given input, which is part of a match whose capture
+    /// groups were reported (see [`Scoper::scope_with_captures`]), if `captures`.
+    ///
+    /// Only actions making use of capture groups, like [`Replacement`], need to
+    /// implement this. By default, it is the same as [`Self::act`].
+    ///
+    /// [`Scoper::scope_with_captures`]: crate::scoping::Scoper::scope_with_captures
+    fn act_with_captures(&self, input: &str, captures: Option<&CaptureGroups>) -> String {
+        let _ = captures;
+        self.act(input)
+    }
+
+    /// Whether lines following line breaks in results of this action are to be
+    /// indented like the line the part in scope is found on (see [`ScopedView::map`]).
+    ///
+    /// Only sensible for actions coming up with entirely new text, like a
+    /// [`Replacement`]: results of others merely carry over line breaks of their input,
+    /// which are indented already. Defaults to `false`.
+    ///
+    /// [`ScopedView::map`]: crate::scoping::view::ScopedView::map
+    fn reindents(&self) -> bool {
+        false
+    }
+
+    /// Whether lines following line breaks in results of this action are to continue
+    /// a comment the part in scope is found in, by repeating its comment leader (such
+    /// as `// `, `# ` or ` * `), indented like the line the part is found on (see
+    /// [`ScopedView::map`]).
+    ///
+    /// As with [`Self::reindents`], only sensible for actions coming up with entirely
+    /// new text. Defaults to `false`.
+    ///
+    /// [`ScopedView::map`]: crate::scoping::view::ScopedView::map
+    fn continues_comments(&self) -> bool {
+        false
+    }
+}
+
+/// Any function that can be used as an [`Action`].
+impl<T> Action for T
+where
+    T: Fn(&str) -> String + Send + Sync,
+{
+    fn act(&self, input: &str) -> String {
+        self(input)
+    }
+}
+
+/// Any [`Action`] that can be boxed.
+// https://www.reddit.com/r/rust/comments/droxdg/why_arent_traits_impld_for_boxdyn_trait/
+impl Action for Box<dyn Action> {
+    fn act(&self, input: &str) -> String {
+        self.as_ref().act(input)
+    }
+
+    fn act_with_captures(&self, input: &str, captures: Option<&CaptureGroups>) -> String {
+        self.as_ref().act_with_captures(input, captures)
+    }
+
+    fn reindents(&self) -> bool {
+        self.as_ref().reindents()
+    }
+
+    fn continues_comments(&self) -> bool {
+        self.as_ref().continues_comments()
+    }
+}
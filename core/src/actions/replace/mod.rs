@@ -0,0 +1,479 @@
+use super::Action;
+use crate::scoping::regex::CaptureGroups;
+use log::info;
+use std::{error::Error, fmt};
+use unescape::unescape;
+
+/// Replaces input with a fixed string.
+///
+/// ## Example: replacing invalid characters in identifiers
+///
+/// ```rust
+/// use srgn_core::RegexPattern;
+/// use srgn_core::scoping::{view::ScopedViewBuilder, regex::Regex};
+///
+/// let scoper = Regex::new(RegexPattern::new(r"[^a-zA-Z0-9]+").unwrap());
+/// let mut builder = ScopedViewBuilder::new("hyphenated-variable-name");
+/// builder.explode(&scoper);
+/// let mut view = builder.build();
+/// view.replace("_".to_string());
+///
+/// assert_eq!(
+///    view.to_string(),
+///   "hyphenated_variable_name"
+/// );
+/// ```
+///
+/// ## Example: replace emojis
+///
+/// ```rust
+/// use srgn_core::RegexPattern;
+/// use srgn_core::scoping::{view::ScopedViewBuilder, regex::Regex};
+///
+/// // A Unicode character class category. See also
+/// // https://github.com/rust-lang/regex/blob/061ee815ef2c44101dba7b0b124600fcb03c1912/UNICODE.md#rl12-properties
+/// let scoper = Regex::new(RegexPattern::new(r"\p{Emoji}").unwrap());
+/// let mut builder = ScopedViewBuilder::new("Party! 😁 💃 🎉 🥳 So much fun! ╰(°▽°)╯");
+/// builder.explode(&scoper);
+/// let mut view = builder.build();
+/// view.replace(":(".to_string());
+///
+/// assert_eq!(
+///    view.to_string(),
+///    // Party is over, sorry ¯\_(ツ)_/¯
+///   "Party! :( :( :( :( So much fun! ╰(°▽°)╯"
+/// );
+/// ```
+///
+/// ## Example: referring to capture groups
+///
+/// Replacements may refer to what capture groups of a regex matched, by name
+/// (`$name` or `${name}`) or by index (`$1`, `${1}`). `$0` is the entire match, `$$` a
+/// literal `$`. The regex has to [report its capture
+/// groups][`crate::scoping::regex::Regex::with_capture_groups`].
+///
+/// ```rust
+/// use srgn_core::RegexPattern;
+/// use srgn_core::scoping::{view::ScopedViewBuilder, regex::Regex};
+///
+/// let pattern = RegexPattern::new(r"(?P<year>\d{4})-(?P<month>\d{2})").unwrap();
+/// let scoper = Regex::new(pattern).with_capture_groups();
+/// let mut builder = ScopedViewBuilder::new("Due 2024-01, costs $5");
+/// builder.explode(&scoper);
+/// let mut view = builder.build();
+/// view.replace("${month}/$year ($0), in $$".to_string());
+///
+/// assert_eq!(
+///    view.to_string(),
+///   "Due 01/2024 (2024-01), in $, costs $5"
+/// );
+/// ```
+///
+/// References to groups which do not exist or did not participate in a match are
+/// replaced by nothing. Without any capture groups to go by, such as for parts in
+/// scope of a language grammar, `$0` is the entire part in scope.
+#[derive(Debug, Clone, PartialEq, Eq, Default)]
+pub struct Replacement {
+    /// The replacement, with escape sequences processed.
+    replacement: String,
+    /// The replacement, broken up into literal text and references to capture groups.
+    parts: Vec<Part>,
+    /// Whether to indent lines of the replacement like the line of what it replaces.
+    reindent: bool,
+    /// Whether to continue comments which what the replacement replaces is found in.
+    continue_comments: bool,
+    /// Whether to mimic the case of what the replacement replaces.
+    preserve_case: bool,
+}
+
+/// A part of a [`Replacement`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Part {
+    Literal(String),
+    Group(Group),
+}
+
+/// A reference to a capture group.
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Group {
+    Index(usize),
+    Name(String),
+}
+
+impl Replacement {
+    /// Whether this replacement refers to any capture groups (including `$0`).
+    #[must_use]
+    pub fn has_references(&self) -> bool {
+        self.parts.iter().any(|part| matches!(part, Part::Group(_)))
+    }
+
+    /// Take this replacement literally: a `$` never refers to capture groups, and
+    /// `$$` is not an escape either.
+    ///
+    /// ```
+    /// use srgn_core::actions::{Action, Replacement};
+    ///
+    /// let replacement = Replacement::try_from("${{ env.HOME }}$$".to_owned()).unwrap();
+    /// assert_eq!(replacement.without_references().act("x"), "${{ env.HOME }}$$");
+    /// ```
+    #[must_use]
+    pub fn without_references(mut self) -> Self {
+        self.parts = vec![Part::Literal(self.replacement.clone())];
+        self
+    }
+
+    /// Indent all lines of this replacement but the first like the line the part it
+    /// replaces starts on.
+    ///
+    /// Replacements spanning multiple lines are usually written without any leading
+    /// indentation, which would be out of place when replacing, say, an indented
+    /// statement. Empty lines stay empty.
+    ///
+    /// ```
+    /// use srgn_core::actions::Replacement;
+    /// use srgn_core::scoping::{literal::Literal, view::ScopedViewBuilder};
+    ///
+    /// let replacement = Replacement::try_from("a()\nb()".to_owned())
+    ///     .unwrap()
+    ///     .reindented();
+    /// let scoper = Literal::try_from("x()".to_owned()).unwrap();
+    /// let mut builder = ScopedViewBuilder::new("def f():\n    x()\n");
+    /// builder.explode(&scoper);
+    /// let mut view = builder.build();
+    /// view.map(&replacement);
+    ///
+    /// assert_eq!(view.to_string(), "def f():\n    a()\n    b()\n");
+    /// ```
+    #[must_use]
+    pub fn reindented(mut self) -> Self {
+        self.reindent = true;
+        self
+    }
+
+    /// Continue comments across all lines of this replacement, for replacing parts of
+    /// comments.
+    ///
+    /// Lines but the first are prefixed with the comment leader (such as `// `, `# `
+    /// or ` * `) of the line the part it replaces starts on, and indented like it.
+    /// Lines already starting with the leader are only indented. Without any comment
+    /// leader found, this is the same as [`Self::reindented`].
+    ///
+    /// ```
+    /// use srgn_core::actions::Replacement;
+    /// use srgn_core::scoping::{literal::Literal, view::ScopedViewBuilder};
+    ///
+    /// let replacement = Replacement::try_from("Fix:\nsoon".to_owned())
+    ///     .unwrap()
+    ///     .continuing_comments();
+    /// let scoper = Literal::try_from("TODO".to_owned()).unwrap();
+    /// let mut builder = ScopedViewBuilder::new("    // TODO\n    f();\n");
+    /// builder.explode(&scoper);
+    /// let mut view = builder.build();
+    /// view.map(&replacement);
+    ///
+    /// assert_eq!(view.to_string(), "    // Fix:\n    // soon\n    f();\n");
+    /// ```
+    #[must_use]
+    pub fn continuing_comments(mut self) -> Self {
+        self.continue_comments = true;
+        self
+    }
+
+    /// Mimic the case of what this replacement replaces: if that is all uppercase, so
+    /// is the result; similarly for all lowercase and titlecase (only the first letter
+    /// uppercase). Otherwise, such as for `camelCase`, the result is left as-is.
+    ///
+    /// Useful for renaming identifiers and words across all their spellings at once,
+    /// such as when matching case-insensitively.
+    ///
+    /// ```
+    /// use srgn_core::actions::{Action, Replacement};
+    ///
+    /// let replacement = Replacement::try_from("bar".to_owned())
+    ///     .unwrap()
+    ///     .preserving_case();
+    ///
+    /// assert_eq!(replacement.act("FOO"), "BAR");
+    /// assert_eq!(replacement.act("Foo"), "Bar");
+    /// assert_eq!(replacement.act("foo"), "bar");
+    /// assert_eq!(replacement.act("fOo"), "bar");
+    /// ```
+    #[must_use]
+    pub fn preserving_case(mut self) -> Self {
+        self.preserve_case = true;
+        self
+    }
+
+    /// Expands references to capture groups of the match `input` is part of.
+    fn expand(&self, input: &str, captures: Option<&CaptureGroups>) -> String {
+        if !self.has_references() {
+            return self.replacement.clone();
+        }
+
+        let mut res = String::with_capacity(self.replacement.len());
+        for part in &self.parts {
+            let expanded = match (part, captures) {
+                (Part::Literal(literal), _) => Some(literal.as_str()),
+                (Part::Group(Group::Index(index)), Some(captures)) => captures.get(*index),
+                (Part::Group(Group::Name(name)), Some(captures)) => captures.name(name),
+                (Part::Group(Group::Index(0)), None) => Some(input),
+                (Part::Group(_), None) => None,
+            };
+
+            res.push_str(expanded.unwrap_or_default());
+        }
+
+        res
+    }
+}
+
+/// Changes the case of `s` to mimic that of `original`, if all uppercase, lowercase or
+/// titlecase. Otherwise, or if `original` has no cased letters at all, returns `s`
+/// as-is.
+fn mimic_case(original: &str, s: &str) -> String {
+    let mut cased = original
+        .chars()
+        .filter(|c| c.is_uppercase() || c.is_lowercase());
+
+    let Some(first) = cased.next() else {
+        return s.to_owned();
+    };
+    let rest_upper = cased.clone().all(char::is_uppercase);
+    let rest_lower = cased.all(char::is_lowercase);
+
+    match (first.is_uppercase(), rest_upper, rest_lower) {
+        // Single letters count as uppercase, not titlecase.
+        (true, true, _) => s.to_uppercase(),
+        (true, false, true) => {
+            let mut chars = s.chars();
+            chars.next().map_or_else(String::new, |first| {
+                format!("{}{}", first.to_uppercase(), chars.as_str().to_lowercase())
+            })
+        }
+        (false, _, true) => s.to_lowercase(),
+        _ => s.to_owned(),
+    }
+}
+
+/// Breaks `replacement` up into literal text and references to capture groups.
+///
+/// Follows the syntax of the `regex` crate: `$name` takes the longest possible name
+/// made up of ASCII letters, digits and underscores, `${name}` anything up to the
+/// closing brace. Names consisting of digits only are indices. A `$` not starting a
+/// reference is taken literally.
+fn parse(replacement: &str) -> Vec<Part> {
+    let mut parts = Vec::new();
+    let mut literal = String::new();
+    let mut rest = replacement;
+
+    while let Some(dollar) = rest.find('$') {
+        literal.push_str(&rest[..dollar]);
+        rest = &rest[dollar + 1..];
+
+        if let Some(after) = rest.strip_prefix('$') {
+            literal.push('$');
+            rest = after;
+            continue;
+        }
+
+        let (name, after) = match rest.strip_prefix('{') {
+            Some(braced) => match braced.find('}') {
+                Some(end) => (&braced[..end], &braced[end + 1..]),
+                None => ("", rest),
+            },
+            None => {
+                let end = rest
+                    .find(|c: char| !(c.is_ascii_alphanumeric() || c == '_'))
+                    .unwrap_or(rest.len());
+                (&rest[..end], &rest[end..])
+            }
+        };
+
+        if name.is_empty() {
+            literal.push('$');
+            continue;
+        }
+
+        if !literal.is_empty() {
+            parts.push(Part::Literal(std::mem::take(&mut literal)));
+        }
+        parts.push(Part::Group(match name.parse() {
+            Ok(index) => Group::Index(index),
+            Err(_) => Group::Name(name.to_owned()),
+        }));
+        rest = after;
+    }
+
+    literal.push_str(rest);
+    if !literal.is_empty() {
+        parts.push(Part::Literal(literal));
+    }
+
+    parts
+}
+
+impl TryFrom<String> for Replacement {
+    type Error = ReplacementCreationError;
+
+    /// Creates a new replacement from an owned string.
+    ///
+    /// Escape sequences are accepted and processed, with invalid escape sequences
+    /// returning an [`Err`].
+    ///
+    /// ## Example: Basic usage
+    ///
+    /// ```
+    /// use srgn_core::actions::Replacement;
+    ///
+    /// // Successful creation of a regular string
+    /// let replacement = Replacement::try_from("Some Replacement".to_owned());
+    /// assert!(replacement.is_ok());
+    ///
+    /// // Successful creation, with escape characters
+    /// let replacement = Replacement::try_from(r"Some \t Escape".to_owned());
+    /// assert!(replacement.is_ok());
+    /// ```
+    ///
+    /// ## Example: Invalid escape sequence
+    ///
+    /// Creation fails due to invalid escape sequences.
+    ///
+    /// ```
+    /// use srgn_core::actions::{Replacement, ReplacementCreationError};
+    ///
+    /// let replacement = Replacement::try_from(r"Invalid \z Escape".to_owned());
+    /// assert_eq!(
+    ///    replacement,
+    ///    Err(ReplacementCreationError::InvalidEscapeSequences(
+    ///      "Invalid \\z Escape".to_owned()
+    ///    ))
+    /// );
+    /// ```
+    fn try_from(replacement: String) -> Result<Self, Self::Error> {
+        match unescape(&replacement) {
+            Some(res) => Ok(Self {
+                parts: parse(&res),
+                replacement: res,
+                reindent: false,
+                continue_comments: false,
+                preserve_case: false,
+            }),
+            None => Err(ReplacementCreationError::InvalidEscapeSequences(
+                replacement,
+            )),
+        }
+    }
+}
+
+/// An error that can occur when creating a replacement.
+#[derive(Debug, PartialEq, Eq)]
+pub enum ReplacementCreationError {
+    /// The replacement contains invalid escape sequences.
+    InvalidEscapeSequences(String),
+}
+
+impl fmt::Display for ReplacementCreationError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::InvalidEscapeSequences(replacement) => {
+                write!(f, "Contains invalid escape sequences: '{replacement}'")
+            }
+        }
+    }
+}
+
+impl Error for ReplacementCreationError {}
+
+impl Action for Replacement {
+    fn act(&self, input: &str) -> String {
+        self.act_with_captures(input, None)
+    }
+
+    fn act_with_captures(&self, input: &str, captures: Option<&CaptureGroups>) -> String {
+        info!("Substituting '{}' with '{}'", input, self.replacement);
+
+        let res = self.expand(input, captures);
+
+        if self.preserve_case {
+            mimic_case(input, &res)
+        } else {
+            res
+        }
+    }
+
+    fn reindents(&self) -> bool {
+        self.reindent
+    }
+
+    fn continues_comments(&self) -> bool {
+        self.continue_comments
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use rstest::rstest;
+
+    #[rstest]
+    #[case("", vec![])]
+    #[case("abc", vec![Part::Literal("abc".into())])]
+    #[case("$name", vec![Part::Group(Group::Name("name".into()))])]
+    #[case("${name}", vec![Part::Group(Group::Name("name".into()))])]
+    #[case("$1", vec![Part::Group(Group::Index(1))])]
+    #[case("${1}", vec![Part::Group(Group::Index(1))])]
+    #[case("$0", vec![Part::Group(Group::Index(0))])]
+    #[case("$1a", vec![Part::Group(Group::Name("1a".into()))])]
+    #[case("${1}a", vec![Part::Group(Group::Index(1)), Part::Literal("a".into())])]
+    #[case("a $b c", vec![Part::Literal("a ".into()), Part::Group(Group::Name("b".into())), Part::Literal(" c".into())])]
+    #[case("${some name}", vec![Part::Group(Group::Name("some name".into()))])]
+    #[case("$a$b", vec![Part::Group(Group::Name("a".into())), Part::Group(Group::Name("b".into()))])]
+    //
+    // Taken literally.
+    #[case("$$", vec![Part::Literal("$".into())])]
+    #[case("$$name", vec![Part::Literal("$name".into())])]
+    #[case("$", vec![Part::Literal("$".into())])]
+    #[case("5 $", vec![Part::Literal("5 $".into())])]
+    #[case("$ 5", vec![Part::Literal("$ 5".into())])]
+    #[case("${}", vec![Part::Literal("${}".into())])]
+    #[case("${name", vec![Part::Literal("${name".into())])]
+    #[case("$-", vec![Part::Literal("$-".into())])]
+    fn test_parse(#[case] replacement: &str, #[case] expected: Vec<Part>) {
+        assert_eq!(parse(replacement), expected);
+    }
+
+    #[rstest]
+    #[case("FOO", "bar", "BAR")]
+    #[case("Foo", "bar", "Bar")]
+    #[case("foo", "bar", "bar")]
+    #[case("F", "bar", "BAR")]
+    #[case("f", "BAR", "bar")]
+    #[case("Foo", "bAR", "Bar")]
+    #[case("FOO_BAR", "baz_qux", "BAZ_QUX")]
+    #[case("Foo bar", "baz qux", "Baz qux")]
+    #[case("fooBar", "bazQux", "bazQux")]
+    #[case("FooBar", "bazQux", "bazQux")]
+    #[case("1", "bar", "bar")]
+    #[case("", "bar", "bar")]
+    #[case("ÄRGER", "übel", "ÜBEL")]
+    #[case("Foo", "", "")]
+    fn test_mimic_case(#[case] original: &str, #[case] s: &str, #[case] expected: &str) {
+        assert_eq!(mimic_case(original, s), expected);
+    }
+
+    #[rstest]
+    #[case("X", "abc", "X")]
+    #[case("<$0>", "abc", "<abc>")]
+    #[case("<$1>", "abc", "<>")]
+    #[case("<$name>", "abc", "<>")]
+    #[case("$$0", "abc", "$0")]
+    fn test_act_without_captures(
+        #[case] replacement: &str,
+        #[case] input: &str,
+        #[case] expected: &str,
+    ) {
+        let replacement = Replacement::try_from(replacement.to_owned()).unwrap();
+
+        assert_eq!(replacement.act(input), expected);
+    }
+}
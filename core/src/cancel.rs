@@ -0,0 +1,375 @@
+//! Bounding how long operations run for, and how much memory they take up, by
+//! cancelling them from elsewhere, after a timeout, or once over a memory limit.
+//!
+//! For servers and other hosts embedding this crate, which cannot afford a single
+//! pathological input (a huge file, a regex prone to backtracking) blocking them or
+//! running them out of memory. A [`CancellationToken`] is handed to e.g.
+//! [`Pipeline::try_run`][`crate::Pipeline::try_run`], and cancelled from any other
+//! thread, or set up to expire by itself.
+//!
+//! While an operation runs under a token, its token is [current][`current`] for
+//! the running thread. Built-in scopers check it as they go:
+//!
+//! - tree-sitter parsing is handed the token, and stops (nearly) right away,
+//! - regular expressions check it between matches. A *single* match attempt cannot be
+//!   interrupted, however. Instead, [its backtracking is
+//!   limited][`crate::RegexPatternBuilder::backtrack_limit`]; hitting that limit
+//!   cancels the current token, too.
+//!
+//! Custom [scopers][`crate::scoping::Scoper`] and [actions][`crate::actions::Action`]
+//! taking long are encouraged to call [`is_cancelled`] periodically, and bail out early
+//! if it returns `true`. What they return then is thrown away.
+//!
+//! Memory is not measured, but *estimated*: operations [`charge`] what they are about
+//! to allocate in bulk (scopes, syntax trees, results of actions) against the limit,
+//! without ever crediting anything back. The estimate is hence closer to peak
+//! memory use than to current use, and intentionally errs on the high side.
+//!
+//! ```rust
+//! use srgn_core::cancel::CancellationToken;
+//! use srgn_core::actions::Upper;
+//! use srgn_core::Pipeline;
+//!
+//! let pipeline = Pipeline::builder().action(Upper::default()).build();
+//!
+//! let token = CancellationToken::new();
+//! assert_eq!(pipeline.try_run("abc", &token).unwrap(), "ABC");
+//!
+//! token.cancel();
+//! assert!(pipeline.try_run("abc", &token).is_err());
+//! ```
+
+use log::debug;
+use std::{
+    cell::RefCell,
+    error::Error,
+    fmt,
+    sync::{
+        atomic::{AtomicUsize, Ordering},
+        Arc,
+    },
+    time::{Duration, Instant},
+};
+
+/// A handle for cancelling operations, cheap to clone and share across threads.
+///
+/// Clones share state: cancelling one cancels all.
+#[derive(Debug, Clone, Default)]
+pub struct CancellationToken {
+    // `usize` instead of `bool`, as this is handed to tree-sitter as-is, which expects
+    // any non-zero value to signal cancellation.
+    flag: Arc<AtomicUsize>,
+    deadline: Option<Instant>,
+    memory: Option<Arc<MemoryBudget>>,
+}
+
+/// Values of the flag. Any non-zero one signals cancellation.
+const EXPLICITLY: usize = 1;
+const BACKTRACK_LIMIT_EXCEEDED: usize = 2;
+const OVERLAPPING_SCOPES: usize = 3;
+
+#[derive(Debug)]
+struct MemoryBudget {
+    limit: usize,
+    used: AtomicUsize,
+}
+
+impl CancellationToken {
+    /// Create a new token, not cancelled until [`CancellationToken::cancel`] is called.
+    #[must_use]
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Create a new token which cancels itself once `timeout` has passed (from now), or
+    /// when [cancelled][`CancellationToken::cancel`] explicitly, whichever is first.
+    #[must_use]
+    pub fn with_timeout(timeout: Duration) -> Self {
+        Self {
+            deadline: Instant::now().checked_add(timeout),
+            ..Self::default()
+        }
+    }
+
+    /// Additionally cancel once operations running under this token (or any of its
+    /// clones) are estimated to use more than `bytes` of memory, in total.
+    #[must_use]
+    pub fn with_memory_limit(self, bytes: usize) -> Self {
+        Self {
+            memory: Some(Arc::new(MemoryBudget {
+                limit: bytes,
+                used: AtomicUsize::new(0),
+            })),
+            ..self
+        }
+    }
+
+    /// Cancel all operations running under this token (or any of its clones).
+    pub fn cancel(&self) {
+        debug!("Cancelling operations");
+        self.flag.store(EXPLICITLY, Ordering::Relaxed);
+    }
+
+    /// Check whether this token was cancelled, timed out or exceeded its memory limit.
+    #[must_use]
+    pub fn is_cancelled(&self) -> bool {
+        self.reason().is_some()
+    }
+
+    /// Why this token was cancelled, if it was.
+    #[must_use]
+    pub fn reason(&self) -> Option<Cancelled> {
+        if let Some(memory) = &self.memory {
+            if memory.used.load(Ordering::Relaxed) > memory.limit {
+                return Some(Cancelled::MemoryLimitExceeded(memory.limit));
+            }
+        }
+
+        match self.flag.load(Ordering::Relaxed) {
+            0 => {}
+            BACKTRACK_LIMIT_EXCEEDED => return Some(Cancelled::BacktrackLimitExceeded),
+            OVERLAPPING_SCOPES => return Some(Cancelled::OverlappingScopes),
+            _ => return Some(Cancelled::Explicitly),
+        }
+
+        self.deadline
+            .is_some_and(|deadline| Instant::now() >= deadline)
+            .then_some(Cancelled::TimedOut)
+    }
+
+    /// Record `bytes` of memory as (about to be) used, against this token's memory
+    /// limit, if any.
+    pub fn charge(&self, bytes: usize) {
+        let Some(memory) = &self.memory else {
+            return;
+        };
+
+        let previous = memory
+            .used
+            .fetch_update(Ordering::Relaxed, Ordering::Relaxed, |used| {
+                Some(used.saturating_add(bytes))
+            })
+            .expect("Closure always returns `Some`");
+
+        if previous.saturating_add(bytes) > memory.limit {
+            debug!("Memory limit of {} bytes exceeded", memory.limit);
+            // Also stops whatever only looks at the flag, like tree-sitter.
+            self.flag.store(EXPLICITLY, Ordering::Relaxed);
+        }
+    }
+
+    /// Time left until this token times out, if it has a timeout at all.
+    #[must_use]
+    pub fn remaining(&self) -> Option<Duration> {
+        self.deadline
+            .map(|deadline| deadline.saturating_duration_since(Instant::now()))
+    }
+
+    /// Return an error if this token was cancelled, timed out or exceeded its memory
+    /// limit.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`], giving the reason, if the token was cancelled.
+    pub fn check(&self) -> Result<(), Cancelled> {
+        self.reason().map_or(Ok(()), Err)
+    }
+
+    /// Run `f` with this token [current][`is_cancelled`] for the running thread,
+    /// restoring the previous one (if any) afterwards.
+    ///
+    /// Fails if the token was cancelled by the time `f` returns, discarding its result.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`], giving the reason, if the token was cancelled.
+    pub fn run<T>(&self, f: impl FnOnce() -> T) -> Result<T, Cancelled> {
+        /// Restores the previously current token, even when unwinding.
+        struct Restore(Option<CancellationToken>);
+
+        impl Drop for Restore {
+            fn drop(&mut self) {
+                CURRENT.with(|current| *current.borrow_mut() = self.0.take());
+            }
+        }
+
+        self.check()?;
+
+        let previous = CURRENT.with(|current| current.borrow_mut().replace(self.clone()));
+        let _restore = Restore(previous);
+
+        let res = f();
+        self.check()?;
+
+        Ok(res)
+    }
+
+    /// The raw flag, for handing to tree-sitter.
+    pub(crate) fn flag(&self) -> &AtomicUsize {
+        &self.flag
+    }
+}
+
+thread_local! {
+    static CURRENT: RefCell<Option<CancellationToken>> = RefCell::new(None);
+}
+
+/// The token current for the running thread, if any.
+///
+/// See [`CancellationToken::run`].
+#[must_use]
+pub fn current() -> Option<CancellationToken> {
+    CURRENT.with(|current| current.borrow().clone())
+}
+
+/// Check whether the token current for the running thread (if any) was cancelled.
+#[must_use]
+pub fn is_cancelled() -> bool {
+    CURRENT.with(|current| {
+        current
+            .borrow()
+            .as_ref()
+            .is_some_and(CancellationToken::is_cancelled)
+    })
+}
+
+/// Record `bytes` of memory as (about to be) used, against the memory limit of the
+/// token current for the running thread, if any.
+pub fn charge(bytes: usize) {
+    CURRENT.with(|current| {
+        if let Some(token) = current.borrow().as_ref() {
+            token.charge(bytes);
+        }
+    });
+}
+
+/// Cancel the [current] token (if any), as a regular expression exceeded its
+/// backtracking limit.
+pub(crate) fn backtrack_limit_exceeded() {
+    CURRENT.with(|current| {
+        if let Some(token) = current.borrow().as_ref() {
+            debug!("Backtracking limit exceeded, cancelling operations");
+            token
+                .flag
+                .store(BACKTRACK_LIMIT_EXCEEDED, Ordering::Relaxed);
+        }
+    });
+}
+
+/// Cancel the [current] token (if any), as a language query captured overlapping
+/// nodes, which [is configured][`crate::scoping::langs::Overlaps::Error`] to fail.
+pub(crate) fn overlapping_scopes() {
+    CURRENT.with(|current| {
+        if let Some(token) = current.borrow().as_ref() {
+            debug!("Overlapping scopes, cancelling operations");
+            token.flag.store(OVERLAPPING_SCOPES, Ordering::Relaxed);
+        }
+    });
+}
+
+/// An operation was cancelled before it completed.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+#[non_exhaustive]
+pub enum Cancelled {
+    /// [Cancelled][`CancellationToken::cancel`] explicitly.
+    Explicitly,
+    /// Timed out.
+    TimedOut,
+    /// Exceeded the memory limit (in bytes).
+    MemoryLimitExceeded(usize),
+    /// A regular expression exceeded its backtracking limit.
+    BacktrackLimitExceeded,
+    /// A language query captured overlapping nodes, configured to be an error.
+    OverlappingScopes,
+}
+
+impl fmt::Display for Cancelled {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Explicitly => write!(f, "Operation cancelled"),
+            Self::TimedOut => write!(f, "Operation timed out"),
+            Self::MemoryLimitExceeded(limit) => write!(
+                f,
+                "Operation exceeded memory limit of {} bytes (estimated)",
+                limit
+            ),
+            Self::BacktrackLimitExceeded => {
+                write!(f, "Operation exceeded regex backtracking limit")
+            }
+            Self::OverlappingScopes => {
+                write!(f, "Language query captured overlapping nodes")
+            }
+        }
+    }
+}
+
+impl Error for Cancelled {}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_cancellation_token() {
+        let token = CancellationToken::new();
+        assert!(!token.is_cancelled());
+        assert_eq!(token.remaining(), None);
+
+        let clone = token.clone();
+        clone.cancel();
+        assert!(token.is_cancelled());
+        assert_eq!(token.check(), Err(Cancelled::Explicitly));
+    }
+
+    #[test]
+    fn test_cancellation_token_timeout() {
+        let token = CancellationToken::with_timeout(Duration::ZERO);
+        assert_eq!(token.reason(), Some(Cancelled::TimedOut));
+
+        assert!(!CancellationToken::with_timeout(Duration::from_secs(3600)).is_cancelled());
+    }
+
+    #[test]
+    fn test_cancellation_token_memory_limit() {
+        let token = CancellationToken::new().with_memory_limit(10);
+
+        token.charge(6);
+        token.charge(4);
+        assert!(!token.is_cancelled());
+
+        token.charge(1);
+        assert_eq!(token.reason(), Some(Cancelled::MemoryLimitExceeded(10)));
+
+        // Cannot overflow.
+        token.charge(usize::MAX);
+        assert_eq!(token.reason(), Some(Cancelled::MemoryLimitExceeded(10)));
+
+        // Without limit, charging is meaningless.
+        let token = CancellationToken::new();
+        token.charge(usize::MAX);
+        assert!(!token.is_cancelled());
+    }
+
+    #[test]
+    fn test_cancellation_token_run() {
+        let outer = CancellationToken::new();
+        let inner = CancellationToken::new();
+        assert!(current().is_none());
+
+        let res = outer.run(|| {
+            assert!(!is_cancelled());
+
+            let res = inner.run(|| {
+                inner.cancel();
+                is_cancelled()
+            });
+            assert_eq!(res, Err(Cancelled::Explicitly));
+
+            // Outer token is current again, and not affected.
+            is_cancelled()
+        });
+
+        assert_eq!(res, Ok(false));
+        assert!(current().is_none());
+    }
+}
@@ -0,0 +1,113 @@
+//! Writing results back to files without ever leaving them half-written.
+
+use log::{debug, warn};
+use std::{
+    ffi::OsString,
+    fs::{self, OpenOptions},
+    io::{self, Write},
+    path::Path,
+};
+
+/// Writes `contents` to the file at `path`, atomically.
+///
+/// Contents are first written to a temporary file next to the original, which is then
+/// renamed to replace it. Interrupted runs can hence not leave truncated files behind.
+/// Permissions of the original are carried over, as is ownership (on Unix, and only if
+/// privileges allow) and, if `preserve_mtime` is set, the modification time.
+///
+/// Symbolic links are followed, replacing their target, not the link.
+///
+/// # Errors
+///
+/// Returns an error if the original cannot be inspected, or the temporary file cannot
+/// be written or moved into place. The original is left untouched then.
+pub fn write_atomically(path: &Path, contents: &[u8], preserve_mtime: bool) -> io::Result<()> {
+    let path = fs::canonicalize(path).map_err(context("Failed to resolve path"))?;
+    let metadata = fs::metadata(&path).map_err(context("Failed to read file metadata"))?;
+
+    let file_name = path
+        .file_name()
+        .ok_or_else(|| io::Error::new(io::ErrorKind::InvalidInput, "Path has no file name"))?;
+    let tmp_path = {
+        let mut name = OsString::from(".");
+        name.push(file_name);
+        name.push(format!(".srgn-{}.tmp", std::process::id()));
+        path.with_file_name(name)
+    };
+    debug!("Writing to temporary file: {:?}", tmp_path);
+
+    let write = || -> io::Result<()> {
+        let mut file = OpenOptions::new()
+            .write(true)
+            .create_new(true)
+            .open(&tmp_path)
+            .map_err(context("Failed to create temporary file"))?;
+
+        file.write_all(contents)
+            .map_err(context("Failed to write to temporary file"))?;
+        file.set_permissions(metadata.permissions())
+            .map_err(context("Failed to set permissions"))?;
+
+        #[cfg(unix)]
+        {
+            use std::os::unix::fs::{fchown, MetadataExt};
+
+            if let Err(e) = fchown(&file, Some(metadata.uid()), Some(metadata.gid())) {
+                debug!("Could not preserve ownership (continuing anyway): {}", e);
+            }
+        }
+
+        if preserve_mtime {
+            file.set_modified(metadata.modified()?)
+                .map_err(context("Failed to set modification time"))?;
+        }
+
+        file.sync_all()
+            .map_err(context("Failed to flush temporary file"))?;
+
+        fs::rename(&tmp_path, &path).map_err(context("Failed to replace original file"))
+    };
+
+    write().map_err(|e| {
+        if let Err(cleanup) = fs::remove_file(&tmp_path) {
+            warn!(
+                "Failed to remove temporary file {:?}: {}",
+                tmp_path, cleanup
+            );
+        }
+
+        e
+    })
+}
+
+/// Prefix an error with what was being done when it occurred, keeping its kind.
+fn context(doing: &'static str) -> impl Fn(io::Error) -> io::Error {
+    move |e| io::Error::new(e.kind(), format!("{doing}: {e}"))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_write_atomically_replaces_contents() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("file.txt");
+        fs::write(&path, "old contents, longer than new ones").unwrap();
+
+        write_atomically(&path, b"new", false).unwrap();
+
+        assert_eq!(fs::read_to_string(&path).unwrap(), "new");
+        // No temporary files left behind.
+        assert_eq!(fs::read_dir(dir.path()).unwrap().count(), 1);
+    }
+
+    #[test]
+    fn test_write_atomically_missing_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("missing.txt");
+
+        assert!(write_atomically(&path, b"new", false).is_err());
+        assert!(!path.exists());
+    }
+}
@@ -0,0 +1,853 @@
+//! A pipeline bundles scopers and actions into a single, reusable unit, built once and
+//! then run over any number of inputs.
+//!
+//! This is the high-level counterpart to working with [`ScopedViewBuilder`] and
+//! [`ScopedView`] directly, and mirrors what the binary does for a single invocation.
+//!
+//! ```rust
+//! use srgn_core::actions::{Replacement, Upper};
+//! use srgn_core::scoping::langs::CodeQuery;
+//! use srgn_core::scoping::langs::python::{PremadePythonQuery, Python};
+//! use srgn_core::Pipeline;
+//!
+//! let pipeline = Pipeline::builder()
+//!     .language(Python::new(CodeQuery::Premade(PremadePythonQuery::Comments)))
+//!     .regex(r"TODO")
+//!     .unwrap()
+//!     .action(Replacement::try_from("done".to_string()).unwrap())
+//!     .action(Upper::default())
+//!     .build();
+//!
+//! let input = "x = 'TODO'  # TODO: fix\n";
+//! assert_eq!(pipeline.run(input), "x = 'TODO'  # DONE: fix\n");
+//! ```
+//!
+//! [`ScopedView`]: crate::scoping::view::ScopedView
+
+#[cfg(feature = "german")]
+use crate::actions::German;
+#[cfg(feature = "symbols")]
+use crate::actions::Symbols;
+use crate::{
+    actions::{
+        Action, Deletion, Lower, Normalization, Replacement, ReplacementCreationError, Titlecase,
+        Upper,
+    },
+    cancel::{self, CancellationToken, Cancelled},
+    fs::write_atomically,
+    scoping::{
+        langs::{self, CompiledQuery, LanguageError, LanguageScoper, NodeScoper, RawQuery},
+        literal::{Literal, LiteralError},
+        regex::{Regex, RegexError},
+        view::{Match, ScopedViewBuilder},
+        Scoper,
+    },
+};
+use log::debug;
+use serde::Deserialize;
+use std::{
+    error::Error,
+    fmt, fs,
+    io::{self, BufRead, BufReader, Read},
+    path::Path,
+};
+
+/// Checks whether a file at some path, with some contents, is valid for a language.
+type FileValidator = fn(&Path, &str) -> bool;
+
+/// A single step of scoping.
+enum Layer {
+    /// Any scoper.
+    Plain(Box<dyn Scoper>),
+    /// A language scoper, which knows about syntax nodes and files of its language.
+    Language(Box<dyn NodeScoper>, FileValidator),
+}
+
+/// Number of layers which are languages.
+fn count_languages(layers: &[Layer]) -> usize {
+    layers
+        .iter()
+        .filter(|layer| matches!(layer, Layer::Language(..)))
+        .count()
+}
+
+/// A reusable sequence of scopers and actions.
+///
+/// Construct one using [`Pipeline::builder`].
+pub struct Pipeline {
+    layers: Vec<Layer>,
+    actions: Vec<Box<dyn Action>>,
+    squeeze: bool,
+    linewise: bool,
+}
+
+impl fmt::Debug for Pipeline {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        // Trait objects are opaque, so only their number can be shown.
+        f.debug_struct("Pipeline")
+            .field("scopers", &self.layers.len())
+            .field("actions", &self.actions.len())
+            .field("languages", &count_languages(&self.layers))
+            .field("squeeze", &self.squeeze)
+            .field("linewise", &self.linewise)
+            .finish()
+    }
+}
+
+/// The outcome of running a [`Pipeline`] on a file.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Outcome {
+    /// The file is not written in (one of) the pipeline's languages, and was left
+    /// untouched.
+    Skipped,
+    /// The file was processed, but its contents did not change. It was not written to.
+    Unchanged,
+    /// The file was processed and written to with changed contents.
+    Changed,
+}
+
+impl Pipeline {
+    /// Start building a new pipeline.
+    #[must_use]
+    pub fn builder() -> PipelineBuilder {
+        PipelineBuilder::default()
+    }
+
+    /// Scope `input` down, in the order scopers were added.
+    #[must_use]
+    pub fn scope<'viewee>(&self, input: &'viewee str) -> ScopedViewBuilder<'viewee> {
+        let mut builder = ScopedViewBuilder::new(input);
+        for layer in &self.layers {
+            if cancel::is_cancelled() {
+                break;
+            }
+
+            match layer {
+                Layer::Plain(scoper) => builder.explode(scoper),
+                Layer::Language(scoper, _) => builder.explode(scoper),
+            };
+        }
+
+        builder
+    }
+
+    /// Check whether anything in `input` is in scope of this pipeline.
+    #[must_use]
+    pub fn has_any_in_scope(&self, input: &str) -> bool {
+        self.scope(input).build().has_any_in_scope()
+    }
+
+    /// Find all parts of `input` in scope of this pipeline, without applying any
+    /// actions.
+    ///
+    /// If a [language][`PipelineBuilder::language`] was added, each match carries the
+    /// innermost syntax node [captured][`NodeScoper::captures`] by the first language
+    /// which fully contains the match.
+    #[must_use]
+    pub fn matches<'viewee>(&self, input: &'viewee str) -> Vec<Match<'viewee>> {
+        let captures = self
+            .layers
+            .iter()
+            .find_map(|layer| match layer {
+                Layer::Language(scoper, _) => Some(scoper.captures(input)),
+                Layer::Plain(_) => None,
+            })
+            .unwrap_or_default();
+
+        let mut matches = self.scope(input).matches();
+        for m in &mut matches {
+            m.capture = captures
+                .iter()
+                .filter(|c| c.range.start <= m.range.start && m.range.end <= c.range.end)
+                .min_by_key(|c| c.range.len())
+                .cloned();
+        }
+
+        matches
+    }
+
+    /// Run the pipeline over `input`: scope it, then apply all actions in the order
+    /// they were added.
+    #[must_use]
+    pub fn run(&self, input: &str) -> String {
+        let mut view = self.scope(input).build();
+
+        if self.squeeze {
+            view.squeeze();
+        }
+
+        for action in &self.actions {
+            if cancel::is_cancelled() {
+                break;
+            }
+
+            view.map(action);
+        }
+
+        view.to_string()
+    }
+
+    /// Like [`Pipeline::run`], but stops early once `token` is cancelled, times out or
+    /// exceeds its memory limit.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`] if `token` was cancelled before or while running.
+    pub fn try_run(&self, input: &str, token: &CancellationToken) -> Result<String, Cancelled> {
+        token.run(|| self.run(input))
+    }
+
+    /// Run the pipeline over the file at `path`, in-place.
+    ///
+    /// If languages were added, files not written in them are [skipped][`Outcome`].
+    /// The file is only written to if its contents changed, and then
+    /// [atomically][`write_atomically`], like the `srgn` binary does.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file cannot be read (including if it is not valid
+    /// UTF-8) or written.
+    pub fn run_on(&self, path: impl AsRef<Path>) -> io::Result<Outcome> {
+        let path = path.as_ref();
+        let source = fs::read_to_string(path)?;
+
+        if !self.layers.iter().all(|layer| match layer {
+            Layer::Language(_, valid) => valid(path, &source),
+            Layer::Plain(_) => true,
+        }) {
+            debug!("Skipping file not in pipeline's language(s): {:?}", path);
+            return Ok(Outcome::Skipped);
+        }
+
+        let result = self.run(&source);
+        if result == source {
+            return Ok(Outcome::Unchanged);
+        }
+
+        write_atomically(path, result.as_bytes(), false)?;
+        Ok(Outcome::Changed)
+    }
+
+    /// Run the pipeline over everything read from `reader`, writing results to
+    /// `writer`.
+    ///
+    /// For a [linewise][`PipelineBuilder::linewise`] pipeline, input is processed one
+    /// line at a time, as it arrives, holding no more than a single line in memory.
+    /// Scopers and actions see lines without their line endings, which are written back
+    /// unchanged. Otherwise, all of `reader` is read before processing starts.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if reading or writing fails, or if input is not valid UTF-8.
+    pub fn run_stream(&self, reader: impl io::Read, mut writer: impl io::Write) -> io::Result<()> {
+        let mut reader = BufReader::new(reader);
+
+        if !self.linewise || count_languages(&self.layers) > 0 {
+            debug!("Pipeline cannot stream, reading entire input");
+
+            let mut source = String::new();
+            reader.read_to_string(&mut source)?;
+
+            return writer.write_all(self.run(&source).as_bytes());
+        }
+
+        let mut buf = Vec::new();
+        loop {
+            buf.clear();
+            if reader.read_until(b'\n', &mut buf)? == 0 {
+                break;
+            }
+
+            let line = std::str::from_utf8(&buf)
+                .map_err(|e| io::Error::new(io::ErrorKind::InvalidData, e))?;
+            let content = line
+                .strip_suffix('\n')
+                .map_or(line, |l| l.strip_suffix('\r').unwrap_or(l));
+
+            writer.write_all(self.run(content).as_bytes())?;
+            writer.write_all(line[content.len()..].as_bytes())?;
+        }
+
+        writer.flush()
+    }
+}
+
+/// A builder for [`Pipeline`]s.
+#[derive(Default)]
+pub struct PipelineBuilder {
+    layers: Vec<Layer>,
+    actions: Vec<Box<dyn Action>>,
+    squeeze: bool,
+    linewise: bool,
+}
+
+impl fmt::Debug for PipelineBuilder {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_struct("PipelineBuilder")
+            .field("scopers", &self.layers.len())
+            .field("actions", &self.actions.len())
+            .field("languages", &count_languages(&self.layers))
+            .field("squeeze", &self.squeeze)
+            .field("linewise", &self.linewise)
+            .finish()
+    }
+}
+
+impl PipelineBuilder {
+    /// Add a language scoper.
+    ///
+    /// Works like [`PipelineBuilder::scope`], but additionally restricts
+    /// [`Pipeline::run_on`] to files [valid][`LanguageScoper::is_valid_file`] for the
+    /// language, and lets [`Pipeline::matches`] report on syntax nodes.
+    #[must_use]
+    pub fn language<L: LanguageScoper + 'static>(self, language: L) -> Self {
+        self.language_boxed(Box::new(language), L::is_valid_file)
+    }
+
+    /// Add a [precompiled query][`CompiledQuery`] of a language.
+    ///
+    /// Works like [`PipelineBuilder::language`], including its file checks.
+    #[must_use]
+    pub fn compiled_query<L: LanguageScoper + 'static>(self, query: CompiledQuery<L>) -> Self {
+        self.language_boxed(Box::new(query), L::is_valid_file)
+    }
+
+    /// Add a language scoper not known statically, see [`PipelineBuilder::language`].
+    fn language_boxed(mut self, scoper: Box<dyn NodeScoper>, validator: FileValidator) -> Self {
+        self.layers.push(Layer::Language(scoper, validator));
+        self
+    }
+
+    /// Add a scoper. Scopers narrow down scope further, in the order they are added.
+    #[must_use]
+    pub fn scope(mut self, scoper: impl Scoper + 'static) -> Self {
+        self.layers.push(Layer::Plain(Box::new(scoper)));
+        self
+    }
+
+    /// Add a regular expression scoper, from `pattern`.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if `pattern` is not a valid regular expression.
+    pub fn regex(self, pattern: &str) -> Result<Self, RegexError> {
+        Ok(self.scope(Regex::try_from(pattern.to_string())?))
+    }
+
+    /// Add a literal string scoper, from `literal`.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if `literal` contains invalid escape sequences.
+    pub fn literal(self, literal: &str) -> Result<Self, LiteralError> {
+        Ok(self.scope(Literal::try_from(literal.to_string())?))
+    }
+
+    /// Add an action. Actions are applied in the order they are added.
+    #[must_use]
+    pub fn action(mut self, action: impl Action + 'static) -> Self {
+        self.actions.push(Box::new(action));
+        self
+    }
+
+    /// Squeeze consecutive occurrences of scope into one, before any actions run.
+    #[must_use]
+    pub fn squeeze(mut self) -> Self {
+        self.squeeze = true;
+        self
+    }
+
+    /// Allow [streaming][`Pipeline::run_stream`] input line by line.
+    ///
+    /// Only sound if no scope can span multiple lines, which is up to the caller to
+    /// ensure. Ignored if any [language][`PipelineBuilder::language`] was added, as
+    /// those need entire inputs for parsing.
+    #[must_use]
+    pub fn linewise(mut self) -> Self {
+        self.linewise = true;
+        self
+    }
+
+    /// Finish building the pipeline.
+    #[must_use]
+    pub fn build(self) -> Pipeline {
+        Pipeline {
+            layers: self.layers,
+            actions: self.actions,
+            squeeze: self.squeeze,
+            linewise: self.linewise,
+        }
+    }
+}
+
+/// A [`Pipeline`], described by plain data instead of types.
+///
+/// For when pipelines are only known at runtime, such as from configuration files or
+/// bindings to other languages. Fields correspond to the binary's options of the same
+/// name, and deserialize from e.g. JSON such as `{"language": "go", "query":
+/// "comments", "scope": "TODO", "replace": "DONE"}`.
+///
+/// Actions are applied in the same order as the binary applies them.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Deserialize)]
+#[serde(default, deny_unknown_fields)]
+#[allow(clippy::struct_excessive_bools)] // Mirrors CLI flags.
+pub struct PipelineConfig {
+    /// Name of the language to scope to, one of [`langs::NAMES`].
+    #[serde(alias = "lang")]
+    pub language: Option<String>,
+    /// Name of a premade query of the language.
+    pub query: Option<String>,
+    /// A custom tree-sitter query over the language.
+    pub custom_query: Option<String>,
+    /// Scope to apply to, as a regular expression pattern. Everything if missing.
+    pub scope: Option<String>,
+    /// Interpret `scope` as a literal string instead.
+    pub literal_string: bool,
+    /// Match `scope` case-insensitively.
+    pub ignore_case: bool,
+    /// Replace scope by this (fixed) value.
+    pub replace: Option<String>,
+    /// Take `replace` literally, without referring to capture groups.
+    pub replace_literal: bool,
+    /// Squeeze consecutive occurrences of scope into one.
+    pub squeeze: bool,
+    /// Perform German substitutions.
+    #[cfg(feature = "german")]
+    pub german: bool,
+    /// Perform symbols substitutions.
+    #[cfg(feature = "symbols")]
+    pub symbols: bool,
+    /// Delete scope.
+    pub delete: bool,
+    /// Uppercase scope.
+    pub upper: bool,
+    /// Lowercase scope.
+    pub lower: bool,
+    /// Titlecase scope.
+    pub titlecase: bool,
+    /// Normalize (Normalization Form D) scope, and throw away marks.
+    pub normalize: bool,
+}
+
+/// An error building a [`Pipeline`] from a [`PipelineConfig`].
+#[derive(Debug)]
+pub enum ConfigError {
+    /// A query was given without a language.
+    QueryWithoutLanguage,
+    /// A language was given without a query, or with both a premade and a custom one.
+    LanguageQuery,
+    /// The language or its query is invalid.
+    Language(LanguageError),
+    /// The scope is not a valid regular expression.
+    Regex(RegexError),
+    /// The scope is not a valid literal string.
+    Literal(LiteralError),
+    /// The replacement is invalid.
+    Replacement(ReplacementCreationError),
+}
+
+impl fmt::Display for ConfigError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::QueryWithoutLanguage => write!(f, "A query requires a language"),
+            Self::LanguageQuery => write!(
+                f,
+                "A language requires exactly one of a premade or custom query"
+            ),
+            Self::Language(e) => write!(f, "{}", e),
+            Self::Regex(e) => write!(f, "{}", e),
+            Self::Literal(e) => write!(f, "Invalid literal: {}", e),
+            Self::Replacement(e) => write!(f, "Invalid replacement: {}", e),
+        }
+    }
+}
+
+impl Error for ConfigError {}
+
+impl TryFrom<&PipelineConfig> for Pipeline {
+    type Error = ConfigError;
+
+    fn try_from(config: &PipelineConfig) -> Result<Self, Self::Error> {
+        let mut builder = Self::builder();
+
+        match (&config.language, &config.query, &config.custom_query) {
+            (Some(language), query, custom_query) => {
+                let query = match (query, custom_query) {
+                    (Some(premade), None) => RawQuery::Premade(premade),
+                    (None, Some(custom)) => RawQuery::Custom(custom),
+                    _ => return Err(ConfigError::LanguageQuery),
+                };
+
+                let scoper = langs::by_name(language, query).map_err(ConfigError::Language)?;
+                let validator = langs::file_validator_by_name(language).ok_or_else(|| {
+                    ConfigError::Language(LanguageError::UnknownLanguage(language.clone()))
+                })?;
+
+                builder = builder.language_boxed(scoper, validator);
+            }
+            (None, None, None) => {}
+            (None, _, _) => return Err(ConfigError::QueryWithoutLanguage),
+        }
+
+        let replacement = config
+            .replace
+            .as_ref()
+            .map(|replacement| Replacement::try_from(replacement.clone()))
+            .transpose()
+            .map_err(ConfigError::Replacement)?
+            .map(|replacement| {
+                if config.replace_literal {
+                    replacement.without_references()
+                } else {
+                    replacement
+                }
+            });
+
+        if let Some(scope) = &config.scope {
+            builder = if config.literal_string {
+                let literal = Literal::try_from(scope.clone()).map_err(ConfigError::Literal)?;
+
+                if config.ignore_case {
+                    builder.scope(literal.case_insensitive())
+                } else {
+                    builder.scope(literal)
+                }
+            } else {
+                let pattern = if config.ignore_case {
+                    format!("(?i){scope}")
+                } else {
+                    scope.clone()
+                };
+                let regex = Regex::try_from(pattern).map_err(ConfigError::Regex)?;
+
+                if replacement
+                    .as_ref()
+                    .is_some_and(Replacement::has_references)
+                {
+                    builder.scope(regex.with_capture_groups())
+                } else {
+                    builder.scope(regex)
+                }
+            };
+        }
+
+        if let Some(replacement) = replacement {
+            builder = builder.action(replacement);
+        }
+
+        #[cfg(feature = "german")]
+        if config.german {
+            builder = builder.action(German::default());
+        }
+
+        #[cfg(feature = "symbols")]
+        if config.symbols {
+            builder = builder.action(Symbols::default());
+        }
+
+        if config.delete {
+            builder = builder.action(Deletion::default());
+        }
+
+        if config.upper {
+            builder = builder.action(Upper::default());
+        }
+
+        if config.lower {
+            builder = builder.action(Lower::default());
+        }
+
+        if config.titlecase {
+            builder = builder.action(Titlecase::default());
+        }
+
+        if config.normalize {
+            builder = builder.action(Normalization::default());
+        }
+
+        if config.squeeze {
+            builder = builder.squeeze();
+        }
+
+        Ok(builder.build())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::actions::{Deletion, Lower, Replacement, Upper};
+    use crate::scoping::langs::{
+        python::{PremadePythonQuery, Python},
+        CodeQuery, TSQuery,
+    };
+    use rstest::rstest;
+
+    #[rstest]
+    #[case(Pipeline::builder().build(), "abc", "abc")]
+    #[case(Pipeline::builder().action(Upper::default()).build(), "abc", "ABC")]
+    #[case(
+        Pipeline::builder().regex("b+").unwrap().action(Deletion::default()).build(),
+        "abbc",
+        "ac"
+    )]
+    #[case(
+        Pipeline::builder()
+            .regex("b")
+            .unwrap()
+            .squeeze()
+            .action(Upper::default())
+            .build(),
+        "abbc",
+        "aBc"
+    )]
+    #[case(
+        Pipeline::builder()
+            .regex("[a-z]+")
+            .unwrap()
+            .regex("b")
+            .unwrap()
+            .action(Replacement::try_from("X".to_string()).unwrap())
+            .build(),
+        "ab-b",
+        "aX-X"
+    )]
+    #[case(
+        Pipeline::builder()
+            .literal(".")
+            .unwrap()
+            .action(Deletion::default())
+            .build(),
+        "a.b",
+        "ab"
+    )]
+    fn test_pipeline_run(#[case] pipeline: Pipeline, #[case] input: &str, #[case] expected: &str) {
+        assert_eq!(pipeline.run(input), expected);
+    }
+
+    #[rstest]
+    // Whole input: `$` only matches at its very end, past the final newline.
+    #[case(false, "ab\ncb\n", "ab\ncb\n")]
+    #[case(false, "ab\ncb", "ab\ncX")]
+    // Linewise: each line is seen on its own, without line endings.
+    #[case(true, "ab\ncb\n", "aX\ncX\n")]
+    #[case(true, "ab\r\ncb", "aX\r\ncX")]
+    #[case(true, "\n\nb\n", "\n\nX\n")]
+    #[case(true, "", "")]
+    fn test_pipeline_run_stream(
+        #[case] linewise: bool,
+        #[case] input: &str,
+        #[case] expected: &str,
+    ) {
+        let mut builder = Pipeline::builder()
+            .regex("b$")
+            .unwrap()
+            .action(Replacement::try_from("X".to_string()).unwrap());
+        if linewise {
+            builder = builder.linewise();
+        }
+        let pipeline = builder.build();
+
+        let mut output = Vec::new();
+        pipeline.run_stream(input.as_bytes(), &mut output).unwrap();
+
+        assert_eq!(String::from_utf8(output).unwrap(), expected);
+    }
+
+    #[test]
+    fn test_pipeline_run_stream_invalid_utf8() {
+        let pipeline = Pipeline::builder().linewise().build();
+
+        let result = pipeline.run_stream(&b"ok\n\xFF\n"[..], io::sink());
+
+        assert_eq!(result.unwrap_err().kind(), io::ErrorKind::InvalidData);
+    }
+
+    #[rstest]
+    #[case(r#"{}"#, "abc", Some("abc"))]
+    #[case(r#"{"upper": true}"#, "abc", Some("ABC"))]
+    #[case(r#"{"scope": "b+", "replace": "X"}"#, "abbc", Some("aXc"))]
+    #[case(
+        r#"{"scope": ".", "literal_string": true, "delete": true}"#,
+        "a.b",
+        Some("ab")
+    )]
+    #[case(
+        r#"{"scope": "b", "ignore_case": true, "delete": true}"#,
+        "aBbc",
+        Some("ac")
+    )]
+    #[case(
+        r#"{"scope": "b.", "literal_string": true, "ignore_case": true, "delete": true}"#,
+        "aB.bc",
+        Some("abc")
+    )]
+    #[case(
+        r#"{"language": "python", "query": "comments", "scope": "a", "upper": true}"#,
+        "a = 1  # a\n",
+        Some("a = 1  # A\n")
+    )]
+    #[case(
+        r#"{"language": "python", "custom_query": "(comment) @c", "delete": true}"#,
+        "a = 1  # a\n",
+        Some("a = 1  \n")
+    )]
+    #[case(r#"{"query": "comments"}"#, "", None)]
+    #[case(r#"{"language": "python"}"#, "", None)]
+    #[case(r#"{"language": "cobol", "query": "comments"}"#, "", None)]
+    #[case(r#"{"language": "python", "query": "nope"}"#, "", None)]
+    #[case(r#"{"scope": "("}"#, "", None)]
+    fn test_pipeline_from_config(
+        #[case] config: &str,
+        #[case] input: &str,
+        #[case] expected: Option<&str>,
+    ) {
+        let config: PipelineConfig = serde_json::from_str(config).unwrap();
+        let pipeline = Pipeline::try_from(&config);
+
+        assert_eq!(pipeline.ok().map(|p| p.run(input)).as_deref(), expected);
+    }
+
+    #[test]
+    fn test_pipeline_matches() {
+        let pipeline = Pipeline::builder()
+            .language(Python::new(CodeQuery::Premade(
+                PremadePythonQuery::Comments,
+            )))
+            .regex("TODO")
+            .unwrap()
+            .build();
+
+        let matches = pipeline.matches("x = 1  # TODO\n# TODO: more\n");
+        let matches = matches
+            .iter()
+            .map(|m| {
+                let capture = m.capture.as_ref().unwrap();
+                (
+                    m.range.clone(),
+                    (m.line, m.column),
+                    capture.kind,
+                    &*capture.name,
+                )
+            })
+            .collect::<Vec<_>>();
+
+        assert_eq!(
+            matches,
+            vec![
+                (9..13, (1, 9), "comment", "comment"),
+                (16..20, (2, 2), "comment", "comment"),
+            ]
+        );
+        assert!(
+            Pipeline::builder().regex("x").unwrap().build().matches("x")[0]
+                .capture
+                .is_none()
+        );
+    }
+
+    #[test]
+    fn test_pipeline_duplicate_captures() {
+        // Both patterns capture the very same node.
+        let query = "(comment) @first (comment) @second".parse().unwrap();
+        let pipeline = Pipeline::builder()
+            .language(Python::new(CodeQuery::Custom(query)))
+            .action(Replacement::try_from("$0!".to_owned()).unwrap())
+            .build();
+
+        assert_eq!(pipeline.run("x = 1  # a\n"), "x = 1  # a!\n");
+
+        let matches = pipeline.matches("x = 1  # a\n");
+        assert_eq!(matches.len(), 1);
+        assert_eq!(matches[0].capture.as_ref().unwrap().kind, "comment");
+    }
+
+    #[rstest]
+    // All captures.
+    #[case(None, "def A(b): return B")]
+    #[case(Some(vec!["name"]), "def A(b): return b")]
+    #[case(Some(vec!["body"]), "def a(b): return B")]
+    #[case(Some(vec![]), "def a(b): return b")]
+    fn test_pipeline_compiled_query(#[case] captures: Option<Vec<&str>>, #[case] expected: &str) {
+        let query = TSQuery::new(
+            Python::lang(),
+            "(function_definition name: (identifier) @name body: (block) @body)",
+        )
+        .unwrap();
+        let mut query = CompiledQuery::<Python>::new(query);
+        if let Some(captures) = captures {
+            query = query.with_captures(captures);
+        }
+
+        let pipeline = Pipeline::builder()
+            .compiled_query(query)
+            .action(Upper::default())
+            .build();
+
+        // Compiled once, used many times.
+        for _ in 0..2 {
+            assert_eq!(pipeline.run("def a(b): return b"), expected);
+        }
+    }
+
+    #[test]
+    fn test_pipeline_try_run() {
+        let token = CancellationToken::new();
+        let canceller = token.clone();
+        let pipeline = Pipeline::builder()
+            .action(move |s: &str| {
+                canceller.cancel();
+                s.to_uppercase()
+            })
+            .build();
+
+        assert_eq!(
+            pipeline.try_run("abc", &CancellationToken::new()).unwrap(),
+            "ABC"
+        );
+        assert_eq!(pipeline.try_run("abc", &token), Err(Cancelled::Explicitly));
+        // Already cancelled, does not even start.
+        assert_eq!(pipeline.try_run("abc", &token), Err(Cancelled::Explicitly));
+    }
+
+    #[rstest]
+    #[case(usize::MAX, Ok("abc-x".to_string()))]
+    // Shattering capture groups into single bytes is costly.
+    #[case(64, Err(Cancelled::MemoryLimitExceeded(64)))]
+    fn test_pipeline_try_run_memory_limit(
+        #[case] limit: usize,
+        #[case] expected: Result<String, Cancelled>,
+    ) {
+        let pipeline = Pipeline::builder()
+            .regex(r"(\w+)")
+            .unwrap()
+            .action(Lower::default())
+            .build();
+
+        let token = CancellationToken::new().with_memory_limit(limit);
+        assert_eq!(pipeline.try_run("ABC-X", &token), expected);
+    }
+
+    #[test]
+    fn test_pipeline_run_on() {
+        let dir = tempfile::tempdir().unwrap();
+
+        let pipeline = Pipeline::builder()
+            .language(Python::new(CodeQuery::Premade(
+                PremadePythonQuery::Comments,
+            )))
+            .action(Upper::default())
+            .build();
+
+        let py = dir.path().join("a.py");
+        fs::write(&py, "x = 1  # hello\n").unwrap();
+        assert_eq!(pipeline.run_on(&py).unwrap(), Outcome::Changed);
+        assert_eq!(fs::read_to_string(&py).unwrap(), "x = 1  # HELLO\n");
+        assert_eq!(pipeline.run_on(&py).unwrap(), Outcome::Unchanged);
+
+        let txt = dir.path().join("a.txt");
+        fs::write(&txt, "# hello\n").unwrap();
+        assert_eq!(pipeline.run_on(&txt).unwrap(), Outcome::Skipped);
+        assert_eq!(fs::read_to_string(&txt).unwrap(), "# hello\n");
+    }
+}
@@ -0,0 +1,123 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Bash language.
+pub type Bash = Language<BashQuery>;
+/// A query for Bash.
+pub type BashQuery = CodeQuery<CustomBashQuery, PremadeBashQuery>;
+
+/// Premade tree-sitter queries for Bash.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeBashQuery {
+    /// Comments (incl. shebangs).
+    Comments,
+    /// Strings (double- and single-quoted; expansions are respected; quotes included).
+    Strings,
+    /// Heredoc bodies.
+    Heredocs,
+    /// Function definitions (incl. body).
+    Functions,
+    /// Variable expansions (`$var`, `${var}`; incl. `$` and braces).
+    VariableExpansions,
+}
+
+impl From<PremadeBashQuery> for TSQuery {
+    fn from(value: PremadeBashQuery) -> Self {
+        TSQuery::new(
+            Bash::lang(),
+            match value {
+                PremadeBashQuery::Comments => "(comment) @comment",
+                PremadeBashQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string)
+                        (string (expansion) @",
+                        IGNORE,
+                        ")
+                        (string (simple_expansion) @",
+                        IGNORE,
+                        ")
+                        (string (command_substitution) @",
+                        IGNORE,
+                        ")
+                        (raw_string)
+                        (ansi_c_string)
+                    ]
+                    @string"
+                    )
+                }
+                PremadeBashQuery::Heredocs => "(heredoc_body) @heredoc",
+                PremadeBashQuery::Functions => "(function_definition) @function",
+                PremadeBashQuery::VariableExpansions => {
+                    "[(expansion) (simple_expansion)] @expansion"
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Bash.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomBashQuery(String, Precompiled);
+
+impl FromStr for CustomBashQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Bash::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomBashQuery> for TSQuery {
+    fn from(value: CustomBashQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Bash::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Bash {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Bash {
+    fn lang() -> TSLanguage {
+        tree_sitter_bash::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["sh", "bash"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["sh", "bash"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["sh", "bash"]
+    }
+}
@@ -0,0 +1,115 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Clojure language.
+pub type Clojure = Language<ClojureQuery>;
+/// A query for Clojure.
+pub type ClojureQuery = CodeQuery<CustomClojureQuery, PremadeClojureQuery>;
+
+/// Premade tree-sitter queries for Clojure.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeClojureQuery {
+    /// Comments (line comments only, not `#_` or `(comment ...)` forms).
+    Comments,
+    /// Strings (incl. quotes).
+    Strings,
+    /// Keyword literals, as in `:key` or `::key`.
+    Keywords,
+    /// `defn` (and `defn-`) forms.
+    Defns,
+    /// `ns` (namespace declaration) forms.
+    Namespaces,
+}
+
+impl From<PremadeClojureQuery> for TSQuery {
+    fn from(value: PremadeClojureQuery) -> Self {
+        TSQuery::new(
+            Clojure::lang(),
+            match value {
+                PremadeClojureQuery::Comments => "(comment) @comment",
+                PremadeClojureQuery::Strings => "(str_lit) @string",
+                PremadeClojureQuery::Keywords => "(kwd_lit) @keyword",
+                PremadeClojureQuery::Defns => {
+                    r#"
+                    (
+                        (list_lit . (sym_lit) @name) @defn
+                        (#match? @name "^defn-?$")
+                    )
+                    "#
+                }
+                PremadeClojureQuery::Namespaces => {
+                    r#"
+                    (
+                        (list_lit . (sym_lit) @name) @ns
+                        (#eq? @name "ns")
+                    )
+                    "#
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Clojure.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomClojureQuery(String, Precompiled);
+
+impl FromStr for CustomClojureQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Clojure::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomClojureQuery> for TSQuery {
+    fn from(value: CustomClojureQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Clojure::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Clojure {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Clojure {
+    fn lang() -> TSLanguage {
+        tree_sitter_clojure::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["clj", "cljs", "cljc", "edn"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["bb"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["clojure"]
+    }
+}
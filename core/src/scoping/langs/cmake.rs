@@ -0,0 +1,115 @@
+use super::{
+    matching, CodeQuery, Filterable, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage,
+    TSQuery,
+};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The CMake language.
+pub type CMake = Language<CMakeQuery>;
+/// A query for CMake.
+pub type CMakeQuery = CodeQuery<CustomCMakeQuery, PremadeCMakeQuery>;
+
+/// Premade tree-sitter queries for CMake.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeCMakeQuery {
+    /// Comments (line and bracket comments).
+    Comments,
+    /// Command invocations (name and arguments). Filter by command name, as in
+    /// `commands~target_link_libraries`. As in CMake itself, case is ignored.
+    Commands,
+    /// Quoted arguments (incl. quotes).
+    QuotedArguments,
+}
+
+impl PremadeCMakeQuery {
+    fn source(self) -> &'static str {
+        match self {
+            Self::Comments => "[(line_comment) (bracket_comment)] @comment",
+            Self::Commands => "(normal_command (identifier) @_name) @command",
+            Self::QuotedArguments => "(quoted_argument) @argument",
+        }
+    }
+}
+
+impl From<PremadeCMakeQuery> for TSQuery {
+    fn from(value: PremadeCMakeQuery) -> Self {
+        TSQuery::new(CMake::lang(), value.source()).expect("Premade queries to be valid")
+    }
+}
+
+impl Filterable for PremadeCMakeQuery {
+    fn filtered(self, pattern: &str) -> Option<String> {
+        match self {
+            Self::Commands => Some(format!(
+                "({} {})",
+                self.source(),
+                // Command names are case-insensitive.
+                matching("_name", &format!("(?i:{pattern})"))
+            )),
+            Self::Comments | Self::QuotedArguments => None,
+        }
+    }
+}
+
+/// A custom tree-sitter query for CMake.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomCMakeQuery(String, Precompiled);
+
+impl FromStr for CustomCMakeQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(CMake::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomCMakeQuery> for TSQuery {
+    fn from(value: CustomCMakeQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(CMake::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for CMake {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for CMake {
+    fn lang() -> TSLanguage {
+        tree_sitter_cmake::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["cmake"]
+    }
+
+    fn file_names() -> &'static [&'static str] {
+        &["CMakeLists"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["cmake"]
+    }
+}
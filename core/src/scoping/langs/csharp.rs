@@ -1,6 +1,8 @@
-use super::{CodeQuery, Language, LanguageScoper, TSLanguage, TSQuery};
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
 use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
 use clap::ValueEnum;
+use serde::Deserialize;
 use std::{fmt::Debug, str::FromStr};
 use tree_sitter::QueryError;
 
@@ -10,7 +12,9 @@ pub type CSharp = Language<CSharpQuery>;
 pub type CSharpQuery = CodeQuery<CustomCSharpQuery, PremadeCSharpQuery>;
 
 /// Premade tree-sitter queries for C#.
-#[derive(Debug, Clone, Copy, ValueEnum)]
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
 pub enum PremadeCSharpQuery {
     /// Comments (including XML, inline, doc comments).
     Comments,
@@ -51,14 +55,14 @@ impl From<PremadeCSharpQuery> for TSQuery {
 
 /// A custom tree-sitter query for C#.
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
-pub struct CustomCSharpQuery(String);
+pub struct CustomCSharpQuery(String, Precompiled);
 
 impl FromStr for CustomCSharpQuery {
     type Err = QueryError;
 
     fn from_str(s: &str) -> Result<Self, Self::Err> {
         match TSQuery::new(CSharp::lang(), s) {
-            Ok(_) => Ok(Self(s.to_string())),
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
             Err(e) => Err(e),
         }
     }
@@ -66,14 +70,16 @@ impl FromStr for CustomCSharpQuery {
 
 impl From<CustomCSharpQuery> for TSQuery {
     fn from(value: CustomCSharpQuery) -> Self {
-        TSQuery::new(CSharp::lang(), &value.0)
-            .expect("Valid query, as object cannot be constructed otherwise")
+        value.1.take_or_else(|| {
+            TSQuery::new(CSharp::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
     }
 }
 
 impl Scoper for CSharp {
     fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
-        ROScopes::from_raw_ranges(input, Self::scope_via_query(&mut self.query(), input))
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
     }
 }
 
@@ -82,7 +88,23 @@ impl LanguageScoper for CSharp {
         tree_sitter_c_sharp::language()
     }
 
-    fn query(&self) -> TSQuery {
-        self.query.clone().into()
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["cs"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["dotnet-script"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["cs", "csharp"]
     }
 }
@@ -0,0 +1,104 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The CSS language.
+pub type Css = Language<CssQuery>;
+/// A query for CSS.
+pub type CssQuery = CodeQuery<CustomCssQuery, PremadeCssQuery>;
+
+/// Premade tree-sitter queries for CSS.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeCssQuery {
+    /// Comments.
+    Comments,
+    /// Selectors of rule sets.
+    Selectors,
+    /// Property names of declarations.
+    PropertyNames,
+    /// Property values of declarations (excl. `:` and `;`).
+    PropertyValues,
+    /// Custom property declarations (`--name: value;`).
+    CustomProperties,
+}
+
+impl From<PremadeCssQuery> for TSQuery {
+    fn from(value: PremadeCssQuery) -> Self {
+        TSQuery::new(
+            Css::lang(),
+            match value {
+                PremadeCssQuery::Comments => "(comment) @comment",
+                PremadeCssQuery::Selectors => "(rule_set (selectors) @selectors)",
+                PremadeCssQuery::PropertyNames => "(declaration (property_name) @name)",
+                PremadeCssQuery::PropertyValues => "(declaration (property_name) (_) @value)",
+                PremadeCssQuery::CustomProperties => {
+                    r#"
+                    (
+                        (declaration (property_name) @name) @property
+                        (#match? @name "^--")
+                    )
+                    "#
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for CSS.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomCssQuery(String, Precompiled);
+
+impl FromStr for CustomCssQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Css::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomCssQuery> for TSQuery {
+    fn from(value: CustomCssQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Css::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Css {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Css {
+    fn lang() -> TSLanguage {
+        tree_sitter_css::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["css"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["css"]
+    }
+}
@@ -0,0 +1,124 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Dart language.
+pub type Dart = Language<DartQuery>;
+/// A query for Dart.
+pub type DartQuery = CodeQuery<CustomDartQuery, PremadeDartQuery>;
+
+/// Premade tree-sitter queries for Dart.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeDartQuery {
+    /// Comments (incl. documentation comments).
+    Comments,
+    /// Strings (interpolation is respected; quotes included).
+    Strings,
+    /// Class declarations (incl. body).
+    Classes,
+    /// Widget `build` methods (signature and body; excl. annotations).
+    BuildMethods,
+    /// Annotations (incl. `@` and arguments).
+    Annotations,
+}
+
+impl From<PremadeDartQuery> for TSQuery {
+    fn from(value: PremadeDartQuery) -> Self {
+        TSQuery::new(
+            Dart::lang(),
+            match value {
+                PremadeDartQuery::Comments => "[(comment) (documentation_comment)] @comment",
+                PremadeDartQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string_literal)
+                        (string_literal (template_substitution) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadeDartQuery::Classes => "(class_definition) @class",
+                PremadeDartQuery::BuildMethods => {
+                    r#"
+                    (
+                        (method_signature
+                            (function_signature name: (identifier) @name)
+                        ) @build
+                        .
+                        (function_body) @build
+                        (#eq? @name "build")
+                    )
+                    "#
+                }
+                PremadeDartQuery::Annotations => "[(annotation) (marker_annotation)] @annotation",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Dart.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomDartQuery(String, Precompiled);
+
+impl FromStr for CustomDartQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Dart::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomDartQuery> for TSQuery {
+    fn from(value: CustomDartQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Dart::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Dart {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Dart {
+    fn lang() -> TSLanguage {
+        tree_sitter_dart::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["dart"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["dart"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["dart"]
+    }
+}
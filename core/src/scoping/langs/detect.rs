@@ -0,0 +1,162 @@
+//! Detection of a file's language from its contents, for files lacking a recognized
+//! file extension.
+//!
+//! Two sources of information are consulted:
+//!
+//! - a [shebang](https://en.wikipedia.org/wiki/Shebang_(Unix)) on the very first line,
+//!   e.g. `#!/usr/bin/env python3`,
+//! - [Vim](https://vimhelp.org/options.txt.html#modeline) and
+//!   [Emacs](https://www.gnu.org/software/emacs/manual/html_node/emacs/Specifying-File-Variables.html)
+//!   modelines, e.g. `# vim: set ft=python:` or `# -*- mode: python -*-`.
+
+use log::trace;
+
+/// Number of lines at the start and end of a file searched for Vim modelines.
+///
+/// Corresponds to Vim's default for its `modelines` option.
+const VIM_MODELINES: usize = 5;
+
+/// Extract the name of the interpreter from a shebang on the first line of `input`, if
+/// any.
+///
+/// The interpreter is reported as its bare program name, with any trailing version
+/// information stripped off (`/usr/bin/python3.11` yields `python`). Indirection via
+/// `env` is followed, skipping over any options passed to it.
+#[must_use]
+pub fn shebang_interpreter(input: &str) -> Option<&str> {
+    let line = input.lines().next()?.strip_prefix("#!")?;
+    let mut words = line.split_whitespace();
+
+    let mut program = basename(words.next()?);
+    if program == "env" {
+        // Skip options such as `-S` (split string) or `-i` (ignore environment), and
+        // variable assignments such as `FOO=bar`.
+        program = basename(words.find(|w| !w.starts_with('-') && !w.contains('='))?);
+    }
+
+    let interpreter = program.trim_end_matches(|c: char| c.is_ascii_digit() || c == '.');
+    trace!("Shebang interpreter detected: {:?}", interpreter);
+
+    (!interpreter.is_empty()).then_some(interpreter)
+}
+
+/// Extract the language name declared in a Vim or Emacs modeline of `input`, if any.
+///
+/// Vim modelines are searched for in the first and last few lines (as Vim does), Emacs
+/// modelines only in the first line, or in the second one if the first one is a
+/// shebang.
+#[must_use]
+pub fn modeline_language(input: &str) -> Option<&str> {
+    let lines = input.lines().collect::<Vec<_>>();
+
+    let emacs_line = match lines.first() {
+        Some(first) if first.starts_with("#!") => lines.get(1),
+        first => first,
+    };
+    if let Some(lang) = emacs_line.and_then(|l| emacs_modeline(l)) {
+        trace!("Emacs modeline language detected: {:?}", lang);
+        return Some(lang);
+    }
+
+    let head = lines.iter().take(VIM_MODELINES);
+    let tail = lines
+        .iter()
+        .skip(VIM_MODELINES.max(lines.len().saturating_sub(VIM_MODELINES)));
+    let lang = head.chain(tail).find_map(|l| vim_modeline(*l));
+    trace!("Vim modeline language detected: {:?}", lang);
+
+    lang
+}
+
+/// Parse a single line as an Emacs modeline, such as `-*- mode: python -*-` or the
+/// short form `-*- python -*-`.
+fn emacs_modeline(line: &str) -> Option<&str> {
+    let (_, rest) = line.split_once("-*-")?;
+    let (vars, _) = rest.split_once("-*-")?;
+
+    if !vars.contains(':') {
+        let mode = vars.trim();
+        return (!mode.is_empty()).then_some(mode);
+    }
+
+    vars.split(';').find_map(|var| {
+        let (key, value) = var.split_once(':')?;
+        key.trim()
+            .eq_ignore_ascii_case("mode")
+            .then(|| value.trim())
+    })
+}
+
+/// Parse a single line as a Vim modeline, such as `vim: set ft=python:` or
+/// `vi: filetype=python`.
+fn vim_modeline(line: &str) -> Option<&str> {
+    let options = ["vim:", "vi:", "ex:"].iter().find_map(|marker| {
+        line.match_indices(marker)
+            // Markers need to stand on their own, else e.g. `movi:` would qualify.
+            .find(|(i, _)| {
+                line[..*i]
+                    .chars()
+                    .next_back()
+                    .map_or(true, char::is_whitespace)
+            })
+            .map(|(i, _)| &line[i + marker.len()..])
+    })?;
+    let options = options.trim_start();
+    let options = options
+        .strip_prefix("set ")
+        .or_else(|| options.strip_prefix("se "))
+        .unwrap_or(options);
+
+    options
+        .split(|c: char| c == ':' || c.is_whitespace())
+        .find_map(|option| {
+            let (key, value) = option.split_once('=')?;
+            matches!(key, "ft" | "filetype" | "syn" | "syntax").then_some(value)
+        })
+        .filter(|value| !value.is_empty())
+}
+
+fn basename(path: &str) -> &str {
+    path.rsplit('/').next().unwrap_or(path)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use rstest::rstest;
+
+    #[rstest]
+    #[case("#!/usr/bin/env python3\nprint(1)", Some("python"))]
+    #[case("#!/usr/bin/python3.11\n", Some("python"))]
+    #[case("#!/usr/bin/env -S python3 -u\n", Some("python"))]
+    #[case("#!/usr/bin/env FOO=bar ts-node\n", Some("ts-node"))]
+    #[case("#! /bin/sh", Some("sh"))]
+    #[case("#!/usr/bin/env", None)]
+    #[case("#!", None)]
+    #[case("print(1)\n#!/usr/bin/env python3", None)]
+    #[case("", None)]
+    fn test_shebang_interpreter(#[case] input: &str, #[case] expected: Option<&str>) {
+        assert_eq!(shebang_interpreter(input), expected);
+    }
+
+    #[rstest]
+    #[case("# -*- mode: python -*-\n", Some("python"))]
+    #[case("# -*- coding: utf-8; mode: python -*-\n", Some("python"))]
+    #[case("// -*- go -*-\n", Some("go"))]
+    #[case("#!/bin/foo\n# -*- mode: python -*-\n", Some("python"))]
+    #[case("\n\n# -*- mode: python -*-\n", None)]
+    #[case("# vim: set ft=python:\n", Some("python"))]
+    #[case("# vim: ft=rust\n", Some("rust"))]
+    #[case("line\n// vi: set sw=4 filetype=go :\n", Some("go"))]
+    #[case(
+        "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n# vim: syntax=python\n",
+        Some("python")
+    )]
+    #[case("1\n2\n3\n4\n5\n# vim: ft=python\n6\n7\n8\n9\n10\n", None)]
+    #[case("# vim: set ts=4:\n", None)]
+    #[case("# vim: ft=\n", None)]
+    #[case("no modeline here", None)]
+    fn test_modeline_language(#[case] input: &str, #[case] expected: Option<&str>) {
+        assert_eq!(modeline_language(input), expected);
+    }
+}
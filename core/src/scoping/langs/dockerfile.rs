@@ -0,0 +1,115 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Dockerfile language.
+pub type Dockerfile = Language<DockerfileQuery>;
+/// A query for Dockerfiles.
+pub type DockerfileQuery = CodeQuery<CustomDockerfileQuery, PremadeDockerfileQuery>;
+
+/// Premade tree-sitter queries for Dockerfiles.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeDockerfileQuery {
+    /// Comments.
+    Comments,
+    /// Image references of `FROM` instructions (name, tag and digest).
+    Images,
+    /// Commands of `RUN` instructions (excl. flags such as `--mount`).
+    RunCommands,
+    /// Variable declarations of `ENV` and `ARG` instructions (names and values).
+    Variables,
+    /// Key-value pairs of `LABEL` instructions.
+    Labels,
+}
+
+impl From<PremadeDockerfileQuery> for TSQuery {
+    fn from(value: PremadeDockerfileQuery) -> Self {
+        TSQuery::new(
+            Dockerfile::lang(),
+            match value {
+                PremadeDockerfileQuery::Comments => "(comment) @comment",
+                PremadeDockerfileQuery::Images => "(from_instruction (image_spec) @image)",
+                PremadeDockerfileQuery::RunCommands => {
+                    r"
+                    (run_instruction
+                        [(shell_command) (json_string_array)] @command
+                    )
+                    "
+                }
+                PremadeDockerfileQuery::Variables => {
+                    r"
+                    [
+                        (env_instruction (env_pair) @pair)
+                        (arg_instruction name: (_) @name)
+                        (arg_instruction default: (_) @default)
+                    ]
+                    "
+                }
+                PremadeDockerfileQuery::Labels => "(label_instruction (label_pair) @label)",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Dockerfiles.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomDockerfileQuery(String, Precompiled);
+
+impl FromStr for CustomDockerfileQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Dockerfile::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomDockerfileQuery> for TSQuery {
+    fn from(value: CustomDockerfileQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Dockerfile::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Dockerfile {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Dockerfile {
+    fn lang() -> TSLanguage {
+        tree_sitter_dockerfile::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["dockerfile"]
+    }
+
+    fn file_names() -> &'static [&'static str] {
+        &["Dockerfile", "Containerfile"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["dockerfile"]
+    }
+}
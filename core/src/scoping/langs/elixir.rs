@@ -0,0 +1,157 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Elixir language.
+pub type Elixir = Language<ElixirQuery>;
+/// A query for Elixir.
+pub type ElixirQuery = CodeQuery<CustomElixirQuery, PremadeElixirQuery>;
+
+/// Premade tree-sitter queries for Elixir.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeElixirQuery {
+    /// Comments.
+    Comments,
+    /// Strings (incl. charlists and sigils; interpolation is respected; quotes included).
+    Strings,
+    /// Module definitions (`defmodule`; incl. body).
+    Modules,
+    /// Function definitions (`def`, `defp`; incl. body).
+    Functions,
+    /// Module attributes (incl. `@` and value).
+    ModuleAttributes,
+    /// Documentation (values of `@moduledoc`, `@doc` and `@typedoc`).
+    Docs,
+}
+
+impl From<PremadeElixirQuery> for TSQuery {
+    fn from(value: PremadeElixirQuery) -> Self {
+        TSQuery::new(
+            Elixir::lang(),
+            match value {
+                PremadeElixirQuery::Comments => "(comment) @comment",
+                PremadeElixirQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string)
+                        (string (interpolation) @",
+                        IGNORE,
+                        ")
+                        (charlist)
+                        (charlist (interpolation) @",
+                        IGNORE,
+                        ")
+                        (sigil)
+                        (sigil (interpolation) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadeElixirQuery::Modules => {
+                    r#"
+                    (call
+                        target: (identifier) @name
+                        (#eq? @name "defmodule")
+                    ) @module
+                    "#
+                }
+                PremadeElixirQuery::Functions => {
+                    r#"
+                    (call
+                        target: (identifier) @name
+                        (#match? @name "^defp?$")
+                    ) @function
+                    "#
+                }
+                PremadeElixirQuery::ModuleAttributes => {
+                    r#"(unary_operator operator: "@") @attribute"#
+                }
+                PremadeElixirQuery::Docs => {
+                    concatcp!(
+                        r#"
+                    (unary_operator
+                        operator: "@"
+                        operand: (call
+                            target: (identifier) @"#,
+                        IGNORE,
+                        r#"
+                            (arguments) @doc
+                        )
+                        (#match? @"#,
+                        IGNORE,
+                        r#" "^(module|type)?doc$")
+                    )
+                    "#
+                    )
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Elixir.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomElixirQuery(String, Precompiled);
+
+impl FromStr for CustomElixirQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Elixir::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomElixirQuery> for TSQuery {
+    fn from(value: CustomElixirQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Elixir::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Elixir {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Elixir {
+    fn lang() -> TSLanguage {
+        tree_sitter_elixir::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["ex", "exs"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["elixir"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["elixir"]
+    }
+}
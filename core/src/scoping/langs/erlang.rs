@@ -0,0 +1,117 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Erlang language.
+pub type Erlang = Language<ErlangQuery>;
+/// A query for Erlang.
+pub type ErlangQuery = CodeQuery<CustomErlangQuery, PremadeErlangQuery>;
+
+/// Premade tree-sitter queries for Erlang.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeErlangQuery {
+    /// Comments.
+    Comments,
+    /// Strings (incl. quotes).
+    Strings,
+    /// Atoms (incl. quotes, if any).
+    Atoms,
+    /// Function clauses (name, arguments, guards and body).
+    FunctionClauses,
+    /// Module attributes, as in `-module(...)`, `-export(...)` or custom ones (excl. specs,
+    /// types and records).
+    ModuleAttributes,
+    /// `-spec` declarations.
+    Specs,
+}
+
+impl From<PremadeErlangQuery> for TSQuery {
+    fn from(value: PremadeErlangQuery) -> Self {
+        TSQuery::new(
+            Erlang::lang(),
+            match value {
+                PremadeErlangQuery::Comments => "(comment) @comment",
+                PremadeErlangQuery::Strings => "(string) @string",
+                PremadeErlangQuery::Atoms => "(atom) @atom",
+                PremadeErlangQuery::FunctionClauses => "(function_clause) @clause",
+                PremadeErlangQuery::ModuleAttributes => {
+                    r"
+                    [
+                        (module_attribute)
+                        (behaviour_attribute)
+                        (export_attribute)
+                        (import_attribute)
+                        (compile_options_attribute)
+                        (wild_attribute)
+                    ]
+                    @attribute
+                    "
+                }
+                PremadeErlangQuery::Specs => "(spec) @spec",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Erlang.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomErlangQuery(String, Precompiled);
+
+impl FromStr for CustomErlangQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Erlang::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomErlangQuery> for TSQuery {
+    fn from(value: CustomErlangQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Erlang::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Erlang {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Erlang {
+    fn lang() -> TSLanguage {
+        tree_sitter_erlang::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["erl", "hrl"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["escript"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["erlang"]
+    }
+}
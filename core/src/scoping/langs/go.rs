@@ -0,0 +1,262 @@
+use super::{
+    matching, predicate, CodeQuery, Filterable, Language, LanguageScoper, Overlaps, Precompiled,
+    TSLanguage, TSQuery,
+};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use fancy_regex::Regex;
+use serde::Deserialize;
+use std::{
+    fmt::Debug,
+    hash::{Hash, Hasher},
+    ops::Range,
+    str::FromStr,
+};
+use tree_sitter::{QueryError, QueryErrorKind, QueryPredicateArg};
+
+/// The Go language.
+pub type Go = Language<GoQuery>;
+/// A query for Go.
+pub type GoQuery = CodeQuery<CustomGoQuery, PremadeGoQuery>;
+
+/// Premade tree-sitter queries for Go.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeGoQuery {
+    /// Comments (single- and multi-line).
+    Comments,
+    /// Strings (interpreted and raw; excluding struct tags).
+    Strings,
+    /// Imports.
+    Imports,
+    /// Struct tags (raw string literals of struct fields, such as `json:"name"`).
+    /// Filter by key, as in `struct-tags~json`, to scope only the values (excl. quotes)
+    /// of matching keys.
+    StructTags,
+    /// Interface type declarations, incl. their method sets. Filter by interface name,
+    /// as in `interface~Testable`.
+    Interface,
+    /// Method receivers (incl. parentheses), such as `(tc *TestCase)`.
+    Receiver,
+}
+
+impl From<PremadeGoQuery> for TSQuery {
+    fn from(value: PremadeGoQuery) -> Self {
+        TSQuery::new(
+            Go::lang(),
+            match value {
+                PremadeGoQuery::Comments => "(comment) @comment",
+                PremadeGoQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (raw_string_literal)
+                        (interpreted_string_literal)
+                        (import_spec (interpreted_string_literal) @",
+                        IGNORE,
+                        ")
+                        (field_declaration tag: (raw_string_literal) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadeGoQuery::Imports => {
+                    r"(import_spec path: (interpreted_string_literal) @path)"
+                }
+                PremadeGoQuery::StructTags => STRUCT_TAGS,
+                PremadeGoQuery::Interface => INTERFACE,
+                PremadeGoQuery::Receiver => {
+                    r"(method_declaration receiver: (parameter_list) @receiver)"
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+const STRUCT_TAGS: &str = "(field_declaration tag: (raw_string_literal) @tag)";
+const INTERFACE: &str =
+    "(type_spec name: (type_identifier) @_name type: (interface_type)) @interface";
+
+/// Predicate narrowing struct tags down to the values of keys matching its pattern.
+///
+/// Not known to tree-sitter itself, but evaluated while scoping.
+const TAG_KEY_MATCH: &str = "tag-key-match?";
+
+impl Filterable for PremadeGoQuery {
+    fn filtered(self, pattern: &str) -> Option<String> {
+        match self {
+            Self::StructTags => Some(format!(
+                "({STRUCT_TAGS} {})",
+                predicate(TAG_KEY_MATCH, "tag", pattern)
+            )),
+            Self::Interface => Some(format!("({INTERFACE} {})", matching("_name", pattern))),
+            Self::Comments | Self::Strings | Self::Imports | Self::Receiver => None,
+        }
+    }
+}
+
+/// A custom tree-sitter query for Go.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomGoQuery(String, Precompiled, TagKey);
+
+impl FromStr for CustomGoQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Go::lang(), s) {
+            Ok(query) => {
+                let key = TagKey(tag_key(&query)?);
+
+                Ok(Self(s.to_string(), Precompiled::new(query), key))
+            }
+            Err(e) => Err(e),
+        }
+    }
+}
+
+/// The pattern of a query's [`TAG_KEY_MATCH`] predicate, if any, compiled once while
+/// validating the query.
+///
+/// Derived entirely from the query's source, so it is ignored for comparisons and
+/// hashing: all instances compare equal and hash alike. That is only sound because
+/// [`CustomGoQuery`] also holds the source (field 0), which does decide equality.
+#[derive(Debug, Clone)]
+struct TagKey(Option<Regex>);
+
+impl PartialEq for TagKey {
+    fn eq(&self, _other: &Self) -> bool {
+        true
+    }
+}
+
+impl Eq for TagKey {}
+
+impl Hash for TagKey {
+    fn hash<H: Hasher>(&self, _state: &mut H) {}
+}
+
+impl From<CustomGoQuery> for TSQuery {
+    fn from(value: CustomGoQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Go::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Go {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Go {
+    fn lang() -> TSLanguage {
+        tree_sitter_go::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["go"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["gorun"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["go"]
+    }
+
+    fn narrow(&self, range: Range<usize>, input: &str) -> Vec<Range<usize>> {
+        let CodeQuery::Custom(CustomGoQuery(_, _, TagKey(Some(key)))) = &self.query else {
+            return vec![range];
+        };
+
+        tag_values(&input[range.clone()], key)
+            .into_iter()
+            .map(|value| range.start + value.start..range.start + value.end)
+            .collect()
+    }
+}
+
+/// The pattern struct tag keys have to match, as given to a [`TAG_KEY_MATCH`] predicate
+/// in `query`, if any.
+fn tag_key(query: &TSQuery) -> Result<Option<Regex>, QueryError> {
+    let pattern = (0..query.pattern_count())
+        .flat_map(|i| query.general_predicates(i))
+        .filter(|predicate| &*predicate.operator == TAG_KEY_MATCH)
+        .find_map(|predicate| match predicate.args.get(1) {
+            Some(QueryPredicateArg::String(pattern)) => Some(pattern),
+            _ => None,
+        });
+
+    pattern
+        .map(|pattern| Regex::new(pattern))
+        .transpose()
+        .map_err(|e| QueryError {
+            row: 0,
+            column: 0,
+            offset: 0,
+            message: e.to_string(),
+            kind: QueryErrorKind::Predicate,
+        })
+}
+
+/// Ranges of the values (excl. quotes) of all keys in `tag` matching `key`.
+///
+/// Tags are taken to follow Go's convention of space-separated `key:"value"` pairs, as
+/// understood by `reflect.StructTag`. Parsing stops at the first pair not following it.
+fn tag_values(tag: &str, key: &Regex) -> Vec<Range<usize>> {
+    let bytes = tag.as_bytes();
+    let mut values = Vec::new();
+    let mut i = 0;
+
+    loop {
+        // Skips the literal's opening backtick as well.
+        while i < bytes.len() && matches!(bytes[i], b' ' | b'`') {
+            i += 1;
+        }
+
+        let name_start = i;
+        while i < bytes.len() && bytes[i] > b' ' && !matches!(bytes[i], b':' | b'"' | b'`') {
+            i += 1;
+        }
+        if i == name_start || !tag[i..].starts_with(":\"") {
+            break;
+        }
+        let name = &tag[name_start..i];
+
+        i += 2;
+        let value_start = i;
+        while i < bytes.len() && bytes[i] != b'"' {
+            if bytes[i] == b'\\' {
+                i += 1;
+            }
+            i += 1;
+        }
+        if i >= bytes.len() {
+            break;
+        }
+
+        if key.is_match(name).unwrap_or(false) {
+            values.push(value_start..i);
+        }
+        i += 1;
+    }
+
+    values
+}
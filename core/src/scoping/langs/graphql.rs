@@ -0,0 +1,98 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The GraphQL language.
+pub type GraphQL = Language<GraphQLQuery>;
+/// A query for GraphQL.
+pub type GraphQLQuery = CodeQuery<CustomGraphQLQuery, PremadeGraphQLQuery>;
+
+/// Premade tree-sitter queries for GraphQL.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeGraphQLQuery {
+    /// Comments.
+    Comments,
+    /// Descriptions (of types, fields, ...), incl. quotes.
+    Descriptions,
+    /// Type definitions (objects, interfaces, unions, enums, input objects and
+    /// scalars).
+    TypeDefinitions,
+    /// Field definitions (name, arguments and type) of objects and interfaces.
+    FieldDefinitions,
+    /// Directives applied, as in `@deprecated(reason: "...")`.
+    Directives,
+}
+
+impl From<PremadeGraphQLQuery> for TSQuery {
+    fn from(value: PremadeGraphQLQuery) -> Self {
+        TSQuery::new(
+            GraphQL::lang(),
+            match value {
+                PremadeGraphQLQuery::Comments => "(comment) @comment",
+                PremadeGraphQLQuery::Descriptions => "(description) @description",
+                PremadeGraphQLQuery::TypeDefinitions => "(type_definition) @type",
+                PremadeGraphQLQuery::FieldDefinitions => "(field_definition) @field",
+                PremadeGraphQLQuery::Directives => "(directive) @directive",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for GraphQL.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomGraphQLQuery(String, Precompiled);
+
+impl FromStr for CustomGraphQLQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(GraphQL::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomGraphQLQuery> for TSQuery {
+    fn from(value: CustomGraphQLQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(GraphQL::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for GraphQL {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for GraphQL {
+    fn lang() -> TSLanguage {
+        tree_sitter_graphql::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["graphql", "gql"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["graphql"]
+    }
+}
@@ -0,0 +1,130 @@
+use super::{
+    matching, CodeQuery, Filterable, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage,
+    TSQuery,
+};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Groovy language.
+pub type Groovy = Language<GroovyQuery>;
+/// A query for Groovy.
+pub type GroovyQuery = CodeQuery<CustomGroovyQuery, PremadeGroovyQuery>;
+
+/// Premade tree-sitter queries for Groovy.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeGroovyQuery {
+    /// Comments.
+    Comments,
+    /// Strings (incl. GStrings, excl. their interpolations).
+    Strings,
+    /// Closures.
+    Closures,
+    /// Method calls (incl. ones without parentheses, as common in Gradle build
+    /// scripts). Filter by method name, as in `calls~implementation|api`.
+    Calls,
+}
+
+impl PremadeGroovyQuery {
+    fn source(self) -> &'static str {
+        match self {
+            Self::Comments => "(comment) @comment",
+            Self::Strings => concatcp!("(string) @string (interpolation) @", IGNORE),
+            Self::Closures => "(closure) @closure",
+            Self::Calls => {
+                r"
+                [
+                    (function_call function: (_) @_name)
+                    (juxt_function_call function: (_) @_name)
+                ]
+                @call
+                "
+            }
+        }
+    }
+}
+
+impl From<PremadeGroovyQuery> for TSQuery {
+    fn from(value: PremadeGroovyQuery) -> Self {
+        TSQuery::new(Groovy::lang(), value.source()).expect("Premade queries to be valid")
+    }
+}
+
+impl Filterable for PremadeGroovyQuery {
+    fn filtered(self, pattern: &str) -> Option<String> {
+        match self {
+            Self::Calls => Some(format!(
+                "({} {})",
+                self.source(),
+                matching("_name", pattern)
+            )),
+            Self::Comments | Self::Strings | Self::Closures => None,
+        }
+    }
+}
+
+/// A custom tree-sitter query for Groovy.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomGroovyQuery(String, Precompiled);
+
+impl FromStr for CustomGroovyQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Groovy::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomGroovyQuery> for TSQuery {
+    fn from(value: CustomGroovyQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Groovy::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Groovy {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Groovy {
+    fn lang() -> TSLanguage {
+        tree_sitter_groovy::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["groovy", "gvy", "gradle"]
+    }
+
+    fn file_names() -> &'static [&'static str] {
+        &["Jenkinsfile"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["groovy"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["groovy"]
+    }
+}
@@ -0,0 +1,101 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Haskell language.
+pub type Haskell = Language<HaskellQuery>;
+/// A query for Haskell.
+pub type HaskellQuery = CodeQuery<CustomHaskellQuery, PremadeHaskellQuery>;
+
+/// Premade tree-sitter queries for Haskell.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeHaskellQuery {
+    /// Comments (line and block; excl. pragmas).
+    Comments,
+    /// Strings (incl. quotes).
+    Strings,
+    /// Type signatures (incl. name).
+    TypeSignatures,
+    /// Function bindings (incl. arguments and body).
+    Functions,
+    /// Pragmas, such as `{-# LANGUAGE ... #-}` (incl. braces).
+    Pragmas,
+}
+
+impl From<PremadeHaskellQuery> for TSQuery {
+    fn from(value: PremadeHaskellQuery) -> Self {
+        TSQuery::new(
+            Haskell::lang(),
+            match value {
+                PremadeHaskellQuery::Comments => "(comment) @comment",
+                PremadeHaskellQuery::Strings => "(string) @string",
+                PremadeHaskellQuery::TypeSignatures => "(signature) @signature",
+                PremadeHaskellQuery::Functions => "[(function) (bind)] @function",
+                PremadeHaskellQuery::Pragmas => "(pragma) @pragma",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Haskell.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomHaskellQuery(String, Precompiled);
+
+impl FromStr for CustomHaskellQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Haskell::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomHaskellQuery> for TSQuery {
+    fn from(value: CustomHaskellQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Haskell::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Haskell {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Haskell {
+    fn lang() -> TSLanguage {
+        tree_sitter_haskell::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["hs"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["runhaskell", "runghc"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["haskell"]
+    }
+}
@@ -0,0 +1,136 @@
+use super::{
+    matching, CodeQuery, Filterable, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage,
+    TSQuery,
+};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The HTML language.
+pub type Html = Language<HtmlQuery>;
+/// A query for HTML.
+pub type HtmlQuery = CodeQuery<CustomHtmlQuery, PremadeHtmlQuery>;
+
+/// Premade tree-sitter queries for HTML.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeHtmlQuery {
+    /// Comments.
+    Comments,
+    /// Text content of elements.
+    Text,
+    /// Attributes (name and value). Filter by name, as in `attributes~data-.*`.
+    Attributes,
+    /// Attribute values (quotes included). Filter by attribute name, as in
+    /// `attribute-values~class`.
+    AttributeValues,
+    /// Elements (incl. tags and content). Filter by tag name, as in `elements~div`.
+    Elements,
+}
+
+impl PremadeHtmlQuery {
+    fn source(self) -> &'static str {
+        match self {
+            Self::Comments => "(comment) @comment",
+            Self::Text => "(text) @text",
+            Self::Attributes => "(attribute (attribute_name) @_name) @attribute",
+            Self::AttributeValues => {
+                concatcp!(
+                    "(attribute (attribute_name) @",
+                    IGNORE,
+                    " [(attribute_value) (quoted_attribute_value)] @value)"
+                )
+            }
+            Self::Elements => {
+                r"
+                [
+                    (element (start_tag (tag_name) @_name))
+                    (element (self_closing_tag (tag_name) @_name))
+                    (script_element (start_tag (tag_name) @_name))
+                    (style_element (start_tag (tag_name) @_name))
+                ]
+                @element
+                "
+            }
+        }
+    }
+}
+
+impl From<PremadeHtmlQuery> for TSQuery {
+    fn from(value: PremadeHtmlQuery) -> Self {
+        TSQuery::new(Html::lang(), value.source()).expect("Premade queries to be valid")
+    }
+}
+
+impl Filterable for PremadeHtmlQuery {
+    fn filtered(self, pattern: &str) -> Option<String> {
+        let capture = match self {
+            Self::Attributes | Self::Elements => "_name",
+            Self::AttributeValues => IGNORE,
+            Self::Comments | Self::Text => return None,
+        };
+
+        Some(format!(
+            "({} {})",
+            self.source(),
+            matching(capture, pattern)
+        ))
+    }
+}
+
+/// A custom tree-sitter query for HTML.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomHtmlQuery(String, Precompiled);
+
+impl FromStr for CustomHtmlQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Html::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomHtmlQuery> for TSQuery {
+    fn from(value: CustomHtmlQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Html::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Html {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Html {
+    fn lang() -> TSLanguage {
+        tree_sitter_html::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["html", "htm"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["html"]
+    }
+}
@@ -0,0 +1,106 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Java language.
+pub type Java = Language<JavaQuery>;
+/// A query for Java.
+pub type JavaQuery = CodeQuery<CustomJavaQuery, PremadeJavaQuery>;
+
+/// Premade tree-sitter queries for Java.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeJavaQuery {
+    /// Comments (line and block; incl. Javadoc).
+    Comments,
+    /// Strings (incl. quotes).
+    Strings,
+    /// Imports (incl. periods; excl. `import`/`static`/`*`).
+    Imports,
+    /// Class definitions (entire class, incl. modifiers and body).
+    Classes,
+    /// Method definitions (entire method, incl. modifiers and body).
+    Methods,
+    /// Annotations (incl. `@` and arguments).
+    Annotations,
+}
+
+impl From<PremadeJavaQuery> for TSQuery {
+    fn from(value: PremadeJavaQuery) -> Self {
+        TSQuery::new(
+            Java::lang(),
+            match value {
+                PremadeJavaQuery::Comments => "[(line_comment) (block_comment)] @comment",
+                PremadeJavaQuery::Strings => "(string_literal) @string",
+                PremadeJavaQuery::Imports => {
+                    r"(import_declaration [(identifier) (scoped_identifier)] @import)"
+                }
+                PremadeJavaQuery::Classes => "(class_declaration) @class",
+                PremadeJavaQuery::Methods => "(method_declaration) @method",
+                PremadeJavaQuery::Annotations => "[(annotation) (marker_annotation)] @annotation",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Java.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomJavaQuery(String, Precompiled);
+
+impl FromStr for CustomJavaQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Java::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomJavaQuery> for TSQuery {
+    fn from(value: CustomJavaQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Java::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Java {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Java {
+    fn lang() -> TSLanguage {
+        tree_sitter_java::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["java"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["java"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["java"]
+    }
+}
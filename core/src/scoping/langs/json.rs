@@ -0,0 +1,100 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The JSON language.
+pub type Json = Language<JsonQuery>;
+/// A query for JSON (incl. JSONC).
+pub type JsonQuery = CodeQuery<CustomJsonQuery, PremadeJsonQuery>;
+
+/// Premade tree-sitter queries for JSON (incl. JSONC).
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeJsonQuery {
+    /// Keys of objects (quotes included).
+    Keys,
+    /// String values (excl. keys; quotes included).
+    Strings,
+    /// Comments (JSONC).
+    Comments,
+}
+
+impl From<PremadeJsonQuery> for TSQuery {
+    fn from(value: PremadeJsonQuery) -> Self {
+        TSQuery::new(
+            Json::lang(),
+            match value {
+                PremadeJsonQuery::Keys => "(pair key: (string) @key)",
+                PremadeJsonQuery::Strings => {
+                    concatcp!(
+                        "
+                    (string) @string
+                    (pair key: (_) @",
+                        IGNORE,
+                        ")"
+                    )
+                }
+                PremadeJsonQuery::Comments => "(comment) @comment",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for JSON (incl. JSONC).
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomJsonQuery(String, Precompiled);
+
+impl FromStr for CustomJsonQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Json::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomJsonQuery> for TSQuery {
+    fn from(value: CustomJsonQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Json::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Json {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Json {
+    fn lang() -> TSLanguage {
+        tree_sitter_json::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["json", "jsonc"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["json", "jsonc"]
+    }
+}
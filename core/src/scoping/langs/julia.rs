@@ -0,0 +1,127 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Julia language.
+pub type Julia = Language<JuliaQuery>;
+/// A query for Julia.
+pub type JuliaQuery = CodeQuery<CustomJuliaQuery, PremadeJuliaQuery>;
+
+/// Premade tree-sitter queries for Julia.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeJuliaQuery {
+    /// Comments (line and block).
+    Comments,
+    /// Docstrings (strings immediately preceding a definition; quotes included).
+    DocStrings,
+    /// Strings (incl. triple-quoted; interpolation is respected; quotes included).
+    Strings,
+    /// Macro invocations (incl. `@` and arguments).
+    Macros,
+    /// Function definitions (`function` ... `end`; incl. body).
+    Functions,
+}
+
+impl From<PremadeJuliaQuery> for TSQuery {
+    fn from(value: PremadeJuliaQuery) -> Self {
+        TSQuery::new(
+            Julia::lang(),
+            match value {
+                PremadeJuliaQuery::Comments => "[(line_comment) (block_comment)] @comment",
+                PremadeJuliaQuery::DocStrings => {
+                    r"
+                    (
+                        (string_literal) @docstring
+                        .
+                        [
+                            (function_definition)
+                            (macro_definition)
+                            (struct_definition)
+                            (module_definition)
+                            (abstract_definition)
+                        ]
+                    )
+                    "
+                }
+                PremadeJuliaQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string_literal)
+                        (string_literal (string_interpolation) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadeJuliaQuery::Macros => "(macrocall_expression) @macro",
+                PremadeJuliaQuery::Functions => "(function_definition) @function",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Julia.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomJuliaQuery(String, Precompiled);
+
+impl FromStr for CustomJuliaQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Julia::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomJuliaQuery> for TSQuery {
+    fn from(value: CustomJuliaQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Julia::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Julia {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Julia {
+    fn lang() -> TSLanguage {
+        tree_sitter_julia::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["jl"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["julia"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["julia"]
+    }
+}
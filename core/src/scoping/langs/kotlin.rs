@@ -0,0 +1,116 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Kotlin language.
+pub type Kotlin = Language<KotlinQuery>;
+/// A query for Kotlin.
+pub type KotlinQuery = CodeQuery<CustomKotlinQuery, PremadeKotlinQuery>;
+
+/// Premade tree-sitter queries for Kotlin.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeKotlinQuery {
+    /// Comments (line and multi-line).
+    Comments,
+    /// Strings (incl. quotes; excl. interpolated identifiers and expressions).
+    Strings,
+    /// Function declarations (entire function, incl. modifiers and body).
+    Functions,
+    /// Companion objects (incl. body).
+    CompanionObjects,
+    /// Annotations (incl. `@` and arguments).
+    Annotations,
+}
+
+impl From<PremadeKotlinQuery> for TSQuery {
+    fn from(value: PremadeKotlinQuery) -> Self {
+        TSQuery::new(
+            Kotlin::lang(),
+            match value {
+                PremadeKotlinQuery::Comments => "[(line_comment) (multiline_comment)] @comment",
+                PremadeKotlinQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string_literal)
+                        (string_literal (interpolated_identifier) @",
+                        IGNORE,
+                        ")
+                        (string_literal (interpolated_expression) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadeKotlinQuery::Functions => "(function_declaration) @function",
+                PremadeKotlinQuery::CompanionObjects => "(companion_object) @companion",
+                PremadeKotlinQuery::Annotations => "(annotation) @annotation",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Kotlin.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomKotlinQuery(String, Precompiled);
+
+impl FromStr for CustomKotlinQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Kotlin::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomKotlinQuery> for TSQuery {
+    fn from(value: CustomKotlinQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Kotlin::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Kotlin {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Kotlin {
+    fn lang() -> TSLanguage {
+        tree_sitter_kotlin::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["kt", "kts"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["kotlin"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["kotlin"]
+    }
+}
@@ -0,0 +1,100 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Lua language.
+pub type Lua = Language<LuaQuery>;
+/// A query for Lua.
+pub type LuaQuery = CodeQuery<CustomLuaQuery, PremadeLuaQuery>;
+
+/// Premade tree-sitter queries for Lua.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeLuaQuery {
+    /// Comments (single- and multi-line).
+    Comments,
+    /// Strings (incl. long brackets; quotes included).
+    Strings,
+    /// Function definitions (incl. local and anonymous functions; incl. body).
+    Functions,
+    /// Table constructors (incl. braces).
+    Tables,
+}
+
+impl From<PremadeLuaQuery> for TSQuery {
+    fn from(value: PremadeLuaQuery) -> Self {
+        TSQuery::new(
+            Lua::lang(),
+            match value {
+                PremadeLuaQuery::Comments => "(comment) @comment",
+                PremadeLuaQuery::Strings => "(string) @string",
+                PremadeLuaQuery::Functions => {
+                    "[(function_declaration) (function_definition)] @function"
+                }
+                PremadeLuaQuery::Tables => "(table_constructor) @table",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Lua.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomLuaQuery(String, Precompiled);
+
+impl FromStr for CustomLuaQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Lua::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomLuaQuery> for TSQuery {
+    fn from(value: CustomLuaQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Lua::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Lua {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Lua {
+    fn lang() -> TSLanguage {
+        tree_sitter_lua::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["lua"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["lua", "luajit"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["lua"]
+    }
+}
@@ -0,0 +1,105 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Makefile language.
+pub type Makefile = Language<MakefileQuery>;
+/// A query for Makefiles.
+pub type MakefileQuery = CodeQuery<CustomMakefileQuery, PremadeMakefileQuery>;
+
+/// Premade tree-sitter queries for Makefiles.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeMakefileQuery {
+    /// Comments.
+    Comments,
+    /// Targets of rules.
+    Targets,
+    /// Prerequisites of rules.
+    Prerequisites,
+    /// Recipe lines of rules (excl. leading tabs).
+    Recipes,
+    /// Variable assignments (names, operators and values).
+    Variables,
+}
+
+impl From<PremadeMakefileQuery> for TSQuery {
+    fn from(value: PremadeMakefileQuery) -> Self {
+        TSQuery::new(
+            Makefile::lang(),
+            match value {
+                PremadeMakefileQuery::Comments => "(comment) @comment",
+                PremadeMakefileQuery::Targets => "(rule (targets) @targets)",
+                PremadeMakefileQuery::Prerequisites => "(rule (prerequisites) @prerequisites)",
+                PremadeMakefileQuery::Recipes => "(recipe (recipe_line) @line)",
+                PremadeMakefileQuery::Variables => "(variable_assignment) @assignment",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Makefiles.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomMakefileQuery(String, Precompiled);
+
+impl FromStr for CustomMakefileQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Makefile::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomMakefileQuery> for TSQuery {
+    fn from(value: CustomMakefileQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Makefile::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Makefile {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Makefile {
+    fn lang() -> TSLanguage {
+        tree_sitter_make::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["mk", "mak", "make"]
+    }
+
+    fn file_names() -> &'static [&'static str] {
+        &["Makefile", "makefile", "GNUmakefile"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["make"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["make", "makefile"]
+    }
+}
@@ -0,0 +1,216 @@
+use super::{
+    matching, parse_parts, CodeQuery, Filterable, Language, LanguageScoper, Overlaps, Precompiled,
+    TSLanguage, TSNode, TSQuery, TSTree,
+};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::{QueryError, Range as TSRange};
+
+/// The Markdown language.
+pub type Markdown = Language<MarkdownQuery>;
+/// A query for Markdown.
+pub type MarkdownQuery = CodeQuery<CustomMarkdownQuery, PremadeMarkdownQuery>;
+
+/// Premade tree-sitter queries for Markdown.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeMarkdownQuery {
+    /// Contents of fenced code blocks. Filter by info string language, as in
+    /// `code-blocks~python`.
+    CodeBlocks,
+    /// Inline code (contents only, without backticks).
+    InlineCode,
+    /// Inline links (incl. reference links and autolinks).
+    Links,
+    /// Contents of headings (ATX and setext).
+    Headings,
+}
+
+impl PremadeMarkdownQuery {
+    fn source(self) -> &'static str {
+        match self {
+            Self::CodeBlocks => "(fenced_code_block (code_fence_content) @content)",
+            Self::InlineCode => {
+                concatcp!("(code_span) @code (code_span_delimiter) @", IGNORE)
+            }
+            Self::Links => {
+                r"
+                [
+                    (inline_link)
+                    (full_reference_link)
+                    (collapsed_reference_link)
+                    (uri_autolink)
+                    (email_autolink)
+                ]
+                @link
+                "
+            }
+            Self::Headings => {
+                r"
+                [
+                    (atx_heading heading_content: (_) @heading)
+                    (setext_heading heading_content: (_) @heading)
+                ]
+                "
+            }
+        }
+    }
+
+    /// Whether the query is over inline content, which tree-sitter parses using a
+    /// grammar separate from the one for the document's block structure.
+    fn is_inline(self) -> bool {
+        match self {
+            Self::InlineCode | Self::Links => true,
+            Self::CodeBlocks | Self::Headings => false,
+        }
+    }
+}
+
+impl From<PremadeMarkdownQuery> for TSQuery {
+    fn from(value: PremadeMarkdownQuery) -> Self {
+        let lang = if value.is_inline() {
+            tree_sitter_md::inline_language()
+        } else {
+            Markdown::lang()
+        };
+
+        TSQuery::new(lang, value.source()).expect("Premade queries to be valid")
+    }
+}
+
+impl Filterable for PremadeMarkdownQuery {
+    fn filtered(self, pattern: &str) -> Option<String> {
+        const LABELLED_CODE_BLOCKS: &str = concatcp!(
+            "(fenced_code_block (info_string (language) @",
+            IGNORE,
+            ") (code_fence_content) @content)"
+        );
+
+        match self {
+            Self::CodeBlocks => Some(format!(
+                "({LABELLED_CODE_BLOCKS} {})",
+                matching(IGNORE, pattern)
+            )),
+            Self::InlineCode | Self::Links | Self::Headings => None,
+        }
+    }
+}
+
+/// A custom tree-sitter query for Markdown.
+///
+/// Runs against the document's block structure only (paragraphs, headings, code blocks,
+/// ...), not against inline content such as links.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomMarkdownQuery(String, Precompiled);
+
+impl FromStr for CustomMarkdownQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Markdown::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomMarkdownQuery> for TSQuery {
+    fn from(value: CustomMarkdownQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Markdown::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Markdown {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Markdown {
+    fn lang() -> TSLanguage {
+        tree_sitter_md::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["md", "markdown"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["markdown"]
+    }
+
+    fn query_trees(&self, tree: &TSTree, input: &str) -> Vec<TSTree> {
+        if !matches!(self.query, CodeQuery::Premade(premade) if premade.is_inline()) {
+            return vec![tree.clone()];
+        }
+
+        let mut inlines = Vec::new();
+        collect_inlines(tree.root_node(), &mut inlines);
+
+        // Each inline node is parsed on its own, so constructs such as code spans cannot
+        // reach across paragraphs.
+        parse_parts(
+            tree_sitter_md::inline_language(),
+            inlines.into_iter().map(included_ranges),
+            input,
+        )
+    }
+}
+
+/// Collect all `inline` nodes at or below `node`, in order.
+fn collect_inlines<'tree>(node: TSNode<'tree>, inlines: &mut Vec<TSNode<'tree>>) {
+    if node.kind() == "inline" {
+        inlines.push(node);
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        collect_inlines(child, inlines);
+    }
+}
+
+/// The ranges of an `inline` node which hold its actual content.
+///
+/// Its children are left out: they are block-level markup continuing onto further
+/// lines, such as the `>` of block quotes.
+fn included_ranges(node: TSNode<'_>) -> Vec<TSRange> {
+    let mut ranges = Vec::new();
+    let (mut start_byte, mut start_point) = (node.start_byte(), node.start_position());
+
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        ranges.push(TSRange {
+            start_byte,
+            end_byte: child.start_byte(),
+            start_point,
+            end_point: child.start_position(),
+        });
+        (start_byte, start_point) = (child.end_byte(), child.end_position());
+    }
+
+    ranges.push(TSRange {
+        start_byte,
+        end_byte: node.end_byte(),
+        start_point,
+        end_point: node.end_position(),
+    });
+
+    ranges
+}
@@ -0,0 +1,1174 @@
+use super::Scoper;
+use crate::cancel;
+#[cfg(doc)]
+use crate::scoping::scope::Scope::{In, Out};
+use crate::scoping::scope::{merge, subtract, ROScopes};
+use crate::stats::{self, Phase};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use log::{debug, info, trace, warn};
+use serde::de::{DeserializeOwned, IntoDeserializer};
+use serde::Deserialize;
+use std::{
+    error::Error,
+    ffi::OsStr,
+    fmt,
+    hash::{Hash, Hasher},
+    marker::PhantomData,
+    ops::Range,
+    path::Path,
+    str::FromStr,
+    sync::{Arc, Mutex, OnceLock},
+};
+use tree_sitter::Range as TSRange;
+pub use tree_sitter::{
+    Language as TSLanguage, Node as TSNode, Parser as TSParser, Query as TSQuery,
+    QueryCursor as TSQueryCursor, Tree as TSTree,
+};
+
+/// Bash.
+pub mod bash;
+/// Clojure.
+pub mod clojure;
+/// CMake.
+pub mod cmake;
+/// C#.
+pub mod csharp;
+/// CSS.
+pub mod css;
+/// Dart.
+pub mod dart;
+/// Detecting languages from file contents.
+pub mod detect;
+/// Dockerfile.
+pub mod dockerfile;
+/// Elixir.
+pub mod elixir;
+/// Erlang.
+pub mod erlang;
+/// Go.
+pub mod go;
+/// GraphQL.
+pub mod graphql;
+/// Groovy.
+pub mod groovy;
+/// Haskell.
+pub mod haskell;
+/// HTML.
+pub mod html;
+/// Java.
+pub mod java;
+/// JSON.
+pub mod json;
+/// Julia.
+pub mod julia;
+/// Kotlin.
+pub mod kotlin;
+/// Lua.
+pub mod lua;
+/// Makefile.
+pub mod makefile;
+/// Markdown.
+pub mod markdown;
+/// Nix.
+pub mod nix;
+/// Objective-C.
+pub mod objc;
+/// OCaml.
+pub mod ocaml;
+/// Perl.
+pub mod perl;
+/// PHP.
+pub mod php;
+/// PowerShell.
+pub mod powershell;
+/// Protobuf.
+pub mod proto;
+/// Python.
+pub mod python;
+/// Ruby.
+pub mod ruby;
+/// Rust.
+pub mod rust;
+/// Scala.
+pub mod scala;
+/// SCSS.
+pub mod scss;
+/// Solidity.
+pub mod solidity;
+/// SQL.
+pub mod sql;
+/// Svelte.
+pub mod svelte;
+/// Swift.
+pub mod swift;
+/// TOML.
+pub mod toml;
+/// TypeScript.
+pub mod typescript;
+/// Vue.
+pub mod vue;
+/// YAML.
+pub mod yaml;
+/// Zig.
+pub mod zig;
+
+/// Represents a (programming) language.
+///
+/// Its query is compiled on first use only, and reused for all inputs scoped from then
+/// on, across threads.
+#[derive(Debug)]
+pub struct Language<Q> {
+    query: Q,
+    compiled: OnceLock<TSQuery>,
+    overlaps: Overlaps,
+}
+
+impl<Q> Language<Q> {
+    /// Create a new language with the given associated query over it.
+    pub fn new(query: Q) -> Self {
+        Self {
+            query,
+            compiled: OnceLock::new(),
+            overlaps: Overlaps::default(),
+        }
+    }
+
+    /// Resolve overlapping captures of the query as given by `overlaps`.
+    #[must_use]
+    pub fn with_overlaps(mut self, overlaps: Overlaps) -> Self {
+        self.overlaps = overlaps;
+        self
+    }
+}
+
+/// How to resolve nodes captured by a query which overlap, such as a function and a
+/// closure within it, both captured.
+///
+/// Nodes merely bordering each other never overlap. Nodes captured multiple times,
+/// such as by several patterns of a query, count once, and are thus acted on once only.
+/// How many duplicates were dropped is logged.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Hash, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum Overlaps {
+    /// Merge overlapping nodes into one, as well as bordering ones.
+    #[default]
+    Merge,
+    /// Of nested nodes, keep only the innermost ones. Partially overlapping ones are
+    /// merged.
+    Innermost,
+    /// Of nested nodes, keep only the outermost ones. Partially overlapping ones are
+    /// merged.
+    Outermost,
+    /// Fail if any nodes overlap, [cancelling][`crate::cancel`] the operation. Without
+    /// a current cancellation token, nothing is scoped.
+    Error,
+}
+
+impl Overlaps {
+    /// Resolve overlaps among `ranges` according to this policy.
+    ///
+    /// Returns [`None`] if [`Overlaps::Error`] and any do overlap.
+    fn resolve(self, mut ranges: Vec<Range<usize>>) -> Option<Vec<Range<usize>>> {
+        // Outermost first, for nested ones.
+        ranges.sort_by_key(|r| (r.start, std::cmp::Reverse(r.end)));
+        let n_captured = ranges.len();
+        ranges.dedup();
+        if ranges.len() < n_captured {
+            info!(
+                "Dropped {} duplicate captures of the same nodes",
+                n_captured - ranges.len()
+            );
+        }
+
+        let ranges = match self {
+            Self::Merge => return Some(merge(ranges)),
+            Self::Error => {
+                return ranges
+                    .windows(2)
+                    .all(|w| w[0].end <= w[1].start)
+                    .then_some(ranges);
+            }
+            Self::Outermost => {
+                let mut max_end = 0;
+                ranges
+                    .into_iter()
+                    .filter(|r| {
+                        let is_outermost = r.end > max_end;
+                        max_end = max_end.max(r.end);
+                        is_outermost
+                    })
+                    .collect()
+            }
+            Self::Innermost => {
+                // Going backwards, all ranges seen so far which start no earlier than the
+                // current one either lie after it, or nested within it.
+                let mut min_end = usize::MAX;
+                let mut res = ranges
+                    .into_iter()
+                    .rev()
+                    .filter(|r| {
+                        let is_innermost = r.end < min_end;
+                        min_end = min_end.min(r.end);
+                        is_innermost
+                    })
+                    .collect::<Vec<_>>();
+                res.reverse();
+                res
+            }
+        };
+
+        // Remaining overlaps are partial; bordering ranges stay apart.
+        let mut res: Vec<Range<usize>> = Vec::with_capacity(ranges.len());
+        for range in ranges {
+            match res.last_mut() {
+                Some(last) if last.end > range.start => last.end = last.end.max(range.end),
+                _ => res.push(range),
+            }
+        }
+
+        Some(res)
+    }
+}
+
+impl<Q: Clone + Into<TSQuery>> Language<Q> {
+    /// The compiled query, compiling it if this is its first use.
+    fn compiled_query(&self) -> &TSQuery {
+        self.compiled.get_or_init(|| {
+            debug!("Compiling query");
+            self.query.clone().into()
+        })
+    }
+}
+
+/// A custom query as compiled while validating it, kept for its first actual use.
+///
+/// Validating a custom query means compiling it. Instead of throwing that result away
+/// and compiling the query again when it is first used, it is handed over. Clones
+/// share it, so it is only ever handed over once.
+///
+/// Carries no information beyond its source, so all instances compare equal.
+#[derive(Clone)]
+pub(super) struct Precompiled(Arc<Mutex<Option<TSQuery>>>);
+
+impl Precompiled {
+    pub(super) fn new(query: TSQuery) -> Self {
+        Self(Arc::new(Mutex::new(Some(query))))
+    }
+
+    /// Take the precompiled query, if not taken yet, otherwise `compile` anew.
+    pub(super) fn take_or_else(&self, compile: impl FnOnce() -> TSQuery) -> TSQuery {
+        let taken = self.0.lock().expect("No panics while holding lock").take();
+
+        taken.unwrap_or_else(compile)
+    }
+}
+
+impl fmt::Debug for Precompiled {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str("Precompiled")
+    }
+}
+
+impl PartialEq for Precompiled {
+    fn eq(&self, _other: &Self) -> bool {
+        true
+    }
+}
+
+impl Eq for Precompiled {}
+
+impl Hash for Precompiled {
+    fn hash<H: Hasher>(&self, _state: &mut H) {}
+}
+
+/// A query over a language, for scoping.
+///
+/// Parts hit by the query are [`In`] scope, parts not hit are [`Out`] of scope.
+#[derive(Debug, Clone)]
+pub enum CodeQuery<C, P>
+where
+    C: FromStr + Into<TSQuery>,
+    P: Into<TSQuery>,
+{
+    /// A custom, user-defined query.
+    Custom(C),
+    /// A premade query.
+    ///
+    /// Availability depends on the language, respective languages features, and
+    /// implementation in this crate.
+    Premade(P),
+}
+
+impl<C, P> From<CodeQuery<C, P>> for TSQuery
+where
+    C: FromStr + Into<TSQuery>,
+    P: Into<TSQuery>,
+{
+    fn from(value: CodeQuery<C, P>) -> Self {
+        match value {
+            CodeQuery::Custom(query) => query.into(),
+            CodeQuery::Premade(query) => query.into(),
+        }
+    }
+}
+
+/// In a query, use this name to mark a capture to be ignored.
+///
+/// Useful for queries where tree-sitter doesn't natively support a fitting node type,
+/// and a result is instead obtained by ignoring unwanted parts of bigger captures.
+pub(super) const IGNORE: &str = "IGNORE";
+
+/// In a query, start a capture's name with this to mark it as a mere helper, such as
+/// for predicates to match against.
+///
+/// Helpers are neither scoped nor ignored, so they cannot overlap the nodes they sit
+/// in, unlike regular captures.
+pub(super) const HELPER: &str = "_";
+
+/// Premade queries some of which can be narrowed down by a pattern, such as to HTML
+/// elements of some tag names only.
+///
+/// On the command line, a filter follows the query's name, as in `elements~div|span`.
+pub trait Filterable {
+    /// Source of this query, narrowed down to parts whose name matches `pattern` (a
+    /// regular expression) in its entirety.
+    ///
+    /// What the name is depends on the query. Returns [`None`] if the query cannot be
+    /// filtered.
+    fn filtered(self, pattern: &str) -> Option<String>;
+}
+
+/// A predicate for the text captured by `capture` to match `pattern` in its entirety.
+pub(super) fn matching(capture: &str, pattern: &str) -> String {
+    predicate("match?", capture, pattern)
+}
+
+/// A predicate `operator` over `capture`, with `pattern` as a regular expression to
+/// match in its entirety.
+pub(super) fn predicate(operator: &str, capture: &str, pattern: &str) -> String {
+    let pattern = format!("^(?:{pattern})$")
+        .replace('\\', r"\\")
+        .replace('"', r#"\""#);
+
+    format!(r#"(#{operator} @{capture} "{pattern}")"#)
+}
+
+/// Rough estimate of the memory a syntax tree takes up, relative to its source.
+///
+/// Trees hold a node per token and then some, each taking up dozens of bytes; sources
+/// average a couple of bytes per token.
+const SYNTAX_TREE_BYTES_PER_INPUT_BYTE: usize = 16;
+
+/// A scoper for a language.
+///
+/// Functions much the same, but provides specific language-related functionality.
+pub trait LanguageScoper: Scoper {
+    /// The language's tree-sitter language.
+    fn lang() -> TSLanguage;
+
+    /// The language's tree-sitter query.
+    ///
+    /// Compiled once, no matter how many inputs it is used for.
+    fn query(&self) -> &TSQuery;
+
+    /// File extensions (without leading period) conventionally used by the language.
+    fn file_extensions() -> &'static [&'static str];
+
+    /// File names (up to any extension) conventionally used by the language, for files
+    /// often going without a telling extension (like `Dockerfile` or `Dockerfile.dev`).
+    fn file_names() -> &'static [&'static str] {
+        &[]
+    }
+
+    /// Interpreter names which, found in a shebang, indicate the language.
+    ///
+    /// As returned by [`detect::shebang_interpreter`], so without version suffixes.
+    fn interpreters() -> &'static [&'static str] {
+        &[]
+    }
+
+    /// Names which, found in a Vim or Emacs modeline, indicate the language.
+    fn modeline_names() -> &'static [&'static str];
+
+    /// Check whether a file at `path`, with the given `contents`, is written in the
+    /// language.
+    ///
+    /// Files with a recognized [extension][`Self::file_extensions`] or
+    /// [name][`Self::file_names`] are taken at face value. For all others, a shebang or
+    /// modeline is looked for in `contents`.
+    fn is_valid_file(path: &Path, contents: &str) -> bool {
+        let has_valid_extension = path
+            .extension()
+            .and_then(OsStr::to_str)
+            .is_some_and(|ext| Self::file_extensions().contains(&ext));
+        let has_valid_name = path
+            .file_stem()
+            .and_then(OsStr::to_str)
+            .is_some_and(|name| Self::file_names().contains(&name));
+
+        if has_valid_extension || has_valid_name {
+            return true;
+        }
+
+        trace!(
+            "No recognized extension for {:?}, inspecting contents",
+            path
+        );
+
+        let by_shebang = detect::shebang_interpreter(contents)
+            .is_some_and(|interpreter| Self::interpreters().contains(&interpreter));
+        let by_modeline = detect::modeline_language(contents)
+            .is_some_and(|name| Self::modeline_names().contains(&name));
+
+        by_shebang || by_modeline
+    }
+
+    /// The language's tree-sitter parser.
+    #[must_use]
+    fn parser() -> TSParser {
+        let mut parser = TSParser::new();
+        parser
+            .set_language(Self::lang())
+            .expect("Should be able to load language grammar and parser");
+
+        parser
+    }
+
+    /// How overlapping captures of [the query][`Self::query`] are resolved.
+    fn overlaps(&self) -> Overlaps {
+        Overlaps::default()
+    }
+
+    /// Scope the given input using the language's query.
+    ///
+    /// In principle, this is the same as [`Scoper::scope`].
+    fn scope_via_query(&self, input: &str) -> Vec<Range<usize>> {
+        parse::<Self>(input).map_or_else(Vec::new, |tree| self.scope_tree_via_query(&tree, input))
+    }
+
+    /// Scope the given input, already parsed into `tree`, using the language's query.
+    ///
+    /// Allows reusing trees, such as ones [incrementally
+    /// updated][`crate::session::Session`] after edits.
+    fn scope_tree_via_query(&self, tree: &TSTree, input: &str) -> Vec<Range<usize>> {
+        self.query_trees(tree, input)
+            .iter()
+            .flat_map(|tree| query_ranges(self.query(), tree, input, |_| true, self.overlaps()))
+            .flat_map(|range| self.narrow(range, input))
+            .collect()
+    }
+
+    /// The parts of a node at `range` in `input`, as captured by [the
+    /// query][`Self::query`], to actually scope, in order.
+    ///
+    /// Ordinarily, that is the node in full. Languages whose queries can narrow nodes
+    /// down further, such as Go's by struct tag keys, cut it down here. Applies alike to
+    /// [scoped ranges][`Self::scope_tree_via_query`] and [reported
+    /// captures][`NodeScoper::captures`], so both agree.
+    fn narrow(&self, range: Range<usize>, _input: &str) -> Vec<Range<usize>> {
+        vec![range]
+    }
+
+    /// The trees [the query][`Self::query`] runs against, given the `tree` parsed from
+    /// `input`, in order of their position in `input`.
+    ///
+    /// Ordinarily, that is just `tree`. Languages whose grammar is split into multiple
+    /// parts, such as Markdown with its inline content, parse further trees from it.
+    fn query_trees(&self, tree: &TSTree, _input: &str) -> Vec<TSTree> {
+        vec![tree.clone()]
+    }
+}
+
+/// Parse `input` using the parser of language `L`.
+///
+/// Returns [`None`] if parsing was [cancelled][`crate::cancel`].
+fn parse<L: LanguageScoper + ?Sized>(input: &str) -> Option<TSTree> {
+    let _timer = stats::Timer::start(Phase::Parse);
+
+    // tree-sitter is about incremental parsing, which we don't use here
+    let old_tree = None;
+
+    trace!("Parsing into AST: {:?}", input);
+
+    let mut parser = L::parser();
+
+    cancel::charge(input.len().saturating_mul(SYNTAX_TREE_BYTES_PER_INPUT_BYTE));
+    if cancel::is_cancelled() {
+        debug!("Cancelled, not parsing");
+        return None;
+    }
+
+    let tree = parse_cancellable(&mut parser, input, old_tree);
+    if tree.is_none() {
+        debug!("Parsing cancelled or timed out, scoping nothing");
+    }
+
+    tree
+}
+
+/// Parse `input` using `parser`, reusing `old_tree`, giving up once the current
+/// [cancellation token][`crate::cancel`] (if any) is cancelled, or its time is up.
+///
+/// Returns [`None`] if it gave up.
+#[allow(unsafe_code)]
+pub(crate) fn parse_cancellable(
+    parser: &mut TSParser,
+    input: &str,
+    old_tree: Option<&TSTree>,
+) -> Option<TSTree> {
+    let token = cancel::current();
+    if let Some(token) = &token {
+        // SAFETY: the parser holds on to the flag, which is unset again below, while
+        // `token` is still alive.
+        unsafe { parser.set_cancellation_flag(Some(token.flag())) };
+
+        if let Some(remaining) = token.remaining() {
+            // Zero would mean no timeout at all.
+            let micros = u64::try_from(remaining.as_micros()).unwrap_or(u64::MAX);
+            parser.set_timeout_micros(micros.max(1));
+        }
+    }
+
+    let tree = parser.parse(input, old_tree);
+
+    if token.is_some() {
+        // SAFETY: unset, the parser holds on to nothing.
+        unsafe { parser.set_cancellation_flag(None) };
+        parser.set_timeout_micros(0);
+    }
+
+    if tree.is_none() {
+        assert!(
+            token.is_some(),
+            "No language set in parser, or other unrecoverable error"
+        );
+        // Would otherwise resume where it gave up next time.
+        parser.reset();
+    }
+
+    tree
+}
+
+/// The raw text contents of all top-level nodes of kind `section` in `tree`, such as of
+/// `<script>` elements in markup.
+fn section_contents<'tree>(tree: &'tree TSTree, section: &str) -> Vec<TSNode<'tree>> {
+    let root = tree.root_node();
+    let mut cursor = root.walk();
+
+    root.children(&mut cursor)
+        .filter(|node| node.kind() == section)
+        .filter_map(|node| {
+            let mut cursor = node.walk();
+            // Bound, so the iterator borrowing `cursor` is dropped before it.
+            let raw_text = node
+                .children(&mut cursor)
+                .find(|child| child.kind() == "raw_text");
+            raw_text
+        })
+        .collect()
+}
+
+/// Parse the parts of `input` spanned by each of `nodes` on their own, using the grammar
+/// of `lang`, such as for code embedded in markup.
+fn parse_embedded(lang: TSLanguage, nodes: &[TSNode<'_>], input: &str) -> Vec<TSTree> {
+    parse_parts(lang, nodes.iter().map(|node| vec![node.range()]), input)
+}
+
+/// Parse each of `parts` of `input` on its own, using the grammar of `lang`. A part is
+/// made up of one or more ranges, parsed as if they were contiguous.
+///
+/// Like [`parse`], respects the current [cancellation token][`crate::cancel`]. Parts
+/// left unparsed because of it are left out.
+fn parse_parts(
+    lang: TSLanguage,
+    parts: impl IntoIterator<Item = Vec<TSRange>>,
+    input: &str,
+) -> Vec<TSTree> {
+    let _timer = stats::Timer::start(Phase::Parse);
+
+    let mut parser = TSParser::new();
+    parser
+        .set_language(lang)
+        .expect("Should be able to load language grammar and parser");
+
+    let mut trees = Vec::new();
+    for ranges in parts {
+        let len = ranges
+            .iter()
+            .map(|r| r.end_byte - r.start_byte)
+            .sum::<usize>();
+        cancel::charge(len.saturating_mul(SYNTAX_TREE_BYTES_PER_INPUT_BYTE));
+        if cancel::is_cancelled() {
+            debug!("Cancelled, not parsing further parts");
+            break;
+        }
+
+        if parser.set_included_ranges(&ranges).is_err() {
+            continue;
+        }
+
+        match parse_cancellable(&mut parser, input, None) {
+            Some(tree) => trees.push(tree),
+            None => {
+                debug!("Parsing part cancelled or timed out, not parsing further parts");
+                break;
+            }
+        }
+    }
+
+    trees
+}
+
+/// Run `query` over `tree` (parsed from `input`), returning ranges of all nodes
+/// captured by captures whose name is `selected`, with `overlaps` resolved, minus those
+/// captured by ones to [ignore][`IGNORE`].
+fn query_ranges(
+    query: &TSQuery,
+    tree: &TSTree,
+    input: &str,
+    selected: impl Fn(&str) -> bool,
+    overlaps: Overlaps,
+) -> Vec<Range<usize>> {
+    let _timer = stats::Timer::start(Phase::Query);
+
+    let root = tree.root_node();
+    debug!(
+        "S expression of parsed source code is: {:?}",
+        root.to_sexp()
+    );
+    trace!("Running query: {:?}", query);
+
+    let names = query.capture_names();
+    let mut ranges = Vec::new();
+    let mut ignored_ranges = Vec::new();
+
+    let mut qc = TSQueryCursor::new();
+    for capture in qc
+        .matches(query, root, input.as_bytes())
+        .flat_map(|query_match| query_match.captures)
+    {
+        let name: &str = &names[capture.index as usize];
+
+        if name.contains(IGNORE) {
+            ignored_ranges.push(capture.node.byte_range());
+        } else if !name.starts_with(HELPER) && selected(name) {
+            ranges.push(capture.node.byte_range());
+        }
+    }
+    trace!("Querying yielded ranges: {:?}", ranges);
+
+    // Resolve, because tree-sitter queries with multiple captures will return them in
+    // some mixed order (not ordered, and not merged), but we later rely on cleanly
+    // ordered, non-overlapping ranges (a bit unfortunate we have to know about that
+    // remote part over here).
+    let Some(ranges) = overlaps.resolve(ranges) else {
+        warn!("Query captured overlapping nodes, scoping nothing");
+        cancel::overlapping_scopes();
+        return Vec::new();
+    };
+
+    if ignored_ranges.is_empty() {
+        ranges
+    } else {
+        let res = subtract(ranges, &merge(ignored_ranges));
+        debug!("Ranges cleaned up after subtracting ignores: {:?}", res);
+
+        res
+    }
+}
+
+/// Run `query` over `tree` (parsed from `input`), reporting on all nodes captured by
+/// captures whose name is `selected`. See [`NodeScoper::captures`].
+fn query_captures(
+    query: &TSQuery,
+    tree: &TSTree,
+    input: &str,
+    selected: impl Fn(&str) -> bool,
+) -> Vec<Capture> {
+    let _timer = stats::Timer::start(Phase::Query);
+
+    let names = query.capture_names();
+    let mut qc = TSQueryCursor::new();
+    let mut captures = qc
+        .matches(query, tree.root_node(), input.as_bytes())
+        .flat_map(|query_match| query_match.captures)
+        .filter_map(|capture| {
+            let name: &str = &names[capture.index as usize];
+
+            let is_scoped = !name.contains(IGNORE) && !name.starts_with(HELPER);
+
+            (is_scoped && selected(name)).then(|| Capture {
+                range: capture.node.byte_range(),
+                kind: capture.node.kind(),
+                name: name.to_string(),
+            })
+        })
+        .collect::<Vec<_>>();
+
+    // Same node might be captured by multiple patterns of the query, under different
+    // names even. Report it once.
+    captures.sort_by_key(|c| (c.range.start, std::cmp::Reverse(c.range.end)));
+    captures.dedup_by(|later, earlier| later.range == earlier.range && later.kind == earlier.kind);
+    trace!("Query captured nodes: {:?}", captures);
+
+    captures
+}
+
+/// A syntax node captured by a language's query.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Capture {
+    /// Byte range of the node in the input.
+    pub range: Range<usize>,
+    /// Kind of the node, as named by the language's grammar (e.g. `comment`).
+    pub kind: &'static str,
+    /// Name of the query's capture the node was captured by (e.g. `comment` for
+    /// `@comment`).
+    pub name: String,
+}
+
+/// A [`Scoper`] which can report on the syntax nodes it captures.
+///
+/// Implemented for all [`LanguageScoper`]s. Unlike those, it is object safe, so it
+/// can be used for languages only known at runtime (see [`by_name`]).
+pub trait NodeScoper: Scoper {
+    /// All syntax nodes in `input` captured by the query, ordered by their start (and
+    /// outermost first, for nested ones).
+    ///
+    /// Unlike with [`Scoper::scope`], captures are neither merged nor cut down: nested
+    /// captures are reported individually, in full. Captures merely marking parts to be
+    /// ignored, or serving as helpers, are left out, and nodes captured multiple times are
+    /// reported once.
+    fn captures(&self, input: &str) -> Vec<Capture>;
+
+    /// A new parser for the language, as from [`LanguageScoper::parser`].
+    fn language_parser(&self) -> TSParser;
+
+    /// Scope an already parsed `tree` of `input`, as
+    /// [`LanguageScoper::scope_tree_via_query`] does.
+    fn scope_tree(&self, tree: &TSTree, input: &str) -> Vec<Range<usize>>;
+}
+
+impl<L: LanguageScoper> NodeScoper for L {
+    fn captures(&self, input: &str) -> Vec<Capture> {
+        parse::<Self>(input).map_or_else(Vec::new, |tree| {
+            self.query_trees(&tree, input)
+                .iter()
+                .flat_map(|tree| query_captures(self.query(), tree, input, |_| true))
+                .flat_map(|capture| {
+                    self.narrow(capture.range.clone(), input)
+                        .into_iter()
+                        .map(move |range| Capture {
+                            range,
+                            ..capture.clone()
+                        })
+                })
+                .collect()
+        })
+    }
+
+    fn language_parser(&self) -> TSParser {
+        L::parser()
+    }
+
+    fn scope_tree(&self, tree: &TSTree, input: &str) -> Vec<Range<usize>> {
+        self.scope_tree_via_query(tree, input)
+    }
+}
+
+/// A tree-sitter query for language `L`, compiled ahead of time.
+///
+/// Like the queries of [`Language`]s, it is compiled only once, but up front, and from
+/// arbitrary sources. Optionally, only some of the query's captures can be
+/// [selected][`CompiledQuery::with_captures`] for scoping, so a single query can serve
+/// multiple purposes.
+///
+/// ```rust
+/// use srgn_core::scoping::langs::{python::Python, CompiledQuery, LanguageScoper, TSQuery};
+/// use srgn_core::scoping::view::ScopedViewBuilder;
+///
+/// let query = TSQuery::new(
+///     Python::lang(),
+///     "(function_definition name: (identifier) @name body: (block) @body)",
+/// )
+/// .unwrap();
+/// let names = CompiledQuery::<Python>::new(query).with_captures(["name"]);
+///
+/// for input in ["def a(): pass", "def b(): pass"] {
+///     let mut builder = ScopedViewBuilder::new(input);
+///     builder.explode(&names);
+///
+///     let mut view = builder.build();
+///     view.delete();
+///     assert_eq!(view.to_string(), "def (): pass");
+/// }
+/// ```
+pub struct CompiledQuery<L> {
+    query: TSQuery,
+    captures: Option<Vec<String>>,
+    overlaps: Overlaps,
+    language: PhantomData<fn() -> L>,
+}
+
+impl<L> fmt::Debug for CompiledQuery<L> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_struct("CompiledQuery")
+            .field("query", &self.query)
+            .field("captures", &self.captures)
+            .field("overlaps", &self.overlaps)
+            .finish()
+    }
+}
+
+impl<L: LanguageScoper> CompiledQuery<L> {
+    /// Use `query`, which has to have been compiled for [`L::lang`][`LanguageScoper::lang`].
+    ///
+    /// All of its captures are used for scoping, unless
+    /// [selected][`CompiledQuery::with_captures`] otherwise.
+    #[must_use]
+    pub fn new(query: TSQuery) -> Self {
+        Self {
+            query,
+            captures: None,
+            overlaps: Overlaps::default(),
+            language: PhantomData,
+        }
+    }
+
+    /// Resolve overlapping captures of the query as given by `overlaps`.
+    #[must_use]
+    pub fn with_overlaps(mut self, overlaps: Overlaps) -> Self {
+        self.overlaps = overlaps;
+        self
+    }
+
+    /// Only scope to nodes captured by captures of the given `names` (without leading
+    /// `@`). Captures marking parts to [ignore][`IGNORE`] are always respected.
+    #[must_use]
+    pub fn with_captures(mut self, names: impl IntoIterator<Item = impl Into<String>>) -> Self {
+        self.captures = Some(names.into_iter().map(Into::into).collect());
+        self
+    }
+
+    /// The underlying query.
+    #[must_use]
+    pub fn query(&self) -> &TSQuery {
+        &self.query
+    }
+
+    fn is_selected(&self, name: &str) -> bool {
+        self.captures
+            .as_ref()
+            .map_or(true, |captures| captures.iter().any(|c| c == name))
+    }
+}
+
+impl<L: LanguageScoper> Scoper for CompiledQuery<L> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        let ranges = parse::<L>(input).map_or_else(Vec::new, |tree| self.scope_tree(&tree, input));
+
+        ROScopes::from_raw_ranges(input, ranges)
+    }
+}
+
+impl<L: LanguageScoper> NodeScoper for CompiledQuery<L> {
+    fn captures(&self, input: &str) -> Vec<Capture> {
+        parse::<L>(input).map_or_else(Vec::new, |tree| {
+            query_captures(&self.query, &tree, input, |name| self.is_selected(name))
+        })
+    }
+
+    fn language_parser(&self) -> TSParser {
+        L::parser()
+    }
+
+    fn scope_tree(&self, tree: &TSTree, input: &str) -> Vec<Range<usize>> {
+        query_ranges(
+            &self.query,
+            tree,
+            input,
+            |name| self.is_selected(name),
+            self.overlaps,
+        )
+    }
+}
+
+impl Scoper for Box<dyn NodeScoper> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        self.as_ref().scope(input)
+    }
+}
+
+impl NodeScoper for Box<dyn NodeScoper> {
+    fn captures(&self, input: &str) -> Vec<Capture> {
+        self.as_ref().captures(input)
+    }
+
+    fn language_parser(&self) -> TSParser {
+        self.as_ref().language_parser()
+    }
+
+    fn scope_tree(&self, tree: &TSTree, input: &str) -> Vec<Range<usize>> {
+        self.as_ref().scope_tree(tree, input)
+    }
+}
+
+impl Scoper for Arc<dyn NodeScoper> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        self.as_ref().scope(input)
+    }
+}
+
+impl NodeScoper for Arc<dyn NodeScoper> {
+    fn captures(&self, input: &str) -> Vec<Capture> {
+        self.as_ref().captures(input)
+    }
+
+    fn language_parser(&self) -> TSParser {
+        self.as_ref().language_parser()
+    }
+
+    fn scope_tree(&self, tree: &TSTree, input: &str) -> Vec<Range<usize>> {
+        self.as_ref().scope_tree(tree, input)
+    }
+}
+
+/// Names of all available languages, as understood by [`by_name`].
+pub const NAMES: &[&str] = &[
+    "bash",
+    "clojure",
+    "cmake",
+    "csharp",
+    "css",
+    "dart",
+    "dockerfile",
+    "elixir",
+    "erlang",
+    "go",
+    "graphql",
+    "groovy",
+    "haskell",
+    "html",
+    "java",
+    "json",
+    "julia",
+    "kotlin",
+    "lua",
+    "makefile",
+    "markdown",
+    "nix",
+    "objc",
+    "ocaml",
+    "perl",
+    "php",
+    "powershell",
+    "proto",
+    "python",
+    "ruby",
+    "rust",
+    "scala",
+    "scss",
+    "solidity",
+    "sql",
+    "svelte",
+    "swift",
+    "toml",
+    "typescript",
+    "vue",
+    "yaml",
+    "zig",
+];
+
+/// A not yet parsed query over some language.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum RawQuery<'a> {
+    /// Name of a premade query, such as `comments`.
+    Premade(&'a str),
+    /// Source of a custom tree-sitter query.
+    Custom(&'a str),
+}
+
+/// An error building a language scoper by name.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum LanguageError {
+    /// No language of that name is available.
+    UnknownLanguage(String),
+    /// The language has no premade query of that name.
+    UnknownPremadeQuery(String),
+    /// The custom query is invalid for the language.
+    InvalidCustomQuery(String),
+}
+
+impl fmt::Display for LanguageError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::UnknownLanguage(name) => write!(
+                f,
+                "Unknown language '{}', expected one of: {}",
+                name,
+                NAMES.join(", ")
+            ),
+            Self::UnknownPremadeQuery(e) => write!(f, "Unknown premade query: {}", e),
+            Self::InvalidCustomQuery(e) => write!(f, "Invalid custom query: {}", e),
+        }
+    }
+}
+
+impl Error for LanguageError {}
+
+/// Build a scoper for the language called `name` (one of [`NAMES`]), using `query`.
+///
+/// For when languages are only known at runtime, such as from configuration or
+/// bindings to other languages. Otherwise, constructing a [`Language`] directly is
+/// preferable.
+///
+/// # Errors
+///
+/// Returns an error if the language is unknown, or the query invalid.
+pub fn by_name(name: &str, query: RawQuery<'_>) -> Result<Box<dyn NodeScoper>, LanguageError> {
+    match name.to_lowercase().as_str() {
+        "bash" => code_scoper::<bash::CustomBashQuery, bash::PremadeBashQuery>(query),
+        "clojure" => {
+            code_scoper::<clojure::CustomClojureQuery, clojure::PremadeClojureQuery>(query)
+        }
+        "cmake" => code_scoper::<cmake::CustomCMakeQuery, cmake::PremadeCMakeQuery>(query),
+        "csharp" => code_scoper::<csharp::CustomCSharpQuery, csharp::PremadeCSharpQuery>(query),
+        "css" => code_scoper::<css::CustomCssQuery, css::PremadeCssQuery>(query),
+        "dart" => code_scoper::<dart::CustomDartQuery, dart::PremadeDartQuery>(query),
+        "dockerfile" => code_scoper::<
+            dockerfile::CustomDockerfileQuery,
+            dockerfile::PremadeDockerfileQuery,
+        >(query),
+        "elixir" => code_scoper::<elixir::CustomElixirQuery, elixir::PremadeElixirQuery>(query),
+        "erlang" => code_scoper::<erlang::CustomErlangQuery, erlang::PremadeErlangQuery>(query),
+        "go" => code_scoper::<go::CustomGoQuery, go::PremadeGoQuery>(query),
+        "graphql" => {
+            code_scoper::<graphql::CustomGraphQLQuery, graphql::PremadeGraphQLQuery>(query)
+        }
+        "groovy" => code_scoper::<groovy::CustomGroovyQuery, groovy::PremadeGroovyQuery>(query),
+        "haskell" => {
+            code_scoper::<haskell::CustomHaskellQuery, haskell::PremadeHaskellQuery>(query)
+        }
+        "html" => code_scoper::<html::CustomHtmlQuery, html::PremadeHtmlQuery>(query),
+        "java" => code_scoper::<java::CustomJavaQuery, java::PremadeJavaQuery>(query),
+        "json" => code_scoper::<json::CustomJsonQuery, json::PremadeJsonQuery>(query),
+        "julia" => code_scoper::<julia::CustomJuliaQuery, julia::PremadeJuliaQuery>(query),
+        "kotlin" => code_scoper::<kotlin::CustomKotlinQuery, kotlin::PremadeKotlinQuery>(query),
+        "lua" => code_scoper::<lua::CustomLuaQuery, lua::PremadeLuaQuery>(query),
+        "makefile" => {
+            code_scoper::<makefile::CustomMakefileQuery, makefile::PremadeMakefileQuery>(query)
+        }
+        "markdown" => {
+            code_scoper::<markdown::CustomMarkdownQuery, markdown::PremadeMarkdownQuery>(query)
+        }
+        "nix" => code_scoper::<nix::CustomNixQuery, nix::PremadeNixQuery>(query),
+        "objc" => code_scoper::<objc::CustomObjCQuery, objc::PremadeObjCQuery>(query),
+        "ocaml" => code_scoper::<ocaml::CustomOCamlQuery, ocaml::PremadeOCamlQuery>(query),
+        "perl" => code_scoper::<perl::CustomPerlQuery, perl::PremadePerlQuery>(query),
+        "php" => code_scoper::<php::CustomPhpQuery, php::PremadePhpQuery>(query),
+        "powershell" => code_scoper::<
+            powershell::CustomPowerShellQuery,
+            powershell::PremadePowerShellQuery,
+        >(query),
+        "proto" => code_scoper::<proto::CustomProtoQuery, proto::PremadeProtoQuery>(query),
+        "python" => code_scoper::<python::CustomPythonQuery, python::PremadePythonQuery>(query),
+        "ruby" => code_scoper::<ruby::CustomRubyQuery, ruby::PremadeRubyQuery>(query),
+        "rust" => code_scoper::<rust::CustomRustQuery, rust::PremadeRustQuery>(query),
+        "scala" => code_scoper::<scala::CustomScalaQuery, scala::PremadeScalaQuery>(query),
+        "scss" => code_scoper::<scss::CustomScssQuery, scss::PremadeScssQuery>(query),
+        "solidity" => {
+            code_scoper::<solidity::CustomSolidityQuery, solidity::PremadeSolidityQuery>(query)
+        }
+        "sql" => code_scoper::<sql::CustomSqlQuery, sql::PremadeSqlQuery>(query),
+        "svelte" => code_scoper::<svelte::CustomSvelteQuery, svelte::PremadeSvelteQuery>(query),
+        "swift" => code_scoper::<swift::CustomSwiftQuery, swift::PremadeSwiftQuery>(query),
+        "toml" => code_scoper::<toml::CustomTomlQuery, toml::PremadeTomlQuery>(query),
+        "typescript" => code_scoper::<
+            typescript::CustomTypeScriptQuery,
+            typescript::PremadeTypeScriptQuery,
+        >(query),
+        "vue" => code_scoper::<vue::CustomVueQuery, vue::PremadeVueQuery>(query),
+        "yaml" => code_scoper::<yaml::CustomYamlQuery, yaml::PremadeYamlQuery>(query),
+        "zig" => code_scoper::<zig::CustomZigQuery, zig::PremadeZigQuery>(query),
+        _ => Err(LanguageError::UnknownLanguage(name.to_string())),
+    }
+}
+
+/// The [file check][`LanguageScoper::is_valid_file`] of the language called `name`
+/// (one of [`NAMES`]), if available.
+#[must_use]
+pub fn file_validator_by_name(name: &str) -> Option<fn(&Path, &str) -> bool> {
+    let validator: fn(&Path, &str) -> bool = match name.to_lowercase().as_str() {
+        "bash" => bash::Bash::is_valid_file,
+        "clojure" => clojure::Clojure::is_valid_file,
+        "cmake" => cmake::CMake::is_valid_file,
+        "csharp" => csharp::CSharp::is_valid_file,
+        "css" => css::Css::is_valid_file,
+        "dart" => dart::Dart::is_valid_file,
+        "dockerfile" => dockerfile::Dockerfile::is_valid_file,
+        "elixir" => elixir::Elixir::is_valid_file,
+        "erlang" => erlang::Erlang::is_valid_file,
+        "go" => go::Go::is_valid_file,
+        "graphql" => graphql::GraphQL::is_valid_file,
+        "groovy" => groovy::Groovy::is_valid_file,
+        "haskell" => haskell::Haskell::is_valid_file,
+        "html" => html::Html::is_valid_file,
+        "java" => java::Java::is_valid_file,
+        "json" => json::Json::is_valid_file,
+        "julia" => julia::Julia::is_valid_file,
+        "kotlin" => kotlin::Kotlin::is_valid_file,
+        "lua" => lua::Lua::is_valid_file,
+        "makefile" => makefile::Makefile::is_valid_file,
+        "markdown" => markdown::Markdown::is_valid_file,
+        "nix" => nix::Nix::is_valid_file,
+        "objc" => objc::ObjC::is_valid_file,
+        "ocaml" => ocaml::OCaml::is_valid_file,
+        "perl" => perl::Perl::is_valid_file,
+        "php" => php::Php::is_valid_file,
+        "powershell" => powershell::PowerShell::is_valid_file,
+        "proto" => proto::Proto::is_valid_file,
+        "python" => python::Python::is_valid_file,
+        "ruby" => ruby::Ruby::is_valid_file,
+        "rust" => rust::Rust::is_valid_file,
+        "scala" => scala::Scala::is_valid_file,
+        "scss" => scss::Scss::is_valid_file,
+        "solidity" => solidity::Solidity::is_valid_file,
+        "sql" => sql::Sql::is_valid_file,
+        "svelte" => svelte::Svelte::is_valid_file,
+        "swift" => swift::Swift::is_valid_file,
+        "toml" => toml::Toml::is_valid_file,
+        "typescript" => typescript::TypeScript::is_valid_file,
+        "vue" => vue::Vue::is_valid_file,
+        "yaml" => yaml::Yaml::is_valid_file,
+        "zig" => zig::Zig::is_valid_file,
+        _ => return None,
+    };
+
+    Some(validator)
+}
+
+fn code_scoper<C, P>(query: RawQuery<'_>) -> Result<Box<dyn NodeScoper>, LanguageError>
+where
+    C: FromStr + Into<TSQuery>,
+    C::Err: fmt::Display,
+    P: DeserializeOwned + Into<TSQuery>,
+    Language<CodeQuery<C, P>>: LanguageScoper + 'static,
+{
+    let query = match query {
+        RawQuery::Premade(name) => {
+            // Premade queries are named the same as in the binary's options.
+            let name = name.to_lowercase();
+            let premade = P::deserialize(name.as_str().into_deserializer()).map_err(
+                |e: serde::de::value::Error| LanguageError::UnknownPremadeQuery(e.to_string()),
+            )?;
+
+            CodeQuery::Premade(premade)
+        }
+        RawQuery::Custom(source) => CodeQuery::Custom(
+            C::from_str(source).map_err(|e| LanguageError::InvalidCustomQuery(e.to_string()))?,
+        ),
+    };
+
+    Ok(Box::new(Language::new(query)))
+}
@@ -0,0 +1,157 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Nix language.
+pub type Nix = Language<NixQuery>;
+/// A query for Nix.
+pub type NixQuery = CodeQuery<CustomNixQuery, PremadeNixQuery>;
+
+/// Premade tree-sitter queries for Nix.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeNixQuery {
+    /// Comments.
+    Comments,
+    /// Strings (double-quoted and indented, excl. interpolations).
+    Strings,
+    /// Attribute sets (incl. recursive ones).
+    AttrSets,
+    /// Function arguments, be it a single identifier or a set pattern.
+    FunctionArgs,
+    /// URLs passed to `fetch*` functions (like `fetchurl` or `builtins.fetchTarball`),
+    /// as `url` or `urls`.
+    FetchUrls,
+}
+
+impl From<PremadeNixQuery> for TSQuery {
+    fn from(value: PremadeNixQuery) -> Self {
+        TSQuery::new(
+            Nix::lang(),
+            match value {
+                PremadeNixQuery::Comments => "(comment) @comment",
+                PremadeNixQuery::Strings => {
+                    concatcp!(
+                        "
+                        [
+                            (string_expression)
+                            (indented_string_expression)
+                        ]
+                        @string
+                        (interpolation) @",
+                        IGNORE
+                    )
+                }
+                PremadeNixQuery::AttrSets => {
+                    "[(attrset_expression) (rec_attrset_expression)] @attrset"
+                }
+                PremadeNixQuery::FunctionArgs => {
+                    r"
+                    [
+                        (function_expression universal: (identifier) @argument)
+                        (function_expression formals: (formals) @arguments)
+                    ]
+                    "
+                }
+                PremadeNixQuery::FetchUrls => {
+                    concatcp!(
+                        "
+                        (apply_expression
+                            function: [
+                                (variable_expression name: (identifier) @",
+                        IGNORE,
+                        "_fetcher)
+                                (select_expression attrpath: (attrpath attr: (identifier) @",
+                        IGNORE,
+                        "_fetcher .))
+                            ]
+                            argument: (attrset_expression
+                                (binding_set
+                                    (binding
+                                        attrpath: (attrpath attr: (identifier) @",
+                        IGNORE,
+                        "_key)
+                                        expression: [
+                                            (string_expression) @url
+                                            (list_expression (string_expression) @url)
+                                        ]
+                                    )
+                                )
+                            )
+                            (#match? @",
+                        IGNORE,
+                        "_fetcher \"^fetch\")
+                            (#match? @",
+                        IGNORE,
+                        "_key \"^urls?$\")
+                        )
+                        "
+                    )
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Nix.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomNixQuery(String, Precompiled);
+
+impl FromStr for CustomNixQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Nix::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomNixQuery> for TSQuery {
+    fn from(value: CustomNixQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Nix::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Nix {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Nix {
+    fn lang() -> TSLanguage {
+        tree_sitter_nix::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["nix"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["nix-shell"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["nix"]
+    }
+}
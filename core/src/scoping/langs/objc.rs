@@ -0,0 +1,97 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Objective-C language.
+pub type ObjC = Language<ObjCQuery>;
+/// A query for Objective-C.
+pub type ObjCQuery = CodeQuery<CustomObjCQuery, PremadeObjCQuery>;
+
+/// Premade tree-sitter queries for Objective-C.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeObjCQuery {
+    /// Comments.
+    Comments,
+    /// String literals (`@"..."`).
+    Strings,
+    /// Method declarations (in interfaces) and definitions (in implementations).
+    Methods,
+    /// `#import` (and `#include`) directives.
+    Imports,
+    /// Property declarations.
+    Properties,
+}
+
+impl From<PremadeObjCQuery> for TSQuery {
+    fn from(value: PremadeObjCQuery) -> Self {
+        TSQuery::new(
+            ObjC::lang(),
+            match value {
+                PremadeObjCQuery::Comments => "(comment) @comment",
+                PremadeObjCQuery::Strings => "(string_expression) @string",
+                PremadeObjCQuery::Methods => "[(method_declaration) (method_definition)] @method",
+                PremadeObjCQuery::Imports => "(preproc_include) @import",
+                PremadeObjCQuery::Properties => "(property_declaration) @property",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Objective-C.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomObjCQuery(String, Precompiled);
+
+impl FromStr for CustomObjCQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(ObjC::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomObjCQuery> for TSQuery {
+    fn from(value: CustomObjCQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(ObjC::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for ObjC {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for ObjC {
+    fn lang() -> TSLanguage {
+        tree_sitter_objc::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["m"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["objc"]
+    }
+}
@@ -0,0 +1,109 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The OCaml language.
+pub type OCaml = Language<OCamlQuery>;
+/// A query for OCaml.
+pub type OCamlQuery = CodeQuery<CustomOCamlQuery, PremadeOCamlQuery>;
+
+/// Premade tree-sitter queries for OCaml.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeOCamlQuery {
+    /// Comments.
+    Comments,
+    /// Strings (incl. quoted strings; quotes included).
+    Strings,
+    /// `let` bindings (incl. `let`, `rec` and body).
+    Lets,
+    /// Module signatures (`sig ... end`).
+    ModuleSignatures,
+    /// Functors (incl. parameters and body).
+    Functors,
+}
+
+impl From<PremadeOCamlQuery> for TSQuery {
+    fn from(value: PremadeOCamlQuery) -> Self {
+        TSQuery::new(
+            OCaml::lang(),
+            match value {
+                PremadeOCamlQuery::Comments => "(comment) @comment",
+                PremadeOCamlQuery::Strings => "[(string) (quoted_string)] @string",
+                PremadeOCamlQuery::Lets => "(value_definition) @let",
+                PremadeOCamlQuery::ModuleSignatures => "(signature) @signature",
+                PremadeOCamlQuery::Functors => {
+                    r"
+                    [
+                        (functor)
+                        (module_binding (module_parameter))
+                    ]
+                    @functor
+                    "
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for OCaml.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomOCamlQuery(String, Precompiled);
+
+impl FromStr for CustomOCamlQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(OCaml::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomOCamlQuery> for TSQuery {
+    fn from(value: CustomOCamlQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(OCaml::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for OCaml {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for OCaml {
+    fn lang() -> TSLanguage {
+        tree_sitter_ocaml::language_ocaml()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["ml"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["ocaml"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["ocaml"]
+    }
+}
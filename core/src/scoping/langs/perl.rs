@@ -0,0 +1,119 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Perl language.
+pub type Perl = Language<PerlQuery>;
+/// A query for Perl.
+pub type PerlQuery = CodeQuery<CustomPerlQuery, PremadePerlQuery>;
+
+/// Premade tree-sitter queries for Perl.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadePerlQuery {
+    /// Comments.
+    Comments,
+    /// Strings (single-quoted, interpolated and heredoc bodies).
+    Strings,
+    /// Regular expression literals (matches, substitutions and `qr//`).
+    Regexes,
+    /// POD (Plain Old Documentation) blocks.
+    Pod,
+    /// Subroutine definitions.
+    Subroutines,
+}
+
+impl From<PremadePerlQuery> for TSQuery {
+    fn from(value: PremadePerlQuery) -> Self {
+        TSQuery::new(
+            Perl::lang(),
+            match value {
+                PremadePerlQuery::Comments => "(comment) @comment",
+                PremadePerlQuery::Strings => {
+                    r"
+                    [
+                        (string_literal)
+                        (interpolated_string_literal)
+                        (heredoc_content)
+                    ]
+                    @string
+                    "
+                }
+                PremadePerlQuery::Regexes => {
+                    r"
+                    [
+                        (match_regexp)
+                        (substitution_regexp)
+                        (quoted_regexp)
+                    ]
+                    @regex
+                    "
+                }
+                PremadePerlQuery::Pod => "(pod) @pod",
+                PremadePerlQuery::Subroutines => "(subroutine_declaration_statement) @sub",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Perl.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomPerlQuery(String, Precompiled);
+
+impl FromStr for CustomPerlQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Perl::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomPerlQuery> for TSQuery {
+    fn from(value: CustomPerlQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Perl::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Perl {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Perl {
+    fn lang() -> TSLanguage {
+        tree_sitter_perl::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["pl", "pm", "t"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["perl"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["perl"]
+    }
+}
@@ -0,0 +1,133 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The PHP language.
+pub type Php = Language<PhpQuery>;
+/// A query for PHP.
+pub type PhpQuery = CodeQuery<CustomPhpQuery, PremadePhpQuery>;
+
+/// Premade tree-sitter queries for PHP.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadePhpQuery {
+    /// Comments (excluding docblocks; comment chars incl.).
+    Comments,
+    /// Docblocks (`/** ... */`; comment chars incl.).
+    DocBlocks,
+    /// Strings (incl. heredocs; variables are respected; quotes included).
+    Strings,
+    /// Function and method definitions (incl. modifiers and body).
+    Functions,
+    /// Class bodies (incl. braces).
+    ClassBodies,
+}
+
+impl From<PremadePhpQuery> for TSQuery {
+    fn from(value: PremadePhpQuery) -> Self {
+        TSQuery::new(
+            Php::lang(),
+            match value {
+                PremadePhpQuery::Comments => {
+                    r#"
+                    (
+                        (comment) @comment
+                        (#not-match? @comment "^/\\*\\*")
+                    )
+                    "#
+                }
+                PremadePhpQuery::DocBlocks => {
+                    r#"
+                    (
+                        (comment) @comment
+                        (#match? @comment "^/\\*\\*")
+                    )
+                    "#
+                }
+                PremadePhpQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string)
+                        (encapsed_string)
+                        (encapsed_string (variable_name) @",
+                        IGNORE,
+                        ")
+                        (heredoc)
+                    ]
+                    @string"
+                    )
+                }
+                PremadePhpQuery::Functions => {
+                    "[(function_definition) (method_declaration)] @function"
+                }
+                PremadePhpQuery::ClassBodies => {
+                    "(class_declaration body: (declaration_list) @body)"
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for PHP.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomPhpQuery(String, Precompiled);
+
+impl FromStr for CustomPhpQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Php::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomPhpQuery> for TSQuery {
+    fn from(value: CustomPhpQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Php::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Php {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Php {
+    fn lang() -> TSLanguage {
+        tree_sitter_php::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["php"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["php"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["php"]
+    }
+}
@@ -0,0 +1,126 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The PowerShell language.
+pub type PowerShell = Language<PowerShellQuery>;
+/// A query for PowerShell.
+pub type PowerShellQuery = CodeQuery<CustomPowerShellQuery, PremadePowerShellQuery>;
+
+/// Premade tree-sitter queries for PowerShell.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadePowerShellQuery {
+    /// Comments (line and block).
+    Comments,
+    /// Expandable strings (double-quoted, incl. here-strings; variables are respected;
+    /// quotes included).
+    ExpandableStrings,
+    /// Literal strings (single-quoted, incl. here-strings; quotes included).
+    LiteralStrings,
+    /// Function definitions (incl. body).
+    Functions,
+    /// `param` blocks (incl. `param` and parentheses).
+    ParamBlocks,
+}
+
+impl From<PremadePowerShellQuery> for TSQuery {
+    fn from(value: PremadePowerShellQuery) -> Self {
+        TSQuery::new(
+            PowerShell::lang(),
+            match value {
+                PremadePowerShellQuery::Comments => "(comment) @comment",
+                PremadePowerShellQuery::ExpandableStrings => {
+                    concatcp!(
+                        "
+                    [
+                        (expandable_string_literal)
+                        (expandable_string_literal (variable) @",
+                        IGNORE,
+                        ")
+                        (expandable_here_string_literal)
+                        (expandable_here_string_literal (variable) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadePowerShellQuery::LiteralStrings => {
+                    r"
+                    [
+                        (verbatim_string_characters)
+                        (verbatim_here_string_characters)
+                    ]
+                    @string
+                    "
+                }
+                PremadePowerShellQuery::Functions => "(function_statement) @function",
+                PremadePowerShellQuery::ParamBlocks => "(param_block) @param",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for PowerShell.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomPowerShellQuery(String, Precompiled);
+
+impl FromStr for CustomPowerShellQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(PowerShell::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomPowerShellQuery> for TSQuery {
+    fn from(value: CustomPowerShellQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(PowerShell::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for PowerShell {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for PowerShell {
+    fn lang() -> TSLanguage {
+        tree_sitter_powershell::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["ps1", "psm1", "psd1"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["pwsh"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["powershell", "ps1"]
+    }
+}
@@ -0,0 +1,116 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Protocol Buffers language.
+pub type Proto = Language<ProtoQuery>;
+/// A query for Protocol Buffers.
+pub type ProtoQuery = CodeQuery<CustomProtoQuery, PremadeProtoQuery>;
+
+/// Premade tree-sitter queries for Protocol Buffers.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeProtoQuery {
+    /// Comments.
+    Comments,
+    /// Message and enum definitions.
+    Definitions,
+    /// Names of fields (incl. map and oneof fields).
+    FieldNames,
+    /// Options of fields (excl. brackets), as in `[deprecated = true]`.
+    FieldOptions,
+    /// Name of the `package`.
+    Package,
+    /// Paths of `import` statements (incl. quotes).
+    Imports,
+}
+
+impl From<PremadeProtoQuery> for TSQuery {
+    fn from(value: PremadeProtoQuery) -> Self {
+        TSQuery::new(
+            Proto::lang(),
+            match value {
+                PremadeProtoQuery::Comments => "(comment) @comment",
+                PremadeProtoQuery::Definitions => "[(message) (enum)] @definition",
+                PremadeProtoQuery::FieldNames => {
+                    r"
+                    [
+                        (field (identifier) @name)
+                        (map_field (identifier) @name)
+                        (oneof_field (identifier) @name)
+                    ]
+                    "
+                }
+                PremadeProtoQuery::FieldOptions => {
+                    r"
+                    [
+                        (field (field_options) @options)
+                        (map_field (field_options) @options)
+                        (oneof_field (field_options) @options)
+                    ]
+                    "
+                }
+                PremadeProtoQuery::Package => "(package (full_ident) @package)",
+                PremadeProtoQuery::Imports => "(import (string) @path)",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Protocol Buffers.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomProtoQuery(String, Precompiled);
+
+impl FromStr for CustomProtoQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Proto::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomProtoQuery> for TSQuery {
+    fn from(value: CustomProtoQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Proto::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Proto {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Proto {
+    fn lang() -> TSLanguage {
+        tree_sitter_proto::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["proto"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["proto"]
+    }
+}
@@ -1,7 +1,9 @@
-use super::{CodeQuery, Language, LanguageScoper, TSLanguage, TSQuery};
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
 use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
 use clap::ValueEnum;
 use const_format::concatcp;
+use serde::Deserialize;
 use std::{fmt::Debug, str::FromStr};
 use tree_sitter::QueryError;
 
@@ -11,7 +13,9 @@ pub type Python = Language<PythonQuery>;
 pub type PythonQuery = CodeQuery<CustomPythonQuery, PremadePythonQuery>;
 
 /// Premade tree-sitter queries for Python.
-#[derive(Debug, Clone, Copy, ValueEnum)]
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
 pub enum PremadePythonQuery {
     /// Comments.
     Comments,
@@ -98,14 +102,14 @@ impl From<PremadePythonQuery> for TSQuery {
 
 /// A custom tree-sitter query for Python.
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
-pub struct CustomPythonQuery(String);
+pub struct CustomPythonQuery(String, Precompiled);
 
 impl FromStr for CustomPythonQuery {
     type Err = QueryError;
 
     fn from_str(s: &str) -> Result<Self, Self::Err> {
         match TSQuery::new(Python::lang(), s) {
-            Ok(_) => Ok(Self(s.to_string())),
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
             Err(e) => Err(e),
         }
     }
@@ -113,14 +117,16 @@ impl FromStr for CustomPythonQuery {
 
 impl From<CustomPythonQuery> for TSQuery {
     fn from(value: CustomPythonQuery) -> Self {
-        TSQuery::new(Python::lang(), &value.0)
-            .expect("Valid query, as object cannot be constructed otherwise")
+        value.1.take_or_else(|| {
+            TSQuery::new(Python::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
     }
 }
 
 impl Scoper for Python {
     fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
-        ROScopes::from_raw_ranges(input, Self::scope_via_query(&mut self.query(), input))
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
     }
 }
 
@@ -129,7 +135,23 @@ impl LanguageScoper for Python {
         tree_sitter_python::language()
     }
 
-    fn query(&self) -> TSQuery {
-        self.query.clone().into()
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["py"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["python", "pypy"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["python"]
     }
 }
@@ -0,0 +1,122 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Ruby language.
+pub type Ruby = Language<RubyQuery>;
+/// A query for Ruby.
+pub type RubyQuery = CodeQuery<CustomRubyQuery, PremadeRubyQuery>;
+
+/// Premade tree-sitter queries for Ruby.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeRubyQuery {
+    /// Comments.
+    Comments,
+    /// Strings (incl. heredocs and symbols; interpolation is respected; quotes included).
+    Strings,
+    /// Method definitions (incl. singleton methods and body).
+    Methods,
+    /// Class definitions (incl. body).
+    Classes,
+    /// Module definitions (incl. body).
+    Modules,
+    /// Blocks (braces and `do`/`end`; incl. parameters).
+    Blocks,
+}
+
+impl From<PremadeRubyQuery> for TSQuery {
+    fn from(value: PremadeRubyQuery) -> Self {
+        TSQuery::new(
+            Ruby::lang(),
+            match value {
+                PremadeRubyQuery::Comments => "(comment) @comment",
+                PremadeRubyQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string)
+                        (string (interpolation) @",
+                        IGNORE,
+                        ")
+                        (heredoc_body)
+                        (heredoc_body (interpolation) @",
+                        IGNORE,
+                        ")
+                        (simple_symbol)
+                        (delimited_symbol)
+                    ]
+                    @string"
+                    )
+                }
+                PremadeRubyQuery::Methods => "[(method) (singleton_method)] @method",
+                PremadeRubyQuery::Classes => "(class) @class",
+                PremadeRubyQuery::Modules => "(module) @module",
+                PremadeRubyQuery::Blocks => "[(block) (do_block)] @block",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Ruby.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomRubyQuery(String, Precompiled);
+
+impl FromStr for CustomRubyQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Ruby::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomRubyQuery> for TSQuery {
+    fn from(value: CustomRubyQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Ruby::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Ruby {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Ruby {
+    fn lang() -> TSLanguage {
+        tree_sitter_ruby::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["rb", "rake", "gemspec"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["ruby"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["ruby"]
+    }
+}
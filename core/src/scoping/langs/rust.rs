@@ -1,6 +1,8 @@
-use super::{CodeQuery, Language, LanguageScoper, TSLanguage, TSQuery};
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
 use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
 use clap::ValueEnum;
+use serde::Deserialize;
 use std::{fmt::Debug, str::FromStr};
 use tree_sitter::QueryError;
 
@@ -10,7 +12,9 @@ pub type Rust = Language<RustQuery>;
 pub type RustQuery = CodeQuery<CustomRustQuery, PremadeRustQuery>;
 
 /// Premade tree-sitter queries for Rust.
-#[derive(Debug, Clone, Copy, ValueEnum)]
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
 pub enum PremadeRustQuery {
     /// Comments (line and block styles; excluding doc comments; comment chars incl.).
     Comments,
@@ -80,14 +84,14 @@ impl From<PremadeRustQuery> for TSQuery {
 
 /// A custom tree-sitter query for Rust.
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
-pub struct CustomRustQuery(String);
+pub struct CustomRustQuery(String, Precompiled);
 
 impl FromStr for CustomRustQuery {
     type Err = QueryError;
 
     fn from_str(s: &str) -> Result<Self, Self::Err> {
         match TSQuery::new(Rust::lang(), s) {
-            Ok(_) => Ok(Self(s.to_string())),
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
             Err(e) => Err(e),
         }
     }
@@ -95,14 +99,16 @@ impl FromStr for CustomRustQuery {
 
 impl From<CustomRustQuery> for TSQuery {
     fn from(value: CustomRustQuery) -> Self {
-        TSQuery::new(Rust::lang(), &value.0)
-            .expect("Valid query, as object cannot be constructed otherwise")
+        value.1.take_or_else(|| {
+            TSQuery::new(Rust::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
     }
 }
 
 impl Scoper for Rust {
     fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
-        ROScopes::from_raw_ranges(input, Self::scope_via_query(&mut self.query(), input))
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
     }
 }
 
@@ -111,7 +117,23 @@ impl LanguageScoper for Rust {
         tree_sitter_rust::language()
     }
 
-    fn query(&self) -> TSQuery {
-        self.query.clone().into()
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["rs"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["rust-script", "run-cargo-script"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["rust"]
     }
 }
@@ -0,0 +1,125 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Scala language.
+pub type Scala = Language<ScalaQuery>;
+/// A query for Scala.
+pub type ScalaQuery = CodeQuery<CustomScalaQuery, PremadeScalaQuery>;
+
+/// Premade tree-sitter queries for Scala.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeScalaQuery {
+    /// Comments (line and block).
+    Comments,
+    /// Strings (incl. interpolated strings; interpolation is respected; quotes included).
+    Strings,
+    /// Interpolations in strings (incl. `$` and braces).
+    Interpolations,
+    /// Case class definitions (incl. body).
+    CaseClasses,
+    /// Object definitions (incl. case objects and body).
+    Objects,
+    /// Implicit definitions and parameter lists (incl. `implicit`).
+    Implicits,
+}
+
+impl From<PremadeScalaQuery> for TSQuery {
+    fn from(value: PremadeScalaQuery) -> Self {
+        TSQuery::new(
+            Scala::lang(),
+            match value {
+                PremadeScalaQuery::Comments => "[(comment) (block_comment)] @comment",
+                PremadeScalaQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (string)
+                        (interpolated_string)
+                        (interpolated_string (interpolation) @",
+                        IGNORE,
+                        ")
+                    ]
+                    @string"
+                    )
+                }
+                PremadeScalaQuery::Interpolations => "(interpolation) @interpolation",
+                PremadeScalaQuery::CaseClasses => "(class_definition \"case\") @class",
+                PremadeScalaQuery::Objects => "(object_definition) @object",
+                PremadeScalaQuery::Implicits => {
+                    r#"
+                    [
+                        (_ (modifiers "implicit"))
+                        (parameters "implicit")
+                    ]
+                    @implicit
+                    "#
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Scala.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomScalaQuery(String, Precompiled);
+
+impl FromStr for CustomScalaQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Scala::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomScalaQuery> for TSQuery {
+    fn from(value: CustomScalaQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Scala::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Scala {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Scala {
+    fn lang() -> TSLanguage {
+        tree_sitter_scala::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["scala", "sc"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["scala"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["scala"]
+    }
+}
@@ -0,0 +1,104 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The SCSS language.
+pub type Scss = Language<ScssQuery>;
+/// A query for SCSS.
+pub type ScssQuery = CodeQuery<CustomScssQuery, PremadeScssQuery>;
+
+/// Premade tree-sitter queries for SCSS.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeScssQuery {
+    /// Comments (block and single-line).
+    Comments,
+    /// Selectors of rule sets.
+    Selectors,
+    /// Property names of declarations.
+    PropertyNames,
+    /// Property values of declarations (excl. `:` and `;`).
+    PropertyValues,
+    /// Custom property declarations (`--name: value;`).
+    CustomProperties,
+}
+
+impl From<PremadeScssQuery> for TSQuery {
+    fn from(value: PremadeScssQuery) -> Self {
+        TSQuery::new(
+            Scss::lang(),
+            match value {
+                PremadeScssQuery::Comments => "[(comment) (single_line_comment)] @comment",
+                PremadeScssQuery::Selectors => "(rule_set (selectors) @selectors)",
+                PremadeScssQuery::PropertyNames => "(declaration (property_name) @name)",
+                PremadeScssQuery::PropertyValues => "(declaration (property_name) (_) @value)",
+                PremadeScssQuery::CustomProperties => {
+                    r#"
+                    (
+                        (declaration (property_name) @name) @property
+                        (#match? @name "^--")
+                    )
+                    "#
+                }
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for SCSS.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomScssQuery(String, Precompiled);
+
+impl FromStr for CustomScssQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Scss::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomScssQuery> for TSQuery {
+    fn from(value: CustomScssQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Scss::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Scss {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Scss {
+    fn lang() -> TSLanguage {
+        tree_sitter_scss::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["scss"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["scss"]
+    }
+}
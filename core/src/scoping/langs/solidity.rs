@@ -0,0 +1,118 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Solidity language.
+pub type Solidity = Language<SolidityQuery>;
+/// A query for Solidity.
+pub type SolidityQuery = CodeQuery<CustomSolidityQuery, PremadeSolidityQuery>;
+
+/// Premade tree-sitter queries for Solidity.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeSolidityQuery {
+    /// Comments (incl. NatSpec).
+    Comments,
+    /// NatSpec documentation comments (`///` and `/** */`).
+    Natspec,
+    /// String literals (incl. quotes).
+    Strings,
+    /// Contract definitions (incl. interfaces and libraries).
+    Contracts,
+    /// Function modifiers, both definitions and invocations on functions.
+    Modifiers,
+    /// Event declarations.
+    Events,
+}
+
+impl From<PremadeSolidityQuery> for TSQuery {
+    fn from(value: PremadeSolidityQuery) -> Self {
+        TSQuery::new(
+            Solidity::lang(),
+            match value {
+                PremadeSolidityQuery::Comments => "(comment) @comment",
+                PremadeSolidityQuery::Natspec => {
+                    r#"
+                    (
+                        (comment) @comment
+                        (#match? @comment "^(///|/\\*\\*)")
+                    )
+                    "#
+                }
+                PremadeSolidityQuery::Strings => "(string) @string",
+                PremadeSolidityQuery::Contracts => {
+                    r"
+                    [
+                        (contract_declaration)
+                        (interface_declaration)
+                        (library_declaration)
+                    ]
+                    @contract
+                    "
+                }
+                PremadeSolidityQuery::Modifiers => {
+                    "[(modifier_definition) (modifier_invocation)] @modifier"
+                }
+                PremadeSolidityQuery::Events => "(event_definition) @event",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Solidity.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomSolidityQuery(String, Precompiled);
+
+impl FromStr for CustomSolidityQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Solidity::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomSolidityQuery> for TSQuery {
+    fn from(value: CustomSolidityQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Solidity::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Solidity {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Solidity {
+    fn lang() -> TSLanguage {
+        tree_sitter_solidity::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["sol"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["solidity"]
+    }
+}
@@ -0,0 +1,104 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The SQL language.
+pub type Sql = Language<SqlQuery>;
+/// A query for SQL.
+pub type SqlQuery = CodeQuery<CustomSqlQuery, PremadeSqlQuery>;
+
+/// Premade tree-sitter queries for SQL.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeSqlQuery {
+    /// Comments (line and block).
+    Comments,
+    /// String literals (quotes included).
+    Strings,
+    /// Identifiers (such as column, table and alias names).
+    Identifiers,
+    /// Column lists of `SELECT` statements.
+    SelectColumns,
+    /// Table references (in `FROM` and `JOIN` clauses; excl. aliases).
+    Tables,
+}
+
+impl From<PremadeSqlQuery> for TSQuery {
+    fn from(value: PremadeSqlQuery) -> Self {
+        TSQuery::new(
+            Sql::lang(),
+            match value {
+                PremadeSqlQuery::Comments => "[(comment) (marginalia)] @comment",
+                PremadeSqlQuery::Strings => {
+                    r#"
+                    (
+                        (literal) @string
+                        (#match? @string "^'")
+                    )
+                    "#
+                }
+                PremadeSqlQuery::Identifiers => "(identifier) @identifier",
+                PremadeSqlQuery::SelectColumns => "(select_expression) @columns",
+                PremadeSqlQuery::Tables => "(relation (object_reference) @table)",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for SQL.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomSqlQuery(String, Precompiled);
+
+impl FromStr for CustomSqlQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Sql::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomSqlQuery> for TSQuery {
+    fn from(value: CustomSqlQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Sql::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Sql {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Sql {
+    fn lang() -> TSLanguage {
+        tree_sitter_sequel::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["sql"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["sql"]
+    }
+}
@@ -0,0 +1,142 @@
+use super::{
+    css::{Css, PremadeCssQuery},
+    parse_embedded, section_contents,
+    typescript::{PremadeTypeScriptQuery, TypeScript},
+    CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery, TSTree,
+};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Svelte language.
+pub type Svelte = Language<SvelteQuery>;
+/// A query for Svelte components.
+pub type SvelteQuery = CodeQuery<CustomSvelteQuery, PremadeSvelteQuery>;
+
+/// Premade tree-sitter queries for Svelte components.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeSvelteQuery {
+    /// Markup, i.e. everything but `<script>` and `<style>` sections.
+    Markup,
+    /// Contents of `<script>` sections.
+    Script,
+    /// Contents of `<style>` sections.
+    Style,
+    /// Template expressions (excl. braces), as in `{count}` or `{#if count > 1}`.
+    Expressions,
+    /// Comments in `<script>` sections.
+    ScriptComments,
+    /// Strings in `<script>` sections (literal, template; includes quote characters).
+    ScriptStrings,
+    /// Comments in `<style>` sections.
+    StyleComments,
+}
+
+impl PremadeSvelteQuery {
+    /// The kind of section node whose contents the query runs against, and their
+    /// language, if not the component itself.
+    ///
+    /// Scripts are taken as TypeScript, which covers JavaScript as well.
+    fn embedded(self) -> Option<(&'static str, TSLanguage)> {
+        match self {
+            Self::ScriptComments | Self::ScriptStrings => {
+                Some(("script_element", TypeScript::lang()))
+            }
+            Self::StyleComments => Some(("style_element", Css::lang())),
+            Self::Markup | Self::Script | Self::Style | Self::Expressions => None,
+        }
+    }
+}
+
+impl From<PremadeSvelteQuery> for TSQuery {
+    fn from(value: PremadeSvelteQuery) -> Self {
+        let source = match value {
+            PremadeSvelteQuery::Markup => {
+                concatcp!(
+                    "(document) @markup [(script_element) (style_element)] @",
+                    IGNORE
+                )
+            }
+            PremadeSvelteQuery::Script => "(script_element (raw_text) @script)",
+            PremadeSvelteQuery::Style => "(style_element (raw_text) @style)",
+            PremadeSvelteQuery::Expressions => "(raw_text_expr) @expression",
+            PremadeSvelteQuery::ScriptComments => return PremadeTypeScriptQuery::Comments.into(),
+            PremadeSvelteQuery::ScriptStrings => return PremadeTypeScriptQuery::Strings.into(),
+            PremadeSvelteQuery::StyleComments => return PremadeCssQuery::Comments.into(),
+        };
+
+        TSQuery::new(Svelte::lang(), source).expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Svelte components.
+///
+/// Runs against components themselves, not against the code embedded in their
+/// sections.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomSvelteQuery(String, Precompiled);
+
+impl FromStr for CustomSvelteQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Svelte::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomSvelteQuery> for TSQuery {
+    fn from(value: CustomSvelteQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Svelte::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Svelte {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Svelte {
+    fn lang() -> TSLanguage {
+        tree_sitter_svelte::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["svelte"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["svelte"]
+    }
+
+    fn query_trees(&self, tree: &TSTree, input: &str) -> Vec<TSTree> {
+        let CodeQuery::Premade(premade) = self.query else {
+            return vec![tree.clone()];
+        };
+        let Some((section, lang)) = premade.embedded() else {
+            return vec![tree.clone()];
+        };
+
+        parse_embedded(lang, &section_contents(tree, section), input)
+    }
+}
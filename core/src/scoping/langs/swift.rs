@@ -0,0 +1,118 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Swift language.
+pub type Swift = Language<SwiftQuery>;
+/// A query for Swift.
+pub type SwiftQuery = CodeQuery<CustomSwiftQuery, PremadeSwiftQuery>;
+
+/// Premade tree-sitter queries for Swift.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeSwiftQuery {
+    /// Comments (single- and multi-line).
+    Comments,
+    /// Strings (incl. multi-line and raw; interpolation is respected; quotes included).
+    Strings,
+    /// Function declarations (incl. body).
+    Functions,
+    /// Class declarations (incl. structs, enums, extensions and actors; incl. body).
+    Classes,
+    /// Attributes, such as property wrappers (incl. `@`).
+    Attributes,
+}
+
+impl From<PremadeSwiftQuery> for TSQuery {
+    fn from(value: PremadeSwiftQuery) -> Self {
+        TSQuery::new(
+            Swift::lang(),
+            match value {
+                PremadeSwiftQuery::Comments => "[(comment) (multiline_comment)] @comment",
+                PremadeSwiftQuery::Strings => {
+                    concatcp!(
+                        "
+                    [
+                        (line_string_literal)
+                        (line_string_literal (interpolated_expression) @",
+                        IGNORE,
+                        ")
+                        (multi_line_string_literal)
+                        (multi_line_string_literal (interpolated_expression) @",
+                        IGNORE,
+                        ")
+                        (raw_string_literal)
+                    ]
+                    @string"
+                    )
+                }
+                PremadeSwiftQuery::Functions => "(function_declaration) @function",
+                PremadeSwiftQuery::Classes => "(class_declaration) @class",
+                PremadeSwiftQuery::Attributes => "(attribute) @attribute",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Swift.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomSwiftQuery(String, Precompiled);
+
+impl FromStr for CustomSwiftQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Swift::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomSwiftQuery> for TSQuery {
+    fn from(value: CustomSwiftQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Swift::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Swift {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Swift {
+    fn lang() -> TSLanguage {
+        tree_sitter_swift::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["swift"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["swift"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["swift"]
+    }
+}
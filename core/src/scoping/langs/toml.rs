@@ -0,0 +1,101 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The TOML language.
+pub type Toml = Language<TomlQuery>;
+/// A query for TOML.
+pub type TomlQuery = CodeQuery<CustomTomlQuery, PremadeTomlQuery>;
+
+/// Premade tree-sitter queries for TOML.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeTomlQuery {
+    /// Comments.
+    Comments,
+    /// Names in table headers (`[table]`, `[[array]]`; excl. brackets).
+    TableHeaders,
+    /// Keys of key/value pairs (incl. dotted keys; quotes included).
+    Keys,
+    /// String values (basic, literal and multi-line; quotes included).
+    Strings,
+}
+
+impl From<PremadeTomlQuery> for TSQuery {
+    fn from(value: PremadeTomlQuery) -> Self {
+        TSQuery::new(
+            Toml::lang(),
+            match value {
+                PremadeTomlQuery::Comments => "(comment) @comment",
+                PremadeTomlQuery::TableHeaders => {
+                    r"
+                    [
+                        (table [(bare_key) (quoted_key) (dotted_key)] @header)
+                        (table_array_element [(bare_key) (quoted_key) (dotted_key)] @header)
+                    ]
+                    "
+                }
+                PremadeTomlQuery::Keys => "(pair [(bare_key) (quoted_key) (dotted_key)] @key)",
+                PremadeTomlQuery::Strings => "(string) @string",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for TOML.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomTomlQuery(String, Precompiled);
+
+impl FromStr for CustomTomlQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Toml::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomTomlQuery> for TSQuery {
+    fn from(value: CustomTomlQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Toml::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Toml {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Toml {
+    fn lang() -> TSLanguage {
+        tree_sitter_toml::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["toml"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["toml"]
+    }
+}
@@ -1,7 +1,9 @@
-use super::{CodeQuery, Language, LanguageScoper, TSLanguage, TSQuery};
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
 use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
 use clap::ValueEnum;
 use const_format::concatcp;
+use serde::Deserialize;
 use std::{fmt::Debug, str::FromStr};
 use tree_sitter::QueryError;
 
@@ -10,7 +12,9 @@ pub type TypeScript = Language<TypeScriptQuery>;
 /// A query for TypeScript.
 pub type TypeScriptQuery = CodeQuery<CustomTypeScriptQuery, PremadeTypeScriptQuery>;
 /// Premade tree-sitter queries for TypeScript.
-#[derive(Debug, Clone, Copy, ValueEnum)]
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
 pub enum PremadeTypeScriptQuery {
     /// Comments.
     Comments,
@@ -49,14 +53,14 @@ impl From<PremadeTypeScriptQuery> for TSQuery {
 
 /// A custom tree-sitter query for TypeScript.
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
-pub struct CustomTypeScriptQuery(String);
+pub struct CustomTypeScriptQuery(String, Precompiled);
 
 impl FromStr for CustomTypeScriptQuery {
     type Err = QueryError;
 
     fn from_str(s: &str) -> Result<Self, Self::Err> {
         match TSQuery::new(TypeScript::lang(), s) {
-            Ok(_) => Ok(Self(s.to_string())),
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
             Err(e) => Err(e),
         }
     }
@@ -64,14 +68,16 @@ impl FromStr for CustomTypeScriptQuery {
 
 impl From<CustomTypeScriptQuery> for TSQuery {
     fn from(value: CustomTypeScriptQuery) -> Self {
-        TSQuery::new(TypeScript::lang(), &value.0)
-            .expect("Valid query, as object cannot be constructed otherwise")
+        value.1.take_or_else(|| {
+            TSQuery::new(TypeScript::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
     }
 }
 
 impl Scoper for TypeScript {
     fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
-        let ranges = Self::scope_via_query(&mut self.query(), input);
+        let ranges = self.scope_via_query(input);
 
         ROScopes::from_raw_ranges(input, ranges)
     }
@@ -82,7 +88,23 @@ impl LanguageScoper for TypeScript {
         tree_sitter_typescript::language_typescript()
     }
 
-    fn query(&self) -> TSQuery {
-        self.query.clone().into()
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["ts", "mts", "cts"]
+    }
+
+    fn interpreters() -> &'static [&'static str] {
+        &["ts-node", "deno", "bun"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["typescript"]
     }
 }
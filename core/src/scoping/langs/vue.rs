@@ -0,0 +1,150 @@
+use super::{
+    css::{Css, PremadeCssQuery},
+    parse_embedded, section_contents,
+    typescript::{PremadeTypeScriptQuery, TypeScript},
+    CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery, TSTree,
+};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Vue language.
+pub type Vue = Language<VueQuery>;
+/// A query for Vue single-file components.
+pub type VueQuery = CodeQuery<CustomVueQuery, PremadeVueQuery>;
+
+/// Premade tree-sitter queries for Vue single-file components.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeVueQuery {
+    /// Contents of the `<template>` section.
+    Template,
+    /// Contents of `<script>` sections.
+    Script,
+    /// Contents of `<style>` sections.
+    Style,
+    /// Comments in `<script>` sections.
+    ScriptComments,
+    /// Strings in `<script>` sections (literal, template; includes quote characters).
+    ScriptStrings,
+    /// Comments in `<style>` sections.
+    StyleComments,
+}
+
+impl PremadeVueQuery {
+    /// The kind of section node whose contents the query runs against, and their
+    /// language, if not the component itself.
+    ///
+    /// Scripts are taken as TypeScript, which covers JavaScript as well.
+    fn embedded(self) -> Option<(&'static str, TSLanguage)> {
+        match self {
+            Self::ScriptComments | Self::ScriptStrings => {
+                Some(("script_element", TypeScript::lang()))
+            }
+            Self::StyleComments => Some(("style_element", Css::lang())),
+            Self::Template | Self::Script | Self::Style => None,
+        }
+    }
+}
+
+impl From<PremadeVueQuery> for TSQuery {
+    fn from(value: PremadeVueQuery) -> Self {
+        let source = match value {
+            PremadeVueQuery::Template => {
+                concatcp!(
+                    "
+                    (fragment
+                        (element
+                            (start_tag (tag_name) @name) @",
+                    IGNORE,
+                    "
+                            (end_tag) @",
+                    IGNORE,
+                    "
+                        ) @template
+                        (#eq? @name \"template\")
+                    )
+                    "
+                )
+            }
+            PremadeVueQuery::Script => "(script_element (raw_text) @script)",
+            PremadeVueQuery::Style => "(style_element (raw_text) @style)",
+            PremadeVueQuery::ScriptComments => return PremadeTypeScriptQuery::Comments.into(),
+            PremadeVueQuery::ScriptStrings => return PremadeTypeScriptQuery::Strings.into(),
+            PremadeVueQuery::StyleComments => return PremadeCssQuery::Comments.into(),
+        };
+
+        TSQuery::new(Vue::lang(), source).expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Vue single-file components.
+///
+/// Runs against components as parsed as HTML, not against the code embedded in their
+/// sections.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomVueQuery(String, Precompiled);
+
+impl FromStr for CustomVueQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Vue::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomVueQuery> for TSQuery {
+    fn from(value: CustomVueQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Vue::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Vue {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Vue {
+    fn lang() -> TSLanguage {
+        tree_sitter_html::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["vue"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["vue"]
+    }
+
+    fn query_trees(&self, tree: &TSTree, input: &str) -> Vec<TSTree> {
+        let CodeQuery::Premade(premade) = self.query else {
+            return vec![tree.clone()];
+        };
+        let Some((section, lang)) = premade.embedded() else {
+            return vec![tree.clone()];
+        };
+
+        parse_embedded(lang, &section_contents(tree, section), input)
+    }
+}
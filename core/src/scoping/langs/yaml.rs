@@ -0,0 +1,124 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{langs::IGNORE, ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use const_format::concatcp;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The YAML language.
+pub type Yaml = Language<YamlQuery>;
+/// A query for YAML.
+pub type YamlQuery = CodeQuery<CustomYamlQuery, PremadeYamlQuery>;
+
+/// Premade tree-sitter queries for YAML.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeYamlQuery {
+    /// Comments.
+    Comments,
+    /// Keys of mappings (quotes included).
+    Keys,
+    /// Scalar values (not keys; incl. block scalars; quotes included).
+    Values,
+    /// Block scalars (literal `|` and folded `>`; incl. indicators).
+    BlockScalars,
+    /// Anchors and aliases (incl. `&` and `*`).
+    Anchors,
+}
+
+impl From<PremadeYamlQuery> for TSQuery {
+    fn from(value: PremadeYamlQuery) -> Self {
+        TSQuery::new(
+            Yaml::lang(),
+            match value {
+                PremadeYamlQuery::Comments => "(comment) @comment",
+                PremadeYamlQuery::Keys => {
+                    r"
+                    [
+                        (block_mapping_pair key: (_) @key)
+                        (flow_pair key: (_) @key)
+                    ]
+                    "
+                }
+                PremadeYamlQuery::Values => {
+                    concatcp!(
+                        "
+                    [
+                        (plain_scalar)
+                        (double_quote_scalar)
+                        (single_quote_scalar)
+                        (block_scalar)
+                    ]
+                    @value
+                    [
+                        (block_mapping_pair key: (_) @",
+                        IGNORE,
+                        ")
+                        (flow_pair key: (_) @",
+                        IGNORE,
+                        ")
+                    ]"
+                    )
+                }
+                PremadeYamlQuery::BlockScalars => "(block_scalar) @scalar",
+                PremadeYamlQuery::Anchors => "[(anchor) (alias)] @anchor",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for YAML.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomYamlQuery(String, Precompiled);
+
+impl FromStr for CustomYamlQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Yaml::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomYamlQuery> for TSQuery {
+    fn from(value: CustomYamlQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Yaml::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Yaml {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Yaml {
+    fn lang() -> TSLanguage {
+        tree_sitter_yaml::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["yaml", "yml"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["yaml"]
+    }
+}
@@ -0,0 +1,99 @@
+use super::{CodeQuery, Language, LanguageScoper, Overlaps, Precompiled, TSLanguage, TSQuery};
+use crate::scoping::{ROScopes, Scoper};
+#[cfg(feature = "clap")]
+use clap::ValueEnum;
+use serde::Deserialize;
+use std::{fmt::Debug, str::FromStr};
+use tree_sitter::QueryError;
+
+/// The Zig language.
+pub type Zig = Language<ZigQuery>;
+/// A query for Zig.
+pub type ZigQuery = CodeQuery<CustomZigQuery, PremadeZigQuery>;
+
+/// Premade tree-sitter queries for Zig.
+#[derive(Debug, Clone, Copy, Deserialize)]
+#[cfg_attr(feature = "clap", derive(ValueEnum))]
+#[serde(rename_all = "kebab-case")]
+pub enum PremadeZigQuery {
+    /// Comments (incl. doc and container doc comments).
+    Comments,
+    /// Strings (single-line and multiline; quotes and `\\` included).
+    Strings,
+    /// Top-level `comptime` blocks (incl. `comptime`).
+    Comptime,
+    /// Test declarations (incl. name and body).
+    Tests,
+    /// Function definitions (incl. prototype and body).
+    Functions,
+}
+
+impl From<PremadeZigQuery> for TSQuery {
+    fn from(value: PremadeZigQuery) -> Self {
+        TSQuery::new(
+            Zig::lang(),
+            match value {
+                PremadeZigQuery::Comments => {
+                    "[(line_comment) (doc_comment) (container_doc_comment)] @comment"
+                }
+                PremadeZigQuery::Strings => "[(STRINGLITERALSINGLE) (LINESTRING)] @string",
+                PremadeZigQuery::Comptime => "(TopLevelComptime) @comptime",
+                PremadeZigQuery::Tests => "(TestDecl) @test",
+                PremadeZigQuery::Functions => "(TopLevelDecl (FnProto) (Block)) @function",
+            },
+        )
+        .expect("Premade queries to be valid")
+    }
+}
+
+/// A custom tree-sitter query for Zig.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct CustomZigQuery(String, Precompiled);
+
+impl FromStr for CustomZigQuery {
+    type Err = QueryError;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match TSQuery::new(Zig::lang(), s) {
+            Ok(query) => Ok(Self(s.to_string(), Precompiled::new(query))),
+            Err(e) => Err(e),
+        }
+    }
+}
+
+impl From<CustomZigQuery> for TSQuery {
+    fn from(value: CustomZigQuery) -> Self {
+        value.1.take_or_else(|| {
+            TSQuery::new(Zig::lang(), &value.0)
+                .expect("Valid query, as object cannot be constructed otherwise")
+        })
+    }
+}
+
+impl Scoper for Zig {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.scope_via_query(input))
+    }
+}
+
+impl LanguageScoper for Zig {
+    fn lang() -> TSLanguage {
+        tree_sitter_zig::language()
+    }
+
+    fn query(&self) -> &TSQuery {
+        self.compiled_query()
+    }
+
+    fn overlaps(&self) -> Overlaps {
+        self.overlaps
+    }
+
+    fn file_extensions() -> &'static [&'static str] {
+        &["zig"]
+    }
+
+    fn modeline_names() -> &'static [&'static str] {
+        &["zig"]
+    }
+}
@@ -0,0 +1,297 @@
+use super::{
+    regex::{CaptureGroups, Regex},
+    ROScopes, Scoper,
+};
+#[cfg(doc)]
+use crate::scoping::{
+    scope::Scope::{In, Out},
+    view::ScopedViewBuilder,
+};
+use log::trace;
+use std::ops::Range;
+
+/// Scopes `input` using `scoper`, keeping only the parts [`In`] scope at (0-based)
+/// `occurrences`.
+fn scope_occurrences<'viewee>(
+    scoper: &impl Scoper,
+    input: &'viewee str,
+    occurrences: &Range<usize>,
+) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+    let (mut scopes, mut captures) = scoper.scope_with_captures(input);
+    let kept = scopes.keep(occurrences);
+    captures.retain(|(range, _)| kept.start <= range.start && range.end <= kept.end);
+
+    (scopes, captures)
+}
+
+/// Scopes like some other [`Scoper`], but only the first so many parts [`In`] scope of
+/// each input, leaving all later ones out.
+///
+/// Exploding a view with this, the limit applies to each part left in scope by
+/// previous scopers separately, such as to each comment found by a language scoper.
+/// For a limit applying to an entire view, see [`ScopedViewBuilder::at_most`].
+///
+/// Each part counts, so wrapped scopers should scope whole matches: by default, a
+/// [`Regex`] with capture groups splits each match into many parts, see
+/// [`Regex::with_capture_groups`].
+///
+/// ```rust
+/// use srgn_core::scoping::langs::{python::{PremadePythonQuery, Python}, CodeQuery};
+/// use srgn_core::scoping::{limit::AtMost, regex::Regex, view::ScopedViewBuilder};
+/// use srgn_core::RegexPattern;
+///
+/// let input = "# a1 a2 a3\n# a4\n";
+///
+/// let mut builder = ScopedViewBuilder::new(input);
+/// builder.explode(&Python::new(CodeQuery::Premade(PremadePythonQuery::Comments)));
+/// builder.explode(&AtMost::new(Regex::new(RegexPattern::new(r"a\d").unwrap()), 2));
+///
+/// let mut view = builder.build();
+/// view.upper();
+///
+/// assert_eq!(view.to_string(), "# A1 A2 a3\n# A4\n");
+/// ```
+#[derive(Debug, Clone)]
+pub struct AtMost<S> {
+    scoper: S,
+    max: usize,
+}
+
+impl<S> AtMost<S> {
+    /// Create a new instance, keeping at most `max` parts of what `scoper` scopes.
+    #[must_use]
+    pub fn new(scoper: S, max: usize) -> Self {
+        Self { scoper, max }
+    }
+}
+
+impl<S: Scoper> Scoper for AtMost<S> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        let mut scopes = self.scoper.scope(input);
+        scopes.keep(&(0..self.max));
+        scopes
+    }
+
+    fn scope_with_captures<'viewee>(
+        &self,
+        input: &'viewee str,
+    ) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+        scope_occurrences(&self.scoper, input, &(0..self.max))
+    }
+}
+
+/// Scopes like some other [`Scoper`], but only certain occurrences of parts [`In`]
+/// scope of each input, such as only the second one, or all but the first.
+///
+/// Occurrences are given as a range of 0-based indices. Counting happens like for
+/// [`AtMost`], with the same caveats. For a selection applying to an entire view, see
+/// [`ScopedViewBuilder::occurrences`].
+///
+/// ```rust
+/// use srgn_core::scoping::{limit::Occurrences, regex::Regex, view::ScopedViewBuilder};
+/// use srgn_core::RegexPattern;
+///
+/// let mut builder = ScopedViewBuilder::new("a1 a2 a3 a4");
+/// // All but the first.
+/// builder.explode(&Occurrences::new(Regex::new(RegexPattern::new(r"a\d").unwrap()), 1..usize::MAX));
+///
+/// let mut view = builder.build();
+/// view.upper();
+///
+/// assert_eq!(view.to_string(), "a1 A2 A3 A4");
+/// ```
+#[derive(Debug, Clone)]
+pub struct Occurrences<S> {
+    scoper: S,
+    occurrences: Range<usize>,
+}
+
+impl<S> Occurrences<S> {
+    /// Create a new instance, keeping only the parts of what `scoper` scopes which are
+    /// at (0-based) `occurrences`.
+    #[must_use]
+    pub fn new(scoper: S, occurrences: Range<usize>) -> Self {
+        Self {
+            scoper,
+            occurrences,
+        }
+    }
+}
+
+impl<S: Scoper> Scoper for Occurrences<S> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        let mut scopes = self.scoper.scope(input);
+        scopes.keep(&self.occurrences);
+        scopes
+    }
+
+    fn scope_with_captures<'viewee>(
+        &self,
+        input: &'viewee str,
+    ) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+        scope_occurrences(&self.scoper, input, &self.occurrences)
+    }
+}
+
+/// Scopes like some other [`Scoper`], but only inputs a guard holds for, leaving any
+/// other input [`Out`] of scope entirely.
+///
+/// The guard holds if all [required][`Self::requiring`] regexes match somewhere in an
+/// input, and none of the [forbidden][`Self::forbidding`] ones do. Exploding a view
+/// with this, the guard is checked against each part left in scope by previous scopers
+/// separately, such as against each comment found by a language scoper.
+///
+/// ```rust
+/// use srgn_core::scoping::langs::{python::{PremadePythonQuery, Python}, CodeQuery};
+/// use srgn_core::scoping::{limit::Guarded, regex::Regex, view::ScopedViewBuilder};
+/// use srgn_core::RegexPattern;
+///
+/// let regex = |pattern| Regex::new(RegexPattern::new(pattern).unwrap());
+/// let input = "# TODO: fix a\n# fix b\n# TODO: fix c, done\n";
+///
+/// let mut builder = ScopedViewBuilder::new(input);
+/// builder.explode(&Python::new(CodeQuery::Premade(PremadePythonQuery::Comments)));
+/// builder.explode(
+///     &Guarded::new(regex("fix"))
+///         .requiring(regex("TODO"))
+///         .forbidding(regex("done")),
+/// );
+///
+/// let mut view = builder.build();
+/// view.upper();
+///
+/// assert_eq!(view.to_string(), "# TODO: FIX a\n# fix b\n# TODO: fix c, done\n");
+/// ```
+#[derive(Debug)]
+pub struct Guarded<S> {
+    scoper: S,
+    required: Vec<Regex>,
+    forbidden: Vec<Regex>,
+}
+
+impl<S> Guarded<S> {
+    /// Create a new instance, scoping like `scoper` unconditionally, until guarded
+    /// further.
+    #[must_use]
+    pub fn new(scoper: S) -> Self {
+        Self {
+            scoper,
+            required: Vec::new(),
+            forbidden: Vec::new(),
+        }
+    }
+
+    /// Only scope inputs `regex` matches somewhere in.
+    #[must_use]
+    pub fn requiring(mut self, regex: Regex) -> Self {
+        self.required.push(regex);
+        self
+    }
+
+    /// Only scope inputs `regex` does not match anywhere in.
+    #[must_use]
+    pub fn forbidding(mut self, regex: Regex) -> Self {
+        self.forbidden.push(regex);
+        self
+    }
+
+    /// Whether the guard holds for `input`.
+    fn holds(&self, input: &str) -> bool {
+        let holds = self.required.iter().all(|regex| regex.is_match(input))
+            && !self.forbidden.iter().any(|regex| regex.is_match(input));
+        trace!("Guard holds for '{}': {holds}", input.escape_debug());
+
+        holds
+    }
+}
+
+impl<S: Scoper> Scoper for Guarded<S> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        if self.holds(input) {
+            self.scoper.scope(input)
+        } else {
+            ROScopes::from_raw_ranges(input, Vec::new())
+        }
+    }
+
+    fn scope_with_captures<'viewee>(
+        &self,
+        input: &'viewee str,
+    ) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+        if self.holds(input) {
+            self.scoper.scope_with_captures(input)
+        } else {
+            (ROScopes::from_raw_ranges(input, Vec::new()), Vec::new())
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::scoping::{
+        literal::Literal,
+        scope::{
+            ROScope,
+            Scope::{In, Out},
+        },
+    };
+    use rstest::rstest;
+
+    #[rstest]
+    #[case("a a a", 0, vec![ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a"))])]
+    #[case("a a a", 1, vec![ROScope(In("a")), ROScope(Out(" ")), ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a"))])]
+    #[case("a a a", 2, vec![ROScope(In("a")), ROScope(Out(" ")), ROScope(In("a")), ROScope(Out(" ")), ROScope(Out("a"))])]
+    #[case("a a a", 3, vec![ROScope(In("a")), ROScope(Out(" ")), ROScope(In("a")), ROScope(Out(" ")), ROScope(In("a"))])]
+    #[case("a a a", 4, vec![ROScope(In("a")), ROScope(Out(" ")), ROScope(In("a")), ROScope(Out(" ")), ROScope(In("a"))])]
+    #[case("aa", 1, vec![ROScope(In("a")), ROScope(Out("a"))])]
+    #[case("b", 1, vec![ROScope(Out("b"))])]
+    fn test_at_most(#[case] input: &str, #[case] max: usize, #[case] expected: Vec<ROScope>) {
+        let scoper = AtMost::new(Literal::try_from("a".to_owned()).unwrap(), max);
+
+        assert_eq!(scoper.scope(input), ROScopes(expected));
+    }
+
+    #[rstest]
+    #[case("a a a", 0..0, vec![ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a"))])]
+    #[case("a a a", 1..2, vec![ROScope(Out("a")), ROScope(Out(" ")), ROScope(In("a")), ROScope(Out(" ")), ROScope(Out("a"))])]
+    #[case("a a a", 1..usize::MAX, vec![ROScope(Out("a")), ROScope(Out(" ")), ROScope(In("a")), ROScope(Out(" ")), ROScope(In("a"))])]
+    #[case("a a a", 5..6, vec![ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a")), ROScope(Out(" ")), ROScope(Out("a"))])]
+    fn test_occurrences(
+        #[case] input: &str,
+        #[case] occurrences: Range<usize>,
+        #[case] expected: Vec<ROScope>,
+    ) {
+        let scoper = Occurrences::new(Literal::try_from("a".to_owned()).unwrap(), occurrences);
+
+        assert_eq!(scoper.scope(input), ROScopes(expected));
+    }
+
+    #[rstest]
+    #[case("a b", &[], &[], vec![ROScope(In("a")), ROScope(Out(" b"))])]
+    #[case("a b", &["b"], &[], vec![ROScope(In("a")), ROScope(Out(" b"))])]
+    #[case("a b", &["c"], &[], vec![ROScope(Out("a b"))])]
+    #[case("a b", &[], &["b"], vec![ROScope(Out("a b"))])]
+    #[case("a b", &["a", "b"], &["c"], vec![ROScope(In("a")), ROScope(Out(" b"))])]
+    #[case("a b", &["a", "c"], &[], vec![ROScope(Out("a b"))])]
+    #[case("a b", &[], &["c", "b"], vec![ROScope(Out("a b"))])]
+    #[case("", &["a"], &[], vec![])]
+    fn test_guarded(
+        #[case] input: &str,
+        #[case] required: &[&str],
+        #[case] forbidden: &[&str],
+        #[case] expected: Vec<ROScope>,
+    ) {
+        let regex = |pattern: &&str| Regex::try_from((*pattern).to_owned()).unwrap();
+
+        let mut scoper = Guarded::new(Literal::try_from("a".to_owned()).unwrap());
+        for pattern in required {
+            scoper = scoper.requiring(regex(pattern));
+        }
+        for pattern in forbidden {
+            scoper = scoper.forbidding(regex(pattern));
+        }
+
+        assert_eq!(scoper.scope(input), ROScopes(expected));
+    }
+}
@@ -1,11 +1,17 @@
-use super::{ROScopes, Scoper};
+use super::{prefilter::Prefilter, regex::Regex, ROScopes, Scoper};
+use crate::RegexPattern;
 use log::trace;
 use std::{error::Error, fmt, ops::Range};
 use unescape::unescape;
 
 /// A literal string for querying.
 #[derive(Debug)]
-pub struct Literal(String);
+pub struct Literal {
+    literal: String,
+    /// For matching case-insensitively, the literal as a regex: case folding is not as
+    /// simple as it looks.
+    case_insensitive: Option<Regex>,
+}
 
 /// An error that can occur when parsing a literal.
 #[derive(Debug)]
@@ -26,6 +32,37 @@ impl fmt::Display for LiteralError {
 
 impl Error for LiteralError {}
 
+impl Literal {
+    /// Match the literal case-insensitively, such that e.g. `hello` also matches
+    /// `HeLLo`.
+    ///
+    /// Follows Unicode's simple case folding, as regexes do with `(?i)`.
+    ///
+    /// ## Panics
+    ///
+    /// Never: any literal escaped for use in a regex is a valid regex.
+    #[must_use]
+    pub fn case_insensitive(mut self) -> Self {
+        let pattern = format!("(?i){}", regex_syntax::escape(&self.literal));
+        let pattern = RegexPattern::new(&pattern).expect("Escaped literal to be a valid regex");
+
+        self.case_insensitive = Some(Regex::new(pattern));
+        self
+    }
+
+    /// A [`Prefilter`] for inputs to this literal, which is simply the literal itself
+    /// (or its variants in case, if matching [case-insensitively][`Self::case_insensitive`]).
+    ///
+    /// There is none for the empty literal.
+    #[must_use]
+    pub fn prefilter(&self) -> Option<Prefilter> {
+        match &self.case_insensitive {
+            Some(regex) => regex.prefilter(),
+            None => Prefilter::new([&self.literal]),
+        }
+    }
+}
+
 impl TryFrom<String> for Literal {
     type Error = LiteralError;
 
@@ -33,17 +70,24 @@ impl TryFrom<String> for Literal {
         let unescaped =
             unescape(&literal).ok_or(LiteralError::InvalidEscapeSequences(literal.to_string()))?;
 
-        Ok(Self(unescaped))
+        Ok(Self {
+            literal: unescaped,
+            case_insensitive: None,
+        })
     }
 }
 
 impl Scoper for Literal {
     fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        if let Some(regex) = &self.case_insensitive {
+            return regex.scope(input);
+        }
+
         let ranges = {
-            let len = self.0.len();
+            let len = self.literal.len();
 
             let ranges = input
-                .match_indices(&self.0)
+                .match_indices(&self.literal)
                 .map(|(i, _)| Range {
                     start: i,
                     end: i + len,
@@ -96,4 +140,35 @@ mod tests {
 
         assert_eq!(actual, expected);
     }
+
+    #[rstest]
+    #[case("a A", "a", vec!["a", "A"])]
+    #[case("Straße STRASSE STRAßE", "straße", vec!["Straße", "STRAßE"])]
+    #[case("1+1 = 2", "1+1", vec!["1+1"])]
+    #[case("(A.B) AxB", "(a.b)", vec!["(A.B)"])]
+    #[case("A\tB", r"a\tb", vec!["A\tB"])]
+    #[case("abc", "x", vec![])]
+    fn test_literal_scoping_case_insensitive(
+        #[case] input: &str,
+        #[case] literal: &str,
+        #[case] expected: Vec<&str>,
+    ) {
+        use crate::scoping::scope::ROScope;
+
+        let literal = Literal::try_from(literal.to_owned())
+            .unwrap()
+            .case_insensitive();
+
+        let actual = literal
+            .scope(input)
+            .0
+            .into_iter()
+            .filter_map(|scope| match scope {
+                ROScope(In(s)) => Some(s),
+                ROScope(Out(_)) => None,
+            })
+            .collect::<Vec<_>>();
+
+        assert_eq!(actual, expected);
+    }
 }
@@ -0,0 +1,120 @@
+//! Items for defining the scope actions are applied within.
+
+use crate::scoping::{regex::CaptureGroups, scope::ROScopes};
+#[cfg(doc)]
+use crate::scoping::{scope::Scope, view::ScopedView};
+use std::ops::Range;
+
+/// Fixes for DOS-style line endings.
+pub mod dosfix;
+/// Create scoped views using programming language grammar-aware types.
+pub mod langs;
+/// Limiting how much of an input ends up in scope.
+pub mod limit;
+/// Create scoped views using string literals.
+pub mod literal;
+/// Cheaply ruling out inputs which cannot contain anything in scope.
+pub mod prefilter;
+/// Create scoped views using regular expressions.
+pub mod regex;
+/// [`Scope`] and its various wrappers.
+pub mod scope;
+/// [`ScopedView`] and its related types.
+pub mod view;
+
+/// An item capable of scoping down a given input into individual scopes.
+///
+/// All built-in scopers (regular expressions, literals, language grammars) implement
+/// this trait, and so may any other type. Custom scopers compose with the built-in
+/// ones: when [exploding][`view::ScopedViewBuilder::explode`] a view, each scoper only
+/// ever sees the parts left [`In`][`scope::Scope::In`] scope by previous ones.
+///
+/// ## Contract
+///
+/// The returned scopes must, concatenated in order, yield `input` exactly. The
+/// easiest way to uphold this is [`ROScopes::from_raw_ranges`], which only requires the
+/// byte ranges to be in scope.
+///
+/// ## Example: a custom scoper
+///
+/// Scoping down to only lines containing a marker, then further down to comments of
+/// those lines only:
+///
+/// ```rust
+/// use srgn_core::scoping::{scope::ROScopes, view::ScopedViewBuilder, Scoper};
+/// use srgn_core::scoping::langs::{python::{PremadePythonQuery, Python}, CodeQuery};
+///
+/// struct LinesContaining(&'static str);
+///
+/// impl Scoper for LinesContaining {
+///     fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+///         let mut ranges = Vec::new();
+///         let mut start = 0;
+///         for line in input.split_inclusive('\n') {
+///             if line.contains(self.0) {
+///                 ranges.push(start..start + line.len());
+///             }
+///             start += line.len();
+///         }
+///
+///         ROScopes::from_raw_ranges(input, ranges)
+///     }
+/// }
+///
+/// let input = "a = 1  # fix\nb = 2  # fix  # HOT\n";
+///
+/// let mut builder = ScopedViewBuilder::new(input);
+/// builder.explode(&Python::new(CodeQuery::Premade(PremadePythonQuery::Comments)));
+/// builder.explode(&LinesContaining("HOT"));
+///
+/// let mut view = builder.build();
+/// view.upper();
+///
+/// assert_eq!(view.to_string(), "a = 1  # fix\nb = 2  # FIX  # HOT\n");
+/// ```
+///
+/// Closures of the fitting signature are scopers as well.
+pub trait Scoper: Send + Sync {
+    /// Scope the given `input`.
+    ///
+    /// After application, the returned scopes are a collection of either in-scope or
+    /// out-of-scope parts of the input. Assembling them back together should yield the
+    /// original input.
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee>;
+
+    /// Scope the given `input` like [`Self::scope`], additionally reporting what the
+    /// capture groups of each match matched, along with the match's range in `input`.
+    ///
+    /// Only scopers matching with capture groups, such as a [`regex::Regex`]
+    /// [configured][`regex::Regex::with_capture_groups`] to, have anything to report.
+    /// By default, nothing is.
+    fn scope_with_captures<'viewee>(
+        &self,
+        input: &'viewee str,
+    ) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+        (self.scope(input), Vec::new())
+    }
+}
+
+impl<T> Scoper for T
+where
+    T: Fn(&str) -> ROScopes + Send + Sync,
+{
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        self(input)
+    }
+}
+
+// https://www.reddit.com/r/rust/comments/droxdg/why_arent_traits_impld_for_boxdyn_trait/
+impl Scoper for Box<dyn Scoper> {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        self.as_ref().scope(input)
+    }
+
+    fn scope_with_captures<'viewee>(
+        &self,
+        input: &'viewee str,
+    ) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+        self.as_ref().scope_with_captures(input)
+    }
+}
@@ -0,0 +1,123 @@
+use aho_corasick::AhoCorasick;
+use log::trace;
+
+/// A fast check for whether an input can possibly contain anything in scope.
+///
+/// Built from the literal fragments any match of a scoper has to contain. If none of
+/// them occur in an input, nothing in it can end up in scope, and all (potentially
+/// expensive) scoping, such as parsing for [language
+/// scopers](crate::scoping::langs), can be skipped.
+///
+/// A prefilter can only ever rule inputs *out*: an input passing it is not guaranteed
+/// to contain any match.
+#[derive(Debug, Clone)]
+pub struct Prefilter(AhoCorasick);
+
+impl Prefilter {
+    /// Creates a prefilter from the given `literals`, of which any input has to contain
+    /// at least one to pass.
+    ///
+    /// Returns [`None`] if no meaningful prefilter exists, which is the case if there
+    /// are no literals or any of them is empty.
+    #[must_use]
+    pub fn new<I, B>(literals: I) -> Option<Self>
+    where
+        I: IntoIterator<Item = B>,
+        B: AsRef<[u8]>,
+    {
+        let literals: Vec<B> = literals.into_iter().collect();
+
+        if literals.is_empty() || literals.iter().any(|l| l.as_ref().is_empty()) {
+            return None;
+        }
+
+        AhoCorasick::new(&literals).ok().map(Self)
+    }
+
+    /// Whether `input` contains any of the required literals, i.e. whether it can
+    /// contain anything in scope at all.
+    #[must_use]
+    pub fn is_match(&self, input: &[u8]) -> bool {
+        let res = self.0.is_match(input);
+        trace!("Prefilter matched: {res}");
+        res
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::scoping::{literal::Literal, regex::Regex};
+    use rstest::rstest;
+
+    #[rstest]
+    #[case("foo", "a foo b", Some(true))]
+    #[case("foo", "a fo b", Some(false))]
+    #[case("foo|bar", "a bar b", Some(true))]
+    #[case("foo|bar", "a baz b", Some(false))]
+    #[case("ba[rz]", "a baz b", Some(true))]
+    #[case("ba[rz]", "a bay b", Some(false))]
+    #[case(r"def \w+\(", "def f(x):", Some(true))]
+    #[case(r"def \w+\(", "class C:", Some(false))]
+    #[case("(?i)foo", "FOO", Some(true))]
+    #[case("(?i)foo", "bar", Some(false))]
+    #[case(r"\bfoo\b", "foo", Some(true))]
+    #[case("^foo", "a\nfoo", Some(true))]
+    //
+    // No meaningful prefilter.
+    #[case(r"\w+", "", None)]
+    #[case(".*", "", None)]
+    #[case("foo|", "", None)]
+    #[case("(?:foo)?", "", None)]
+    //
+    // Not analyzable (fancy features).
+    #[case("(?<=a)foo", "", None)]
+    #[case(r"(a)\1", "", None)]
+    fn test_regex_prefilter(
+        #[case] pattern: &str,
+        #[case] input: &str,
+        #[case] expected: Option<bool>,
+    ) {
+        let regex = Regex::try_from(pattern.to_owned()).unwrap();
+
+        let actual = regex.prefilter().map(|p| p.is_match(input.as_bytes()));
+
+        assert_eq!(actual, expected);
+    }
+
+    #[rstest]
+    #[case("foo", "a foo b", Some(true))]
+    #[case("foo", "a fo b", Some(false))]
+    #[case(r"\t", "a\tb", Some(true))]
+    #[case("", "", None)]
+    fn test_literal_prefilter(
+        #[case] literal: &str,
+        #[case] input: &str,
+        #[case] expected: Option<bool>,
+    ) {
+        let literal = Literal::try_from(literal.to_owned()).unwrap();
+
+        let actual = literal.prefilter().map(|p| p.is_match(input.as_bytes()));
+
+        assert_eq!(actual, expected);
+    }
+
+    #[rstest]
+    #[case("foo", "a FOO b", Some(true))]
+    #[case("foo", "a fo b", Some(false))]
+    #[case("a.b", "A.B", Some(true))]
+    #[case("a.b", "AxB", Some(false))]
+    fn test_case_insensitive_literal_prefilter(
+        #[case] literal: &str,
+        #[case] input: &str,
+        #[case] expected: Option<bool>,
+    ) {
+        let literal = Literal::try_from(literal.to_owned())
+            .unwrap()
+            .case_insensitive();
+
+        let actual = literal.prefilter().map(|p| p.is_match(input.as_bytes()));
+
+        assert_eq!(actual, expected);
+    }
+}
@@ -0,0 +1,786 @@
+use super::prefilter::Prefilter;
+use super::ROScopes;
+use super::Scoper;
+use crate::cancel;
+use crate::scoping::scope::subtract;
+use crate::stats::{self, Phase};
+use crate::RegexPattern;
+use crate::GLOBAL_SCOPE;
+use fancy_regex::RuntimeError;
+use log::{debug, trace, warn};
+use regex_syntax::hir::literal::{ExtractKind, Extractor};
+use std::convert::Infallible;
+use std::error::Error;
+use std::fmt;
+use std::ops::Range;
+use std::str::FromStr;
+use std::sync::Arc;
+
+/// A regular expression for querying.
+#[derive(Debug)]
+pub struct Regex {
+    pattern: RegexPattern,
+    whole_matches: bool,
+    /// The capture group to narrow each match down to, if any.
+    capture: Option<CaptureGroup>,
+}
+
+impl Regex {
+    /// Create a new regular expression.
+    #[must_use]
+    pub fn new(pattern: RegexPattern) -> Self {
+        Self {
+            pattern,
+            whole_matches: false,
+            capture: None,
+        }
+    }
+
+    /// Scope to whole matches, reporting what their capture groups matched (see
+    /// [`Scoper::scope_with_captures`]).
+    ///
+    /// By default, capture groups are scoped specially instead: the characters they
+    /// match are scoped individually. That is of no use to
+    /// [replacements][`crate::actions::Replacement`] referring to groups, which need
+    /// to replace entire matches at once.
+    #[must_use]
+    pub fn with_capture_groups(mut self) -> Self {
+        self.whole_matches = true;
+        self
+    }
+
+    /// Scope only what `group` matched of each match, leaving the rest of it out of
+    /// scope.
+    ///
+    /// Allows matching context around what is to be acted on, without resorting to
+    /// look-arounds. Matches in which `group` did not participate are left out
+    /// entirely. Like [`Self::with_capture_groups`], what all capture groups matched
+    /// is reported, with `$0` remaining the entire match.
+    ///
+    /// ```rust
+    /// use srgn_core::RegexPattern;
+    /// use srgn_core::scoping::{regex::{CaptureGroup, Regex}, view::ScopedViewBuilder};
+    ///
+    /// let pattern = RegexPattern::new(r#"key = "(?P<value>[^"]*)""#).unwrap();
+    /// let regex = Regex::new(pattern)
+    ///     .with_capture(CaptureGroup::Name("value".into()))
+    ///     .unwrap();
+    ///
+    /// let mut builder = ScopedViewBuilder::new(r#"key = "value", other = "value""#);
+    /// builder.explode(&regex);
+    /// let mut view = builder.build();
+    /// view.upper();
+    ///
+    /// assert_eq!(view.to_string(), r#"key = "VALUE", other = "value""#);
+    /// ```
+    ///
+    /// ## Errors
+    ///
+    /// If the pattern has no such group.
+    pub fn with_capture(mut self, group: CaptureGroup) -> Result<Self, UnknownCaptureGroup> {
+        let exists = match &group {
+            CaptureGroup::Index(index) => *index < self.pattern.captures_len(),
+            CaptureGroup::Name(name) => self
+                .pattern
+                .capture_names()
+                .any(|n| n == Some(name.as_str())),
+        };
+
+        if !exists {
+            return Err(UnknownCaptureGroup(group));
+        }
+
+        self.capture = Some(group);
+        Ok(self)
+    }
+
+    /// Whether this regex matches anywhere in `input`.
+    ///
+    /// Matching failing, such as by exceeding the backtracking limit, counts as no
+    /// match.
+    #[must_use]
+    pub fn is_match(&self, input: &str) -> bool {
+        let _timer = stats::Timer::start(Phase::Regex);
+
+        self.pattern.is_match(input).unwrap_or_else(|e| {
+            matching_failed(&self.pattern, &e);
+            false
+        })
+    }
+
+    /// All matches in `input`, in order, along with what their capture groups matched.
+    fn captures(&self, input: &str) -> Vec<(Range<usize>, CaptureGroups)> {
+        let names: Arc<[Option<String>]> = self
+            .pattern
+            .capture_names()
+            .map(|name| name.map(ToOwned::to_owned))
+            .collect();
+
+        let mut matches = Vec::new();
+        for cap in self.pattern.captures_iter(input) {
+            let Ok(cap) = cap.map_err(|e| matching_failed(&self.pattern, &e)) else {
+                break;
+            };
+
+            if cancel::is_cancelled() {
+                debug!("Cancelled, stopping regex matching");
+                break;
+            }
+
+            let range = match &self.capture {
+                Some(CaptureGroup::Index(index)) => cap.get(*index),
+                Some(CaptureGroup::Name(name)) => cap.name(name),
+                None => Some(
+                    cap.get(0)
+                        .expect("First element guaranteed to be non-None (whole match)"),
+                ),
+            };
+            let Some(range) = range.map(|m| m.range()) else {
+                trace!("Capture group did not participate in match, skipping");
+                continue;
+            };
+            let groups = cap
+                .iter()
+                .map(|group| group.map(|group| group.as_str().to_owned()))
+                .collect::<Vec<_>>();
+            cancel::charge(groups.iter().flatten().map(String::len).sum());
+
+            matches.push((
+                range,
+                CaptureGroups {
+                    groups,
+                    names: Arc::clone(&names),
+                },
+            ));
+        }
+        trace!("Matches with capture groups: {:?}", matches);
+
+        matches
+    }
+
+    /// A [`Prefilter`] for inputs to this regular expression, if one can be derived.
+    ///
+    /// This is only possible if every match has to start with one of a finite set of
+    /// literals, like for `foo|ba[rz]`, but not for `\w+`. Patterns using features
+    /// beyond regular languages, such as look-arounds and backreferences, are not
+    /// analyzed at all.
+    #[must_use]
+    pub fn prefilter(&self) -> Option<Prefilter> {
+        let pattern = self.pattern.as_str();
+
+        let Ok(hir) = regex_syntax::Parser::new().parse(pattern) else {
+            debug!("Cannot analyze pattern '{pattern}' for prefiltering");
+            return None;
+        };
+
+        let seq = Extractor::new().kind(ExtractKind::Prefix).extract(&hir);
+        // Infinite sequences (`None`) can match anything.
+        let literals = seq.literals()?;
+        trace!("Literals of pattern '{pattern}' for prefiltering: {literals:?}");
+
+        Prefilter::new(literals.iter().map(|l| l.as_bytes()))
+    }
+}
+
+/// An error that can occur when parsing a regular expression.
+///
+/// Simple wrapper.
+#[derive(Debug)]
+pub struct RegexError(fancy_regex::Error);
+
+impl fmt::Display for RegexError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "Invalid regex: {}", self.0)
+    }
+}
+
+impl Error for RegexError {}
+
+/// A capture group of a regular expression, by index or by name.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum CaptureGroup {
+    /// The group at this index, with `0` being the entire match.
+    Index(usize),
+    /// The group of this name.
+    Name(String),
+}
+
+impl FromStr for CaptureGroup {
+    type Err = Infallible;
+
+    /// Parses digits as an index, anything else as a name.
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        Ok(s.parse()
+            .map_or_else(|_| Self::Name(s.to_owned()), Self::Index))
+    }
+}
+
+impl fmt::Display for CaptureGroup {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Index(index) => write!(f, "{index}"),
+            Self::Name(name) => write!(f, "{name}"),
+        }
+    }
+}
+
+/// A [`CaptureGroup`] not present in a regular expression.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct UnknownCaptureGroup(pub CaptureGroup);
+
+impl fmt::Display for UnknownCaptureGroup {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "No such capture group: {}", self.0)
+    }
+}
+
+impl Error for UnknownCaptureGroup {}
+
+/// What the capture groups of a regular expression matched, for one of its matches.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct CaptureGroups {
+    /// By index; the zeroth group is the entire match. Groups not participating in the
+    /// match are [`None`].
+    groups: Vec<Option<String>>,
+    /// Names of groups, by index (shared by all matches of the same pattern).
+    names: Arc<[Option<String>]>,
+}
+
+impl CaptureGroups {
+    /// What the group at `index` matched, if it exists and participated in the match.
+    ///
+    /// Index `0` is the entire match.
+    #[must_use]
+    pub fn get(&self, index: usize) -> Option<&str> {
+        self.groups.get(index)?.as_deref()
+    }
+
+    /// What the group called `name` matched, if it exists and participated in the
+    /// match.
+    #[must_use]
+    pub fn name(&self, name: &str) -> Option<&str> {
+        let index = self.names.iter().position(|n| n.as_deref() == Some(name))?;
+
+        self.get(index)
+    }
+}
+
+impl TryFrom<String> for Regex {
+    type Error = RegexError;
+
+    fn try_from(pattern: String) -> Result<Self, Self::Error> {
+        let pattern = RegexPattern::new(&pattern).map_err(RegexError)?;
+
+        Ok(Self::new(pattern))
+    }
+}
+
+impl Default for Regex {
+    fn default() -> Self {
+        Self::new(RegexPattern::new(GLOBAL_SCOPE).unwrap())
+    }
+}
+
+impl Scoper for Regex {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        let _timer = stats::Timer::start(Phase::Regex);
+
+        let has_capture_groups = self.pattern.captures_len() > 1;
+
+        let ranges = if self.whole_matches || self.capture.is_some() {
+            self.captures(input)
+                .into_iter()
+                .map(|(range, _)| range)
+                .collect()
+        } else if has_capture_groups {
+            trace!(
+                "Pattern '{}' has capture groups, iterating over matches",
+                self.pattern
+            );
+            let mut ranges = Vec::new();
+            for cap in self.pattern.captures_iter(input) {
+                let Ok(cap) = cap.map_err(|e| matching_failed(&self.pattern, &e)) else {
+                    break;
+                };
+
+                if cancel::is_cancelled() {
+                    debug!("Cancelled, stopping regex matching");
+                    break;
+                }
+
+                let mut it = cap.iter();
+
+                let overall_match = it
+                    .next()
+                    // https://docs.rs/regex/1.9.5/regex/struct.SubCaptureMatches.html
+                    .expect("Entered iterator of matches, but zeroth (whole) match missing")
+                    .expect("First element guaranteed to be non-None (whole match)");
+                trace!(
+                    "Overall match: '{}' from index {} to {}",
+                    overall_match.as_str().escape_debug(),
+                    overall_match.start(),
+                    overall_match.end()
+                );
+
+                let mut subranges = Vec::new();
+                for group in it.flatten() {
+                    trace!(
+                        "Group match: '{}' from index {} to {}",
+                        group.as_str().escape_debug(),
+                        group.start(),
+                        group.end()
+                    );
+                    subranges.push(group.range());
+                }
+
+                // Parts of the overall match, but not the capture groups: push as-is
+                ranges.extend(subtract(vec![overall_match.range()], &subranges));
+
+                // Treat the capture groups specially now
+                for subrange in &subranges {
+                    cancel::charge(subrange.len() * std::mem::size_of::<Range<usize>>());
+                    ranges.extend(shatter(subrange));
+                }
+            }
+
+            ranges.sort_by_key(|r| r.start);
+            debug!("Ranges to scope after regex: {:?}", ranges);
+            ranges
+        } else {
+            trace!(
+                "No capture groups in pattern '{}', short-circuiting",
+                input.escape_debug()
+            );
+
+            self.pattern
+                .find_iter(input)
+                .map_while(|m| m.map_err(|e| matching_failed(&self.pattern, &e)).ok())
+                .take_while(|_| !cancel::is_cancelled())
+                .map(|m| m.range())
+                .collect()
+        };
+
+        ROScopes::from_raw_ranges(input, ranges)
+    }
+
+    fn scope_with_captures<'viewee>(
+        &self,
+        input: &'viewee str,
+    ) -> (ROScopes<'viewee>, Vec<(Range<usize>, CaptureGroups)>) {
+        if !self.whole_matches && self.capture.is_none() {
+            return (self.scope(input), Vec::new());
+        }
+
+        let _timer = stats::Timer::start(Phase::Regex);
+
+        let matches = self.captures(input);
+        let ranges = matches.iter().map(|(range, _)| range.clone()).collect();
+
+        (ROScopes::from_raw_ranges(input, ranges), matches)
+    }
+}
+
+/// Handles `error` in matching `pattern`, after which matching cannot continue.
+///
+/// Matches found until then are kept. Running into the backtracking limit cancels the
+/// [current](cancel::current) operation, as its results cannot be trusted.
+fn matching_failed(pattern: &RegexPattern, error: &fancy_regex::Error) {
+    warn!("Matching regex '{pattern}' failed, stopping: {error}");
+
+    if let fancy_regex::Error::RuntimeError(RuntimeError::BacktrackLimitExceeded) = error {
+        cancel::backtrack_limit_exceeded();
+    }
+}
+
+/// For a given [`Range`], shatters it into pieces of length 1, returning a [`Vec`] of
+/// length equal to the length of the original range.
+fn shatter(range: &Range<usize>) -> Vec<Range<usize>> {
+    let mut ranges = Vec::new();
+
+    for i in range.start..range.end {
+        ranges.push(Range {
+            start: i,
+            end: i + 1,
+        });
+    }
+
+    ranges
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+
+    use crate::scoping::{
+        scope::{
+            ROScope, RWScope, RWScopes,
+            Scope::{In, Out},
+        },
+        view::ScopedView,
+    };
+    use std::borrow::Cow::Borrowed as B;
+
+    use super::*;
+
+    #[rstest]
+    #[case("a", "a", ScopedView::new(RWScopes(vec![RWScope(In(B("a")))])))]
+    #[case("aa", "a", ScopedView::new(RWScopes(vec![RWScope(In(B("a"))), RWScope(In(B("a")))])))]
+    #[case("aba", "a", ScopedView::new(RWScopes(vec![RWScope(In(B("a"))), RWScope(Out("b")), RWScope(In(B("a")))])))]
+    //
+    #[case("a", "", ScopedView::new(RWScopes(vec![RWScope(Out("a"))])))]
+    #[case("", "a", ScopedView::new(RWScopes(vec![])))] // Empty results are discarded
+    //
+    #[case("a", "a", ScopedView::new(RWScopes(vec![RWScope(In(B("a")))])))]
+    #[case("a", "b", ScopedView::new(RWScopes(vec![RWScope(Out("a"))])))]
+    //
+    #[case("a", ".*", ScopedView::new(RWScopes(vec![RWScope(In(B("a")))])))]
+    #[case("a", ".+?", ScopedView::new(RWScopes(vec![RWScope(In(B("a")))])))]
+    //
+    #[case("a\na", ".*", ScopedView::new(RWScopes(vec![RWScope(In(B("a"))), RWScope(Out("\n")), RWScope(In(B("a")))])))]
+    #[case("a\na", "(?s).*", ScopedView::new(RWScopes(vec![RWScope(In(B("a\na")))])))] // Dot matches newline
+    //
+    #[case("abc", "a", ScopedView::new(RWScopes(vec![RWScope(In(B("a"))), RWScope(Out("bc"))])))]
+    //
+    #[case("abc", r"\w", ScopedView::new(RWScopes(vec![RWScope(In(B("a"))), RWScope(In(B("b"))), RWScope(In(B("c")))])))]
+    #[case("abc", r"\W", ScopedView::new(RWScopes(vec![RWScope(Out("abc"))])))]
+    #[case("abc", r"\w+", ScopedView::new(RWScopes(vec![RWScope(In(B("abc")))])))]
+    //
+    #[case("Work 69 on 420 words", r"\w+", ScopedView::new(RWScopes(vec![RWScope(In(B("Work"))), RWScope(Out(" ")), RWScope(In(B("69"))), RWScope(Out(" ")), RWScope(In(B("on"))), RWScope(Out(" ")), RWScope(In(B("420"))), RWScope(Out(" ")), RWScope(In(B("words")))])))]
+    #[case("Ignore 69 the 420 digits", r"\p{letter}+", ScopedView::new(RWScopes(vec![RWScope(In(B("Ignore"))), RWScope(Out(" 69 ")), RWScope(In(B("the"))), RWScope(Out(" 420 ")), RWScope(In(B("digits")))])))]
+    //
+    #[case(".", ".", ScopedView::new(RWScopes(vec![RWScope(In(B(".")))])))]
+    #[case(r"\.", ".", ScopedView::new(RWScopes(vec![RWScope(In(B(r"\"))), RWScope(In(B(".")))])))]
+    #[case(r".", r"\.", ScopedView::new(RWScopes(vec![RWScope(In(B(r".")))])))]
+    #[case(r"\.", r"\.", ScopedView::new(RWScopes(vec![RWScope(Out(r"\")), RWScope(In(B(r".")))])))]
+    #[case(r"\w", r"\w", ScopedView::new(RWScopes(vec![RWScope(Out(r"\")), RWScope(In(B(r"w")))])))]
+    //
+    // Capture groups
+    #[case(r"Hello", r"\w+", ScopedView::new(RWScopes(vec![RWScope(In(B(r"Hello")))])))]
+    #[case(
+        r"Hello", r"(\w+)",
+        ScopedView::new(RWScopes(
+            vec![
+                RWScope(In(B(r"H"))),
+                RWScope(In(B(r"e"))),
+                RWScope(In(B(r"l"))),
+                RWScope(In(B(r"l"))),
+                RWScope(In(B(r"o")))
+            ]
+        ))
+    )]
+    #[case(
+        r"Hello World", r"Hello (\w+)",
+        ScopedView::new(RWScopes(
+            vec![
+                RWScope(In(B(r"Hello "))),
+                RWScope(In(B(r"W"))),
+                RWScope(In(B(r"o"))),
+                RWScope(In(B(r"r"))),
+                RWScope(In(B(r"l"))),
+                RWScope(In(B(r"d")))
+            ]
+        ))
+    )]
+    #[case(
+        // https://github.com/alexpovel/srgn/issues/71; this used to panic
+        r#""error"; "x" => %x, "y" => %y"#,
+        r"(?P<msg>.+);(?P<structure>.+)",
+        ScopedView::new(RWScopes(
+            vec![
+                RWScope(In(B(r#"""#))),
+                RWScope(In(B("e"))),
+                RWScope(In(B("r"))),
+                RWScope(In(B("r"))),
+                RWScope(In(B("o"))),
+                RWScope(In(B("r"))),
+                RWScope(In(B(r#"""#))),
+                RWScope(In(B(";"))),
+                RWScope(In(B(" "))),
+                RWScope(In(B(r#"""#))),
+                RWScope(In(B("x"))),
+                RWScope(In(B(r#"""#))),
+                RWScope(In(B(" "))),
+                RWScope(In(B("="))),
+                RWScope(In(B(">"))),
+                RWScope(In(B(" "))),
+                RWScope(In(B("%"))),
+                RWScope(In(B("x"))),
+                RWScope(In(B(","))),
+                RWScope(In(B(" "))),
+                RWScope(In(B(r#"""#))),
+                RWScope(In(B("y"))),
+                RWScope(In(B(r#"""#))),
+                RWScope(In(B(" "))),
+                RWScope(In(B("="))),
+                RWScope(In(B(">"))),
+                RWScope(In(B(" "))),
+                RWScope(In(B("%"))),
+                RWScope(In(B("y"))),
+            ]
+        ))
+    )]
+    fn test_regex_scoping(
+        #[case] input: &str,
+        #[case] pattern: &str,
+        #[case] expected: ScopedView,
+    ) {
+        let mut builder = crate::scoping::view::ScopedViewBuilder::new(input);
+        let regex = Regex::new(RegexPattern::new(pattern).unwrap());
+        builder.explode(&regex);
+        let actual = builder.build();
+
+        assert_eq!(actual, expected);
+    }
+
+    #[rstest]
+    #[case("a1 b2", r"(?P<letter>[a-z])(\d)", vec![(0..2, "a1", "a", "1"), (3..5, "b2", "b", "2")])]
+    #[case("x", r"(?P<letter>[a-z])(\d)?", vec![(0..1, "x", "x", "")])]
+    #[case("1", r"(?P<letter>[a-z])", vec![])]
+    fn test_regex_scoping_with_captures(
+        #[case] input: &str,
+        #[case] pattern: &str,
+        #[case] expected: Vec<(Range<usize>, &str, &str, &str)>,
+    ) {
+        let regex = Regex::new(RegexPattern::new(pattern).unwrap()).with_capture_groups();
+
+        let (scopes, captures) = regex.scope_with_captures(input);
+
+        let actual = captures
+            .iter()
+            .map(|(range, groups)| {
+                (
+                    range.clone(),
+                    groups.get(0).unwrap(),
+                    groups.name("letter").unwrap(),
+                    groups.get(2).unwrap_or_default(),
+                )
+            })
+            .collect::<Vec<_>>();
+        assert_eq!(actual, expected);
+
+        // Whole matches are in scope, capture groups are not treated specially.
+        let in_scope = scopes
+            .0
+            .iter()
+            .filter_map(|scope| match scope {
+                ROScope(In(s)) => Some(*s),
+                ROScope(Out(_)) => None,
+            })
+            .collect::<Vec<_>>();
+        assert_eq!(
+            in_scope,
+            expected.iter().map(|(_, m, _, _)| *m).collect::<Vec<_>>()
+        );
+    }
+
+    #[rstest]
+    #[case("a1 b2", r"([a-z])(\d)", CaptureGroup::Index(2), vec!["1", "2"])]
+    #[case("a1 b2", r"([a-z])(\d)", CaptureGroup::Index(0), vec!["a1", "b2"])]
+    #[case("a1 b", r"([a-z])(\d)?", CaptureGroup::Index(2), vec!["1"])]
+    #[case("k=v", r"(?P<key>\w)=(?P<value>\w)", CaptureGroup::Name("value".into()), vec!["v"])]
+    #[case("aa", r"a(a)?", CaptureGroup::Index(1), vec!["a"])]
+    fn test_regex_scoping_with_capture(
+        #[case] input: &str,
+        #[case] pattern: &str,
+        #[case] group: CaptureGroup,
+        #[case] expected: Vec<&str>,
+    ) {
+        let regex = Regex::new(RegexPattern::new(pattern).unwrap())
+            .with_capture(group)
+            .unwrap();
+
+        let in_scope = |scopes: ROScopes<'_>| {
+            scopes
+                .0
+                .into_iter()
+                .filter_map(|scope| match scope {
+                    ROScope(In(s)) => Some(s),
+                    ROScope(Out(_)) => None,
+                })
+                .collect::<Vec<_>>()
+        };
+
+        assert_eq!(in_scope(regex.scope(input)), expected);
+
+        let (scopes, captures) = regex.scope_with_captures(input);
+        assert_eq!(in_scope(scopes), expected);
+        // All groups are reported, `$0` still being the entire match.
+        assert!(captures
+            .iter()
+            .all(|(range, groups)| groups.get(0).unwrap().contains(&input[range.clone()])));
+    }
+
+    #[rstest]
+    #[case(r"(a)", CaptureGroup::Index(2))]
+    #[case(r"(a)", CaptureGroup::Name("a".into()))]
+    #[case(r"(?P<b>a)", CaptureGroup::Name("a".into()))]
+    fn test_regex_with_unknown_capture(#[case] pattern: &str, #[case] group: CaptureGroup) {
+        let regex = Regex::new(RegexPattern::new(pattern).unwrap());
+
+        assert_eq!(
+            regex.with_capture(group.clone()).unwrap_err(),
+            UnknownCaptureGroup(group)
+        );
+    }
+
+    #[rstest]
+    #[case("0", CaptureGroup::Index(0))]
+    #[case("12", CaptureGroup::Index(12))]
+    #[case("name", CaptureGroup::Name("name".into()))]
+    #[case("1a", CaptureGroup::Name("1a".into()))]
+    fn test_capture_group_from_str(#[case] input: &str, #[case] expected: CaptureGroup) {
+        assert_eq!(input.parse::<CaptureGroup>().unwrap(), expected);
+    }
+
+    #[test]
+    fn test_regex_backtrack_limit_cancels() {
+        use crate::cancel::{CancellationToken, Cancelled};
+        use crate::RegexPatternBuilder;
+
+        let pattern = RegexPatternBuilder::new("(a|b|ab)*(?=c)")
+            .backtrack_limit(100)
+            .build()
+            .unwrap();
+        let regex = Regex::new(pattern);
+
+        let res = CancellationToken::new().run(|| regex.scope(&"ab".repeat(30)));
+
+        assert_eq!(res.unwrap_err(), Cancelled::BacktrackLimitExceeded);
+    }
+
+    mod fuzzyish {
+        use std::time::{Duration, Instant};
+
+        use crate::scoping::scope::ROScope;
+
+        use super::*;
+
+        use log::info;
+        use rand;
+        use rand::seq::SliceRandom;
+        use rand::Rng;
+        use test_log::test;
+
+        fn generate_random_regex(mut rng: &mut rand::rngs::ThreadRng) -> Option<RegexPattern> {
+            let atoms: [&str; 7] = [".", "\\d", "\\D", "\\w", "\\W", "\\s", "\\S"];
+            let quantifiers: [&str; 5] = ["*", "+", "?", "{2,5}", "{3}"];
+            let others: [&str; 3] = ["|", "^", "$"];
+            let letters: [&str; 26] = [
+                "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p",
+                "q", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+            ];
+
+            let mut regex = String::new();
+
+            for _ in 0..rng.gen_range(1..=2) {
+                if rng.gen_bool(0.3) {
+                    regex.push_str(atoms.choose(&mut rng).unwrap());
+                }
+
+                if rng.gen_bool(0.6) {
+                    let letter = letters.choose(&mut rng).unwrap();
+                    if rng.gen_bool(0.5) {
+                        let uc = letter.to_uppercase();
+                        regex.push_str(uc.as_str());
+                    } else {
+                        regex.push_str(letter);
+                    }
+                }
+
+                if rng.gen_bool(0.3) {
+                    regex.push_str(quantifiers.choose(&mut rng).unwrap());
+                }
+
+                if rng.gen_bool(0.1) {
+                    regex.push_str(others.choose(&mut rng).unwrap());
+                }
+            }
+
+            RegexPattern::new(regex.as_str()).ok()
+        }
+
+        /// Run fuzz-like testing.
+        ///
+        /// This is much like fuzzing, but a bit more manually controlled and part of
+        /// the core test harness, hence running always. Property testing like
+        /// `proptest` would be much better ("given some input in this shape, and some
+        /// regex, test the property that reassembly works"), but setup for that crate
+        /// is substantial. The below approach is 'good enough' and emulates property
+        /// testing a fair bit. We just need some random inputs and some short-ish but
+        /// random regex to split by (generating random, valid regex is... interesting).
+        ///
+        /// Run for a duration instead of a fixed number of tries, as we would have to
+        /// choose that fixed number rather low for CI to not be too slow. That would
+        /// waste potential when running locally.
+        #[test]
+        fn test_regex_scoping_randomly() {
+            let mut n_tries = 0;
+            let mut n_matches = 0;
+
+            let duration = if std::env::var("CI").is_ok() {
+                Duration::from_secs(5)
+            } else {
+                // SORRY if this crashed the test on your machine. Flaky one :(
+                Duration::from_millis(500)
+            };
+
+            let mut rng = rand::thread_rng();
+
+            // "Anything but 'other'", see also:
+            // https://docs.rs/regex/latest/regex/#matching-one-character
+            // https://www.unicode.org/reports/tr44/tr44-24.html#General_Category_Values
+            let pattern = r"\P{other}+";
+            let gen = rand_regex::Regex::compile(pattern, 100).unwrap();
+
+            let now = Instant::now();
+
+            loop {
+                n_tries += 1;
+
+                let Some(regex) = generate_random_regex(&mut rng) else {
+                    continue;
+                };
+                let scope = Regex::new(regex);
+                let input: String = rng.sample(&gen);
+
+                let scopes = scope.scope(&input);
+
+                if scopes.0.iter().any(|s| match s {
+                    ROScope(In(_)) => true,
+                    ROScope(Out(_)) => false,
+                }) {
+                    n_matches += 1;
+                }
+
+                let mut reassembled = String::new();
+                for scope in scopes.0 {
+                    reassembled.push_str((&scope).into());
+                }
+
+                assert_eq!(input, reassembled);
+
+                if now.elapsed() > duration {
+                    break;
+                }
+            }
+
+            info!(
+                // To test anything, we actually need matches so splits happen.
+                "Processed {} inputs, of which {} were matched and successfully reassembled",
+                n_tries, n_matches
+            );
+
+            assert!(
+                n_matches >= n_tries / 20,
+                "Too few regex matches; try lowering regex length"
+            );
+
+            assert!(
+                n_tries > 100,
+                // Might happen in CI, but we should ensure a certain lower bound;
+                // locally, many more tests can run.
+                "Too few tries; is the host machine very slow?"
+            );
+        }
+    }
+}
@@ -1,5 +1,4 @@
 use crate::scoping::scope::Scope::{In, Out};
-use itertools::Itertools;
 use log::{debug, trace};
 use std::{borrow::Cow, ops::Range};
 
@@ -50,15 +49,27 @@ impl<'viewee> ROScopes<'viewee> {
     ///
     /// Panics if the given `ranges` contain indices out-of-bounds for `input`.
     #[must_use]
-    pub fn from_raw_ranges(input: &'viewee str, ranges: Vec<Range<usize>>) -> Self {
+    pub fn from_raw_ranges(input: &'viewee str, mut ranges: Vec<Range<usize>>) -> Self {
         trace!("Constructing scopes from raw ranges: {:?}", ranges);
 
-        let mut scopes = Vec::with_capacity(ranges.len());
+        // Mostly sorted already (scopers tend to find ranges in order), which sorting
+        // in place handles well, without allocating.
+        ranges.sort_by_key(|r| r.start);
+
+        // Worst case: every range is surrounded by out-of-scope parts. Reserving that
+        // upfront spares reallocations for inputs with many small scopes.
+        let mut scopes = Vec::with_capacity(2 * ranges.len() + 1);
 
         let mut last_end = 0;
-        for Range { start, end } in ranges.into_iter().sorted_by_key(|r| r.start) {
-            scopes.push(ROScope(Out(&input[last_end..start])));
-            scopes.push(ROScope(In(&input[start..end])));
+        for Range { start, end } in ranges {
+            for scope in [
+                ROScope(Out(&input[last_end..start])),
+                ROScope(In(&input[start..end])),
+            ] {
+                if !scope.is_empty() {
+                    scopes.push(scope);
+                }
+            }
             last_end = end;
         }
 
@@ -66,8 +77,6 @@ impl<'viewee> ROScopes<'viewee> {
             scopes.push(ROScope(Out(&input[last_end..])));
         }
 
-        scopes.retain(|s| !s.is_empty());
-
         debug!("Scopes: {:?}", scopes);
 
         ROScopes(scopes)
@@ -89,6 +98,42 @@ impl<'viewee> ROScopes<'viewee> {
 
         Self(scopes)
     }
+
+    /// Keeps only those parts [`In`] scope whose index (0-based, counting only parts
+    /// [`In`] scope) is contained in `occurrences`, turning all others [`Out`] of
+    /// scope.
+    ///
+    /// Returns the byte range of the scoped input spanned by the parts kept.
+    pub(crate) fn keep(&mut self, occurrences: &Range<usize>) -> Range<usize> {
+        let mut index = 0;
+        let mut offset = 0;
+        let mut kept: Option<Range<usize>> = None;
+
+        for scope in &mut self.0 {
+            match *scope {
+                ROScope(In(s)) => {
+                    let range = offset..offset + s.len();
+                    offset = range.end;
+
+                    if occurrences.contains(&index) {
+                        kept = Some(kept.map_or(range.clone(), |k| k.start..range.end));
+                    } else {
+                        *scope = ROScope(Out(s));
+                    }
+                    index += 1;
+                }
+                ROScope(Out(s)) => offset += s.len(),
+            }
+        }
+
+        trace!(
+            "Kept occurrences {:?} of {}, spanning {:?}",
+            occurrences,
+            index,
+            kept
+        );
+        kept.unwrap_or_default()
+    }
 }
 
 /// Checks for equality, regarding only raw [`str`] parts, i.e. disregards whether an
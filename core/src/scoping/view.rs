@@ -0,0 +1,1317 @@
+use crate::actions::{self, Action, ReplacementCreationError};
+use crate::cancel;
+use crate::scoping::dosfix::DosFix;
+use crate::scoping::langs::Capture;
+#[cfg(doc)]
+use crate::scoping::limit::{AtMost, Occurrences};
+use crate::scoping::regex::CaptureGroups;
+use crate::scoping::scope::{
+    ROScope, ROScopes, RWScope, RWScopes,
+    Scope::{In, Out},
+};
+use crate::scoping::Scoper;
+use log::{debug, trace, warn};
+use rayon::prelude::*;
+use std::borrow::Cow;
+use std::fmt;
+use std::ops::Range;
+use std::sync::{Arc, OnceLock};
+
+/// Size in bytes of views from which on [`ScopedView::map`] applies actions in parallel.
+///
+/// Below it, spreading work across threads costs more than it saves.
+pub const PARALLEL_MAP_THRESHOLD: usize = 1024 * 1024;
+
+/// A view of some input, sorted into parts, which are either [`In`] or [`Out`] of scope
+/// for processing.
+///
+/// The view is **writable**. It can be manipulated by [mapping][`Self::map`]
+/// [`Action`]s over it.
+///
+/// The main avenue for constructing a view is [`Self::builder`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ScopedView<'viewee> {
+    scopes: RWScopes<'viewee>,
+    /// Capture groups of the match each scope is part of, by index into `scopes`.
+    /// Empty if no scoper reported any.
+    captures: Vec<Option<Arc<CaptureGroups>>>,
+}
+
+/// Core implementations.
+impl<'viewee> ScopedView<'viewee> {
+    /// Create a new view from the given scopes.
+    #[must_use]
+    pub fn new(scopes: RWScopes<'viewee>) -> Self {
+        Self {
+            scopes,
+            captures: Vec::new(),
+        }
+    }
+
+    /// Return a builder for a view of the given input.
+    ///
+    /// For API discoverability.
+    #[must_use]
+    pub fn builder(input: &'viewee str) -> ScopedViewBuilder<'viewee> {
+        ScopedViewBuilder::new(input)
+    }
+
+    /// Apply an `action` to all [`In`] scope items contained in this view.
+    ///
+    /// They are **replaced** with whatever the action returns for the particular scope.
+    /// Items part of a match whose capture groups were
+    /// [reported][`Scoper::scope_with_captures`] are handed to the action along with
+    /// them.
+    ///
+    /// See implementors of [`Action`] for available types.
+    ///
+    /// Line breaks newly introduced by the action follow the line ending style (LF or
+    /// CRLF) of the line the scope is found on, so that results do not end up with
+    /// mixed line endings.
+    ///
+    /// For actions which [reindent][`Action::reindents`], lines following line breaks
+    /// in results are indented like the line the scope starts on. For actions which
+    /// [continue comments][`Action::continues_comments`], they additionally start with
+    /// the comment leader of that line.
+    ///
+    /// For views of large inputs (see [`PARALLEL_MAP_THRESHOLD`]), scopes are processed
+    /// in parallel. Results are the same either way.
+    pub fn map(&mut self, action: &impl Action) -> &mut Self {
+        let results = self.results(action);
+
+        for (scope, res) in self.scopes.0.iter_mut().zip(results) {
+            if let Some(res) = res {
+                *scope = RWScope(In(Cow::Owned(res)));
+            }
+        }
+
+        self
+    }
+
+    /// The edits [mapping][`Self::map`] `action` would make, as the byte range of each
+    /// scope it changes alongside its replacement, leaving the view as is.
+    ///
+    /// Ranges are into the view's current contents.
+    pub(crate) fn edits(&self, action: &impl Action) -> Vec<(Range<usize>, String)> {
+        let mut offset = 0;
+
+        self.scopes
+            .0
+            .iter()
+            .zip(self.results(action))
+            .filter_map(|(scope, res)| {
+                let s: &str = scope.into();
+                let range = offset..offset + s.len();
+                offset = range.end;
+
+                res.map(|res| (range, res))
+            })
+            .collect()
+    }
+
+    /// Applies `action` to all scopes, returning the replacement of each it changed.
+    fn results(&self, action: &impl Action) -> Vec<Option<String>> {
+        let len: usize = self.scopes.0.iter().map(|s| <&str>::from(s).len()).sum();
+        // Only needed for results spanning lines, and then computed once for all.
+        let layout = OnceLock::new();
+
+        if len < PARALLEL_MAP_THRESHOLD {
+            (0..self.scopes.0.len())
+                .map(|i| self.act(i, action, &layout))
+                .collect()
+        } else {
+            debug!("View of {len} bytes is large, mapping in parallel");
+            // Workers see none of the calling thread's state, so hand the token over.
+            let token = cancel::current();
+
+            (0..self.scopes.0.len())
+                .into_par_iter()
+                .map(|i| match &token {
+                    Some(token) => token.run(|| self.act(i, action, &layout)).ok().flatten(),
+                    None => self.act(i, action, &layout),
+                })
+                .collect()
+        }
+    }
+
+    /// Applies `action` to the scope at `index`, returning its replacement if it is
+    /// [`In`] scope and the action changed it.
+    ///
+    /// The view's `layout` is computed on first use.
+    fn act(&self, index: usize, action: &impl Action, layout: &OnceLock<Layout>) -> Option<String> {
+        let s = match &self.scopes.0[index] {
+            RWScope(In(s)) => s,
+            RWScope(Out(s)) => {
+                debug!("Appending '{}'", s.escape_debug());
+                return None;
+            }
+        };
+
+        let captures = self.captures.get(index).and_then(Option::as_deref);
+        let mut res = action.act_with_captures(s, captures);
+        if res == *s {
+            // Keep borrowing from the input, instead of holding an owned copy of it.
+            trace!("Action left '{}' unchanged", s.escape_debug());
+            return None;
+        }
+
+        cancel::charge(res.len());
+        let layout = || layout.get_or_init(|| Layout::of(&self.scopes));
+        if res.contains('\n') && (action.reindents() || action.continues_comments()) {
+            let line = layout().line_before(index);
+            let indentation = &line[..line.len() - line.trim_start_matches([' ', '\t']).len()];
+            let leader = if action.continues_comments() {
+                let rest = format!("{}{}", &line[indentation.len()..], first_line(s));
+                // Trailing comments, such as after code, do not start their line.
+                comment_leader(&rest).or_else(|| comment_leader(first_line(s)))
+            } else {
+                None
+            };
+
+            trace!(
+                "Continuing lines of replacement with indentation '{}' and comment leader {:?}",
+                indentation.escape_debug(),
+                leader
+            );
+            res = indent(&res, indentation, leader.as_deref());
+        }
+        if res.contains('\n') && layout().line_ending_at(index) == LineEnding::CrLf {
+            trace!("Adjusting line endings of replacement to CRLF");
+            res = to_crlf(&res);
+        }
+
+        debug!(
+            "Replacing '{}' with '{}'",
+            s.escape_debug(),
+            res.escape_debug()
+        );
+
+        Some(res)
+    }
+
+    /// Squeeze all consecutive [`In`] scopes into a single occurrence (the first one).
+    pub fn squeeze(&mut self) -> &mut Self {
+        debug!("Squeezing view by collapsing all consecutive in-scope occurrences.");
+
+        let mut prev_was_in = false;
+        let keep = self
+            .scopes
+            .0
+            .iter()
+            .map(|scope| {
+                let keep = !(prev_was_in && matches!(scope, RWScope(In(_))));
+                prev_was_in = matches!(scope, RWScope(In(_)));
+                trace!("keep: {}, scope: {:?}", keep, scope);
+                keep
+            })
+            .collect::<Vec<_>>();
+
+        let mut kept = keep.iter();
+        self.scopes
+            .0
+            .retain(|_| *kept.next().expect("A flag per scope"));
+        if !self.captures.is_empty() {
+            let mut kept = keep.iter();
+            self.captures
+                .retain(|_| *kept.next().expect("A flag per capture"));
+        }
+
+        debug!("Squeezed: {:?}", self.scopes);
+
+        self
+    }
+
+    /// Check whether anything is [`In`] scope for this view.
+    #[must_use]
+    pub fn has_any_in_scope(&self) -> bool {
+        self.scopes.0.iter().any(|s| match s {
+            RWScope(In(_)) => true,
+            RWScope(Out(_)) => false,
+        })
+    }
+
+    /// Count the parts [`In`] scope for this view.
+    #[must_use]
+    pub fn n_in_scope(&self) -> usize {
+        self.scopes
+            .0
+            .iter()
+            .filter(|s| match s {
+                RWScope(In(_)) => true,
+                RWScope(Out(_)) => false,
+            })
+            .count()
+    }
+
+    /// Check whether this view still reads exactly like the input it was built from,
+    /// i.e. [mapping][`Self::map`] and [squeezing][`Self::squeeze`] changed nothing.
+    ///
+    /// This is cheap, and allows skipping [rendering][`ToString::to_string`] the view
+    /// when nothing changed. Parts of a view left unchanged keep pointing into its
+    /// input, so it suffices to check all parts still do so, back to back.
+    #[must_use]
+    pub fn is_unchanged(&self) -> bool {
+        let mut expected_start = None;
+
+        self.scopes.0.iter().all(|scope| {
+            let s = match &scope.0 {
+                In(Cow::Borrowed(s)) | Out(s) => *s,
+                In(Cow::Owned(_)) => return false,
+            };
+
+            let start = s.as_ptr() as usize;
+            let is_contiguous = expected_start.map_or(true, |expected| expected == start);
+            expected_start = Some(start + s.len());
+
+            is_contiguous
+        })
+    }
+}
+
+/// Implementations of all available actions as dedicated methods.
+///
+/// Where actions don't take arguments, neither do the methods.
+impl<'viewee> ScopedView<'viewee> {
+    /// Apply the default [`actions::Deletion`] action to this view (see [`Self::map`]).
+    pub fn delete(&mut self) -> &mut Self {
+        let action = actions::Deletion::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the default [`actions::German`] action to this view (see [`Self::map`]).
+    #[cfg(feature = "german")]
+    pub fn german(&mut self) -> &mut Self {
+        let action = actions::German::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the default [`actions::Lower`] action to this view (see [`Self::map`]).
+    pub fn lower(&mut self) -> &mut Self {
+        let action = actions::Lower::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the default [`actions::Normalization`] action to this view (see
+    /// [`Self::map`]).
+    pub fn normalize(&mut self) -> &mut Self {
+        let action = actions::Normalization::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the [`actions::Replacement`] action to this view (see [`Self::map`]).
+    ///
+    /// ## Errors
+    ///
+    /// For why and how this can fail, see the implementation of [`TryFrom<String>`] for
+    /// [`actions::Replacement`].
+    pub fn replace(&mut self, replacement: String) -> Result<&mut Self, ReplacementCreationError> {
+        let action = actions::Replacement::try_from(replacement)?;
+
+        Ok(self.map(&action))
+    }
+
+    /// Apply the [`actions::Symbols`] action to this view (see [`Self::map`]).
+    #[cfg(feature = "symbols")]
+    pub fn symbols(&mut self) -> &mut Self {
+        let action = actions::Symbols::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the [`actions::SymbolsInversion`] action to this view (see [`Self::map`]).
+    #[cfg(feature = "symbols")]
+    pub fn invert_symbols(&mut self) -> &mut Self {
+        let action = actions::SymbolsInversion::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the default [`actions::Titlecase`] action to this view (see [`Self::map`]).
+    pub fn titlecase(&mut self) -> &mut Self {
+        let action = actions::Titlecase::default();
+
+        self.map(&action)
+    }
+
+    /// Apply the default [`actions::Upper`] action to this view (see [`Self::map`]).
+    pub fn upper(&mut self) -> &mut Self {
+        let action = actions::Upper::default();
+
+        self.map(&action)
+    }
+}
+
+/// Style of line endings.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum LineEnding {
+    /// Unix-style, `\n`.
+    Lf,
+    /// DOS-style, `\r\n`.
+    CrLf,
+}
+
+impl LineEnding {
+    /// The style of the line ending whose `\n` is at byte `newline` of `s`.
+    fn ending_at(s: &str, newline: usize) -> Self {
+        if s[..newline].ends_with('\r') {
+            Self::CrLf
+        } else {
+            Self::Lf
+        }
+    }
+}
+
+/// The contents of a view laid out in one piece, along with where its scopes and lines
+/// start, to find the line any scope is on without going over all scopes before it.
+#[derive(Debug)]
+struct Layout {
+    text: String,
+    /// Byte offset into `text` each scope starts at.
+    scope_starts: Vec<usize>,
+    /// Byte offset into `text` each line starts at, the first one at 0.
+    line_starts: Vec<usize>,
+}
+
+impl Layout {
+    fn of(scopes: &RWScopes) -> Self {
+        let mut text = String::new();
+        let mut scope_starts = Vec::with_capacity(scopes.0.len());
+        for scope in &scopes.0 {
+            scope_starts.push(text.len());
+            text.push_str(scope.into());
+        }
+
+        let line_starts = std::iter::once(0)
+            .chain(text.match_indices('\n').map(|(i, _)| i + 1))
+            .collect();
+
+        Self {
+            text,
+            scope_starts,
+            line_starts,
+        }
+    }
+
+    /// Determines the line ending in effect for the scope at `index`.
+    ///
+    /// That is the ending of the line the scope is found on: the closest line ending
+    /// following the scope's start, or, failing that (last line), the closest one
+    /// preceding it. Input without any line endings is treated as LF.
+    fn line_ending_at(&self, index: usize) -> LineEnding {
+        let start = self.scope_starts[index];
+        let next_line = self.line_starts.partition_point(|&s| s <= start);
+
+        let newline = match self.line_starts.get(next_line) {
+            Some(next) => Some(next - 1),
+            None => self.line_starts.last().filter(|&&s| s > 0).map(|s| s - 1),
+        };
+
+        newline.map_or(LineEnding::Lf, |newline| {
+            LineEnding::ending_at(&self.text, newline)
+        })
+    }
+
+    /// Determines the text preceding the scope at `index` on the line it starts on.
+    fn line_before(&self, index: usize) -> &str {
+        let start = self.scope_starts[index];
+        // First line always starts at 0, so this cannot underflow.
+        let line = self.line_starts.partition_point(|&s| s <= start) - 1;
+
+        &self.text[self.line_starts[line]..start]
+    }
+}
+
+/// Converts all lone `\n` in `s` to `\r\n`, leaving existing `\r\n` untouched.
+fn to_crlf(s: &str) -> String {
+    let mut res = String::with_capacity(s.len());
+    let mut prev = None;
+
+    for c in s.chars() {
+        if c == '\n' && prev != Some('\r') {
+            res.push('\r');
+        }
+        res.push(c);
+        prev = Some(c);
+    }
+
+    res
+}
+
+/// The first line of `s`, without its line ending.
+fn first_line(s: &str) -> &str {
+    s.lines().next().unwrap_or_default()
+}
+
+/// Determines the comment leader to continue the comment `line` (without indentation)
+/// starts with, if it starts with one.
+///
+/// The leader is the comment marker including any whitespace following it. Block
+/// comments (`/*`, `/**`) are continued by ` * `.
+fn comment_leader(line: &str) -> Option<String> {
+    const MARKERS: [&str; 7] = ["///", "//!", "//", "/**", "/*", "*", "#"];
+
+    let marker = MARKERS.into_iter().find(|m| line.starts_with(m))?;
+    let rest = &line[marker.len()..];
+    let spacing = &rest[..rest.len() - rest.trim_start_matches([' ', '\t']).len()];
+    // Nothing to go by if the marker ends the line, as for an opening `/**`.
+    let spacing = if spacing.is_empty() && rest.is_empty() {
+        " "
+    } else {
+        spacing
+    };
+    let marker = match marker {
+        "/**" | "/*" => " *",
+        marker => marker,
+    };
+
+    Some(format!("{marker}{spacing}"))
+}
+
+/// Prefixes all lines of `s` but the first with `indentation`, followed by the comment
+/// `leader`, if any.
+///
+/// Empty lines stay empty, except for the (whitespace-trimmed) leader. Lines already
+/// starting with the leader's comment marker are only indented.
+fn indent(s: &str, indentation: &str, leader: Option<&str>) -> String {
+    let mut lines = s.split('\n');
+    let mut res = String::with_capacity(s.len());
+    res.push_str(lines.next().unwrap_or_default());
+
+    for line in lines {
+        res.push('\n');
+        let is_empty = line.is_empty() || line == "\r";
+
+        match leader {
+            Some(leader) => {
+                let marker = leader.trim();
+                res.push_str(indentation);
+                if line.starts_with(marker) {
+                    res.push_str(&leader[..leader.len() - leader.trim_start().len()]);
+                } else if is_empty {
+                    res.push_str(leader.trim_end());
+                } else {
+                    res.push_str(leader);
+                }
+            }
+            None if is_empty => {}
+            None => res.push_str(indentation),
+        }
+        res.push_str(line);
+    }
+
+    res
+}
+
+impl fmt::Display for ScopedView<'_> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        for scope in &self.scopes.0 {
+            f.write_str(scope.into())?;
+        }
+        Ok(())
+    }
+}
+
+/// A builder for [`ScopedView`]. Chain [`Self::explode`] to build up the view, then
+/// finally call [`Self::build`].
+///
+/// Note: while building, the view is **read-only**: no manipulation of the contents is
+/// possible yet.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ScopedViewBuilder<'viewee> {
+    scopes: ROScopes<'viewee>,
+    viewee: &'viewee str,
+    /// Capture groups reported while exploding, by the byte range of their match in
+    /// `viewee`, sorted by start.
+    captures: Vec<(Range<usize>, Arc<CaptureGroups>)>,
+}
+
+/// Core implementations.
+impl<'viewee> ScopedViewBuilder<'viewee> {
+    /// Create a new builder from the given input.
+    ///
+    /// Initially, the entire `input` is [`In`] scope.
+    #[must_use]
+    pub fn new(input: &'viewee str) -> Self {
+        Self {
+            scopes: ROScopes(vec![ROScope(In(input))]),
+            viewee: input,
+            captures: Vec::new(),
+        }
+    }
+
+    /// Build the view.
+    ///
+    /// This makes the view writable.
+    #[must_use]
+    pub fn build(mut self) -> ScopedView<'viewee> {
+        self.apply_dos_line_endings_fix();
+
+        let captures = if self.captures.is_empty() {
+            Vec::new()
+        } else {
+            self.captures_by_scope()
+        };
+
+        ScopedView {
+            scopes: RWScopes(
+                self.scopes
+                    .0
+                    .into_iter()
+                    .map(std::convert::Into::into)
+                    .collect(),
+            ),
+            captures,
+        }
+    }
+
+    /// For each scope, the capture groups of the match it is part of, if it is [`In`]
+    /// scope and part of any. Of nested matches, the innermost one wins.
+    fn captures_by_scope(&self) -> Vec<Option<Arc<CaptureGroups>>> {
+        let mut offset = 0;
+
+        self.scopes
+            .0
+            .iter()
+            .map(|scope| {
+                let s: &str = scope.into();
+                let range = offset..offset + s.len();
+                offset = range.end;
+
+                match scope {
+                    ROScope(In(_)) => {
+                        let n_candidates = self
+                            .captures
+                            .partition_point(|(r, _)| r.start <= range.start);
+
+                        self.captures[..n_candidates]
+                            .iter()
+                            .rev()
+                            .find(|(r, _)| r.end >= range.end)
+                            .map(|(_, groups)| Arc::clone(groups))
+                    }
+                    ROScope(Out(_)) => None,
+                }
+            })
+            .collect()
+    }
+
+    /// Keep only the first `max` parts [`In`] scope, turning all later ones [`Out`] of
+    /// scope.
+    ///
+    /// Unlike [`AtMost`], this limits the entire view, no matter which scopers the
+    /// parts stem from. Each part counts, see [`AtMost`] for caveats.
+    pub fn at_most(&mut self, max: usize) -> &mut Self {
+        self.occurrences(0..max)
+    }
+
+    /// Keep only the parts [`In`] scope at (0-based) `occurrences`, turning all others
+    /// [`Out`] of scope.
+    ///
+    /// Unlike [`Occurrences`], this selects from the entire view, no matter which
+    /// scopers the parts stem from. Each part counts, see [`AtMost`] for caveats.
+    pub fn occurrences(&mut self, occurrences: Range<usize>) -> &mut Self {
+        let kept = self.scopes.keep(&occurrences);
+        self.captures
+            .retain(|(range, _)| kept.start <= range.start && range.end <= kept.end);
+
+        self
+    }
+
+    /// Keep only what is [`In`] scope and lies within any of the given byte `ranges` of
+    /// the input, turning everything else [`Out`] of scope.
+    ///
+    /// Parts only partially within are split. `ranges` are expected sorted by their
+    /// start, and have to lie on [`char`] boundaries.
+    ///
+    /// Unlike scopers, this works on the entire view: `ranges` are relative to the
+    /// input, not to any part in scope.
+    ///
+    /// ## Panics
+    ///
+    /// Panics if any range does not lie on [`char`] boundaries of the input.
+    pub fn within(&mut self, ranges: &[Range<usize>]) -> &mut Self {
+        let mut new = Vec::with_capacity(self.scopes.0.len());
+        let mut offset = 0;
+
+        for scope in self.scopes.0.drain(..) {
+            let len = <&str>::from(&scope).len();
+            let range = offset..offset + len;
+            offset = range.end;
+
+            let ROScope(In(s)) = scope else {
+                new.push(scope);
+                continue;
+            };
+
+            // Up to where (relative to the input) parts were pushed already.
+            let mut done = range.start;
+            for r in ranges {
+                let start = r.start.max(done);
+                let end = r.end.min(range.end);
+                if start >= end {
+                    continue;
+                }
+
+                if start > done {
+                    new.push(ROScope(Out(&s[done - range.start..start - range.start])));
+                }
+                new.push(ROScope(In(&s[start - range.start..end - range.start])));
+                done = end;
+            }
+            if done < range.end {
+                new.push(ROScope(Out(&s[done - range.start..])));
+            }
+        }
+        trace!("Scopes within {:?} are: {:?}", ranges, new);
+
+        self.scopes.0 = new;
+        self
+    }
+
+    /// Count the parts currently [`In`] scope.
+    #[must_use]
+    pub fn n_in_scope(&self) -> usize {
+        self.scopes
+            .0
+            .iter()
+            .filter(|s| matches!(s, ROScope(In(_))))
+            .count()
+    }
+
+    /// See [`DosFix`].
+    fn apply_dos_line_endings_fix(&mut self) {
+        if self.scopes.0.windows(2).any(|window| match window {
+            [ROScope(In(left)), ROScope(Out(right))] => {
+                left.ends_with('\r') && right.starts_with('\n')
+            }
+            _ => false,
+        }) {
+            warn!("Split CRLF detected. Likely scoper bug. Auto-fixing (globally).");
+            // One issue with this: it's fixing *everything*, not just the location
+            // where the split was detected. Implementing it differently is less
+            // performant and more complex, and hitting a case where this distinction
+            // (fixing globally vs. fixing locally) matters is quite unlikely.
+            self.explode(&DosFix);
+        }
+    }
+
+    /// Using a `scoper`, iterate over all scopes currently contained in this view under
+    /// construction, apply the scoper to all [`In`] scopes, and **replace** each with
+    /// whatever the scoper returned for the particular scope. These are *multiple*
+    /// entries (hence 'exploding' this view: after application, it will likely be
+    /// longer).
+    ///
+    /// Note this necessarily means a view can only be *narrowed*. What was previously
+    /// [`In`] scope can be:
+    ///
+    /// - either still fully [`In`] scope,
+    /// - or partially [`In`] scope, partially [`Out`] of scope
+    ///
+    /// after application. Anything [`Out`] out of scope can never be brought back.
+    ///
+    /// ## Panics
+    ///
+    /// Panics if the [`Scoper`] scopes such that the view is no longer consistent, i.e.
+    /// gaps were created and the original input can no longer be reconstructed from the
+    /// new view.
+    pub fn explode(&mut self, scoper: &impl Scoper) -> &mut Self {
+        trace!("Exploding scopes: {:?}", self.scopes);
+        let mut new = Vec::with_capacity(self.scopes.0.len());
+        let n_captures = self.captures.len();
+        let mut offset = 0;
+        for scope in self.scopes.0.drain(..) {
+            trace!("Exploding scope: {:?}", scope);
+
+            if scope.is_empty() {
+                trace!("Skipping empty scope");
+                continue;
+            }
+
+            let len = <&str>::from(&scope).len();
+            match scope {
+                // Keep as-is, as results are discarded anyway.
+                _ if cancel::is_cancelled() => new.push(scope),
+                ROScope(In(s)) => {
+                    let (new_scopes, captures) = scoper.scope_with_captures(s);
+                    new.extend(new_scopes.0.into_iter().filter(|s| !s.is_empty()));
+                    self.captures
+                        .extend(captures.into_iter().map(|(range, groups)| {
+                            (offset + range.start..offset + range.end, Arc::new(groups))
+                        }));
+                }
+                // Be explicit about the `Out(_)` case, so changing the enum is a
+                // compile error
+                ROScope(Out("")) => {}
+                out @ ROScope(Out(_)) => new.push(out),
+            }
+
+            trace!("Exploded scope, new scopes are: {:?}", new);
+            offset += len;
+        }
+        trace!("Done exploding scopes.");
+
+        if self.captures.len() > n_captures {
+            cancel::charge(
+                (self.captures.len() - n_captures)
+                    * std::mem::size_of::<(Range<usize>, CaptureGroups)>(),
+            );
+            // Stable, so of matches starting at the same place, later (narrower) ones
+            // stay last.
+            self.captures.sort_by_key(|(range, _)| range.start);
+        }
+
+        cancel::charge(new.capacity() * std::mem::size_of::<ROScope>());
+        self.scopes.0 = new;
+
+        assert_eq!(
+            // Tried to do this 'more proper' using the `contracts` crate, but this
+            // method `mut`ably borrows `self` and returns it as such, which is
+            // worst-case and didn't play well with its macros. The crate doesn't do
+            // much more than this manual `assert` anyway.
+            self.scopes,
+            self.viewee,
+            "Post-condition violated: exploding scopes resulted in inconsistent view. \
+            Aborting, as this is an unrecoverable bug in a scoper. \
+            Please report at {}.",
+            env!("CARGO_PKG_REPOSITORY")
+        );
+
+        self
+    }
+}
+
+/// A contiguous part of some input found to be [`In`] scope.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Match<'viewee> {
+    /// Byte range of the match within the entire input.
+    pub range: Range<usize>,
+    /// The matched text itself.
+    pub text: &'viewee str,
+    /// Number (1-based) of the line the match starts on.
+    pub line: usize,
+    /// Byte offset (0-based) of the match start within its line.
+    pub column: usize,
+    /// Number (1-based) of the line the match ends on.
+    pub end_line: usize,
+    /// Byte offset (0-based) of the match end (exclusive) within its line.
+    pub end_column: usize,
+    /// The syntax node the match was found in, if scoped by a language and known.
+    ///
+    /// Never filled in by [`ScopedViewBuilder::matches`], which has no knowledge of
+    /// syntax; see [`Pipeline::matches`][`crate::Pipeline::matches`].
+    pub capture: Option<Capture>,
+}
+
+/// Reporting.
+impl<'viewee> ScopedViewBuilder<'viewee> {
+    /// Collect all parts currently [`In`] scope, in order.
+    ///
+    /// Adjacent parts in scope are merged into a single match. For example, a regex
+    /// with capture groups splits its matches into many parts, but each overall match
+    /// still results in a single [`Match`].
+    #[must_use]
+    pub fn matches(self) -> Vec<Match<'viewee>> {
+        let mut ranges: Vec<Range<usize>> = Vec::new();
+        let mut offset = 0;
+        for scope in &self.scopes.0 {
+            let s: &str = scope.into();
+            let range = offset..offset + s.len();
+            offset = range.end;
+
+            if let ROScope(In(_)) = scope {
+                match ranges.last_mut() {
+                    Some(last) if last.end == range.start => last.end = range.end,
+                    _ => ranges.push(range),
+                }
+            }
+        }
+
+        let line_starts = std::iter::once(0)
+            .chain(self.viewee.match_indices('\n').map(|(i, _)| i + 1))
+            .collect::<Vec<_>>();
+
+        // First line always starts at 0, so this cannot underflow.
+        let line_of = |offset| line_starts.partition_point(|&start| start <= offset) - 1;
+
+        ranges
+            .into_iter()
+            .map(|range| {
+                let line = line_of(range.start);
+                // Ending right after a line break does not reach into the next line.
+                let end_line = line_of(range.end.saturating_sub(1)).max(line);
+
+                Match {
+                    text: &self.viewee[range.clone()],
+                    line: line + 1,
+                    column: range.start - line_starts[line],
+                    end_line: end_line + 1,
+                    end_column: range.end - line_starts[end_line],
+                    capture: None,
+                    range,
+                }
+            })
+            .collect()
+    }
+}
+
+impl<'viewee> IntoIterator for ScopedViewBuilder<'viewee> {
+    type Item = ROScope<'viewee>;
+
+    type IntoIter = std::vec::IntoIter<ROScope<'viewee>>;
+
+    fn into_iter(self) -> Self::IntoIter {
+        self.scopes.0.into_iter()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use crate::scoping::view::ScopedViewBuilder;
+    use crate::RegexPattern;
+    use rstest::rstest;
+    use std::ops::Range;
+
+    #[rstest]
+    // Pattern only
+    #[case("a", "a", "a")]
+    #[case("aa", "a", "a")]
+    #[case("aaa", "a", "a")]
+    //
+    // Pattern once; nothing to squeeze
+    #[case("aba", "a", "aba")]
+    #[case("bab", "a", "bab")]
+    #[case("babab", "a", "babab")]
+    #[case("ababa", "a", "ababa")]
+    //
+    // Squeezes only the pattern, no other repetitions
+    #[case("aaabbb", "a", "abbb")]
+    //
+    // Squeezes start
+    #[case("aab", "a", "ab")]
+    //
+    // Squeezes middle
+    #[case("baab", "a", "bab")]
+    //
+    // Squeezes end
+    #[case("abaa", "a", "aba")]
+    //
+    // Squeezes as soon as pattern occurs at least twice
+    #[case("a", "ab", "a")]
+    #[case("ab", "ab", "ab")]
+    #[case("aba", "ab", "aba")]
+    #[case("abab", "ab", "ab")]
+    #[case("ababa", "ab", "aba")]
+    #[case("ababab", "ab", "ab")]
+    //
+    // Squeezes nothing if pattern not present
+    #[case("", "b", "")]
+    #[case("a", "b", "a")]
+    #[case("aa", "b", "aa")]
+    #[case("aaa", "b", "aaa")]
+    //
+    // Deals with character classes (space)
+    #[case("Hello World", r"\s", "Hello World")]
+    #[case("Hello  World", r"\s", "Hello World")]
+    #[case("Hello       World", r"\s", "Hello World")]
+    #[case("Hello\tWorld", r"\t", "Hello\tWorld")]
+    #[case("Hello\t\tWorld", r"\t", "Hello\tWorld")]
+    //
+    // Deals with character classes (inverted space)
+    #[case("Hello World", r"\S", "H W")]
+    #[case("Hello\t\tWorld", r"\S", "H\t\tW")]
+    //
+    // Deals with overlapping matches; behavior of `regex` crate
+    #[case("abab", r"aba", "abab")]
+    #[case("ababa", r"aba", "ababa")]
+    #[case("ababab", r"aba", "ababab")]
+    #[case("abababa", r"aba", "abababa")]
+    #[case("aba", r"aba", "aba")]
+    #[case("abaaba", r"aba", "aba")]
+    //
+    // Requires non-greedy matches for meaningful results
+    #[case("ab", r"\s+?", "ab")]
+    #[case("a b", r"\s+?", "a b")]
+    #[case("a\t\tb", r"\s+?", "a\tb")]
+    #[case("a\t\t  b", r"\s+?", "a\tb")]
+    //
+    // Deals with more complex patterns
+    #[case("ab", "", "ab")] // Matches nothing
+    //
+    #[case("ab", r"[ab]", "a")]
+    #[case("ab", r"[ab]+", "ab")]
+    #[case("ab", r"[ab]+?", "a")]
+    //
+    #[case("abab", r"\D", "a")]
+    //
+    // Builds up properly; need non-capturing group
+    #[case("abab", r"(?:ab){2}", "abab")]
+    #[case("ababa", r"(?:ab){2}", "ababa")]
+    #[case("ababab", r"(?:ab){2}", "ababab")]
+    #[case("abababa", r"(?:ab){2}", "abababa")]
+    #[case("abababab", r"(?:ab){2}", "abab")]
+    #[case("ababababa", r"(?:ab){2}", "ababa")]
+    #[case("ababababab", r"(?:ab){2}", "ababab")]
+    #[case("abababababab", r"(?:ab){2}", "abab")]
+    //
+    #[case("Anything whatsoever gets rEkT", r".", "A")]
+    #[case(
+    "Anything whatsoever gets rEkT",
+    r".*", // Greediness inverted
+    "Anything whatsoever gets rEkT"
+)]
+    //
+    // Deals with Unicode shenanigans
+    #[case("😎😎", r"😎", "😎")]
+    #[case("\0😎\0😎\0", r"😎", "\0😎\0😎\0")]
+    //
+    #[case("你你好", r"你", "你好")]
+    //
+    // Longer ("integration") tests; things that come up in the wild
+    #[case(
+        " dirty Strings  \t with  \t\t messed up  whitespace\n\n\n",
+        r"\s",
+        " dirty Strings with messed up whitespace\n"
+    )]
+    #[case(
+        " dirty Strings  \t with  \t\t messed up  whitespace\n\n\n",
+        r" ",
+        " dirty Strings \t with \t\t messed up whitespace\n\n\n"
+    )]
+    fn test_squeeze(#[case] input: &str, #[case] pattern: RegexPattern, #[case] expected: &str) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(pattern.clone()));
+        let mut view = builder.build();
+
+        view.squeeze();
+        let result = view.to_string();
+
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    // No line endings at all
+    #[case("b", "b", "x\ny", "x\ny")]
+    //
+    // Uniform line endings
+    #[case("a\nb\n", "b", "x\ny", "a\nx\ny\n")]
+    #[case("a\r\nb\r\n", "b", "x\ny", "a\r\nx\r\ny\r\n")]
+    #[case("a\r\nb\r\n", "b", "x\n\ny\n", "a\r\nx\r\n\r\ny\r\n\r\n")]
+    //
+    // Replacement already uses CRLF
+    #[case("a\r\nb\r\n", "b", "x\r\ny", "a\r\nx\r\ny\r\n")]
+    #[case("a\nb\n", "b", "x\r\ny", "a\nx\r\ny\n")]
+    //
+    // Last line, without trailing line ending
+    #[case("a\r\nb", "b", "x\ny", "a\r\nx\r\ny")]
+    //
+    // Mixed line endings, decided per line
+    #[case("a\r\nb\nc\r\n", "b", "x\ny", "a\r\nx\ny\nc\r\n")]
+    #[case("a\r\nb\nc\r\n", "c", "x\ny", "a\r\nb\nx\r\ny\r\n")]
+    #[case("a\nb\r\n", "[ab]", "x\ny", "x\ny\nx\r\ny\r\n")]
+    fn test_line_endings_preserved(
+        #[case] input: &str,
+        #[case] pattern: RegexPattern,
+        #[case] replacement: &str,
+        #[case] expected: &str,
+    ) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(pattern));
+        let mut view = builder.build();
+
+        view.replace(replacement.to_owned()).unwrap();
+        let result = view.to_string();
+
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    // Nothing to indent
+    #[case("    b\n", "b", "x", "    x\n")]
+    #[case("b\n", "b", "x\ny", "x\ny\n")]
+    //
+    // Indented like the line the scope starts on
+    #[case("    b\n", "b", "x\ny", "    x\n    y\n")]
+    #[case("\t\tb\n", "b", "x\ny", "\t\tx\n\t\ty\n")]
+    #[case("a\n  c = b\n", "b", "x\ny", "a\n  c = x\n  y\n")]
+    #[case("  a b\n  c\n", "b", "x\ny", "  a x\n  y\n  c\n")]
+    //
+    // Existing indentation of the replacement is kept, relative to the line's
+    #[case("  b\n", "b", "x\n  y", "  x\n    y\n")]
+    //
+    // Empty lines stay empty
+    #[case("  b\n", "b", "x\n\ny\n", "  x\n\n  y\n\n")]
+    //
+    // Several scopes on one line
+    #[case("  b b\n", "b", "x\ny", "  x\n  y x\n  y\n")]
+    //
+    // Together with CRLF
+    #[case("  b\r\n", "b", "x\ny", "  x\r\n  y\r\n")]
+    fn test_map_reindents(
+        #[case] input: &str,
+        #[case] pattern: RegexPattern,
+        #[case] replacement: &str,
+        #[case] expected: &str,
+    ) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(pattern));
+        let mut view = builder.build();
+
+        let replacement = crate::actions::Replacement::try_from(replacement.to_owned())
+            .unwrap()
+            .reindented();
+        view.map(&replacement);
+
+        assert_eq!(view.to_string(), expected);
+    }
+
+    #[rstest]
+    // Line comments
+    #[case("    # TODO\n", "TODO", "a\nb", "    # a\n    # b\n")]
+    #[case("  // TODO\n", "TODO", "a\nb", "  // a\n  // b\n")]
+    #[case("/// TODO\n", "TODO", "a\nb", "/// a\n/// b\n")]
+    #[case("//! TODO\n", "TODO", "a\nb", "//! a\n//! b\n")]
+    #[case("#   TODO\n", "TODO", "a\nb", "#   a\n#   b\n")]
+    //
+    // Entire comment in scope
+    #[case("    # TODO\n", "# TODO", "# a\nb", "    # a\n    # b\n")]
+    //
+    // Trailing comments, only recognized if in scope entirely
+    #[case("x = 1  # TODO\n", "# TODO", "# a\nb", "x = 1  # a\n# b\n")]
+    #[case("x = 1  # TODO\n", "TODO", "a\nb", "x = 1  # a\nb\n")]
+    //
+    // Block comments
+    #[case("/* TODO */\n", "TODO", "a\nb", "/* a\n * b */\n")]
+    #[case("  /** TODO\n   */\n", "TODO", "a\nb", "  /** a\n   * b\n   */\n")]
+    #[case(
+        "  /**\n   * TODO\n   */\n",
+        "TODO",
+        "a\nb",
+        "  /**\n   * a\n   * b\n   */\n"
+    )]
+    //
+    // Lines already commented, and empty ones
+    #[case("    # TODO\n", "TODO", "a\n# b", "    # a\n    # b\n")]
+    #[case("    # TODO\n", "TODO", "a\n\nb", "    # a\n    #\n    # b\n")]
+    #[case(" * TODO\n", "TODO", "a\n* b", " * a\n * b\n")]
+    //
+    // Not a comment: only indented
+    #[case("    TODO\n", "TODO", "a\nb", "    a\n    b\n")]
+    fn test_map_continues_comments(
+        #[case] input: &str,
+        #[case] pattern: RegexPattern,
+        #[case] replacement: &str,
+        #[case] expected: &str,
+    ) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(pattern));
+        let mut view = builder.build();
+
+        let replacement = crate::actions::Replacement::try_from(replacement.to_owned())
+            .unwrap()
+            .continuing_comments();
+        view.map(&replacement);
+
+        assert_eq!(view.to_string(), expected);
+    }
+
+    #[test]
+    fn test_map_keeps_unchanged_scopes_borrowed() {
+        let mut builder = ScopedViewBuilder::new("aBc");
+        builder.explode(&crate::scoping::regex::Regex::new(
+            RegexPattern::new("[a-z]").unwrap(),
+        ));
+        let mut view = builder.build();
+
+        view.lower();
+
+        assert!(!view
+            .scopes
+            .0
+            .iter()
+            .any(|scope| matches!(scope, super::RWScope(super::In(super::Cow::Owned(_))))));
+        assert_eq!(view.to_string(), "aBc");
+    }
+
+    #[rstest]
+    #[case("abc", "b", "b", true)]
+    #[case("abc", "b", "x", false)]
+    #[case("abc", "x", "y", true)]
+    #[case("", "x", "y", true)]
+    fn test_is_unchanged_after_map(
+        #[case] input: &str,
+        #[case] pattern: &str,
+        #[case] replacement: &str,
+        #[case] expected: bool,
+    ) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(
+            RegexPattern::new(pattern).unwrap(),
+        ));
+        let mut view = builder.build();
+
+        view.replace(replacement.to_owned()).unwrap();
+
+        assert_eq!(view.is_unchanged(), expected);
+    }
+
+    #[rstest]
+    #[case("abc", "b", true)]
+    #[case("abbc", "b", false)]
+    fn test_is_unchanged_after_squeeze(
+        #[case] input: &str,
+        #[case] pattern: &str,
+        #[case] expected: bool,
+    ) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(
+            RegexPattern::new(pattern).unwrap(),
+        ));
+        let mut view = builder.build();
+
+        view.squeeze();
+
+        assert_eq!(view.is_unchanged(), expected);
+    }
+
+    #[rstest]
+    #[case("a1 b2 c3", false, "1a 2b 3c")]
+    // Parts narrowed down further keep the capture groups of their match.
+    #[case("a1 b2 c3", true, "1a 2b c3")]
+    fn test_replace_with_capture_groups(
+        #[case] input: &str,
+        #[case] narrow: bool,
+        #[case] expected: &str,
+    ) {
+        use crate::scoping::regex::Regex;
+
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&Regex::new(RegexPattern::new(r"(\w)(\d)").unwrap()).with_capture_groups());
+        if narrow {
+            builder.explode(&Regex::new(RegexPattern::new(r"[ab]\d").unwrap()));
+        }
+        let mut view = builder.build();
+
+        view.replace("$2$1".to_owned()).unwrap();
+
+        assert_eq!(view.to_string(), expected);
+    }
+
+    #[rstest]
+    #[case("a1 b2 c3", 0, "a1 b2 c3")]
+    #[case("a1 b2 c3", 2, "1a 2b c3")]
+    #[case("a1 b2 c3", 5, "1a 2b 3c")]
+    fn test_at_most_with_capture_groups(
+        #[case] input: &str,
+        #[case] max: usize,
+        #[case] expected: &str,
+    ) {
+        use crate::scoping::regex::Regex;
+
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&Regex::new(RegexPattern::new(r"(\w)(\d)").unwrap()).with_capture_groups());
+        builder.at_most(max);
+        let mut view = builder.build();
+
+        assert_eq!(view.n_in_scope(), max.min(3));
+
+        view.replace("$2$1".to_owned()).unwrap();
+
+        assert_eq!(view.to_string(), expected);
+    }
+
+    #[rstest]
+    #[case("a a a", vec![], "a a a")]
+    #[case("a a a", vec![0..1], "b a a")]
+    #[case("a a a", vec![0..1, 4..5], "b a b")]
+    #[case("a a a", vec![1..3], "a b a")]
+    #[case("a a a", vec![0..5], "b b b")]
+    #[case("a a a", vec![0..3, 2..5], "b b b")]
+    #[case("aa aa", vec![1..4], "ab ba")]
+    #[case("a a a", vec![10..20], "a a a")]
+    fn test_within(#[case] input: &str, #[case] ranges: Vec<Range<usize>>, #[case] expected: &str) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(
+            RegexPattern::new("a+").unwrap(),
+        ));
+        builder.within(&ranges);
+        let mut view = builder.build();
+
+        view.replace("b".to_owned()).unwrap();
+
+        assert_eq!(view.to_string(), expected);
+    }
+
+    #[test]
+    fn test_map_parallel() {
+        let n = super::PARALLEL_MAP_THRESHOLD / "ab\r\n".len() + 1;
+        let input = "ab\r\n".repeat(n);
+
+        let mut builder = ScopedViewBuilder::new(&input);
+        builder.explode(&crate::scoping::regex::Regex::new(
+            RegexPattern::new("b").unwrap(),
+        ));
+        let mut view = builder.build();
+
+        view.replace("x\ny".to_owned()).unwrap();
+
+        assert_eq!(view.to_string(), "ax\r\ny\r\n".repeat(n));
+    }
+
+    #[test]
+    fn test_map_parallel_sees_cancellation_token() {
+        let input = "a".repeat(super::PARALLEL_MAP_THRESHOLD);
+
+        let mut builder = ScopedViewBuilder::new(&input);
+        builder.explode(&crate::scoping::regex::Regex::new(
+            RegexPattern::new("a").unwrap(),
+        ));
+        let mut view = builder.build();
+
+        let token = crate::cancel::CancellationToken::new().with_memory_limit(1024);
+        let res = token.run(|| {
+            view.replace("b".to_owned()).unwrap();
+        });
+
+        assert!(res.is_err());
+    }
+
+    #[rstest]
+    #[case("abc", r"x", vec![])]
+    #[case("abc", r"b", vec![(1..2, "b", (1, 1), (1, 2))])]
+    #[case(
+        "abc\nxbbx\n",
+        r"b+",
+        vec![(1..2, "b", (1, 1), (1, 2)), (5..7, "bb", (2, 1), (2, 3))]
+    )]
+    // Capture groups split matches up, which are merged again.
+    #[case("abbc", r"(b)", vec![(1..3, "bb", (1, 1), (1, 3))])]
+    #[case("a\nb\nc", r"b\nc", vec![(2..5, "b\nc", (2, 0), (3, 1))])]
+    #[case("a\nb\nc", r"b\n", vec![(2..4, "b\n", (2, 0), (2, 2))])]
+    fn test_matches(
+        #[case] input: &str,
+        #[case] pattern: RegexPattern,
+        #[case] expected: Vec<(Range<usize>, &str, (usize, usize), (usize, usize))>,
+    ) {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&crate::scoping::regex::Regex::new(pattern));
+
+        let matches = builder
+            .matches()
+            .into_iter()
+            .map(|m| {
+                (
+                    m.range,
+                    m.text,
+                    (m.line, m.column),
+                    (m.end_line, m.end_column),
+                )
+            })
+            .collect::<Vec<_>>();
+
+        assert_eq!(matches, expected);
+    }
+}
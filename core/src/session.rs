@@ -0,0 +1,463 @@
+//! An editing session holds a single input of some language, for codemods taking many
+//! steps over it.
+//!
+//! Compared to running [scopers][`Scoper`] and [actions][`Action`] over the input from
+//! scratch for each step, a session keeps the input's syntax tree around. Edits are fed
+//! back into the tree, so that the next step only re-parses what changed
+//! ([incremental
+//! parsing](https://tree-sitter.github.io/tree-sitter/using-parsers#editing)). That
+//! matters for large files, and for long-running uses such as watching files: when a
+//! file changes, [`Session::update`] re-parses only the changed region.
+//!
+//! Parsing respects the current [cancellation token][`crate::cancel`], if any, failing
+//! with [`Cancelled`] once it is cancelled or times out.
+//!
+//! ```rust
+//! use srgn_core::actions::{Replacement, Upper};
+//! use srgn_core::scoping::langs::CodeQuery;
+//! use srgn_core::scoping::langs::python::{PremadePythonQuery, Python};
+//! use srgn_core::scoping::regex::Regex;
+//! use srgn_core::session::Session;
+//! use srgn_core::RegexPattern;
+//!
+//! let python = Python::new(CodeQuery::Premade(PremadePythonQuery::Comments));
+//! let source = "x = 1  # todo: a\ny = 'todo'  # todo: b\n";
+//! let mut session = Session::new(python, source).unwrap();
+//!
+//! let todo = Regex::new(RegexPattern::new("todo").unwrap());
+//! assert_eq!(session.apply(&todo, &Upper::default()), Ok(2));
+//!
+//! // Query again, seeing the previous step's results.
+//! let done = Regex::new(RegexPattern::new("TODO: b").unwrap());
+//! let replacement = Replacement::try_from("DONE".to_string()).unwrap();
+//! assert_eq!(session.apply(&done, &replacement), Ok(1));
+//!
+//! assert_eq!(session.source(), "x = 1  # TODO: a\ny = 'todo'  # DONE\n");
+//! ```
+
+use crate::{
+    actions::Action,
+    cancel::{self, Cancelled},
+    scoping::{
+        langs::{parse_cancellable, NodeScoper, TSParser, TSTree},
+        scope::ROScopes,
+        view::ScopedViewBuilder,
+        Scoper,
+    },
+};
+use log::{debug, trace};
+use std::{fmt, ops::Range};
+use tree_sitter::{InputEdit, Point};
+
+/// An input of language `L`, together with its syntax tree, kept up to date across
+/// edits.
+///
+/// Languages only known at runtime, [built by name][`crate::scoping::langs::by_name`],
+/// work as well.
+pub struct Session<L> {
+    language: L,
+    parser: TSParser,
+    source: String,
+    tree: TSTree,
+}
+
+impl<L: fmt::Debug> fmt::Debug for Session<L> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_struct("Session")
+            .field("language", &self.language)
+            .field("source", &self.source)
+            .field("tree", &self.tree)
+            .finish_non_exhaustive()
+    }
+}
+
+impl<L: NodeScoper> Session<L> {
+    /// Start a session by parsing `source`.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`] if parsing was [cancelled][`crate::cancel`].
+    pub fn new(language: L, source: impl Into<String>) -> Result<Self, Cancelled> {
+        let source = source.into();
+        let mut parser = language.language_parser();
+        let tree = parse(&mut parser, &source, None)?;
+
+        Ok(Self {
+            language,
+            parser,
+            source,
+            tree,
+        })
+    }
+
+    /// The current source, with all edits so far applied.
+    #[must_use]
+    pub fn source(&self) -> &str {
+        &self.source
+    }
+
+    /// End the session, returning the current source.
+    #[must_use]
+    pub fn into_source(self) -> String {
+        self.source
+    }
+
+    /// The current syntax tree of [the source][`Session::source`].
+    #[must_use]
+    pub fn tree(&self) -> &TSTree {
+        &self.tree
+    }
+
+    /// Scope the current source down to the language's query, reusing the syntax tree.
+    ///
+    /// The returned builder can be narrowed down further as usual, using
+    /// [`ScopedViewBuilder::explode`].
+    #[must_use]
+    pub fn scope(&self) -> ScopedViewBuilder<'_> {
+        let ranges = self.language.scope_tree(&self.tree, &self.source);
+
+        let mut builder = ScopedViewBuilder::new(&self.source);
+        builder.explode(&Precomputed(ranges));
+
+        builder
+    }
+
+    /// Apply `action` to everything in scope of both the language and `scoper`.
+    ///
+    /// The action is applied as
+    /// [mapping it over a view][`crate::scoping::view::ScopedView::map`] would: to each
+    /// part in scope on its own, along with the capture groups its match reported (if
+    /// any). Afterwards, the syntax tree is updated incrementally. Returns the number
+    /// of parts the action changed.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`] if re-parsing was [cancelled][`crate::cancel`]. The
+    /// source is edited regardless, see [`Session::reparse`].
+    pub fn apply(
+        &mut self,
+        scoper: &impl Scoper,
+        action: &impl Action,
+    ) -> Result<usize, Cancelled> {
+        let edits = {
+            let mut builder = self.scope();
+            builder.explode(scoper);
+
+            builder.build().edits(action)
+        };
+        debug!("Applying {} edit(s) in session", edits.len());
+
+        // Back to front, so ranges of edits yet to be made stay valid.
+        for (range, replacement) in edits.iter().rev() {
+            self.splice(range.clone(), replacement);
+        }
+
+        if !edits.is_empty() {
+            self.reparse()?;
+        }
+
+        Ok(edits.len())
+    }
+
+    /// Replace the `range` of bytes in the current source by `replacement`, updating
+    /// the syntax tree incrementally.
+    ///
+    /// # Panics
+    ///
+    /// Panics if `range` is out of bounds or does not lie on [`char`] boundaries.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`] if re-parsing was [cancelled][`crate::cancel`]. The
+    /// source is edited regardless, see [`Session::reparse`].
+    pub fn edit(&mut self, range: Range<usize>, replacement: &str) -> Result<(), Cancelled> {
+        self.splice(range, replacement);
+        self.reparse()
+    }
+
+    /// Replace the entire source by `source`, updating the syntax tree incrementally.
+    ///
+    /// This is for when the source changed elsewhere, and only its new version is
+    /// known, such as a file changed by an editor while watching it. The changed region
+    /// is found by comparing old and new source, so only that needs to be re-parsed,
+    /// instead of the entire source.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`] if re-parsing was [cancelled][`crate::cancel`]. The
+    /// source is updated regardless, see [`Session::reparse`].
+    pub fn update(&mut self, source: &str) -> Result<(), Cancelled> {
+        let Some((range, replacement)) = changed_region(&self.source, source) else {
+            trace!("Source unchanged, nothing to update");
+            return Ok(());
+        };
+        debug!("Updating region {:?} of source in session", range);
+
+        self.splice(range, replacement);
+        self.reparse()
+    }
+
+    /// Edit the source and record the edit in the tree, without re-parsing yet.
+    ///
+    /// Recording multiple edits and re-parsing once is cheaper than re-parsing after
+    /// each one.
+    fn splice(&mut self, range: Range<usize>, replacement: &str) {
+        let start_position = point_at(&self.source, range.start);
+        let old_end_position = point_at(&self.source, range.end);
+
+        self.source.replace_range(range.clone(), replacement);
+
+        let new_end_byte = range.start + replacement.len();
+        let edit = InputEdit {
+            start_byte: range.start,
+            old_end_byte: range.end,
+            new_end_byte,
+            start_position,
+            old_end_position,
+            new_end_position: point_at(&self.source, new_end_byte),
+        };
+        trace!("Recording edit: {:?}", edit);
+
+        self.tree.edit(&edit);
+    }
+
+    /// Bring the syntax tree up to date with the source, re-parsing what changed since
+    /// last time.
+    ///
+    /// Only needed after an edit failed to re-parse, as the source is edited
+    /// regardless. Until re-parsed successfully, the tree lags behind the source, and so
+    /// do [scopes][`Session::scope`] computed from it.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`Cancelled`] if re-parsing was [cancelled][`crate::cancel`].
+    pub fn reparse(&mut self) -> Result<(), Cancelled> {
+        self.tree = parse(&mut self.parser, &self.source, Some(&self.tree))?;
+
+        Ok(())
+    }
+}
+
+/// Parse `source` using `parser`, reusing `old_tree`, under the current cancellation
+/// token, if any.
+fn parse(
+    parser: &mut TSParser,
+    source: &str,
+    old_tree: Option<&TSTree>,
+) -> Result<TSTree, Cancelled> {
+    if let Some(token) = cancel::current() {
+        token.check()?;
+    }
+
+    parse_cancellable(parser, source, old_tree).ok_or_else(|| {
+        debug!("Parsing cancelled or timed out");
+        cancel::current()
+            .and_then(|token| token.reason())
+            // Timeouts of the parser itself may come just ahead of the token's.
+            .unwrap_or(Cancelled::TimedOut)
+    })
+}
+
+/// Scopes to ranges known in advance.
+struct Precomputed(Vec<Range<usize>>);
+
+impl Scoper for Precomputed {
+    fn scope<'viewee>(&self, input: &'viewee str) -> ROScopes<'viewee> {
+        ROScopes::from_raw_ranges(input, self.0.clone())
+    }
+}
+
+/// The single, contiguous region of `old` which has to be replaced to arrive at `new`,
+/// and its replacement, or [`None`] if they are equal.
+///
+/// The region is as small as possible after stripping the longest common prefix and
+/// suffix, and lies on [`char`] boundaries.
+fn changed_region<'new>(old: &str, new: &'new str) -> Option<(Range<usize>, &'new str)> {
+    if old == new {
+        return None;
+    }
+
+    let is_boundary =
+        |old_i: usize, new_i: usize| old.is_char_boundary(old_i) && new.is_char_boundary(new_i);
+
+    let mut prefix = old
+        .bytes()
+        .zip(new.bytes())
+        .take_while(|(a, b)| a == b)
+        .count();
+    while !is_boundary(prefix, prefix) {
+        prefix -= 1;
+    }
+
+    // The suffix must not overlap the prefix, in either of the two.
+    let max_suffix = old.len().min(new.len()) - prefix;
+    let mut suffix = old
+        .bytes()
+        .rev()
+        .zip(new.bytes().rev())
+        .take(max_suffix)
+        .take_while(|(a, b)| a == b)
+        .count();
+    while !is_boundary(old.len() - suffix, new.len() - suffix) {
+        suffix -= 1;
+    }
+
+    Some((prefix..old.len() - suffix, &new[prefix..new.len() - suffix]))
+}
+
+/// The row and (byte) column of `offset` in `s`, as used by tree-sitter.
+fn point_at(s: &str, offset: usize) -> Point {
+    let before = &s[..offset];
+    let row = before.matches('\n').count();
+    let column = offset - before.rfind('\n').map_or(0, |i| i + 1);
+
+    Point::new(row, column)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::actions::{Deletion, Replacement, Upper};
+    use crate::cancel::CancellationToken;
+    use crate::scoping::langs::{
+        python::{PremadePythonQuery, Python},
+        CodeQuery,
+    };
+    use crate::scoping::regex::Regex;
+    use crate::RegexPattern;
+    use rstest::rstest;
+
+    fn comments() -> Python {
+        Python::new(CodeQuery::Premade(PremadePythonQuery::Comments))
+    }
+
+    /// The session's tree is the same as if the source had been parsed from scratch.
+    fn assert_tree_up_to_date(session: &Session<Python>) {
+        let fresh = Python::parser().parse(session.source(), None).unwrap();
+
+        assert_eq!(
+            session.tree().root_node().to_sexp(),
+            fresh.root_node().to_sexp()
+        );
+    }
+
+    #[rstest]
+    #[case("x = 1  # a\n", "a", 1, "x = 1  # A\n")]
+    #[case("x = 1  # a\ny = 'a'  # aa\n", "a+", 2, "x = 1  # A\ny = 'a'  # AA\n")]
+    #[case("x = 1\n", "x", 0, "x = 1\n")]
+    // Already uppercase, so nothing changes.
+    #[case("x = 1  # A\n", "A", 0, "x = 1  # A\n")]
+    fn test_session_apply(
+        #[case] input: &str,
+        #[case] pattern: &str,
+        #[case] expected_edits: usize,
+        #[case] expected: &str,
+    ) {
+        let mut session = Session::new(comments(), input).unwrap();
+        let scoper = Regex::new(RegexPattern::new(pattern).unwrap());
+
+        assert_eq!(
+            session.apply(&scoper, &Upper::default()),
+            Ok(expected_edits)
+        );
+        assert_eq!(session.source(), expected);
+        assert_tree_up_to_date(&session);
+    }
+
+    #[test]
+    fn test_session_apply_with_capture_groups() {
+        let mut session = Session::new(comments(), "x = 1  # a: b\ny = 'a: b'\n").unwrap();
+        let scoper = Regex::new(RegexPattern::new(r"(\w): (\w)").unwrap()).with_capture_groups();
+        let replacement = Replacement::try_from("$2: $1".to_string()).unwrap();
+
+        assert_eq!(session.apply(&scoper, &replacement), Ok(1));
+        assert_eq!(session.source(), "x = 1  # b: a\ny = 'a: b'\n");
+        assert_tree_up_to_date(&session);
+    }
+
+    #[test]
+    fn test_session_steps_see_previous_edits() {
+        let mut session = Session::new(comments(), "x = 1  # a\n").unwrap();
+
+        // Turns the comment into code.
+        session.edit(7..9, "\ny = 2\n#").unwrap();
+        assert_eq!(session.source(), "x = 1  \ny = 2\n#a\n");
+        assert_tree_up_to_date(&session);
+
+        let anything = Regex::new(RegexPattern::new(".+").unwrap());
+        assert_eq!(session.apply(&anything, &Deletion::default()), Ok(1));
+        assert_eq!(session.into_source(), "x = 1  \ny = 2\n\n");
+    }
+
+    #[rstest]
+    #[case("x = 1  # a\n", "x = 1  # b\n")]
+    #[case("x = 1  # a\n", "x = 1\n")]
+    #[case("x = 1\n", "x = 1  # a\n")]
+    #[case("x = 1  # a\n", "")]
+    #[case("", "x = 1  # a\n")]
+    #[case("x = 1  # a\n", "y = 2\nx = 1  # a\n")]
+    #[case("x = 1  # ä\n", "x = 1  # ö\n")]
+    fn test_session_update(#[case] before: &str, #[case] after: &str) {
+        let mut session = Session::new(comments(), before).unwrap();
+
+        session.update(after).unwrap();
+
+        assert_eq!(session.source(), after);
+        assert_tree_up_to_date(&session);
+    }
+
+    #[test]
+    fn test_session_cancelled() {
+        let token = CancellationToken::new();
+        let mut new = None;
+        let _ = token.run(|| {
+            token.cancel();
+            new = Some(Session::new(comments(), "x = 1\n").err());
+        });
+        assert_eq!(new, Some(Some(Cancelled::Explicitly)));
+
+        let mut session = Session::new(comments(), "x = 1\n").unwrap();
+        let token = CancellationToken::new();
+        let mut updated = None;
+        let _ = token.run(|| {
+            token.cancel();
+            updated = Some(session.update("x = 2\n"));
+        });
+        assert_eq!(updated, Some(Err(Cancelled::Explicitly)));
+
+        // Source is updated regardless, and the tree catches up once re-parsed.
+        assert_eq!(session.source(), "x = 2\n");
+        session.reparse().unwrap();
+        assert_tree_up_to_date(&session);
+    }
+
+    #[rstest]
+    #[case("abc", "abc", None)]
+    #[case("abc", "axc", Some((1..2, "x")))]
+    #[case("abc", "ac", Some((1..2, "")))]
+    #[case("ac", "abc", Some((1..1, "b")))]
+    #[case("abc", "", Some((0..3, "")))]
+    #[case("", "abc", Some((0..0, "abc")))]
+    // Prefix and suffix would overlap.
+    #[case("aa", "aaa", Some((2..2, "a")))]
+    #[case("aaa", "aa", Some((2..3, "")))]
+    // Sharing the first byte of 'ä' (0xC3 0xA4) and 'ö' (0xC3 0xB6).
+    #[case("ä", "ö", Some((0..2, "ö")))]
+    fn test_changed_region(
+        #[case] old: &str,
+        #[case] new: &str,
+        #[case] expected: Option<(Range<usize>, &str)>,
+    ) {
+        assert_eq!(changed_region(old, new), expected);
+    }
+
+    #[rstest]
+    #[case("", 0, (0, 0))]
+    #[case("abc", 2, (0, 2))]
+    #[case("a\nbc", 2, (1, 0))]
+    #[case("a\nbc\n", 5, (2, 0))]
+    #[case("a\nbc", 4, (1, 2))]
+    fn test_point_at(#[case] s: &str, #[case] offset: usize, #[case] expected: (usize, usize)) {
+        assert_eq!(point_at(s, offset), Point::new(expected.0, expected.1));
+    }
+}
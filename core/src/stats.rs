@@ -0,0 +1,101 @@
+//! Accounting for where time goes, for diagnosing slow runs.
+//!
+//! Disabled by default, in which case it costs next to nothing. Once
+//! [enabled][`enable`], built-in scopers add up the time they spend parsing, querying
+//! syntax trees and matching regular expressions. Totals are kept for the entire
+//! process, summed across all threads, and can be read at any time using
+//! [`timings`].
+//!
+//! ```rust
+//! use srgn_core::scoping::{regex::Regex, Scoper};
+//! use srgn_core::{stats, RegexPattern};
+//!
+//! stats::enable();
+//!
+//! let regex = Regex::new(RegexPattern::new("a+").unwrap());
+//! regex.scope("aaa");
+//!
+//! assert!(stats::timings().regex > std::time::Duration::ZERO);
+//! ```
+
+use std::{
+    sync::atomic::{AtomicBool, AtomicU64, Ordering},
+    time::{Duration, Instant},
+};
+
+static ENABLED: AtomicBool = AtomicBool::new(false);
+
+// Nanoseconds, as there's no atomic `Duration`.
+static PARSE: AtomicU64 = AtomicU64::new(0);
+static QUERY: AtomicU64 = AtomicU64::new(0);
+static REGEX: AtomicU64 = AtomicU64::new(0);
+
+/// Start accounting for time spent, for the rest of the process.
+pub fn enable() {
+    ENABLED.store(true, Ordering::Relaxed);
+}
+
+/// Time spent in the various phases of scoping, since [enabling][`enable`].
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct Timings {
+    /// Parsing inputs into syntax trees.
+    pub parse: Duration,
+    /// Running queries over syntax trees.
+    pub query: Duration,
+    /// Matching regular expressions.
+    pub regex: Duration,
+}
+
+/// Totals of time spent so far.
+#[must_use]
+pub fn timings() -> Timings {
+    let load = |counter: &AtomicU64| Duration::from_nanos(counter.load(Ordering::Relaxed));
+
+    Timings {
+        parse: load(&PARSE),
+        query: load(&QUERY),
+        regex: load(&REGEX),
+    }
+}
+
+/// A phase of scoping to account time to.
+#[derive(Debug, Clone, Copy)]
+pub(crate) enum Phase {
+    Parse,
+    Query,
+    Regex,
+}
+
+impl Phase {
+    fn counter(self) -> &'static AtomicU64 {
+        match self {
+            Self::Parse => &PARSE,
+            Self::Query => &QUERY,
+            Self::Regex => &REGEX,
+        }
+    }
+}
+
+/// Accounts the time from its creation until it is dropped to some [`Phase`].
+#[derive(Debug)]
+pub(crate) struct Timer(Option<(Phase, Instant)>);
+
+impl Timer {
+    /// Start timing `phase`, if accounting is enabled at all.
+    pub(crate) fn start(phase: Phase) -> Self {
+        Self(
+            ENABLED
+                .load(Ordering::Relaxed)
+                .then(|| (phase, Instant::now())),
+        )
+    }
+}
+
+impl Drop for Timer {
+    fn drop(&mut self) {
+        if let Some((phase, start)) = self.0 {
+            let nanos = u64::try_from(start.elapsed().as_nanos()).unwrap_or(u64::MAX);
+            phase.counter().fetch_add(nanos, Ordering::Relaxed);
+        }
+    }
+}
@@ -0,0 +1,85 @@
+//! A persistent, on-disk cache of results of previous runs, so that files unchanged
+//! since can be skipped.
+//!
+//! Entries are keyed by a hash over the exact invocation, the path and the contents
+//! of a file, and the lines acted on if git narrows them down. Any change to either
+//! is a cache miss, and old entries are simply never hit again. Entries are plain files in the cache directory, and removing them (or
+//! the entire directory) at any time is safe.
+
+use siphasher::sip::SipHasher13;
+use std::{
+    fs,
+    hash::{Hash, Hasher},
+    io,
+    ops::Range,
+    path::{Path, PathBuf},
+};
+
+/// A cache directory, for a single invocation.
+#[derive(Debug)]
+pub(super) struct Cache {
+    dir: PathBuf,
+    invocation: u64,
+}
+
+/// Identifies a single file's contents, as seen by some invocation.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub(super) struct Key(u64);
+
+impl Cache {
+    /// Opens the cache in `dir`, creating it if necessary.
+    ///
+    /// The `invocation` is anything identifying all settings influencing results,
+    /// such as the parsed command line arguments.
+    pub(super) fn open(dir: &Path, invocation: impl Hash) -> io::Result<Self> {
+        fs::create_dir_all(dir)?;
+
+        let mut hasher = hasher();
+        // Results of different versions are not comparable.
+        env!("CARGO_PKG_VERSION").hash(&mut hasher);
+        invocation.hash(&mut hasher);
+
+        Ok(Self {
+            dir: dir.to_owned(),
+            invocation: hasher.finish(),
+        })
+    }
+
+    /// The key for the file at `path` with the given `contents`, of which only `lines`
+    /// are acted on (all, if `None`).
+    pub(super) fn key(&self, path: &Path, contents: &[u8], lines: Option<&[Range<usize>]>) -> Key {
+        let mut hasher = hasher();
+        self.invocation.hash(&mut hasher);
+        path.hash(&mut hasher);
+        contents.hash(&mut hasher);
+        lines.hash(&mut hasher);
+
+        Key(hasher.finish())
+    }
+
+    /// The entry stored for `key`, if any.
+    pub(super) fn get(&self, key: Key) -> Option<Vec<u8>> {
+        fs::read(self.path(key)).ok()
+    }
+
+    /// Stores `value` for `key`, replacing any previous entry.
+    pub(super) fn put(&self, key: Key, value: &[u8]) -> io::Result<()> {
+        let path = self.path(key);
+        // Concurrent runs might write the same entry; never let them see a partial
+        // one.
+        let tmp_path = path.with_extension(format!("{}.tmp", std::process::id()));
+
+        fs::write(&tmp_path, value)?;
+        fs::rename(&tmp_path, path)
+    }
+
+    fn path(&self, key: Key) -> PathBuf {
+        self.dir.join(format!("{:016x}", key.0))
+    }
+}
+
+/// A hasher giving the same hashes across builds and toolchains, so entries written
+/// by one build are found by the next. The standard library's makes no such promise.
+fn hasher() -> SipHasher13 {
+    SipHasher13::new_with_keys(0, 0)
+}
@@ -0,0 +1,1806 @@
+use clap::{
+    builder::{ArgPredicate, PossibleValue, StringValueParser, TypedValueParser},
+    Arg, ArgAction, Command, CommandFactory, Parser, Subcommand, ValueEnum,
+};
+use clap_complete::{generate, Generator, Shell};
+use srgn::{
+    scoping::langs::{
+        bash::{CustomBashQuery, PremadeBashQuery},
+        clojure::{CustomClojureQuery, PremadeClojureQuery},
+        cmake::{CMakeQuery, CustomCMakeQuery, PremadeCMakeQuery},
+        csharp::{CustomCSharpQuery, PremadeCSharpQuery},
+        css::{CustomCssQuery, PremadeCssQuery},
+        dart::{CustomDartQuery, PremadeDartQuery},
+        dockerfile::{CustomDockerfileQuery, PremadeDockerfileQuery},
+        elixir::{CustomElixirQuery, PremadeElixirQuery},
+        erlang::{CustomErlangQuery, PremadeErlangQuery},
+        go::{CustomGoQuery, GoQuery, PremadeGoQuery},
+        graphql::{CustomGraphQLQuery, PremadeGraphQLQuery},
+        groovy::{CustomGroovyQuery, GroovyQuery, PremadeGroovyQuery},
+        haskell::{CustomHaskellQuery, PremadeHaskellQuery},
+        html::{CustomHtmlQuery, HtmlQuery, PremadeHtmlQuery},
+        java::{CustomJavaQuery, PremadeJavaQuery},
+        json::{CustomJsonQuery, PremadeJsonQuery},
+        julia::{CustomJuliaQuery, PremadeJuliaQuery},
+        kotlin::{CustomKotlinQuery, PremadeKotlinQuery},
+        lua::{CustomLuaQuery, PremadeLuaQuery},
+        makefile::{CustomMakefileQuery, PremadeMakefileQuery},
+        markdown::{CustomMarkdownQuery, MarkdownQuery, PremadeMarkdownQuery},
+        nix::{CustomNixQuery, PremadeNixQuery},
+        objc::{CustomObjCQuery, PremadeObjCQuery},
+        ocaml::{CustomOCamlQuery, PremadeOCamlQuery},
+        perl::{CustomPerlQuery, PremadePerlQuery},
+        php::{CustomPhpQuery, PremadePhpQuery},
+        powershell::{CustomPowerShellQuery, PremadePowerShellQuery},
+        proto::{CustomProtoQuery, PremadeProtoQuery},
+        python::{CustomPythonQuery, PremadePythonQuery},
+        ruby::{CustomRubyQuery, PremadeRubyQuery},
+        rust::{CustomRustQuery, PremadeRustQuery},
+        scala::{CustomScalaQuery, PremadeScalaQuery},
+        scss::{CustomScssQuery, PremadeScssQuery},
+        solidity::{CustomSolidityQuery, PremadeSolidityQuery},
+        sql::{CustomSqlQuery, PremadeSqlQuery},
+        svelte::{CustomSvelteQuery, PremadeSvelteQuery},
+        swift::{CustomSwiftQuery, PremadeSwiftQuery},
+        toml::{CustomTomlQuery, PremadeTomlQuery},
+        typescript::{CustomTypeScriptQuery, PremadeTypeScriptQuery},
+        vue::{CustomVueQuery, PremadeVueQuery},
+        yaml::{CustomYamlQuery, PremadeYamlQuery},
+        zig::{CustomZigQuery, PremadeZigQuery},
+        CodeQuery, Filterable, Overlaps, TSQuery,
+    },
+    scoping::regex::CaptureGroup,
+    GLOBAL_SCOPE,
+};
+use std::{
+    ffi::OsStr,
+    marker::PhantomData,
+    ops::Range,
+    path::{Path, PathBuf},
+    str::FromStr,
+    time::Duration,
+};
+
+/// Main CLI entrypoint.
+///
+/// Using `verbatim_doc_comment` a lot as otherwise lines wouldn't wrap neatly. I
+/// format them narrowly manually anyway, so can just use them verbatim.
+#[derive(Parser, Debug)]
+#[command(
+    author,
+    version,
+    about,
+    verbatim_doc_comment,
+    long_about = None,
+    args_conflicts_with_subcommands = true
+)]
+pub(super) struct Cli {
+    /// Scope to apply to, as a regular expression pattern
+    ///
+    /// If string literal mode is requested, will be interpreted as a literal string.
+    ///
+    /// Actions will apply their transformations within this scope only.
+    ///
+    /// The default is the global scope, matching the entire input.
+    ///
+    /// Where that default is meaningless (e.g., deletion), this argument is
+    /// _required_.
+    #[arg(
+        value_name = "SCOPE",
+        default_value = GLOBAL_SCOPE,
+        verbatim_doc_comment,
+        default_value_if("literal_string", ArgPredicate::IsPresent, None)
+    )]
+    pub scope: String,
+
+    /// Print shell completions for the given shell
+    // This thing needs to live up here to show up within `Options` next to `--help`
+    // and `--version`. Further down, it'd show up in the wrong section because we
+    // alter `next_help_heading`.
+    #[arg(long = "completions", value_enum, verbatim_doc_comment)]
+    pub shell: Option<Shell>,
+
+    #[command(subcommand)]
+    pub command: Option<Commands>,
+
+    #[command(flatten)]
+    pub composable_actions: ComposableActions,
+
+    #[command(flatten)]
+    pub standalone_actions: StandaloneActions,
+
+    #[command(flatten)]
+    pub options: GlobalOptions,
+
+    #[command(flatten)]
+    pub languages_scopes: LanguageScopes,
+
+    #[cfg(feature = "german")]
+    #[command(flatten)]
+    pub german_options: GermanOptions,
+}
+
+/// Subcommands, as an alternative to passing scopes and actions directly.
+///
+/// A scope named the same as a subcommand can still be passed after `--`, e.g.
+/// `srgn -- run`.
+#[derive(Subcommand, Debug)]
+pub(super) enum Commands {
+    /// Run a recipe: a YAML file of stages, each a set of files alongside scopes
+    /// and actions to apply to them
+    ///
+    /// Stages run in order. Each stage supports the following keys:
+    ///
+    /// - files: glob of files to work on (required)
+    /// - language, query, custom-query: language scoping, as with e.g.
+    ///   '--python comments'
+    /// - scope: regular expression to scope to (default: everything)
+    /// - literal-string, squeeze: as the respective flags
+    /// - actions: list of 'replace: VALUE', 'upper', 'lower', 'titlecase',
+    ///   'normalize', 'delete', 'german', 'symbols'
+    /// - preconditions: list of 'files-matched', 'any-in-scope', 'none-in-scope',
+    ///   checked before any file of the stage is written
+    /// - name: shown in logs and errors
+    #[command(verbatim_doc_comment)]
+    Run {
+        /// Path to the recipe file
+        #[arg(value_name = "RECIPE")]
+        recipe: PathBuf,
+    },
+    /// Run a recipe on the given files only, for use as a pre-commit hook
+    ///
+    /// Hook frameworks such as pre-commit pass the files about to be committed as
+    /// arguments. Each stage of the recipe works on those of them its 'files' glob
+    /// matches; stages matching none are skipped, preconditions and all.
+    ///
+    /// Changed files are left for review, and not staged. If any file was changed,
+    /// the hook fails, so the commit does not go through as-is.
+    #[command(verbatim_doc_comment)]
+    Hook {
+        /// Path to the recipe file
+        #[arg(long, value_name = "RECIPE", default_value = ".srgn.yaml")]
+        recipe: PathBuf,
+        /// Files to run on
+        #[arg(value_name = "FILES")]
+        files: Vec<PathBuf>,
+    },
+    /// Run a language server, for editors to show parts in scope of a recipe
+    ///
+    /// Speaks the Language Server Protocol over stdin and stdout. For open files
+    /// matching the 'files' glob (and language) of any stage, parts in scope are
+    /// shown as diagnostics. Code actions apply the stage's actions to them, one part
+    /// at a time or to the entire file. Preconditions are not checked.
+    ///
+    /// Globs are relative to the working directory, which is usually the root of the
+    /// editor's workspace.
+    #[command(verbatim_doc_comment)]
+    Lsp {
+        /// Path to the recipe file
+        #[arg(long, value_name = "RECIPE", default_value = ".srgn.yaml")]
+        recipe: PathBuf,
+    },
+    /// Run a Model Context Protocol server, for agents to search and edit files
+    ///
+    /// Speaks the protocol over stdin and stdout. Offers a 'search' tool, listing
+    /// parts in scope, and an 'apply' tool, applying actions to them. Both take the
+    /// keys of a single recipe stage (see 'run') as arguments, such as
+    /// '{"files": "**/*.go", "language": "go", "query": "comments", "scope": "TODO",
+    /// "actions": [{"replace": "DONE"}]}'.
+    ///
+    /// Globs are relative to the working directory.
+    #[command(verbatim_doc_comment)]
+    Mcp,
+}
+
+/// https://github.com/clap-rs/clap/blob/f65d421607ba16c3175ffe76a20820f123b6c4cb/clap_complete/examples/completion-derive.rs#L69
+pub(super) fn print_completions<G: Generator>(gen: G, cmd: &mut Command) {
+    generate(gen, cmd, cmd.get_name().to_string(), &mut std::io::stdout());
+}
+
+#[derive(Parser, Debug)]
+#[group(required = false, multiple = true)]
+#[command(next_help_heading = "Options (global)")]
+pub(super) struct GlobalOptions {
+    /// Glob of files to work on (instead of reading stdin).
+    ///
+    /// If processing occurs, it is done in-place, overwriting originals.
+    ///
+    /// For supported glob syntax, see:
+    /// https://docs.rs/glob/0.3.1/glob/struct.Pattern.html
+    ///
+    /// Names of processed files are written to stdout.
+    #[arg(long, verbatim_doc_comment)]
+    pub files: Option<glob::Pattern>,
+    /// Fail if file globbing is requested but returns no matches.
+    #[arg(long, verbatim_doc_comment, requires = "files")]
+    pub fail_empty_glob: bool,
+    /// Report parts in scope in the given format, instead of processing them
+    ///
+    /// Input is left unchanged: no files are written to. Cannot be used with
+    /// actions, except for 'rdjson', where their results are suggested as fixes.
+    #[arg(long, value_enum, value_name = "FORMAT", verbatim_doc_comment)]
+    pub output: Option<OutputFormat>,
+    /// Keep the modification time of processed files unchanged.
+    ///
+    /// Files are always written atomically, keeping their permissions.
+    #[arg(long, verbatim_doc_comment, requires = "files")]
+    pub preserve_mtime: bool,
+    /// Undo the effects of passed actions, where applicable
+    ///
+    /// Requires a 1:1 mapping (bijection) between replacements and original, which
+    /// is currently available for:
+    ///
+    /// - symbols: '≠' <-> '!=' etc.
+    ///
+    /// Other actions:
+    ///
+    /// - german: inverting e.g. 'Ä' is ambiguous (can be 'Ae' or 'AE')
+    ///
+    /// - upper, lower, deletion, squeeze: inversion is impossible as information is
+    ///   lost
+    ///
+    /// These may still be passed, but will be ignored for inversion and applied
+    /// normally
+    #[cfg(feature = "symbols")]
+    #[arg(long, env, requires = "symbols", verbatim_doc_comment)]
+    pub invert: bool,
+    /// Do not interpret the scope as a regex. Instead, interpret it as a literal
+    /// string. Will require a scope to be passed.
+    ///
+    /// Also available as `-F/--fixed-strings`, like for grep.
+    #[arg(
+        short('L'),
+        long,
+        env,
+        visible_short_alias('F'),
+        visible_alias("fixed-strings"),
+        verbatim_doc_comment
+    )]
+    pub literal_string: bool,
+    /// Match the scope case-insensitively, as if prefixed by `(?i)`.
+    ///
+    /// Applies to literal strings as well.
+    #[arg(short, long, env, verbatim_doc_comment)]
+    pub ignore_case: bool,
+    /// Let '^' and '$' match at the start and end of lines, as if prefixed by
+    /// `(?m)`.
+    ///
+    /// Without it, they match at the start and end of each part in scope, which is
+    /// the entire input only without a language scoper. With a language scoper,
+    /// the regex sees each part it scoped (e.g. each comment) on its own: '^' is
+    /// the start of that part, and with this flag also of any line within it, but
+    /// never of a line's text outside of it.
+    ///
+    /// To anchor to the start and end of each part in scope regardless, use '\A'
+    /// and '\z', which are unaffected by this flag.
+    #[arg(long, env, conflicts_with = "literal_string", verbatim_doc_comment)]
+    pub multiline: bool,
+    /// Let '.' match line breaks as well, as if prefixed by `(?s)`.
+    ///
+    /// Matches can then span lines, but never the boundaries of parts in scope of a
+    /// language scoper.
+    #[arg(long, env, conflicts_with = "literal_string", verbatim_doc_comment)]
+    pub dotall: bool,
+    /// Act only on what this capture group of the scope matched, by index or name
+    ///
+    /// Allows matching context around what is to be acted on: with a scope of
+    /// 'key = "(.*)"' and '--capture 1', only the value is. Matches the group did
+    /// not participate in are left alone. Replacements can still refer to all
+    /// groups, '$0' being the entire match.
+    #[arg(
+        long,
+        env,
+        value_name = "GROUP",
+        conflicts_with = "literal_string",
+        verbatim_doc_comment
+    )]
+    pub capture: Option<CaptureGroup>,
+    /// Leave at most this many matches in scope per file (or stdin), stopping
+    /// after the first ones
+    ///
+    /// Useful to limit risky replacements, or to only ever touch the first
+    /// occurrence ('1'). Matches of regexes with capture groups count as a whole.
+    #[arg(long, value_name = "N", verbatim_doc_comment)]
+    pub max_count: Option<usize>,
+    /// Like '--max-count', but counting separately in each part in scope of the
+    /// language scoper, such as every comment
+    ///
+    /// Without a language scoper, the same as '--max-count'.
+    #[arg(long, value_name = "N", verbatim_doc_comment)]
+    pub max_count_per_scope: Option<usize>,
+    /// Leave only these occurrences of matches in scope per file (or stdin), all
+    /// 1-based and inclusive
+    ///
+    /// Either a single one such as '2', or a range: '2..4' (second to fourth),
+    /// '3..' (third onwards) or '..2' (up to the second). Combined with
+    /// '--max-count', at most that many of the selected ones remain.
+    #[arg(long, value_name = "OCCURRENCE", verbatim_doc_comment)]
+    pub occurrence: Option<Occurrence>,
+    /// Like '--occurrence', but counting separately in each part in scope of the
+    /// language scoper, such as every comment
+    ///
+    /// Without a language scoper, the same as '--occurrence'.
+    #[arg(long, value_name = "OCCURRENCE", verbatim_doc_comment)]
+    pub occurrence_per_scope: Option<Occurrence>,
+    /// Only act on files (or stdin) this regex matches somewhere in
+    ///
+    /// Can be given multiple times, in which case all have to match. Files failing
+    /// the condition are left unchanged, as if nothing were in scope. With
+    /// '--stream', each line is checked separately.
+    #[arg(long = "if", value_name = "PATTERN", verbatim_doc_comment)]
+    pub required: Vec<String>,
+    /// Only act on files (or stdin) this regex matches nowhere in
+    ///
+    /// Can be given multiple times, in which case none may match.
+    #[arg(long = "unless", value_name = "PATTERN", verbatim_doc_comment)]
+    pub forbidden: Vec<String>,
+    /// Check '--if' and '--unless' against each part in scope of the language
+    /// scoper, such as every function, instead of entire files
+    ///
+    /// Without a language scoper, they are checked against entire files either way.
+    #[arg(long, verbatim_doc_comment)]
+    pub guard_per_scope: bool,
+    /// Only act on lines changed relative to this git revision [default: HEAD]
+    ///
+    /// Parts in scope are cut down to lines added or modified since, as 'git diff'
+    /// reports them, uncommitted changes included. Files git does not track count
+    /// as changed entirely. 'REV...' compares against where the current branch
+    /// forked off 'REV', e.g. '--changed-lines=main...'.
+    #[arg(
+        long,
+        value_name = "REV",
+        num_args = 0..=1,
+        require_equals = true,
+        default_missing_value = "HEAD",
+        requires = "files",
+        conflicts_with = "verify_idempotent",
+        verbatim_doc_comment
+    )]
+    pub changed_lines: Option<String>,
+    /// Only act on files with changes staged in git, or only on staged lines
+    /// [default: files]
+    ///
+    /// For use in pre-commit hooks. With 'hunks', parts in scope are cut down to
+    /// lines staged for commit, leaving lines with unstaged changes alone. Files are
+    /// processed in the working tree, and not staged again.
+    #[arg(
+        long,
+        value_enum,
+        value_name = "WHAT",
+        num_args = 0..=1,
+        require_equals = true,
+        default_missing_value = "files",
+        requires = "files",
+        conflicts_with_all = ["changed_lines", "verify_idempotent"],
+        verbatim_doc_comment
+    )]
+    pub staged: Option<Staged>,
+    /// Only act on lines last changed by an author this regex matches, as 'git
+    /// blame' reports them
+    ///
+    /// Authors are matched as 'Name <email>'. Lines not committed yet are by 'Not
+    /// Committed Yet <not.committed.yet>'.
+    #[arg(
+        long,
+        value_name = "PATTERN",
+        requires = "files",
+        conflicts_with = "verify_idempotent",
+        verbatim_doc_comment
+    )]
+    pub blame_author: Option<String>,
+    /// Only act on lines last changed no earlier than this date, as 'git blame'
+    /// reports them, e.g. '2024-01-31' or '2 weeks ago'
+    ///
+    /// Any date git understands works. Lines not committed yet always count as
+    /// recent.
+    #[arg(
+        long,
+        value_name = "DATE",
+        requires = "files",
+        conflicts_with = "verify_idempotent",
+        verbatim_doc_comment
+    )]
+    pub blame_since: Option<String>,
+    /// Skip files marked 'linguist-generated' or 'linguist-vendored' in
+    /// '.gitattributes'
+    ///
+    /// Rewriting generated or third-party code is rarely intended. Files whose
+    /// attributes cannot be read are processed, with a warning.
+    #[arg(long, env, requires = "files", verbatim_doc_comment)]
+    pub skip_generated: bool,
+    /// Only process files changed by commits since this git revision, e.g. a tag
+    ///
+    /// The same as '--rev-range REV..HEAD'.
+    #[arg(
+        long,
+        value_name = "REV",
+        requires = "files",
+        conflicts_with = "rev_range",
+        verbatim_doc_comment
+    )]
+    pub since: Option<String>,
+    /// Only process files changed by commits in this git revision range, e.g.
+    /// 'v1.0..v1.1'
+    ///
+    /// Useful for follow-ups to previous migrations, applying only where they
+    /// landed. Any range 'git log' understands works.
+    #[arg(long, value_name = "RANGE", requires = "files", verbatim_doc_comment)]
+    pub rev_range: Option<String>,
+    /// How to resolve nodes captured by a language query which overlap, such as a
+    /// function and a closure within it
+    ///
+    /// Nodes only bordering each other never overlap.
+    #[arg(
+        long,
+        env,
+        value_enum,
+        value_name = "POLICY",
+        default_value_t = Overlaps::Merge,
+        verbatim_doc_comment
+    )]
+    pub overlaps: Overlaps,
+    /// Do not interpret `$` in the replacement as referring to capture groups.
+    /// Instead, insert the replacement as-is, `$` and all.
+    ///
+    /// Useful for replacements such as shell snippets or GitHub Actions
+    /// expressions, which would otherwise need every `$` escaped as `$$`.
+    #[arg(long, env, requires = "replace", verbatim_doc_comment)]
+    pub replace_literal: bool,
+    /// Indent lines of a multiline replacement like the line of the scope it
+    /// replaces.
+    ///
+    /// Lines after the first are prefixed with the leading whitespace of the line
+    /// the scope starts on, so replacing indented statements or blocks with
+    /// unindented replacements yields properly indented results. Indentation
+    /// within the replacement is kept, relative to that. Empty lines stay empty.
+    #[arg(long, env, requires = "replace", verbatim_doc_comment)]
+    pub reindent: bool,
+    /// Mimic the case of what is replaced: if all uppercase, so is the replacement,
+    /// and likewise for all lowercase and titlecase
+    ///
+    /// For example, replacing 'foo' by 'bar' turns 'FOO' into 'BAR' and 'Foo' into
+    /// 'Bar'. Mixed case, such as 'fooBar', leaves the replacement as given.
+    /// Combine with '--ignore-case' to find all spellings in the first place.
+    #[arg(long, env, requires = "replace", verbatim_doc_comment)]
+    pub preserve_case: bool,
+    /// If anything at all is found to be in scope, fail.
+    ///
+    /// The default is to continue processing normally.
+    #[arg(long, verbatim_doc_comment)]
+    pub fail_any: bool,
+    /// If nothing is found to be in scope, fail.
+    ///
+    /// The default is to return the input unchanged (without failure).
+    #[arg(long, verbatim_doc_comment)]
+    pub fail_none: bool,
+    /// Apply actions a second time, in memory, and fail if that would change the
+    /// result any further.
+    ///
+    /// Catches replacements feeding on their own output (e.g., 'foo' to 'foofoo')
+    /// before they are written anywhere. With '--files', the run fails, leaving the
+    /// offending file unchanged.
+    ///
+    /// Selecting occurrences leaves others alone on purpose, so cannot be combined.
+    #[arg(
+        long,
+        conflicts_with_all = [
+            "max_count",
+            "max_count_per_scope",
+            "occurrence",
+            "occurrence_per_scope",
+        ],
+        verbatim_doc_comment
+    )]
+    pub verify_idempotent: bool,
+    /// Treat files matching a glob as written in a language, e.g. '*.pyi=python'
+    ///
+    /// Useful for files with nonstandard names, which language scopes would
+    /// otherwise skip. Globs without a path separator are matched against file
+    /// names only (so 'Jenkinsfile' matches anywhere), others against full paths.
+    ///
+    /// Can be given multiple times; the first matching mapping wins. Mappings take
+    /// precedence over built-in detection by file extension and contents.
+    #[arg(
+        long = "map-language",
+        value_name = "GLOB=LANGUAGE",
+        requires = "files",
+        verbatim_doc_comment
+    )]
+    pub language_mappings: Vec<LanguageMapping>,
+    /// Abort if processing a single file (or stdin) takes up more memory than this,
+    /// e.g. '512M'
+    ///
+    /// Units K, M and G are binary (powers of 1024). Memory use is estimated, not
+    /// measured, and errs on the high side.
+    #[arg(long, value_name = "SIZE", verbatim_doc_comment)]
+    pub memory_limit: Option<ByteSize>,
+    /// Abort if processing a single file (or stdin) takes longer than this, e.g.
+    /// '500ms' or '2s'
+    ///
+    /// With '--files', the file is skipped and left unchanged, while processing of
+    /// other files continues. The run fails at the end.
+    #[arg(long, value_name = "DURATION", verbatim_doc_comment)]
+    pub timeout: Option<Timeout>,
+    /// Maximum number of backtracking steps for a single regex match attempt
+    /// [default: 1000000]
+    ///
+    /// Only regexes using fancy features (look-arounds, backreferences) backtrack.
+    /// Exceeding the limit is handled like exceeding '--timeout'.
+    #[arg(long, value_name = "STEPS", verbatim_doc_comment)]
+    pub regex_backtrack_limit: Option<usize>,
+    /// Process stdin line by line, writing out each line when done
+    ///
+    /// Memory use stays bounded no matter the size of the input, for use on
+    /// endless streams such as logs. Scopes cannot span lines, and language scopes
+    /// are not available.
+    #[arg(
+        long,
+        conflicts_with_all = ["files", "output", stringify!(LanguageScopes)],
+        verbatim_doc_comment
+    )]
+    pub stream: bool,
+    /// Path the contents of stdin belong to, as passed by editors running
+    /// formatters
+    ///
+    /// Language scopes apply only if the path is in their language; if given
+    /// multiple, the one it is in is picked. If it is in none of them, nothing is in
+    /// scope. Reports refer to the path instead of '<stdin>'. The path itself is
+    /// never read from or written to.
+    #[arg(
+        long,
+        value_name = "PATH",
+        conflicts_with_all = ["files", "stream"],
+        verbatim_doc_comment
+    )]
+    pub stdin_filepath: Option<PathBuf>,
+    /// Only act on these lines of stdin, e.g. '3', '3..5' or '3..' (1-based,
+    /// inclusive)
+    ///
+    /// For formatting a selection in an editor. Can be given multiple times.
+    #[arg(
+        long,
+        value_name = "LINES",
+        conflicts_with_all = ["files", "stream"],
+        verbatim_doc_comment
+    )]
+    pub range: Vec<LineRange>,
+    /// Write what changed in stdin as a JSON list of edits, instead of all of it
+    ///
+    /// Each edit is an object of 'start' and 'end' byte offsets into stdin (as
+    /// UTF-8, without any byte order mark) and the 'text' to replace that part
+    /// with. The list is empty if nothing changed.
+    #[arg(
+        long,
+        conflicts_with_all = ["files", "stream", "output"],
+        verbatim_doc_comment
+    )]
+    pub edits: bool,
+    /// Print counters and timings of the run to stderr, once done
+    ///
+    /// Times spent parsing, querying and matching regexes are summed up across
+    /// threads, so can add up to more than the total time.
+    #[arg(long, verbatim_doc_comment)]
+    pub stats: bool,
+    /// Directory to cache results in, to skip files unchanged since a previous run
+    ///
+    /// Only results of identical invocations are reused. Unchanged means neither
+    /// modified by others, nor by this program in a previous run. The directory is
+    /// created if missing, and can be deleted at any time.
+    #[arg(long, value_name = "DIR", requires = "files", verbatim_doc_comment)]
+    pub cache_dir: Option<PathBuf>,
+    /// Increase log verbosity level
+    ///
+    /// The base log level to use is read from the `RUST_LOG` environment variable
+    /// (if missing, 'error'), and increased according to the number of times this
+    /// flag is given.
+    #[arg(
+        short = 'v',
+        long = "verbose",
+        action = ArgAction::Count,
+        verbatim_doc_comment
+    )]
+    pub additional_verbosity: u8,
+}
+
+#[derive(Parser, Debug)]
+#[group(required = false, multiple = true)]
+#[command(next_help_heading = "Composable Actions")]
+pub(super) struct ComposableActions {
+    /// Replace scope by this (fixed) value
+    ///
+    /// Specially treated action for ergonomics and compatibility with `tr`.
+    ///
+    /// May refer to capture groups of the scope, by name (`$name`, `${name}`) or by
+    /// index (`$1`), with `$0` being the entire match. Use `$$` for a literal `$`.
+    ///
+    /// When scoping comments (premade language queries only), lines of multiline
+    /// replacements continue the comment, starting with its comment leader.
+    ///
+    /// If given, will run before any other action.
+    #[arg(value_name = "REPLACEMENT", env, verbatim_doc_comment)]
+    pub replace: Option<String>,
+    /// Uppercase scope
+    #[arg(short, long, env, verbatim_doc_comment)]
+    pub upper: bool,
+    /// Lowercase scope
+    #[arg(short, long, env, verbatim_doc_comment)]
+    pub lower: bool,
+    /// Titlecase scope
+    #[arg(short, long, env, verbatim_doc_comment)]
+    pub titlecase: bool,
+    /// Normalize (Normalization Form D) scope, and throw away marks
+    #[arg(short, long, env, verbatim_doc_comment)]
+    pub normalize: bool,
+    /// Perform substitutions on German words, such as 'Abenteuergruesse' to
+    /// 'Abenteuergrüße'
+    ///
+    /// ASCII spellings for Umlauts (ae, oe, ue) and Eszett (ss) are replaced by
+    /// their respective native Unicode (ä, ö, ü, ß).
+    ///
+    /// Arbitrary compound words are supported.
+    ///
+    /// Words legally containing alternative spellings are not modified.
+    ///
+    /// Words require correct spelling to be detected.
+    #[cfg(feature = "german")]
+    #[arg(
+        short,
+        long,
+        verbatim_doc_comment,
+        // `true` as string is very ugly, but there's no other way?
+        default_value_if("german-opts", ArgPredicate::IsPresent, "true")
+    )]
+    pub german: bool,
+    /// Perform substitutions on symbols, such as '!=' to '≠', '->' to '→'
+    ///
+    /// Helps translate 'ASCII art' into native Unicode representations.
+    #[cfg(feature = "symbols")]
+    #[arg(short = 'S', long, verbatim_doc_comment)]
+    pub symbols: bool,
+}
+
+#[derive(Parser, Debug)]
+#[group(required = false, multiple = false)]
+#[command(next_help_heading = "Standalone Actions (only usable alone)")]
+pub(super) struct StandaloneActions {
+    /// Delete scope
+    ///
+    /// Cannot be used with any other action: no point in deleting and performing any
+    /// other action. Sibling actions would either receive empty input or have their
+    /// work wiped.
+    #[arg(
+        short,
+        long,
+        requires = "scope",
+        conflicts_with = stringify!(ComposableActions),
+        verbatim_doc_comment
+    )]
+    pub delete: bool,
+    /// Squeeze consecutive occurrences of scope into one
+    #[arg(
+        short,
+        long,
+        visible_alias("squeeze-repeats"),
+        env,
+        requires = "scope",
+        verbatim_doc_comment
+    )]
+    pub squeeze: bool,
+}
+
+// Any number of languages can be scoped at once (each with one query). Files are
+// processed by the scope of the language they are written in.
+#[derive(Parser, Debug)]
+#[group(required = false, multiple = true)]
+#[command(next_help_heading = "Language scopes")]
+pub(super) struct LanguageScopes {
+    #[command(flatten)]
+    pub bash: Option<BashScope>,
+    #[command(flatten)]
+    pub clojure: Option<ClojureScope>,
+    #[command(flatten)]
+    pub cmake: Option<CMakeScope>,
+    #[command(flatten)]
+    pub csharp: Option<CSharpScope>,
+    #[command(flatten)]
+    pub css: Option<CssScope>,
+    #[command(flatten)]
+    pub dart: Option<DartScope>,
+    #[command(flatten)]
+    pub dockerfile: Option<DockerfileScope>,
+    #[command(flatten)]
+    pub elixir: Option<ElixirScope>,
+    #[command(flatten)]
+    pub erlang: Option<ErlangScope>,
+    #[command(flatten)]
+    pub go: Option<GoScope>,
+    #[command(flatten)]
+    pub graphql: Option<GraphQLScope>,
+    #[command(flatten)]
+    pub groovy: Option<GroovyScope>,
+    #[command(flatten)]
+    pub haskell: Option<HaskellScope>,
+    #[command(flatten)]
+    pub html: Option<HtmlScope>,
+    #[command(flatten)]
+    pub java: Option<JavaScope>,
+    #[command(flatten)]
+    pub json: Option<JsonScope>,
+    #[command(flatten)]
+    pub julia: Option<JuliaScope>,
+    #[command(flatten)]
+    pub kotlin: Option<KotlinScope>,
+    #[command(flatten)]
+    pub lua: Option<LuaScope>,
+    #[command(flatten)]
+    pub makefile: Option<MakefileScope>,
+    #[command(flatten)]
+    pub markdown: Option<MarkdownScope>,
+    #[command(flatten)]
+    pub nix: Option<NixScope>,
+    #[command(flatten)]
+    pub objc: Option<ObjCScope>,
+    #[command(flatten)]
+    pub ocaml: Option<OCamlScope>,
+    #[command(flatten)]
+    pub perl: Option<PerlScope>,
+    #[command(flatten)]
+    pub php: Option<PhpScope>,
+    #[command(flatten)]
+    pub powershell: Option<PowerShellScope>,
+    #[command(flatten)]
+    pub proto: Option<ProtoScope>,
+    #[command(flatten)]
+    pub python: Option<PythonScope>,
+    #[command(flatten)]
+    pub ruby: Option<RubyScope>,
+    #[command(flatten)]
+    pub rust: Option<RustScope>,
+    #[command(flatten)]
+    pub scala: Option<ScalaScope>,
+    #[command(flatten)]
+    pub scss: Option<ScssScope>,
+    #[command(flatten)]
+    pub solidity: Option<SolidityScope>,
+    #[command(flatten)]
+    pub sql: Option<SqlScope>,
+    #[command(flatten)]
+    pub svelte: Option<SvelteScope>,
+    #[command(flatten)]
+    pub swift: Option<SwiftScope>,
+    #[command(flatten)]
+    pub toml: Option<TomlScope>,
+    #[command(flatten)]
+    pub typescript: Option<TypeScriptScope>,
+    #[command(flatten)]
+    pub vue: Option<VueScope>,
+    #[command(flatten)]
+    pub yaml: Option<YamlScope>,
+    #[command(flatten)]
+    pub zig: Option<ZigScope>,
+}
+
+impl LanguageScopes {
+    /// Whether there are language scopes, all of which scope comments using a
+    /// premade query.
+    pub(super) fn are_comments(&self) -> bool {
+        let scopes = [
+            self.bash
+                .as_ref()
+                .map(|s| matches!(s.bash, Some(PremadeBashQuery::Comments))),
+            self.clojure
+                .as_ref()
+                .map(|s| matches!(s.clojure, Some(PremadeClojureQuery::Comments))),
+            self.cmake.as_ref().map(|s| {
+                matches!(
+                    s.cmake,
+                    Some(CMakeQuery::Premade(PremadeCMakeQuery::Comments))
+                )
+            }),
+            self.csharp
+                .as_ref()
+                .map(|s| matches!(s.csharp, Some(PremadeCSharpQuery::Comments))),
+            self.css
+                .as_ref()
+                .map(|s| matches!(s.css, Some(PremadeCssQuery::Comments))),
+            self.dart
+                .as_ref()
+                .map(|s| matches!(s.dart, Some(PremadeDartQuery::Comments))),
+            self.dockerfile
+                .as_ref()
+                .map(|s| matches!(s.dockerfile, Some(PremadeDockerfileQuery::Comments))),
+            self.elixir
+                .as_ref()
+                .map(|s| matches!(s.elixir, Some(PremadeElixirQuery::Comments))),
+            self.erlang
+                .as_ref()
+                .map(|s| matches!(s.erlang, Some(PremadeErlangQuery::Comments))),
+            self.go
+                .as_ref()
+                .map(|s| matches!(s.go, Some(GoQuery::Premade(PremadeGoQuery::Comments)))),
+            self.graphql
+                .as_ref()
+                .map(|s| matches!(s.graphql, Some(PremadeGraphQLQuery::Comments))),
+            self.groovy.as_ref().map(|s| {
+                matches!(
+                    s.groovy,
+                    Some(GroovyQuery::Premade(PremadeGroovyQuery::Comments))
+                )
+            }),
+            self.haskell
+                .as_ref()
+                .map(|s| matches!(s.haskell, Some(PremadeHaskellQuery::Comments))),
+            self.html
+                .as_ref()
+                .map(|s| matches!(s.html, Some(HtmlQuery::Premade(PremadeHtmlQuery::Comments)))),
+            self.java
+                .as_ref()
+                .map(|s| matches!(s.java, Some(PremadeJavaQuery::Comments))),
+            self.json
+                .as_ref()
+                .map(|s| matches!(s.json, Some(PremadeJsonQuery::Comments))),
+            self.julia
+                .as_ref()
+                .map(|s| matches!(s.julia, Some(PremadeJuliaQuery::Comments))),
+            self.kotlin
+                .as_ref()
+                .map(|s| matches!(s.kotlin, Some(PremadeKotlinQuery::Comments))),
+            self.lua
+                .as_ref()
+                .map(|s| matches!(s.lua, Some(PremadeLuaQuery::Comments))),
+            self.makefile
+                .as_ref()
+                .map(|s| matches!(s.makefile, Some(PremadeMakefileQuery::Comments))),
+            // Markdown has no comments of its own.
+            self.markdown.as_ref().map(|_| false),
+            self.nix
+                .as_ref()
+                .map(|s| matches!(s.nix, Some(PremadeNixQuery::Comments))),
+            self.objc
+                .as_ref()
+                .map(|s| matches!(s.objc, Some(PremadeObjCQuery::Comments))),
+            self.ocaml
+                .as_ref()
+                .map(|s| matches!(s.ocaml, Some(PremadeOCamlQuery::Comments))),
+            self.perl
+                .as_ref()
+                .map(|s| matches!(s.perl, Some(PremadePerlQuery::Comments))),
+            self.php.as_ref().map(|s| {
+                matches!(
+                    s.php,
+                    Some(PremadePhpQuery::Comments | PremadePhpQuery::DocBlocks)
+                )
+            }),
+            self.powershell
+                .as_ref()
+                .map(|s| matches!(s.powershell, Some(PremadePowerShellQuery::Comments))),
+            self.proto
+                .as_ref()
+                .map(|s| matches!(s.proto, Some(PremadeProtoQuery::Comments))),
+            self.python
+                .as_ref()
+                .map(|s| matches!(s.python, Some(PremadePythonQuery::Comments))),
+            self.ruby
+                .as_ref()
+                .map(|s| matches!(s.ruby, Some(PremadeRubyQuery::Comments))),
+            self.rust.as_ref().map(|s| {
+                matches!(
+                    s.rust,
+                    Some(PremadeRustQuery::Comments | PremadeRustQuery::DocComments)
+                )
+            }),
+            self.scala
+                .as_ref()
+                .map(|s| matches!(s.scala, Some(PremadeScalaQuery::Comments))),
+            self.scss
+                .as_ref()
+                .map(|s| matches!(s.scss, Some(PremadeScssQuery::Comments))),
+            self.solidity.as_ref().map(|s| {
+                matches!(
+                    s.solidity,
+                    Some(PremadeSolidityQuery::Comments | PremadeSolidityQuery::Natspec)
+                )
+            }),
+            self.sql
+                .as_ref()
+                .map(|s| matches!(s.sql, Some(PremadeSqlQuery::Comments))),
+            self.svelte.as_ref().map(|s| {
+                matches!(
+                    s.svelte,
+                    Some(PremadeSvelteQuery::ScriptComments | PremadeSvelteQuery::StyleComments)
+                )
+            }),
+            self.swift
+                .as_ref()
+                .map(|s| matches!(s.swift, Some(PremadeSwiftQuery::Comments))),
+            self.toml
+                .as_ref()
+                .map(|s| matches!(s.toml, Some(PremadeTomlQuery::Comments))),
+            self.typescript
+                .as_ref()
+                .map(|s| matches!(s.typescript, Some(PremadeTypeScriptQuery::Comments))),
+            self.vue.as_ref().map(|s| {
+                matches!(
+                    s.vue,
+                    Some(PremadeVueQuery::ScriptComments | PremadeVueQuery::StyleComments)
+                )
+            }),
+            self.yaml
+                .as_ref()
+                .map(|s| matches!(s.yaml, Some(PremadeYamlQuery::Comments))),
+            self.zig
+                .as_ref()
+                .map(|s| matches!(s.zig, Some(PremadeZigQuery::Comments))),
+        ];
+
+        scopes.iter().any(Option::is_some) && scopes.into_iter().flatten().all(|c| c)
+    }
+}
+
+/// What of the changes staged in git to act on.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, ValueEnum)]
+pub(super) enum Staged {
+    /// Entire files with any changes staged
+    Files,
+    /// Only lines staged, of files with any changes staged
+    Hunks,
+}
+
+/// Formats for reporting on parts in scope.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, ValueEnum)]
+pub(super) enum OutputFormat {
+    /// 'file:line:column:text' per match, for Vim's quickfix list and similar
+    Vimgrep,
+    /// JSON Lines, following the message format of ripgrep's '--json'
+    Json,
+    /// A single JSON document in reviewdog's Diagnostic Format, with suggested
+    /// fixes if actions are given
+    Rdjson,
+}
+
+/// Names of available languages, for referring to them in options.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, ValueEnum)]
+pub(super) enum LanguageName {
+    Bash,
+    Clojure,
+    #[value(name = "cmake")]
+    CMake,
+    #[value(name = "csharp")]
+    CSharp,
+    Css,
+    Dart,
+    Dockerfile,
+    Elixir,
+    Erlang,
+    Go,
+    #[value(name = "graphql")]
+    GraphQL,
+    Groovy,
+    Haskell,
+    Html,
+    Java,
+    Json,
+    Julia,
+    Kotlin,
+    Lua,
+    Makefile,
+    Markdown,
+    Nix,
+    #[value(name = "objc")]
+    ObjC,
+    #[value(name = "ocaml")]
+    OCaml,
+    Perl,
+    Php,
+    #[value(name = "powershell")]
+    PowerShell,
+    Proto,
+    Python,
+    Ruby,
+    Rust,
+    Scala,
+    Scss,
+    Solidity,
+    Sql,
+    Svelte,
+    Swift,
+    Toml,
+    #[value(name = "typescript")]
+    TypeScript,
+    Vue,
+    Yaml,
+    Zig,
+}
+
+/// A mapping of files matching a glob to a language.
+#[derive(Debug, Clone)]
+pub(super) struct LanguageMapping {
+    glob: glob::Pattern,
+    pub language: LanguageName,
+}
+
+impl LanguageMapping {
+    /// Check whether the given `path` is covered by this mapping.
+    pub(super) fn matches(&self, path: &Path) -> bool {
+        let file_name_only = !self.glob.as_str().contains(std::path::is_separator);
+
+        if file_name_only {
+            path.file_name()
+                .is_some_and(|name| self.glob.matches_path(Path::new(name)))
+        } else {
+            self.glob.matches_path(path)
+        }
+    }
+}
+
+impl FromStr for LanguageMapping {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let (glob, language) = s
+            .rsplit_once('=')
+            .ok_or_else(|| format!("Expected 'GLOB=LANGUAGE', got '{s}'"))?;
+
+        let glob = glob::Pattern::new(glob).map_err(|e| format!("Invalid glob: {e}"))?;
+        let language = LanguageName::from_str(language, true)?;
+
+        Ok(Self { glob, language })
+    }
+}
+
+/// A number of bytes, optionally suffixed by a binary unit, e.g. '512M'.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub(super) struct ByteSize(pub usize);
+
+impl FromStr for ByteSize {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let (number, unit) = s.split_at(s.find(|c: char| !c.is_ascii_digit()).unwrap_or(s.len()));
+
+        let shift = match unit.to_ascii_uppercase().as_str() {
+            "" | "B" => 0,
+            "K" | "KB" | "KIB" => 10,
+            "M" | "MB" | "MIB" => 20,
+            "G" | "GB" | "GIB" => 30,
+            _ => return Err(format!("Unknown unit '{unit}', expected one of K, M, G")),
+        };
+        let number = number
+            .parse::<usize>()
+            .map_err(|e| format!("Invalid size '{s}': {e}"))?;
+
+        number
+            .checked_mul(1 << shift)
+            .map(Self)
+            .ok_or_else(|| format!("Size too large: '{s}'"))
+    }
+}
+
+/// Occurrences of matches, 1-based and inclusive, e.g. '2', '2..4', '3..' or '..2'.
+///
+/// Held as a range of 0-based indices; unbounded ranges end at [`usize::MAX`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(super) struct Occurrence(pub Range<usize>);
+
+impl Default for Occurrence {
+    fn default() -> Self {
+        Self(0..usize::MAX)
+    }
+}
+
+impl FromStr for Occurrence {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let parse = |n: &str| match n.parse::<usize>() {
+            Ok(0) => Err(format!("Occurrences start at 1, got '{s}'")),
+            Ok(n) => Ok(n),
+            Err(e) => Err(format!("Invalid occurrence '{s}': {e}")),
+        };
+
+        let bound = |n: &str, default| if n.is_empty() { Ok(default) } else { parse(n) };
+
+        let (first, last) = match s.split_once("..") {
+            Some(("", "")) => return Err(format!("Invalid occurrence '{s}': empty range")),
+            Some((first, last)) => (bound(first, 1)?, bound(last, usize::MAX)?),
+            None => {
+                let n = parse(s)?;
+                (n, n)
+            }
+        };
+
+        if first > last {
+            return Err(format!("Invalid occurrence '{s}': start is past end"));
+        }
+
+        Ok(Self(first - 1..last))
+    }
+}
+
+/// Lines of some input, 1-based and inclusive, e.g. '3', '3..5' or '3..'.
+///
+/// Held as a range of 1-based lines, end-exclusive; unbounded ranges end at
+/// [`usize::MAX`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(super) struct LineRange(pub Range<usize>);
+
+impl FromStr for LineRange {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let parse = |n: &str| match n.parse::<usize>() {
+            Ok(0) => Err(format!("Lines start at 1, got '{s}'")),
+            Ok(n) => Ok(n),
+            Err(e) => Err(format!("Invalid lines '{s}': {e}")),
+        };
+
+        let (first, last) = match s.split_once("..") {
+            Some((first, "")) => return Ok(Self(parse(first)?..usize::MAX)),
+            Some((first, last)) => (parse(first)?, parse(last)?),
+            None => {
+                let n = parse(s)?;
+                (n, n)
+            }
+        };
+
+        if first > last {
+            return Err(format!("Invalid lines '{s}': start is past end"));
+        }
+
+        Ok(Self(first..last + 1))
+    }
+}
+
+/// A duration, suffixed by a unit of 'ms', 's' or 'm', e.g. '500ms'.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub(super) struct Timeout(pub Duration);
+
+impl FromStr for Timeout {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let (number, unit) = s.split_at(s.find(|c: char| !c.is_ascii_digit()).unwrap_or(s.len()));
+
+        let number = number
+            .parse::<u64>()
+            .map_err(|e| format!("Invalid duration '{s}': {e}"))?;
+
+        match unit {
+            "ms" => Ok(Duration::from_millis(number)),
+            "s" => Ok(Duration::from_secs(number)),
+            "m" => number
+                .checked_mul(60)
+                .map(Duration::from_secs)
+                .ok_or_else(|| format!("Duration too large: '{s}'")),
+            _ => Err(format!("Unknown unit '{unit}', expected one of ms, s, m")),
+        }
+        .map(Self)
+    }
+}
+
+/// Parses premade queries, optionally followed by a `~` and a pattern to
+/// [filter][`Filterable`] them by, as in `elements~div`.
+///
+/// Filtered queries are compiled into custom ones. All premade queries are listed as
+/// possible values, as for their plain [`ValueEnum`] parser.
+#[derive(Debug)]
+pub(super) struct FilterableParser<C, P>(PhantomData<(C, P)>);
+
+impl<C, P> FilterableParser<C, P> {
+    pub(super) fn new() -> Self {
+        Self(PhantomData)
+    }
+}
+
+impl<C, P> Clone for FilterableParser<C, P> {
+    fn clone(&self) -> Self {
+        Self::new()
+    }
+}
+
+impl<C, P> TypedValueParser for FilterableParser<C, P>
+where
+    C: FromStr + Into<TSQuery> + Clone + Send + Sync + 'static,
+    C::Err: std::fmt::Display,
+    P: ValueEnum + Filterable + Into<TSQuery> + Clone + Send + Sync + 'static,
+{
+    type Value = CodeQuery<C, P>;
+
+    fn parse_ref(
+        &self,
+        cmd: &Command,
+        arg: Option<&Arg>,
+        value: &OsStr,
+    ) -> Result<Self::Value, clap::Error> {
+        StringValueParser::new()
+            .try_map(|s| {
+                let (name, pattern) = match s.split_once('~') {
+                    Some((name, pattern)) => (name, Some(pattern)),
+                    None => (s.as_str(), None),
+                };
+                let premade = <P as ValueEnum>::from_str(name, false)?;
+
+                let Some(pattern) = pattern else {
+                    return Ok(CodeQuery::Premade(premade));
+                };
+                let source = premade
+                    .filtered(pattern)
+                    .ok_or_else(|| format!("Query '{name}' cannot be filtered"))?;
+
+                C::from_str(&source)
+                    .map(CodeQuery::Custom)
+                    .map_err(|e| format!("Invalid filter '{pattern}': {e}"))
+            })
+            .parse_ref(cmd, arg, value)
+    }
+
+    fn possible_values(&self) -> Option<Box<dyn Iterator<Item = PossibleValue> + '_>> {
+        Some(Box::new(
+            P::value_variants()
+                .iter()
+                .filter_map(ValueEnum::to_possible_value),
+        ))
+    }
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct BashScope {
+    /// Scope Bash code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub bash: Option<PremadeBashQuery>,
+
+    /// Scope Bash code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub bash_query: Option<CustomBashQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ClojureScope {
+    /// Scope Clojure code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub clojure: Option<PremadeClojureQuery>,
+
+    /// Scope Clojure code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub clojure_query: Option<CustomClojureQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct CMakeScope {
+    /// Scope CMake code using a premade query.
+    #[arg(
+        long,
+        env,
+        verbatim_doc_comment,
+        value_parser = FilterableParser::<CustomCMakeQuery, PremadeCMakeQuery>::new()
+    )]
+    pub cmake: Option<CMakeQuery>,
+
+    /// Scope CMake code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub cmake_query: Option<CustomCMakeQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct CSharpScope {
+    /// Scope CSharp code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub csharp: Option<PremadeCSharpQuery>,
+
+    /// Scope CSharp code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub csharp_query: Option<CustomCSharpQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct CssScope {
+    /// Scope CSS code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub css: Option<PremadeCssQuery>,
+
+    /// Scope CSS code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub css_query: Option<CustomCssQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct DartScope {
+    /// Scope Dart code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub dart: Option<PremadeDartQuery>,
+
+    /// Scope Dart code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub dart_query: Option<CustomDartQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct DockerfileScope {
+    /// Scope Dockerfile code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub dockerfile: Option<PremadeDockerfileQuery>,
+
+    /// Scope Dockerfile code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub dockerfile_query: Option<CustomDockerfileQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ElixirScope {
+    /// Scope Elixir code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub elixir: Option<PremadeElixirQuery>,
+
+    /// Scope Elixir code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub elixir_query: Option<CustomElixirQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ErlangScope {
+    /// Scope Erlang code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub erlang: Option<PremadeErlangQuery>,
+
+    /// Scope Erlang code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub erlang_query: Option<CustomErlangQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct GoScope {
+    /// Scope Go code using a premade query.
+    #[arg(
+        long,
+        env,
+        verbatim_doc_comment,
+        value_parser = FilterableParser::<CustomGoQuery, PremadeGoQuery>::new()
+    )]
+    pub go: Option<GoQuery>,
+
+    /// Scope Go code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub go_query: Option<CustomGoQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct GraphQLScope {
+    /// Scope GraphQL code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub graphql: Option<PremadeGraphQLQuery>,
+
+    /// Scope GraphQL code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub graphql_query: Option<CustomGraphQLQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct GroovyScope {
+    /// Scope Groovy code using a premade query.
+    #[arg(
+        long,
+        env,
+        verbatim_doc_comment,
+        value_parser = FilterableParser::<CustomGroovyQuery, PremadeGroovyQuery>::new()
+    )]
+    pub groovy: Option<GroovyQuery>,
+
+    /// Scope Groovy code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub groovy_query: Option<CustomGroovyQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct HaskellScope {
+    /// Scope Haskell code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub haskell: Option<PremadeHaskellQuery>,
+
+    /// Scope Haskell code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub haskell_query: Option<CustomHaskellQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct HtmlScope {
+    /// Scope HTML code using a premade query.
+    #[arg(
+        long,
+        env,
+        verbatim_doc_comment,
+        value_parser = FilterableParser::<CustomHtmlQuery, PremadeHtmlQuery>::new()
+    )]
+    pub html: Option<HtmlQuery>,
+
+    /// Scope HTML code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub html_query: Option<CustomHtmlQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct JavaScope {
+    /// Scope Java code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub java: Option<PremadeJavaQuery>,
+
+    /// Scope Java code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub java_query: Option<CustomJavaQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct JsonScope {
+    /// Scope JSON code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub json: Option<PremadeJsonQuery>,
+
+    /// Scope JSON code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub json_query: Option<CustomJsonQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct JuliaScope {
+    /// Scope Julia code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub julia: Option<PremadeJuliaQuery>,
+
+    /// Scope Julia code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub julia_query: Option<CustomJuliaQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct KotlinScope {
+    /// Scope Kotlin code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub kotlin: Option<PremadeKotlinQuery>,
+
+    /// Scope Kotlin code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub kotlin_query: Option<CustomKotlinQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct LuaScope {
+    /// Scope Lua code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub lua: Option<PremadeLuaQuery>,
+
+    /// Scope Lua code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub lua_query: Option<CustomLuaQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct MakefileScope {
+    /// Scope Makefile code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub makefile: Option<PremadeMakefileQuery>,
+
+    /// Scope Makefile code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub makefile_query: Option<CustomMakefileQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct MarkdownScope {
+    /// Scope Markdown code using a premade query.
+    #[arg(
+        long,
+        env,
+        verbatim_doc_comment,
+        value_parser = FilterableParser::<CustomMarkdownQuery, PremadeMarkdownQuery>::new()
+    )]
+    pub markdown: Option<MarkdownQuery>,
+
+    /// Scope Markdown code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub markdown_query: Option<CustomMarkdownQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct NixScope {
+    /// Scope Nix code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub nix: Option<PremadeNixQuery>,
+
+    /// Scope Nix code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub nix_query: Option<CustomNixQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ObjCScope {
+    /// Scope Objective-C code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub objc: Option<PremadeObjCQuery>,
+
+    /// Scope Objective-C code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub objc_query: Option<CustomObjCQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct OCamlScope {
+    /// Scope OCaml code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub ocaml: Option<PremadeOCamlQuery>,
+
+    /// Scope OCaml code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub ocaml_query: Option<CustomOCamlQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct PerlScope {
+    /// Scope Perl code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub perl: Option<PremadePerlQuery>,
+
+    /// Scope Perl code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub perl_query: Option<CustomPerlQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct PhpScope {
+    /// Scope PHP code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub php: Option<PremadePhpQuery>,
+
+    /// Scope PHP code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub php_query: Option<CustomPhpQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct PowerShellScope {
+    /// Scope PowerShell code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub powershell: Option<PremadePowerShellQuery>,
+
+    /// Scope PowerShell code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub powershell_query: Option<CustomPowerShellQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ProtoScope {
+    /// Scope Protobuf code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub proto: Option<PremadeProtoQuery>,
+
+    /// Scope Protobuf code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub proto_query: Option<CustomProtoQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct PythonScope {
+    /// Scope Python code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub python: Option<PremadePythonQuery>,
+
+    /// Scope Python code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub python_query: Option<CustomPythonQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct RubyScope {
+    /// Scope Ruby code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub ruby: Option<PremadeRubyQuery>,
+
+    /// Scope Ruby code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub ruby_query: Option<CustomRubyQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct RustScope {
+    /// Scope Rust code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub rust: Option<PremadeRustQuery>,
+
+    /// Scope Rust code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub rust_query: Option<CustomRustQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ScalaScope {
+    /// Scope Scala code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub scala: Option<PremadeScalaQuery>,
+
+    /// Scope Scala code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub scala_query: Option<CustomScalaQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ScssScope {
+    /// Scope SCSS code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub scss: Option<PremadeScssQuery>,
+
+    /// Scope SCSS code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub scss_query: Option<CustomScssQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct SolidityScope {
+    /// Scope Solidity code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub solidity: Option<PremadeSolidityQuery>,
+
+    /// Scope Solidity code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub solidity_query: Option<CustomSolidityQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct SqlScope {
+    /// Scope SQL code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub sql: Option<PremadeSqlQuery>,
+
+    /// Scope SQL code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub sql_query: Option<CustomSqlQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct SvelteScope {
+    /// Scope Svelte code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub svelte: Option<PremadeSvelteQuery>,
+
+    /// Scope Svelte code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub svelte_query: Option<CustomSvelteQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct SwiftScope {
+    /// Scope Swift code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub swift: Option<PremadeSwiftQuery>,
+
+    /// Scope Swift code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub swift_query: Option<CustomSwiftQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct TomlScope {
+    /// Scope TOML code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub toml: Option<PremadeTomlQuery>,
+
+    /// Scope TOML code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub toml_query: Option<CustomTomlQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct TypeScriptScope {
+    /// Scope TypeScript code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub typescript: Option<PremadeTypeScriptQuery>,
+
+    /// Scope TypeScript code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub typescript_query: Option<CustomTypeScriptQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct VueScope {
+    /// Scope Vue code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub vue: Option<PremadeVueQuery>,
+
+    /// Scope Vue code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub vue_query: Option<CustomVueQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct YamlScope {
+    /// Scope YAML code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub yaml: Option<PremadeYamlQuery>,
+
+    /// Scope YAML code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub yaml_query: Option<CustomYamlQuery>,
+}
+
+#[derive(Parser, Debug, Clone)]
+#[group(required = false, multiple = false)]
+pub(super) struct ZigScope {
+    /// Scope Zig code using a premade query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub zig: Option<PremadeZigQuery>,
+
+    /// Scope Zig code using a custom tree-sitter query.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub zig_query: Option<CustomZigQuery>,
+}
+
+#[cfg(feature = "german")]
+#[derive(Parser, Debug)]
+#[group(required = false, multiple = true, id("german-opts"))]
+#[command(next_help_heading = "Options (german)")]
+pub(super) struct GermanOptions {
+    /// When some original version and its replacement are equally legal, prefer the
+    /// original and do not modify.
+    ///
+    /// For example, "Busse" (original) and "Buße" (replacement) are equally legal
+    /// words: by default, the tool would prefer the latter.
+    // More fine-grained control is not available. We are not in the business of
+    // natural language processing or LLMs, so that's all we can offer...
+    #[arg(long, env, verbatim_doc_comment)]
+    pub german_prefer_original: bool,
+    /// Always perform any possible replacement ('ae' -> 'ä', 'ss' -> 'ß', etc.),
+    /// regardless of legality of the resulting word
+    ///
+    /// Useful for names, which are otherwise not modifiable as they do not occur in
+    /// dictionaries. Called 'naive' as this does not perform legal checks.
+    #[arg(long, env, verbatim_doc_comment)]
+    pub german_naive: bool,
+}
+
+impl Cli {
+    pub(super) fn init() -> Self {
+        Self::parse()
+    }
+
+    pub(super) fn command() -> clap::Command {
+        <Self as CommandFactory>::command()
+    }
+}
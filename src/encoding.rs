@@ -0,0 +1,86 @@
+//! Handling of text encodings and byte order marks (BOMs).
+//!
+//! A BOM is not part of the text proper: it is stripped before processing (so it
+//! cannot be scoped), and re-emitted unchanged afterwards.
+
+use anyhow::{bail, Result};
+
+/// A byte order mark, indicating the encoding of the text following it.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub(super) enum Bom {
+    Utf8,
+    Utf16Le,
+    Utf16Be,
+}
+
+impl Bom {
+    const fn bytes(self) -> &'static [u8] {
+        match self {
+            Self::Utf8 => &[0xEF, 0xBB, 0xBF],
+            Self::Utf16Le => &[0xFF, 0xFE],
+            Self::Utf16Be => &[0xFE, 0xFF],
+        }
+    }
+
+    fn detect(bytes: &[u8]) -> Option<Self> {
+        [Self::Utf8, Self::Utf16Le, Self::Utf16Be]
+            .into_iter()
+            .find(|bom| bytes.starts_with(bom.bytes()))
+    }
+}
+
+/// Decodes `bytes` into text, detecting and stripping any leading [`Bom`].
+///
+/// Without a BOM, UTF-8 is assumed.
+pub(super) fn decode(mut bytes: Vec<u8>) -> Result<(Option<Bom>, String)> {
+    let bom = Bom::detect(&bytes);
+    bytes.drain(..bom.map_or(0, |bom| bom.bytes().len()));
+
+    Ok((bom, decode_as(bytes, bom)?))
+}
+
+/// Decodes `bytes` into text, in the encoding indicated by `bom` (UTF-8 if
+/// missing), which was detected earlier on, such as at the start of a stream.
+///
+/// Bytes looking like a BOM are not stripped, but taken as part of the text.
+pub(super) fn decode_as(bytes: Vec<u8>, bom: Option<Bom>) -> Result<String> {
+    let text = match bom {
+        None | Some(Bom::Utf8) => String::from_utf8(bytes)?,
+        Some(bom @ (Bom::Utf16Le | Bom::Utf16Be)) => {
+            if bytes.len() % 2 != 0 {
+                bail!("Odd number of bytes for UTF-16 ({bom:?}) input");
+            }
+
+            let units = bytes
+                .chunks_exact(2)
+                .map(|pair| {
+                    let pair = [pair[0], pair[1]];
+                    if bom == Bom::Utf16Le {
+                        u16::from_le_bytes(pair)
+                    } else {
+                        u16::from_be_bytes(pair)
+                    }
+                })
+                .collect::<Vec<_>>();
+
+            String::from_utf16(&units)?
+        }
+    };
+
+    Ok(text)
+}
+
+/// Encodes `text` as indicated by `bom`, prepending that BOM.
+///
+/// Without a BOM, `text` is encoded as UTF-8.
+pub(super) fn encode(text: &str, bom: Option<Bom>) -> Vec<u8> {
+    let mut res = bom.map_or_else(Vec::new, |bom| bom.bytes().to_vec());
+
+    match bom {
+        None | Some(Bom::Utf8) => res.extend_from_slice(text.as_bytes()),
+        Some(Bom::Utf16Le) => res.extend(text.encode_utf16().flat_map(u16::to_le_bytes)),
+        Some(Bom::Utf16Be) => res.extend(text.encode_utf16().flat_map(u16::to_be_bytes)),
+    }
+
+    res
+}
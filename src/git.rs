@@ -0,0 +1,593 @@
+//! Asking git about the history of files, by running it as a subprocess, and about
+//! their attributes, by reading `.gitattributes` files.
+
+use anyhow::{bail, Context, Result};
+use log::warn;
+use srgn::scoping::regex::Regex;
+use std::{
+    collections::{HashMap, HashSet},
+    ffi::OsStr,
+    fs, io,
+    ops::Range,
+    path::{Component, Path, PathBuf},
+    process::Command,
+    sync::{Arc, Mutex},
+};
+
+/// Runs git with `args` in `dir`, returning its stdout.
+fn run<I, S>(dir: &Path, args: I) -> Result<String>
+where
+    I: IntoIterator<Item = S>,
+    S: AsRef<OsStr>,
+{
+    let output = Command::new("git")
+        .arg("-C")
+        .arg(dir)
+        .args(args)
+        .output()
+        .context("Failed to run git (is it installed?)")?;
+
+    if !output.status.success() {
+        bail!(
+            "git failed ({}): {}",
+            output.status,
+            String::from_utf8_lossy(&output.stderr).trim()
+        );
+    }
+
+    Ok(String::from_utf8_lossy(&output.stdout).into_owned())
+}
+
+/// The directory containing `path`, for running git in.
+fn dir_of(path: &Path) -> &Path {
+    match path.parent() {
+        Some(parent) if !parent.as_os_str().is_empty() => parent,
+        _ => Path::new("."),
+    }
+}
+
+/// Resolves `rev` to a revision to compare against.
+///
+/// Like for `git diff`, 'REV...' stands for where the current branch forked off
+/// 'REV', i.e. their merge base. Anything else is left as-is.
+pub(super) fn resolve(rev: &str) -> Result<String> {
+    match rev.strip_suffix("...") {
+        Some(rev) => {
+            let base = run(Path::new("."), ["merge-base", rev, "HEAD"])
+                .with_context(|| format!("Failed to find merge base with {rev}"))?;
+
+            Ok(base.trim().to_owned())
+        }
+        None => Ok(rev.to_owned()),
+    }
+}
+
+/// All lines of the file at `path` added or modified since revision `base`,
+/// uncommitted changes included. Lines are 1-based, ranges end-exclusive.
+///
+/// A file git does not track (and does not ignore) is changed entirely.
+pub(super) fn changed_lines(path: &Path, base: &str) -> Result<Vec<Range<usize>>> {
+    let dir = dir_of(path);
+    let name = path.file_name().context("Path has no file name")?;
+
+    let diff = run(
+        dir,
+        [
+            OsStr::new("diff"),
+            OsStr::new("--no-ext-diff"),
+            OsStr::new("--no-color"),
+            OsStr::new("--unified=0"),
+            OsStr::new(base),
+            OsStr::new("--"),
+            name,
+        ],
+    )?;
+    if !diff.is_empty() {
+        return Ok(hunk_lines(&diff));
+    }
+
+    let untracked = run(
+        dir,
+        [
+            OsStr::new("ls-files"),
+            OsStr::new("--others"),
+            OsStr::new("--exclude-standard"),
+            OsStr::new("--"),
+            name,
+        ],
+    )?;
+
+    Ok(if untracked.is_empty() {
+        Vec::new()
+    } else {
+        vec![1..usize::MAX]
+    })
+}
+
+/// Who last changed some line, as reported by `git blame`.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+struct Blame<'a> {
+    /// Line number, 1-based.
+    line: usize,
+    /// Name of the author of the last change.
+    author: &'a str,
+    /// Email address of `author`, in angle brackets.
+    mail: &'a str,
+    /// Whether the line was last changed before the time window blamed.
+    boundary: bool,
+}
+
+/// Blames of lines not committed yet, as named by git.
+const NOT_COMMITTED_YET: (&str, &str) = ("Not Committed Yet", "<not.committed.yet>");
+
+/// All lines of the file at `path` last changed by an author `author` matches (as
+/// 'Name <email>'), and no earlier than `since`, any date git understands. Lines
+/// are 1-based, ranges end-exclusive.
+///
+/// Lines not committed yet, including all of files git does not track, count as
+/// changed just now, by [`NOT_COMMITTED_YET`].
+pub(super) fn blamed_lines(
+    path: &Path,
+    author: Option<&Regex>,
+    since: Option<&str>,
+) -> Result<Vec<Range<usize>>> {
+    let name = path.file_name().context("Path has no file name")?;
+    let keep = |blame: &Blame| {
+        !blame.boundary
+            && author.map_or(true, |author| {
+                author.is_match(&format!("{} {}", blame.author, blame.mail))
+            })
+    };
+
+    let tracked = run(
+        dir_of(path),
+        [OsStr::new("ls-files"), OsStr::new("--"), name],
+    )?;
+    if tracked.is_empty() {
+        let (author, mail) = NOT_COMMITTED_YET;
+        let blame = Blame {
+            line: 1,
+            author,
+            mail,
+            boundary: false,
+        };
+
+        return Ok(if keep(&blame) {
+            vec![1..usize::MAX]
+        } else {
+            Vec::new()
+        });
+    }
+
+    // Commits are boundaries if older than `since`, root commits never are.
+    let since = since.map(|since| format!("--since={since}"));
+    let mut args = vec![
+        OsStr::new("blame"),
+        OsStr::new("--root"),
+        OsStr::new("--line-porcelain"),
+    ];
+    args.extend(since.as_deref().map(OsStr::new));
+    args.extend([OsStr::new("--"), name]);
+    let porcelain = run(dir_of(path), args)?;
+
+    let mut lines: Vec<Range<usize>> = Vec::new();
+    for blame in blames(&porcelain).filter(keep) {
+        match lines.last_mut() {
+            Some(last) if last.end == blame.line => last.end += 1,
+            _ => lines.push(blame.line..blame.line + 1),
+        }
+    }
+
+    Ok(lines)
+}
+
+/// All lines blamed in `porcelain`, the output of `git blame --line-porcelain`.
+fn blames(porcelain: &str) -> impl Iterator<Item = Blame<'_>> {
+    let mut current: Option<Blame> = None;
+
+    porcelain.lines().filter_map(move |line| {
+        if line.starts_with('\t') {
+            // The line's contents, ending its entry.
+            return current.take();
+        }
+
+        match line.split_once(' ') {
+            Some(("author", author)) => current.as_mut()?.author = author,
+            Some(("author-mail", mail)) => current.as_mut()?.mail = mail,
+            _ if line == "boundary" => current.as_mut()?.boundary = true,
+            // Headers of entries: the commit, then line numbers in the original
+            // and final file.
+            Some((commit, numbers)) if commit.len() >= 40 => {
+                let line = numbers.split(' ').nth(1)?.parse().ok()?;
+                current = Some(Blame {
+                    line,
+                    author: "",
+                    mail: "",
+                    boundary: false,
+                });
+            }
+            _ => {}
+        }
+
+        None
+    })
+}
+
+/// All files changed by commits in `range` (such as 'A..B'), as far as they are
+/// within the working directory, relative to it.
+pub(super) fn files_changed_in(range: &str) -> Result<HashSet<PathBuf>> {
+    let log = run(
+        Path::new("."),
+        ["log", "--format=", "--name-only", "--relative", range, "--"],
+    )?;
+
+    Ok(log
+        .lines()
+        .filter(|line| !line.is_empty())
+        .map(normalized)
+        .collect())
+}
+
+/// `path` without any '.' components, for comparing relative paths.
+pub(super) fn normalized(path: impl AsRef<Path>) -> PathBuf {
+    path.as_ref()
+        .components()
+        .filter(|component| *component != Component::CurDir)
+        .collect()
+}
+
+/// Whether the file at `path` has any changes staged for commit.
+pub(super) fn is_staged(path: &Path) -> Result<bool> {
+    let name = path.file_name().context("Path has no file name")?;
+
+    let staged = run(
+        dir_of(path),
+        [
+            OsStr::new("diff"),
+            OsStr::new("--cached"),
+            OsStr::new("--name-only"),
+            OsStr::new("--"),
+            name,
+        ],
+    )?;
+
+    Ok(!staged.is_empty())
+}
+
+/// All lines of the file at `path` with changes staged for commit, and no further
+/// unstaged ones. Lines are 1-based (as in the working tree), ranges end-exclusive.
+pub(super) fn staged_lines(path: &Path) -> Result<Vec<Range<usize>>> {
+    if !is_staged(path)? {
+        return Ok(Vec::new());
+    }
+
+    let name = path.file_name().context("Path has no file name")?;
+    // Of the working tree compared to the index.
+    let unstaged = hunk_lines(&run(
+        dir_of(path),
+        [
+            OsStr::new("diff"),
+            OsStr::new("--no-ext-diff"),
+            OsStr::new("--no-color"),
+            OsStr::new("--unified=0"),
+            OsStr::new("--"),
+            name,
+        ],
+    )?);
+
+    Ok(changed_lines(path, "HEAD")?
+        .into_iter()
+        .flat_map(|range| subtract(range, &unstaged))
+        .collect())
+}
+
+/// The parts of `range` not covered by any of `holes`.
+fn subtract(range: Range<usize>, holes: &[Range<usize>]) -> Vec<Range<usize>> {
+    holes.iter().fold(vec![range], |pieces, hole| {
+        pieces
+            .into_iter()
+            .flat_map(|piece| {
+                [
+                    piece.start..piece.end.min(hole.start),
+                    piece.start.max(hole.end)..piece.end,
+                ]
+            })
+            .filter(|piece| !piece.is_empty())
+            .collect()
+    })
+}
+
+/// Attributes marking files as not written by hand, as used by GitHub's Linguist.
+const LINGUIST: [&str; 2] = ["linguist-generated", "linguist-vendored"];
+
+/// What is known about a directory, for finding the attributes of files within.
+#[derive(Debug, Clone)]
+struct Dir {
+    /// Whether it is the root of a repository.
+    is_root: bool,
+    /// Contents of its `.gitattributes` file, if any.
+    attributes: Option<Arc<str>>,
+}
+
+/// Answers whether files are marked generated or vendored in `.gitattributes` files.
+///
+/// Walking many files, most share the directories leading up to them. Hence, each
+/// directory is looked at once only, no matter how many files it holds.
+#[derive(Debug, Default)]
+pub(super) struct Attributes {
+    dirs: Mutex<HashMap<PathBuf, Dir>>,
+}
+
+impl Attributes {
+    /// Whether the file at `path` is marked generated or vendored in
+    /// `.gitattributes` files of its repository.
+    ///
+    /// Outside of repositories, no file is. Only `.gitattributes` files in the
+    /// directories leading up to the file are consulted. Files whose attributes
+    /// cannot be read are taken to be unmarked, with a warning.
+    pub(super) fn is_generated(&self, path: &Path) -> bool {
+        let path = match fs::canonicalize(path) {
+            Ok(path) => path,
+            Err(e) => {
+                warn!(
+                    "Failed to resolve path {:?}, taking it as not generated: {}",
+                    path, e
+                );
+                return false;
+            }
+        };
+
+        let mut dirs = Vec::new();
+        let mut in_repository = false;
+        for dir in path.ancestors().skip(1) {
+            let info = self.dir(dir);
+            dirs.push((dir, info.attributes));
+            if info.is_root {
+                in_repository = true;
+                break;
+            }
+        }
+        if !in_repository {
+            return false;
+        }
+
+        // Outermost first, as deeper ones take precedence.
+        let mut marked = [false; LINGUIST.len()];
+        for (dir, attributes) in dirs.into_iter().rev() {
+            let Some(attributes) = attributes else {
+                continue;
+            };
+
+            let relative = path.strip_prefix(dir).expect("Ancestors prefix their path");
+            for (attribute, value) in linguist_assignments(&attributes, relative) {
+                if let Some(i) = LINGUIST.iter().position(|a| *a == attribute) {
+                    marked[i] = value;
+                }
+            }
+        }
+
+        marked.contains(&true)
+    }
+
+    /// What is known about `dir`, looking at it if not done before.
+    fn dir(&self, dir: &Path) -> Dir {
+        if let Some(info) = self
+            .dirs
+            .lock()
+            .expect("No panics while holding lock")
+            .get(dir)
+        {
+            return info.clone();
+        }
+
+        let attributes_path = dir.join(".gitattributes");
+        let attributes = match fs::read_to_string(&attributes_path) {
+            Ok(attributes) => Some(attributes.into()),
+            Err(e) if e.kind() == io::ErrorKind::NotFound => None,
+            Err(e) => {
+                warn!("Failed to read {:?}, ignoring it: {}", attributes_path, e);
+                None
+            }
+        };
+        let info = Dir {
+            is_root: dir.join(".git").exists(),
+            attributes,
+        };
+
+        self.dirs
+            .lock()
+            .expect("No panics while holding lock")
+            .insert(dir.to_owned(), info.clone());
+
+        info
+    }
+}
+
+/// In order, all values `attributes`, the contents of a `.gitattributes` file,
+/// assign to [`LINGUIST`] attributes of the file at `relative` (to it) path.
+///
+/// Unsetting ('-attr') or leaving unspecified ('!attr') assigns false, any value
+/// but 'false' true.
+fn linguist_assignments<'a>(attributes: &'a str, relative: &Path) -> Vec<(&'a str, bool)> {
+    let relative = relative.to_string_lossy().replace('\\', "/");
+    let name = relative.rsplit('/').next().unwrap_or_default();
+    let options = glob::MatchOptions {
+        require_literal_separator: true,
+        ..Default::default()
+    };
+
+    let matches = |pattern: &str| {
+        // Patterns never match directories, only files within them.
+        if pattern.ends_with('/') {
+            return false;
+        }
+
+        if let Some(dir) = pattern.strip_suffix("/**") {
+            let dir = dir.trim_start_matches('/');
+            return relative.starts_with(dir) && relative[dir.len()..].starts_with('/');
+        }
+
+        // As for '.gitignore', patterns with a separator are relative to the
+        // `.gitattributes` file, and others match file names anywhere.
+        let (pattern, target) = if pattern.contains('/') {
+            (pattern.trim_start_matches('/'), relative.as_str())
+        } else {
+            (pattern, name)
+        };
+        glob::Pattern::new(pattern).is_ok_and(|p| p.matches_with(target, options))
+    };
+
+    attributes
+        .lines()
+        .filter_map(|line| {
+            let mut tokens = line.split_whitespace();
+            let pattern = tokens.next().filter(|p| !p.starts_with('#'))?;
+
+            matches(pattern).then_some(tokens)
+        })
+        .flatten()
+        .filter_map(|token| {
+            let (attribute, value) = match token.split_once('=') {
+                Some((attribute, value)) => (attribute, value != "false"),
+                None => match token.strip_prefix(&['-', '!'][..]) {
+                    Some(attribute) => (attribute, false),
+                    None => (token, true),
+                },
+            };
+
+            LINGUIST.contains(&attribute).then_some((attribute, value))
+        })
+        .collect()
+}
+
+/// Lines of the new side of all hunks in `diff`, a unified diff without context.
+///
+/// Hunks only removing lines leave nothing on the new side, so are skipped.
+fn hunk_lines(diff: &str) -> Vec<Range<usize>> {
+    diff.lines()
+        .filter_map(|line| {
+            // For example '@@ -12,3 +14,5 @@ fn foo() {', where counts of 1 are
+            // left out.
+            let new = line.strip_prefix("@@ ")?.split(' ').nth(1)?;
+            let (start, count) = match new.strip_prefix('+')?.split_once(',') {
+                Some((start, count)) => (start.parse().ok()?, count.parse().ok()?),
+                None => (new[1..].parse().ok()?, 1),
+            };
+
+            (count > 0).then(|| start..start + count)
+        })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use rstest::rstest;
+
+    #[test]
+    fn test_blames() {
+        let porcelain = "\
+e90103b9615cdd5e0fdb96ee7bfbd40f22fcf02a 1 1 1
+author Old
+author-mail <old@example.com>
+author-time 1577836800
+summary Initial
+boundary
+filename a.txt
+\t1
+4e50e737ebe7bc2b75ec4b0e046268b76bb900ad 2 2 2
+author New
+author-mail <new@example.com>
+previous e90103b9615cdd5e0fdb96ee7bfbd40f22fcf02a a.txt
+filename a.txt
+\tb
+4e50e737ebe7bc2b75ec4b0e046268b76bb900ad 3 3
+author New
+author-mail <new@example.com>
+filename a.txt
+\t3
+";
+
+        let blames = blames(porcelain)
+            .map(|b| (b.line, b.author, b.mail, b.boundary))
+            .collect::<Vec<_>>();
+
+        assert_eq!(
+            blames,
+            vec![
+                (1, "Old", "<old@example.com>", true),
+                (2, "New", "<new@example.com>", false),
+                (3, "New", "<new@example.com>", false),
+            ]
+        );
+    }
+
+    #[rstest]
+    #[case("", "a.rs", vec![])]
+    #[case("*.rs linguist-generated", "a.rs", vec![("linguist-generated", true)])]
+    #[case("*.rs linguist-generated", "src/a.rs", vec![("linguist-generated", true)])]
+    #[case("*.rs linguist-generated", "a.py", vec![])]
+    #[case("# *.rs linguist-generated", "a.rs", vec![])]
+    #[case("*.rs text linguist-vendored=true", "a.rs", vec![("linguist-vendored", true)])]
+    #[case("*.rs linguist-generated=false", "a.rs", vec![("linguist-generated", false)])]
+    #[case("*.rs -linguist-generated", "a.rs", vec![("linguist-generated", false)])]
+    #[case("*.rs !linguist-generated", "a.rs", vec![("linguist-generated", false)])]
+    #[case(
+        "*.rs linguist-generated\nkeep.rs -linguist-generated",
+        "keep.rs",
+        vec![("linguist-generated", true), ("linguist-generated", false)]
+    )]
+    #[case("gen/* linguist-generated", "gen/a.rs", vec![("linguist-generated", true)])]
+    #[case("gen/* linguist-generated", "src/gen/a.rs", vec![])]
+    #[case("gen/* linguist-generated", "gen/sub/a.rs", vec![])]
+    #[case("/gen/** linguist-generated", "gen/sub/a.rs", vec![("linguist-generated", true)])]
+    #[case("gen/** linguist-generated", "generated/a.rs", vec![])]
+    #[case("**/gen/*.rs linguist-generated", "src/gen/a.rs", vec![("linguist-generated", true)])]
+    #[case("gen/ linguist-generated", "gen/a.rs", vec![])]
+    fn test_linguist_assignments(
+        #[case] attributes: &str,
+        #[case] relative: &str,
+        #[case] expected: Vec<(&str, bool)>,
+    ) {
+        assert_eq!(
+            linguist_assignments(attributes, Path::new(relative)),
+            expected
+        );
+    }
+
+    #[test]
+    fn test_attributes_read_once() {
+        let dir = tempfile::tempdir().unwrap();
+        fs::create_dir_all(dir.path().join(".git")).unwrap();
+        fs::create_dir_all(dir.path().join("gen")).unwrap();
+        fs::write(
+            dir.path().join(".gitattributes"),
+            "gen/** linguist-generated\n",
+        )
+        .unwrap();
+        for name in ["a.rs", "gen/a.rs", "gen/b.rs"] {
+            fs::write(dir.path().join(name), "").unwrap();
+        }
+
+        let attributes = Attributes::default();
+        assert!(attributes.is_generated(&dir.path().join("gen/a.rs")));
+
+        // Not looked at again.
+        fs::remove_file(dir.path().join(".gitattributes")).unwrap();
+        assert!(attributes.is_generated(&dir.path().join("gen/b.rs")));
+        assert!(!attributes.is_generated(&dir.path().join("a.rs")));
+    }
+
+    #[rstest]
+    #[case("", vec![])]
+    #[case("@@ -1 +1 @@\n-a\n+b\n", vec![1..2])]
+    #[case("@@ -12,3 +14,5 @@ fn foo() {\n", vec![14..19])]
+    #[case("@@ -3,2 +2,0 @@\n-a\n-b\n", vec![])]
+    #[case(
+        "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -0,0 +1,2 @@\n+a\n+b\n@@ -5 +7 @@\n-c\n+d\n",
+        vec![1..3, 7..8]
+    )]
+    fn test_hunk_lines(#[case] diff: &str, #[case] expected: Vec<Range<usize>>) {
+        assert_eq!(hunk_lines(diff), expected);
+    }
+}
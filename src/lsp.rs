@@ -0,0 +1,714 @@
+//! A minimal language server, run via `srgn lsp`.
+//!
+//! Speaks the [Language Server
+//! Protocol](https://microsoft.github.io/language-server-protocol/) over stdin and
+//! stdout. The stages of a [recipe](super::recipe) serve as configuration: for open
+//! documents a stage's `files` glob (and language, if any) matches, parts in scope
+//! are published as diagnostics. Code actions apply the stage's actions, to a single
+//! match or the entire document.
+//!
+//! Documents are synchronized incrementally. For stages with a language, each open
+//! document keeps a [`Session`] around, so that on changes only the changed region
+//! is re-parsed, instead of the entire document. Globs are matched against paths
+//! relative to the working directory, as for recipes.
+
+use super::{apply, is_in_language, recipe, report};
+use anyhow::{bail, Context, Result};
+use log::{debug, info, warn};
+use serde::{Deserialize, Serialize};
+use serde_json::{json, Value};
+use srgn::{scoping::langs::NodeScoper, session::Session};
+use std::{
+    collections::HashMap,
+    io::{self, BufRead, Write},
+    ops::Range,
+    path::{Path, PathBuf},
+    sync::Arc,
+};
+
+/// JSON-RPC error code for requests of unknown methods.
+const METHOD_NOT_FOUND: i64 = -32601;
+
+/// A position in a document, as the protocol counts: zero-based lines, and
+/// characters in UTF-16 code units.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+struct Position {
+    line: u32,
+    character: u32,
+}
+
+/// A range in a document, between two [`Position`]s.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+struct Span {
+    start: Position,
+    end: Position,
+}
+
+/// An incoming request, notification or response.
+#[derive(Debug, Deserialize)]
+struct Message {
+    id: Option<Value>,
+    method: Option<String>,
+    #[serde(default)]
+    params: Value,
+}
+
+#[derive(Debug, Deserialize)]
+#[serde(rename_all = "camelCase")]
+struct Document {
+    uri: String,
+    text: Option<String>,
+}
+
+#[derive(Debug, Deserialize)]
+#[serde(rename_all = "camelCase")]
+struct DocumentParams {
+    text_document: Document,
+    #[serde(default)]
+    content_changes: Vec<Change>,
+    range: Option<Span>,
+}
+
+/// A change to a document, replacing `range` by `text`, or everything if no range
+/// is given.
+#[derive(Debug, Deserialize)]
+struct Change {
+    range: Option<Span>,
+    text: String,
+}
+
+#[derive(Debug, Serialize)]
+struct Diagnostic {
+    range: Span,
+    severity: u8,
+    source: &'static str,
+    message: String,
+}
+
+/// An open document.
+struct Open {
+    text: String,
+    /// Sessions keeping the syntax tree of `text` around, by index of the stage
+    /// whose language they are in.
+    sessions: HashMap<usize, Session<Arc<dyn NodeScoper>>>,
+}
+
+struct Server {
+    stages: Vec<recipe::Compiled>,
+    root: PathBuf,
+    /// Open documents, by URI.
+    documents: HashMap<String, Open>,
+    shutdown: bool,
+}
+
+/// Serves the stages of the recipe at `path` over stdin and stdout, until the
+/// client asks to exit (or disconnects).
+pub(super) fn serve(path: &Path) -> Result<()> {
+    let mut server = Server::new(
+        recipe::compile_all(path)?,
+        std::env::current_dir().context("Failed to get working directory")?,
+    );
+    info!("Serving {} stage(s)", server.stages.len());
+
+    let mut input = io::stdin().lock();
+    let mut output = io::stdout().lock();
+
+    while let Some(message) = read_message(&mut input)? {
+        let message: Message = match serde_json::from_value(message) {
+            Ok(message) => message,
+            Err(e) => {
+                warn!("Ignoring malformed message: {e}");
+                continue;
+            }
+        };
+        let Some(method) = message.method else {
+            debug!("Ignoring response from client");
+            continue;
+        };
+        debug!("Received {method}");
+
+        if method == "exit" {
+            if server.shutdown {
+                return Ok(());
+            }
+            bail!("Client exited without shutting down first");
+        }
+
+        for outgoing in server.handle(&method, message.params, message.id) {
+            write_message(&mut output, &outgoing).context("Failed writing to client")?;
+        }
+    }
+
+    info!("Client disconnected");
+    Ok(())
+}
+
+impl Server {
+    /// A server for `stages`, matching their globs relative to `root`.
+    fn new(stages: Vec<recipe::Compiled>, root: PathBuf) -> Self {
+        Self {
+            stages,
+            root,
+            documents: HashMap::new(),
+            shutdown: false,
+        }
+    }
+
+    /// Handles a single message, returning all messages to send in return.
+    fn handle(&mut self, method: &str, params: Value, id: Option<Value>) -> Vec<Value> {
+        let result = match (method, serde_json::from_value::<DocumentParams>(params)) {
+            ("initialize", _) => json!({
+                "capabilities": {
+                    "textDocumentSync": 2, // Incremental
+
+                    "codeActionProvider": true,
+                },
+                "serverInfo": {
+                    "name": env!("CARGO_PKG_NAME"),
+                    "version": env!("CARGO_PKG_VERSION"),
+                },
+            }),
+            ("shutdown", _) => {
+                self.shutdown = true;
+                Value::Null
+            }
+            ("textDocument/didOpen", Ok(params)) => {
+                let Document { uri, text } = params.text_document;
+                let document = Open {
+                    text: text.unwrap_or_default(),
+                    sessions: HashMap::new(),
+                };
+                self.documents.insert(uri.clone(), document);
+                self.start_sessions(&uri);
+                return vec![self.diagnostics(&uri)];
+            }
+            ("textDocument/didChange", Ok(params)) => {
+                let uri = params.text_document.uri;
+                self.change(&uri, params.content_changes);
+                self.start_sessions(&uri);
+                return vec![self.diagnostics(&uri)];
+            }
+            ("textDocument/didClose", Ok(params)) => {
+                let uri = params.text_document.uri;
+                self.documents.remove(&uri);
+                return vec![notification(
+                    "textDocument/publishDiagnostics",
+                    json!({ "uri": uri, "diagnostics": [] }),
+                )];
+            }
+            ("textDocument/codeAction", Ok(params)) => {
+                let uri = params.text_document.uri;
+                match params.range {
+                    Some(range) => Value::Array(self.code_actions(&uri, range)),
+                    None => Value::Array(Vec::new()),
+                }
+            }
+            _ => {
+                let Some(id) = id else {
+                    debug!("Ignoring notification: {method}");
+                    return Vec::new();
+                };
+
+                return vec![json!({
+                    "jsonrpc": "2.0",
+                    "id": id,
+                    "error": {
+                        "code": METHOD_NOT_FOUND,
+                        "message": format!("Method not supported: {method}"),
+                    },
+                })];
+            }
+        };
+
+        match id {
+            Some(id) => vec![json!({ "jsonrpc": "2.0", "id": id, "result": result })],
+            None => Vec::new(),
+        }
+    }
+
+    /// Applies `changes` to the open document at `uri`, in order, feeding them into
+    /// its sessions as well.
+    fn change(&mut self, uri: &str, changes: Vec<Change>) {
+        let Some(document) = self.documents.get_mut(uri) else {
+            warn!("Ignoring changes to document not open: {uri}");
+            return;
+        };
+
+        for Change { range, text } in changes {
+            let range = range.map(|range| {
+                offset(&document.text, range.start)..offset(&document.text, range.end)
+            });
+            document
+                .text
+                .replace_range(range.clone().unwrap_or(0..document.text.len()), &text);
+
+            document.sessions.retain(|i, session| {
+                let updated = match &range {
+                    Some(range) => session.edit(range.clone(), &text),
+                    // Still only re-parses what actually changed.
+                    None => session.update(&document.text),
+                };
+
+                updated
+                    .map_err(|e| warn!("Dropping session of stage {}: {e}", i + 1))
+                    .is_ok()
+            });
+        }
+    }
+
+    /// Starts sessions for all stages with a language applicable to the open
+    /// document at `uri` which do not have one yet.
+    fn start_sessions(&mut self, uri: &str) {
+        let missing: Vec<_> = self
+            .stages_for(uri)
+            .0
+            .into_iter()
+            .filter_map(|(i, stage)| Some((i, Arc::clone(stage.syntax.as_ref()?))))
+            .collect();
+        let Some(document) = self.documents.get_mut(uri) else {
+            return;
+        };
+
+        for (i, syntax) in missing {
+            if document.sessions.contains_key(&i) {
+                continue;
+            }
+
+            match Session::new(syntax, document.text.clone()) {
+                Ok(session) => {
+                    document.sessions.insert(i, session);
+                }
+                Err(e) => warn!("Failed to start session of stage {}: {e}", i + 1),
+            }
+        }
+    }
+
+    /// Stages applicable to the open document at `uri` (with their index), alongside
+    /// its contents.
+    fn stages_for(&self, uri: &str) -> (Vec<(usize, &recipe::Compiled)>, &str) {
+        let source = self
+            .documents
+            .get(uri)
+            .map_or("", |document| document.text.as_str());
+        let Some(path) = path_of(uri) else {
+            return (Vec::new(), source);
+        };
+        let relative = path.strip_prefix(&self.root).unwrap_or(&path);
+
+        let stages = self
+            .stages
+            .iter()
+            .enumerate()
+            .filter(|(_, stage)| stage.files.matches_path(relative))
+            .filter(|(_, stage)| match &stage.language {
+                Some((language, _)) => is_in_language(relative, source, *language, &[]),
+                None => true,
+            })
+            .collect();
+
+        (stages, source)
+    }
+
+    /// The session of the open document at `uri` for the stage at index `stage`, if
+    /// any.
+    fn session(&self, uri: &str, stage: usize) -> Option<&Session<Arc<dyn NodeScoper>>> {
+        self.documents.get(uri)?.sessions.get(&stage)
+    }
+
+    /// A notification publishing all parts in scope of the document at `uri`.
+    fn diagnostics(&self, uri: &str) -> Value {
+        let (stages, source) = self.stages_for(uri);
+
+        let diagnostics: Vec<_> = stages
+            .into_iter()
+            .flat_map(|(i, stage)| {
+                matches(source, stage, self.session(uri, i))
+                    .into_iter()
+                    .map(move |range| Diagnostic {
+                        range: span(source, range),
+                        severity: 3, // Information
+                        source: env!("CARGO_PKG_NAME"),
+                        message: format!("In scope of stage {}", stage.name),
+                    })
+            })
+            .collect();
+        debug!("Publishing {} diagnostic(s) for {uri}", diagnostics.len());
+
+        notification(
+            "textDocument/publishDiagnostics",
+            json!({ "uri": uri, "diagnostics": diagnostics }),
+        )
+    }
+
+    /// Code actions applying stages to parts in scope touching `range`, and to the
+    /// entire document.
+    fn code_actions(&self, uri: &str, range: Span) -> Vec<Value> {
+        let (stages, source) = self.stages_for(uri);
+        let range = offset(source, range.start)..offset(source, range.end);
+
+        let mut actions = Vec::new();
+        for (i, stage) in stages {
+            let matches = matches(source, stage, self.session(uri, i));
+
+            for m in matches.iter().filter(|m| touches(m, &range)) {
+                if let Some(edit) = edit(source, stage, Some(m.clone())) {
+                    actions.push(json!({
+                        "title": format!("Apply stage {}", stage.name),
+                        "kind": "quickfix",
+                        "edit": { "changes": { uri: [edit] } },
+                    }));
+                }
+            }
+
+            if matches.iter().any(|m| touches(m, &range)) {
+                if let Some(edit) = edit(source, stage, None) {
+                    actions.push(json!({
+                        "title": format!("Apply stage {} to entire file", stage.name),
+                        "kind": "source.fixAll",
+                        "edit": { "changes": { uri: [edit] } },
+                    }));
+                }
+            }
+        }
+
+        actions
+    }
+}
+
+/// Byte ranges of all parts of `source` in scope of `stage`, reusing the syntax tree
+/// of `session` (holding `source`) if given.
+fn matches(
+    source: &str,
+    stage: &recipe::Compiled,
+    session: Option<&Session<Arc<dyn NodeScoper>>>,
+) -> Vec<Range<usize>> {
+    let (source, builder) = match session {
+        Some(session) => {
+            let mut builder = session.scope();
+            for scoper in &stage.scopers {
+                builder.explode(scoper);
+            }
+
+            (session.source(), builder)
+        }
+        None => {
+            let language_scoper = stage.language.as_ref().map(|(_, scoper)| scoper);
+            let builder = super::scope(source, language_scoper, &stage.scopers);
+
+            (source, builder)
+        }
+    };
+
+    report::matches(source, builder)
+        .into_iter()
+        .map(|m| m.range)
+        .collect()
+}
+
+/// Whether `m` overlaps `range`, or touches it (for empty ranges, i.e. cursors).
+fn touches(m: &Range<usize>, range: &Range<usize>) -> bool {
+    m.start <= range.end && range.start <= m.end
+}
+
+/// An edit applying the actions of `stage` to `source`, only to parts in scope
+/// `within` the given byte range if any, or [`None`] if nothing would change.
+fn edit(source: &str, stage: &recipe::Compiled, within: Option<Range<usize>>) -> Option<Value> {
+    let language_scoper = stage.language.as_ref().map(|(_, scoper)| scoper);
+    let within = within.map(|range| vec![range]);
+
+    let mut result = Vec::new();
+    let applied = apply(
+        source,
+        None,
+        &mut result,
+        language_scoper,
+        &stage.scopers,
+        &stage.actions,
+        false,
+        false,
+        stage.squeeze,
+        false,
+        within.as_deref(),
+        None,
+    );
+
+    match applied {
+        Ok(applied) if applied.changed => {
+            let result = String::from_utf8(result).ok()?;
+            let (range, new_text) = difference(source, &result);
+
+            Some(json!({ "range": span(source, range), "newText": new_text }))
+        }
+        Ok(_) => None,
+        Err(e) => {
+            warn!("Failed to apply stage {}: {e:#}", stage.name);
+            None
+        }
+    }
+}
+
+/// The byte range of `old` to replace, and what to replace it with, to arrive at
+/// `new`.
+///
+/// Common prefixes and suffixes are left out, so edits stay small and editors can
+/// keep cursors and markers in place.
+pub(super) fn difference<'a>(old: &str, new: &'a str) -> (Range<usize>, &'a str) {
+    let prefix: usize = old
+        .chars()
+        .zip(new.chars())
+        .take_while(|(a, b)| a == b)
+        .map(|(c, _)| c.len_utf8())
+        .sum();
+    let suffix: usize = old[prefix..]
+        .chars()
+        .rev()
+        .zip(new[prefix..].chars().rev())
+        .take_while(|(a, b)| a == b)
+        .map(|(c, _)| c.len_utf8())
+        .sum();
+
+    (prefix..old.len() - suffix, &new[prefix..new.len() - suffix])
+}
+
+fn span(source: &str, range: Range<usize>) -> Span {
+    Span {
+        start: position(source, range.start),
+        end: position(source, range.end),
+    }
+}
+
+/// The protocol's [`Position`] of byte `offset` into `source`.
+fn position(source: &str, offset: usize) -> Position {
+    let before = &source[..offset];
+    let line_start = before.rfind('\n').map_or(0, |i| i + 1);
+
+    Position {
+        line: u32::try_from(before.matches('\n').count()).unwrap_or(u32::MAX),
+        character: u32::try_from(before[line_start..].encode_utf16().count()).unwrap_or(u32::MAX),
+    }
+}
+
+/// Inverse of [`position`].
+///
+/// Positions past the end of their line (or of `source`) are clamped to it, as the
+/// protocol demands.
+fn offset(source: &str, position: Position) -> usize {
+    let line_start = source
+        .split_inclusive('\n')
+        .take(position.line as usize)
+        .map(str::len)
+        .sum::<usize>();
+
+    let mut units = 0;
+    source[line_start..]
+        .char_indices()
+        .find(|(_, c)| {
+            units += c.len_utf16();
+            *c == '\n' || units > position.character as usize
+        })
+        .map_or(source.len(), |(i, _)| line_start + i)
+}
+
+/// The local path a `file://` URI refers to, if it is one.
+fn path_of(uri: &str) -> Option<PathBuf> {
+    let path = uri.strip_prefix("file://")?;
+    // An empty or `localhost` authority both mean the local machine.
+    let path = path.strip_prefix("localhost").unwrap_or(path);
+
+    let mut bytes = Vec::with_capacity(path.len());
+    let mut rest = path.as_bytes();
+    while let Some((&byte, tail)) = rest.split_first() {
+        let decoded = (byte == b'%')
+            .then(|| tail.get(..2))
+            .flatten()
+            .and_then(|hex| u8::from_str_radix(std::str::from_utf8(hex).ok()?, 16).ok());
+
+        match decoded {
+            Some(decoded) => {
+                bytes.push(decoded);
+                rest = &tail[2..];
+            }
+            None => {
+                bytes.push(byte);
+                rest = tail;
+            }
+        }
+    }
+
+    let path = String::from_utf8(bytes).ok()?;
+    // Drive letters on Windows come after a slash, as in `file:///C:/...`.
+    let path = match path.as_bytes() {
+        [b'/', _, b':', ..] if cfg!(windows) => &path[1..],
+        _ => &path,
+    };
+
+    Some(PathBuf::from(path))
+}
+
+fn notification(method: &str, params: Value) -> Value {
+    json!({ "jsonrpc": "2.0", "method": method, "params": params })
+}
+
+/// Reads a single message, framed by a `Content-Length` header, or [`None`] at the
+/// end of input.
+fn read_message(reader: &mut impl BufRead) -> Result<Option<Value>> {
+    let mut length = None;
+
+    loop {
+        let mut header = String::new();
+        if reader.read_line(&mut header)? == 0 {
+            return Ok(None);
+        }
+
+        let header = header.trim_end();
+        if header.is_empty() {
+            break;
+        }
+
+        if let Some(value) = header.strip_prefix("Content-Length:") {
+            length = Some(
+                value
+                    .trim()
+                    .parse::<usize>()
+                    .with_context(|| format!("Invalid header: {header}"))?,
+            );
+        }
+    }
+
+    let Some(length) = length else {
+        bail!("Message without Content-Length header");
+    };
+
+    let mut content = vec![0; length];
+    reader.read_exact(&mut content)?;
+
+    serde_json::from_slice(&content)
+        .map(Some)
+        .context("Message is not valid JSON")
+}
+
+fn write_message(writer: &mut impl Write, message: &Value) -> io::Result<()> {
+    let content = serde_json::to_vec(message)?;
+
+    write!(writer, "Content-Length: {}\r\n\r\n", content.len())?;
+    writer.write_all(&content)?;
+    writer.flush()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use rstest::rstest;
+    use std::fs;
+
+    #[rstest]
+    #[case("", 0, (0, 0))]
+    #[case("abc", 2, (0, 2))]
+    #[case("abc\ndef", 4, (1, 0))]
+    #[case("abc\ndef", 7, (1, 3))]
+    #[case("abc\r\ndef", 6, (1, 1))]
+    #[case("äb", 2, (0, 1))]
+    #[case("😀b", 4, (0, 2))]
+    fn test_lsp_position(#[case] source: &str, #[case] index: usize, #[case] expected: (u32, u32)) {
+        let position = position(source, index);
+        assert_eq!((position.line, position.character), expected);
+
+        assert_eq!(offset(source, position), index);
+    }
+
+    #[rstest]
+    #[case("abc\ndef", (0, 10), 3)]
+    #[case("abc\ndef", (1, 10), 7)]
+    #[case("abc\ndef", (5, 0), 7)]
+    #[case("😀b", (0, 1), 0)]
+    fn test_lsp_offset_clamped(
+        #[case] source: &str,
+        #[case] (line, character): (u32, u32),
+        #[case] expected: usize,
+    ) {
+        assert_eq!(offset(source, Position { line, character }), expected);
+    }
+
+    #[rstest]
+    #[case("abc", "abc", 3..3, "")]
+    #[case("abc", "aXc", 1..2, "X")]
+    #[case("abc", "abXc", 2..2, "X")]
+    #[case("abc", "", 0..3, "")]
+    #[case("aa", "aaa", 2..2, "a")]
+    #[case("äöü", "äü", 2..4, "")]
+    fn test_lsp_difference(
+        #[case] old: &str,
+        #[case] new: &str,
+        #[case] expected_range: Range<usize>,
+        #[case] expected_text: &str,
+    ) {
+        assert_eq!(difference(old, new), (expected_range, expected_text));
+    }
+
+    #[rstest]
+    #[case("file:///home/a.py", Some("/home/a.py"))]
+    #[case("file://localhost/home/a.py", Some("/home/a.py"))]
+    #[case("file:///home/my%20file.py", Some("/home/my file.py"))]
+    #[case("file:///home/%C3%A4.py", Some("/home/ä.py"))]
+    #[case("file:///home/100%.py", Some("/home/100%.py"))]
+    #[case("untitled:Untitled-1", None)]
+    fn test_lsp_path_of(#[case] uri: &str, #[case] expected: Option<&str>) {
+        assert_eq!(path_of(uri), expected.map(PathBuf::from));
+    }
+
+    #[test]
+    fn test_lsp_change_reuses_tree() {
+        let dir = tempfile::tempdir().unwrap();
+        let recipe = dir.path().join("recipe.yaml");
+        fs::write(
+            &recipe,
+            "stages:\n  - files: '*.py'\n    language: python\n    query: comments\n    scope: todo\n",
+        )
+        .unwrap();
+
+        let mut server = Server::new(recipe::compile_all(&recipe).unwrap(), dir.path().to_owned());
+        let uri = format!("file://{}", dir.path().join("a.py").display());
+        let n_diagnostics =
+            |message: &Value| message["params"]["diagnostics"].as_array().unwrap().len();
+
+        let opened = server.handle(
+            "textDocument/didOpen",
+            json!({ "textDocument": { "uri": uri, "text": "a = 1  # todo\nb = 2\n" } }),
+            None,
+        );
+        assert_eq!(n_diagnostics(&opened[0]), 1);
+
+        let first_statement = |server: &Server| {
+            server
+                .session(&uri, 0)
+                .unwrap()
+                .tree()
+                .root_node()
+                .child(0)
+                .unwrap()
+                .id()
+        };
+        let before = first_statement(&server);
+
+        // Turns `b = 2` into `b = 2  # todo`.
+        let changed = server.handle(
+            "textDocument/didChange",
+            json!({
+                "textDocument": { "uri": uri },
+                "contentChanges": [{
+                    "range": {
+                        "start": { "line": 1, "character": 5 },
+                        "end": { "line": 1, "character": 5 },
+                    },
+                    "text": "  # todo",
+                }],
+            }),
+            None,
+        );
+        assert_eq!(n_diagnostics(&changed[0]), 2);
+
+        // Untouched by the change, so taken over from the previous tree as is, instead
+        // of parsed anew.
+        assert_eq!(first_statement(&server), before);
+    }
+}
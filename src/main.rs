@@ -1,3 +1,4 @@
+use anyhow::bail;
 use anyhow::Context;
 use anyhow::Result;
 use log::{debug, error, info, warn, LevelFilter};
@@ -16,27 +17,85 @@ use srgn::scoping::literal::LiteralError;
 use srgn::scoping::regex::RegexError;
 use srgn::{
     actions::Action,
+    cancel::{self, CancellationToken, Cancelled},
+    fs::write_atomically,
     scoping::{
         langs::{
+            bash::{Bash, BashQuery},
+            clojure::{Clojure, ClojureQuery},
+            cmake::{CMake, CMakeQuery},
             csharp::{CSharp, CSharpQuery},
+            css::{Css, CssQuery},
+            dart::{Dart, DartQuery},
+            dockerfile::{Dockerfile, DockerfileQuery},
+            elixir::{Elixir, ElixirQuery},
+            erlang::{Erlang, ErlangQuery},
             go::{Go, GoQuery},
+            graphql::{GraphQL, GraphQLQuery},
+            groovy::{Groovy, GroovyQuery},
+            haskell::{Haskell, HaskellQuery},
+            html::{Html, HtmlQuery},
+            java::{Java, JavaQuery},
+            json::{Json, JsonQuery},
+            julia::{Julia, JuliaQuery},
+            kotlin::{Kotlin, KotlinQuery},
+            lua::{Lua, LuaQuery},
+            makefile::{Makefile, MakefileQuery},
+            markdown::{Markdown, MarkdownQuery},
+            nix::{Nix, NixQuery},
+            objc::{ObjC, ObjCQuery},
+            ocaml::{OCaml, OCamlQuery},
+            perl::{Perl, PerlQuery},
+            php::{Php, PhpQuery},
+            powershell::{PowerShell, PowerShellQuery},
+            proto::{Proto, ProtoQuery},
             python::{Python, PythonQuery},
+            ruby::{Ruby, RubyQuery},
             rust::{Rust, RustQuery},
+            scala::{Scala, ScalaQuery},
+            scss::{Scss, ScssQuery},
+            solidity::{Solidity, SolidityQuery},
+            sql::{Sql, SqlQuery},
+            svelte::{Svelte, SvelteQuery},
+            swift::{Swift, SwiftQuery},
+            toml::{Toml, TomlQuery},
             typescript::{TypeScript, TypeScriptQuery},
+            vue::{Vue, VueQuery},
+            yaml::{Yaml, YamlQuery},
+            zig::{Zig, ZigQuery},
+            LanguageScoper,
         },
+        limit::{Guarded, Occurrences},
         literal::Literal,
+        prefilter::Prefilter,
         regex::Regex,
         view::ScopedViewBuilder,
         Scoper,
     },
+    RegexPatternBuilder,
 };
 use std::{
     error::Error,
-    fmt,
-    fs::File,
-    io::{self, IoSlice, Write},
+    fmt, fs,
+    io::{self, IoSlice, Read, Write},
+    ops::Range,
+    path::Path,
+    sync::{
+        atomic::{AtomicUsize, Ordering},
+        Mutex,
+    },
+    time::{Duration, Instant},
 };
 
+mod cache;
+mod cli;
+mod encoding;
+mod git;
+mod lsp;
+mod mcp;
+mod recipe;
+mod report;
+
 fn main() -> Result<()> {
     let args = cli::Cli::init();
 
@@ -56,24 +115,92 @@ fn main() -> Result<()> {
 
     info!("Launching app with args: {:?}", args);
 
+    match &args.command {
+        Some(cli::Commands::Run { recipe }) => return recipe::run(recipe, None).map(|_| ()),
+        Some(cli::Commands::Hook { recipe, files }) => return recipe::hook(recipe, files),
+        Some(cli::Commands::Lsp { recipe }) => return lsp::serve(recipe),
+        Some(cli::Commands::Mcp) => return mcp::serve(),
+        None => {}
+    }
+
     debug!("Assembling scopers.");
-    let scopers = assemble_scopers(&args)?;
+    let (language_scopers, scopers) = assemble_guards(
+        &args,
+        assemble_language_scopers(&args),
+        assemble_scopers(&args)?,
+    )?;
+    let prefilter = assemble_prefilter(&args);
     debug!("Done assembling scopers.");
 
     debug!("Assembling actions.");
     let actions = assemble_actions(&args)?;
     debug!("Done assembling actions.");
 
+    if let Some(format) = args.options.output {
+        let any_actions = !actions.is_empty() || args.standalone_actions.squeeze;
+        if any_actions && format != cli::OutputFormat::Rdjson {
+            bail!("Actions can only be used with '--output rdjson', for suggested fixes");
+        }
+    }
+
+    let cache = match &args.options.cache_dir {
+        Some(dir) => Some(
+            cache::Cache::open(dir, format!("{args:?}"))
+                .with_context(|| format!("Failed to open cache directory: {:?}", dir))?,
+        ),
+        None => None,
+    };
+
+    if args.options.stats {
+        srgn::stats::enable();
+    }
+    let run_stats = RunStats::default();
+
+    let rev_range = match (&args.options.since, &args.options.rev_range) {
+        (Some(rev), _) => Some(format!("{rev}..HEAD")),
+        (None, range) => range.clone(),
+    };
+    let files_in_range = match &rev_range {
+        Some(range) => Some(
+            git::files_changed_in(range)
+                .with_context(|| format!("Failed to find files changed in {range}"))?,
+        ),
+        None => None,
+    };
+    let blame_author = args
+        .options
+        .blame_author
+        .clone()
+        .map(Regex::try_from)
+        .transpose()
+        .context("Failed building '--blame-author' regex")?;
+    let changed_base = match &args.options.changed_lines {
+        Some(rev) => Some(git::resolve(rev).context("Failed to resolve revision to compare to")?),
+        None => None,
+    };
+
+    let generated = args.options.skip_generated.then(git::Attributes::default);
+
+    let start = Instant::now();
+    let report_stats = Mutex::new(report::Stats::default());
+    let report_output = report::Output::default();
+
     match &args.options.files {
         Some(pattern) => {
             info!("Will use glob pattern: {:?}", pattern);
 
-            let paths = glob::glob(pattern.as_str())
+            // Paths are walked lazily, and handed out to worker threads one by one as
+            // they become free. Hence, only as many files as there are threads are held
+            // in memory at any time, no matter how many there are in total. Nothing
+            // outlives its file's processing, only a count is kept.
+            let n_failed = AtomicUsize::new(0);
+            let n_processed = glob::glob(pattern.as_str())
                 .expect("Pattern is valid, as it's been compiled")
                 .par_bridge()
                 .map(|glob| {
                     let path = glob.context("Failed to glob")?;
                     debug!("Processing path: {:?}", path);
+                    run_stats.add(&run_stats.files_walked, 1);
 
                     if !path.is_file() {
                         warn!(
@@ -83,140 +210,792 @@ fn main() -> Result<()> {
                         );
                     }
 
-                    let contents = {
-                        let file = File::open(&path)
-                            .with_context(|| format!("Failed to read file: {:?}", path))?;
+                    if files_in_range
+                        .as_ref()
+                        .is_some_and(|files| !files.contains(&git::normalized(&path)))
+                    {
+                        info!("Skipping file not changed in revision range: {:?}", path);
+                        run_stats.add(&run_stats.files_skipped, 1);
+                        return Ok(path);
+                    }
 
-                        let mut source = std::io::BufReader::new(file);
-                        let mut destination = std::io::Cursor::new(Vec::new());
+                    if generated
+                        .as_ref()
+                        .is_some_and(|attributes| attributes.is_generated(&path))
+                    {
+                        info!("Skipping file marked generated or vendored: {:?}", path);
+                        run_stats.add(&run_stats.files_skipped, 1);
+                        return Ok(path);
+                    }
 
-                        apply(
-                            &mut source,
-                            &mut destination,
-                            &scopers,
-                            &actions,
-                            args.options.fail_none,
-                            args.options.fail_any,
-                            args.standalone_actions.squeeze,
+                    let mut lines = match (&changed_base, args.options.staged) {
+                        (Some(base), _) => Some(git::changed_lines(&path, base)),
+                        (None, Some(cli::Staged::Hunks)) => Some(git::staged_lines(&path)),
+                        (None, Some(cli::Staged::Files)) => Some(
+                            git::is_staged(&path)
+                                .map(|staged| if staged { vec![1..usize::MAX] } else { vec![] }),
+                        ),
+                        (None, None) => None,
+                    }
+                    .transpose()
+                    .with_context(|| format!("Failed to find changed lines of file: {:?}", path))?;
+                    if blame_author.is_some() || args.options.blame_since.is_some() {
+                        let blamed = git::blamed_lines(
+                            &path,
+                            blame_author.as_ref(),
+                            args.options.blame_since.as_deref(),
                         )
-                        .with_context(|| format!("Failed to process file contents: {:?}", path))?;
+                        .with_context(|| format!("Failed to blame file: {:?}", path))?;
+
+                        lines = Some(match lines {
+                            Some(lines) => intersect_lines(&lines, &blamed),
+                            None => blamed,
+                        });
+                    }
+                    if lines.as_ref().is_some_and(Vec::is_empty) {
+                        info!("Skipping file without relevant changes: {:?}", path);
+                        run_stats.add(&run_stats.files_skipped, 1);
+                        return Ok(path);
+                    }
+
+                    let bytes = std::fs::read(&path)
+                        .with_context(|| format!("Failed to read file: {:?}", path))?;
+
+                    let cached = cache
+                        .as_ref()
+                        .map(|cache| (cache, cache.key(&path, &bytes, lines.as_deref())));
+                    if let Some(entry) = cached.and_then(|(cache, key)| cache.get(key)) {
+                        run_stats.add(&run_stats.files_skipped, 1);
+                        let Some(format) = args.options.output else {
+                            info!("Skipping file left unchanged by a previous run: {:?}", path);
+                            // As it would be if processed again.
+                            write_path(&path)
+                                .context("Failed writing processed file's name to stdout")?;
+                            return Ok(path);
+                        };
+
+                        debug!("Reporting on file from cache: {:?}", path);
+                        let (stats, report) = report::from_cache_entry(&entry)
+                            .with_context(|| format!("Corrupt cache entry for file: {:?}", path))?;
+                        *report_stats.lock().expect("No panics while holding lock") += stats;
+
+                        report_output
+                            .write(format, report)
+                            .context("Failed writing report to stdout")?;
+
+                        return Ok(path);
+                    }
+
+                    let (bom, source) = encoding::decode(bytes)
+                        .with_context(|| format!("Failed to decode file: {:?}", path))?;
+                    let within = lines.map(|lines| line_byte_ranges(&source, &lines));
+
+                    if let Some(prefilter) = &prefilter {
+                        if !prefilter.is_match(source.as_bytes()) {
+                            info!("Skipping file without anything in scope: {:?}", path);
+                            run_stats.add(&run_stats.files_skipped, 1);
+                            if args.options.output.is_some() {
+                                *report_stats.lock().expect("No panics while holding lock") +=
+                                    report::Stats::unmatched(&source);
+                            }
+                            return Ok(path);
+                        }
+                    }
 
-                        destination.into_inner()
+                    let language_scoper = if language_scopers.is_empty() {
+                        None
+                    } else {
+                        let mappings = &args.options.language_mappings;
+                        let Some((language, scoper)) =
+                            language_scopers.iter().find(|(language, _)| {
+                                is_in_language(&path, &source, *language, mappings)
+                            })
+                        else {
+                            info!("Skipping file not in any requested language: {:?}", path);
+                            run_stats.add(&run_stats.files_skipped, 1);
+                            return Ok(path);
+                        };
+
+                        debug!("File {:?} is in language {:?}", path, language);
+                        run_stats.add(&run_stats.files_parsed, 1);
+                        Some(scoper)
                     };
 
-                    debug!("Got new file contents, writing to file: {:?}", path);
-                    let mut file = File::create(&path)
-                        .with_context(|| format!("Failed to truncate file: {:?}", path))?;
-                    file.write_all(&contents)
-                        .with_context(|| format!("Failed to write to file: {:?}", path))?;
-                    debug!("Done processing file: {:?}", path);
+                    if let Some(format) = args.options.output {
+                        let mut destination = Vec::new();
+                        let name = path.display().to_string();
+
+                        let stats = limit_resources(&args.options, || {
+                            report::write(
+                                &source,
+                                &name,
+                                &mut destination,
+                                language_scoper,
+                                &scopers,
+                                format,
+                                &actions,
+                                args.standalone_actions.squeeze,
+                                within.as_deref(),
+                                occurrences(&args.options),
+                            )
+                            .map_err(anyhow::Error::from)
+                        })
+                        .with_context(|| format!("Failed to report on file: {:?}", path))?;
+                        *report_stats.lock().expect("No panics while holding lock") += stats;
+
+                        if let Some((cache, key)) = cached {
+                            let entry = report::to_cache_entry(stats, &destination);
+                            if let Err(e) = cache.put(key, &entry) {
+                                warn!("Failed to cache report on file {:?}: {}", path, e);
+                            }
+                        }
+
+                        report_output
+                            .write(format, &destination)
+                            .context("Failed writing report to stdout")?;
+
+                        return Ok(path);
+                    }
 
-                    {
-                        let path_repr = path.display().to_string();
-                        let slices = &[path_repr.as_bytes(), b"\n"].map(IoSlice::new);
+                    let (applied, contents) = {
+                        let mut destination = std::io::Cursor::new(Vec::new());
+
+                        let applied = limit_resources(&args.options, || {
+                            apply(
+                                &source,
+                                bom,
+                                &mut destination,
+                                language_scoper,
+                                &scopers,
+                                &actions,
+                                args.options.fail_none,
+                                args.options.fail_any,
+                                args.standalone_actions.squeeze,
+                                args.options.verify_idempotent,
+                                within.as_deref(),
+                                occurrences(&args.options),
+                            )
+                        })
+                        .with_context(|| format!("Failed to process file contents: {:?}", path))?;
 
-                        std::io::stdout()
-                            .lock()
-                            .write_vectored(slices)
-                            .context("Failed writing processed file's name to stdout")?;
+                        (applied, destination.into_inner())
+                    };
+
+                    if applied.changed {
+                        debug!("Got new file contents, writing to file: {:?}", path);
+                        write_atomically(&path, &contents, args.options.preserve_mtime)
+                            .with_context(|| format!("Failed to write to file: {:?}", path))?;
+                        run_stats.add(&run_stats.bytes_written, contents.len());
+                    } else {
+                        // Do not touch the file at all, leaving its modification time
+                        // alone as well.
+                        debug!("File contents unchanged, not writing: {:?}", path);
+
+                        // Changed files are written to, and will hence be different next
+                        // time anyway. Only remember files processing leaves alone.
+                        if let Some((cache, key)) = cached {
+                            if let Err(e) = cache.put(key, &[]) {
+                                warn!("Failed to cache result for file {:?}: {}", path, e);
+                            }
+                        }
                     }
+                    debug!("Done processing file: {:?}", path);
+
+                    write_path(&path).context("Failed writing processed file's name to stdout")?;
 
                     Ok(path)
                 })
-                .collect::<Result<Vec<_>>>()
+                .map(|res| match res {
+                    // One pathological file must not take down the entire run.
+                    Err(e) if is_cancellation(&e) => {
+                        error!("Skipping file, left unchanged: {:#}", e);
+                        n_failed.fetch_add(1, Ordering::Relaxed);
+                        run_stats.add(&run_stats.files_skipped, 1);
+                        Ok(1_usize)
+                    }
+                    res => res.map(|_path| 1),
+                })
+                .try_reduce(|| 0, |a, b| Ok(a + b))
                 .context("Failure in processing of files")?;
+            debug!("Processed {} file(s)", n_processed);
+
+            let n_failed = n_failed.into_inner();
+            if n_failed > 0 {
+                return Err(ApplicationError::FilesExceededLimits(n_failed))
+                    .context("Not all files processed");
+            }
 
-            if args.options.fail_empty_glob && paths.is_empty() {
+            if args.options.fail_empty_glob && n_processed == 0 {
                 return Err(ApplicationError::EmptyGlob(pattern.clone()))
                     .context("No files processed");
             }
         }
-        None => {
-            info!("Will use stdin to stdout");
-            let mut source = std::io::stdin().lock();
-            let mut destination = std::io::stdout().lock();
+        None if args.options.stream => {
+            info!("Will stream stdin to stdout, line by line");
 
-            apply(
-                &mut source,
-                &mut destination,
+            apply_streaming(
+                &mut std::io::stdin().lock(),
+                &mut std::io::stdout().lock(),
                 &scopers,
                 &actions,
-                args.options.fail_none,
-                args.options.fail_any,
+                &args.options,
                 args.standalone_actions.squeeze,
             )
             .context("Failed to process stdin")?;
         }
+        None => {
+            info!("Will use stdin to stdout");
+            let (bom, source) = {
+                let mut bytes = Vec::new();
+                std::io::stdin()
+                    .lock()
+                    .read_to_end(&mut bytes)
+                    .context("Failed reading in stdin")?;
+
+                encoding::decode(bytes).context("Failed to decode stdin")?
+            };
+            let mut destination = std::io::stdout().lock();
+            let path = args.options.stdin_filepath.as_deref();
+            let mut within = (!args.options.range.is_empty()).then(|| {
+                let mut lines: Vec<_> = args.options.range.iter().map(|r| r.0.clone()).collect();
+                lines.sort_by_key(|lines| lines.start);
+                line_byte_ranges(&source, &lines)
+            });
+
+            let language_scoper = match (language_scopers.as_slice(), path) {
+                ([], _) => None,
+                (language_scopers, Some(path)) => {
+                    let mappings = &args.options.language_mappings;
+                    let found = language_scopers
+                        .iter()
+                        .find(|(language, _)| is_in_language(path, &source, *language, mappings));
+
+                    if found.is_none() {
+                        info!("Stdin is not in any requested language: {:?}", path);
+                        // Nothing is in scope then.
+                        within = Some(Vec::new());
+                    }
+
+                    found.map(|(_, scoper)| scoper)
+                }
+                ([(_, scoper)], None) => Some(scoper),
+                (_, None) => {
+                    return Err(ApplicationError::MultipleLanguagesForStdin)
+                        .context("Cannot tell which language scope applies");
+                }
+            };
+
+            if let Some(format) = args.options.output {
+                let name = path.map_or_else(|| "<stdin>".into(), |p| p.display().to_string());
+
+                let mut report = Vec::new();
+
+                let stats = limit_resources(&args.options, || {
+                    report::write(
+                        &source,
+                        &name,
+                        &mut report,
+                        language_scoper,
+                        &scopers,
+                        format,
+                        &actions,
+                        args.standalone_actions.squeeze,
+                        within.as_deref(),
+                        occurrences(&args.options),
+                    )
+                    .context("Failed to report on stdin")
+                })?;
+                *report_stats.lock().expect("No panics while holding lock") += stats;
+
+                report_output
+                    .write(format, &report)
+                    .context("Failed writing report to stdout")?;
+            } else {
+                let mut result = Vec::new();
+                let applied = limit_resources(&args.options, || {
+                    apply(
+                        &source,
+                        // Edits refer to offsets into the plain text.
+                        if args.options.edits { None } else { bom },
+                        &mut result,
+                        language_scoper,
+                        &scopers,
+                        &actions,
+                        args.options.fail_none,
+                        args.options.fail_any,
+                        args.standalone_actions.squeeze,
+                        args.options.verify_idempotent,
+                        within.as_deref(),
+                        occurrences(&args.options),
+                    )
+                })
+                .context("Failed to process stdin")?;
+
+                if args.options.edits {
+                    let result = if applied.changed {
+                        String::from_utf8(result).expect("Results are written as UTF-8")
+                    } else {
+                        source.clone()
+                    };
+
+                    write_edits(&mut destination, &source, &result)
+                        .context("Failed writing edits to stdout")?;
+                } else if applied.changed {
+                    destination
+                        .write_all(&result)
+                        .context("Failed writing to stdout")?;
+                } else {
+                    // Unlike files, stdout has to see the input regardless.
+                    destination
+                        .write_all(&encoding::encode(&source, bom))
+                        .context("Failed writing to stdout")?;
+                }
+            }
+        }
+    }
+
+    if let Some(format) = args.options.output {
+        let stats = report_stats
+            .into_inner()
+            .expect("No panics while holding lock");
+
+        report::finish(
+            &mut std::io::stdout().lock(),
+            format,
+            stats,
+            start.elapsed(),
+            report_output,
+        )
+        .context("Failed writing report summary to stdout")?;
+    }
+
+    if args.options.stats {
+        run_stats
+            .write(&mut std::io::stderr().lock(), start.elapsed())
+            .context("Failed writing stats to stderr")?;
     }
 
     info!("Done, exiting");
     Ok(())
 }
 
+/// Counters on a single run, printed for `--stats`.
+#[derive(Debug, Default)]
+struct RunStats {
+    files_walked: AtomicUsize,
+    files_skipped: AtomicUsize,
+    files_parsed: AtomicUsize,
+    /// To files, not stdout.
+    bytes_written: AtomicUsize,
+}
+
+impl RunStats {
+    fn add(&self, counter: &AtomicUsize, n: usize) {
+        counter.fetch_add(n, Ordering::Relaxed);
+    }
+
+    /// Writes all counters, alongside timings of the library, to `destination`.
+    fn write(&self, destination: &mut impl io::Write, elapsed: Duration) -> io::Result<()> {
+        let timings = srgn::stats::timings();
+        let load = |counter: &AtomicUsize| counter.load(Ordering::Relaxed);
+
+        writeln!(destination, "Files walked: {}", load(&self.files_walked))?;
+        writeln!(destination, "Files skipped: {}", load(&self.files_skipped))?;
+        writeln!(destination, "Files parsed: {}", load(&self.files_parsed))?;
+        writeln!(destination, "Bytes written: {}", load(&self.bytes_written))?;
+        // Summed across threads, so can exceed the total.
+        writeln!(
+            destination,
+            "Time parsing: {:.6}s",
+            timings.parse.as_secs_f64()
+        )?;
+        writeln!(
+            destination,
+            "Time querying: {:.6}s",
+            timings.query.as_secs_f64()
+        )?;
+        writeln!(
+            destination,
+            "Time matching regex: {:.6}s",
+            timings.regex.as_secs_f64()
+        )?;
+        writeln!(destination, "Time total: {:.6}s", elapsed.as_secs_f64())
+    }
+}
+
+/// The outcome of [`apply`]ing scopers and actions to some input.
+#[derive(Debug, Clone, Copy)]
+struct Applied {
+    /// Whether anything was in scope.
+    any_in_scope: bool,
+    /// How many parts were in scope, before selecting only some occurrences.
+    n_in_scope: usize,
+    /// Whether the result differs from the input at all.
+    changed: bool,
+}
+
+/// Applies all scopers and actions to `source`, writing the result to `destination`.
+///
+/// The result is written in the encoding indicated by `bom` (UTF-8 if missing), with
+/// the byte order mark itself re-emitted as well. If the result is identical to
+/// `source`, it is never built and **nothing is written**; callers have to handle
+/// [`Applied::changed`].
+///
+/// If `verify_idempotent`, fails instead of writing a result which applying actions
+/// again would change further. Only parts in scope `within` the given byte ranges are
+/// acted on, if given.
+#[allow(clippy::too_many_arguments)]
 fn apply(
-    source: &mut impl io::BufRead,
+    source: &str,
+    bom: Option<encoding::Bom>,
     destination: &mut impl io::Write,
+    language_scoper: Option<&Box<dyn Scoper>>,
     scopers: &Vec<Box<dyn Scoper>>,
     actions: &Vec<Box<dyn Action>>,
     fail_none: bool,
     fail_any: bool,
     squeeze: bool,
-) -> Result<()> {
-    // Streaming (e.g., line-based) wouldn't be too bad, and much more memory-efficient,
-    // but language grammar-aware scoping needs entire files for context. Single lines
+    verify_idempotent: bool,
+    within: Option<&[Range<usize>]>,
+    occurrences: Option<Range<usize>>,
+) -> Result<Applied> {
+    // Language grammar-aware scoping needs entire files for context. Single lines
     // wouldn't do. There's no smart way of streaming that I can think of (where would
-    // one break?).
-    debug!("Reading entire source to string.");
-    let mut buf = String::new();
-    source
-        .read_to_string(&mut buf)
-        .context("Failed reading in source")?;
-    debug!("Done reading source.");
-
+    // one break?). Hence, the entire source is expected to have been read in already.
+    // Only regex-based scoping can be streamed, see `apply_streaming`.
     debug!("Building view.");
-    let mut builder = ScopedViewBuilder::new(&buf);
-    for scoper in scopers {
-        builder.explode(scoper);
+    let mut builder = scope(source, language_scoper, scopers);
+    if let Some(within) = within {
+        builder.within(within);
+    }
+    let n_in_scope = builder.n_in_scope();
+    if let Some(occurrences) = occurrences {
+        builder.occurrences(occurrences);
     }
     let mut view = builder.build();
     debug!("Done building view: {view:?}");
 
-    if fail_none && !view.has_any_in_scope() {
+    let any_in_scope = view.has_any_in_scope();
+
+    if fail_none && !any_in_scope {
         return Err(ApplicationError::NoneInScope.into());
     }
 
-    if fail_any && view.has_any_in_scope() {
+    if fail_any && any_in_scope {
         return Err(ApplicationError::SomeInScope.into());
     };
 
     debug!("Applying actions to view.");
-    let result = {
-        if squeeze {
-            view.squeeze();
-        }
-
-        for action in actions {
-            view.map(action);
-        }
+    if squeeze {
+        view.squeeze();
+    }
 
-        view.to_string()
-    };
+    for action in actions {
+        view.map(action);
+    }
     debug!("Done applying actions to view.");
 
+    // Results of cancelled runs are incomplete, and must not end up anywhere.
+    if let Some(reason) = cancel::current().and_then(|token| token.reason()) {
+        return Err(reason.into());
+    }
+
+    if view.is_unchanged() {
+        debug!("View unchanged, not writing to destination.");
+        return Ok(Applied {
+            any_in_scope,
+            n_in_scope,
+            changed: false,
+        });
+    }
+
+    let result = view.to_string();
+    if verify_idempotent {
+        debug!("Applying actions again, to verify idempotency.");
+        if changes_again(&result, language_scoper, scopers, actions, squeeze) {
+            return Err(ApplicationError::NotIdempotent.into());
+        }
+        if let Some(reason) = cancel::current().and_then(|token| token.reason()) {
+            return Err(reason.into());
+        }
+    }
+
     debug!("Writing to destination.");
     destination
-        .write_all(result.as_bytes())
+        .write_all(&encoding::encode(&result, bom))
         .context("Failed writing to destination")?;
     debug!("Done writing to destination.");
 
+    Ok(Applied {
+        any_in_scope,
+        n_in_scope,
+        changed: true,
+    })
+}
+
+/// Whether scoping `result` (of a previous pass) and applying all actions to it again
+/// changes it further.
+fn changes_again(
+    result: &str,
+    language_scoper: Option<&Box<dyn Scoper>>,
+    scopers: &[Box<dyn Scoper>],
+    actions: &[Box<dyn Action>],
+    squeeze: bool,
+) -> bool {
+    let mut view = scope(result, language_scoper, scopers).build();
+
+    if squeeze {
+        view.squeeze();
+    }
+    for action in actions {
+        view.map(action);
+    }
+
+    !view.is_unchanged()
+}
+
+/// Applies all scopers and actions to `source` line by line, writing results to
+/// `destination` as soon as each line is done.
+///
+/// Only a single line is held in memory at any time, so input can be arbitrarily
+/// large. In turn, scopes cannot span lines. Input has to be UTF-8.
+///
+/// Failing due to `fail_any` happens on the first line with anything in scope, after
+/// all previous lines have already been written.
+fn apply_streaming(
+    source: &mut impl io::BufRead,
+    destination: &mut impl io::Write,
+    scopers: &Vec<Box<dyn Scoper>>,
+    actions: &Vec<Box<dyn Action>>,
+    options: &cli::GlobalOptions,
+    squeeze: bool,
+) -> Result<()> {
+    let mut any_in_scope = false;
+    // Occurrences count across the entire stream, not per line.
+    let occurrences = occurrences(options);
+    let mut n_seen = 0;
+    let mut line = Vec::new();
+    // Detected from the first line only, as a BOM can only lead the entire stream.
+    let mut stream_bom = None;
+
+    for n in 1.. {
+        line.clear();
+        if source
+            .read_until(b'\n', &mut line)
+            .context("Failed reading in line")?
+            == 0
+        {
+            break;
+        }
+
+        let (bom, text) = if n == 1 {
+            let (bom, text) = encoding::decode(std::mem::take(&mut line))
+                .with_context(|| format!("Failed to decode line {n}"))?;
+            if matches!(bom, Some(encoding::Bom::Utf16Le | encoding::Bom::Utf16Be)) {
+                bail!("Streaming UTF-16 input is not supported");
+            }
+            stream_bom = bom;
+
+            (bom, text)
+        } else {
+            let text = encoding::decode_as(std::mem::take(&mut line), stream_bom)
+                .with_context(|| format!("Failed to decode line {n}"))?;
+
+            // Already emitted ahead of the first line.
+            (None, text)
+        };
+
+        let applied = limit_resources(options, || {
+            apply(
+                &text,
+                bom,
+                destination,
+                None,
+                scopers,
+                actions,
+                false,
+                options.fail_any,
+                squeeze,
+                options.verify_idempotent,
+                None,
+                occurrences.as_ref().map(|occurrences| {
+                    occurrences.start.saturating_sub(n_seen)..occurrences.end.saturating_sub(n_seen)
+                }),
+            )
+        })
+        .with_context(|| format!("Failed to process line {n}"))?;
+        n_seen += applied.n_in_scope;
+
+        if !applied.changed {
+            destination
+                .write_all(&encoding::encode(&text, bom))
+                .context("Failed writing line to destination")?;
+        }
+        any_in_scope |= applied.any_in_scope;
+    }
+
+    if options.fail_none && !any_in_scope {
+        return Err(ApplicationError::NoneInScope.into());
+    }
+
     Ok(())
 }
 
+/// Runs `f` under the time and memory limits given in `options`, if any.
+///
+/// `f` always runs under a [`CancellationToken`], even without limits: regular
+/// expressions exceeding their backtracking limit cancel it, turning into an error.
+fn limit_resources<T>(options: &cli::GlobalOptions, f: impl FnOnce() -> Result<T>) -> Result<T> {
+    let mut token = match options.timeout {
+        Some(cli::Timeout(timeout)) => CancellationToken::with_timeout(timeout),
+        None => CancellationToken::new(),
+    };
+
+    if let Some(cli::ByteSize(bytes)) = options.memory_limit {
+        token = token.with_memory_limit(bytes);
+    }
+
+    token.run(f)?
+}
+
+/// Whether `error` stems from an operation cancelled for exceeding some limit, as
+/// opposed to e.g. I/O failing.
+///
+/// Overlapping scopes are no such limit, but an error asked for explicitly.
+fn is_cancellation(error: &anyhow::Error) -> bool {
+    let is_limit = |cancelled: &Cancelled| *cancelled != Cancelled::OverlappingScopes;
+
+    error.chain().any(|cause| {
+        cause.downcast_ref::<Cancelled>().is_some_and(is_limit)
+            // Reports fail with I/O errors, carrying the actual reason within.
+            || cause
+                .downcast_ref::<io::Error>()
+                .and_then(io::Error::get_ref)
+                .and_then(|inner| inner.downcast_ref::<Cancelled>())
+                .is_some_and(is_limit)
+    })
+}
+
+/// Scopes `source` down, using the language scoper (if any) first, then all others.
+fn scope<'viewee>(
+    source: &'viewee str,
+    language_scoper: Option<&Box<dyn Scoper>>,
+    scopers: &[Box<dyn Scoper>],
+) -> ScopedViewBuilder<'viewee> {
+    let mut builder = ScopedViewBuilder::new(source);
+    for scoper in language_scoper.into_iter().chain(scopers) {
+        builder.explode(scoper);
+    }
+
+    builder
+}
+
+/// Lines in both `left` and `right`, each sorted and non-overlapping.
+fn intersect_lines(left: &[Range<usize>], right: &[Range<usize>]) -> Vec<Range<usize>> {
+    left.iter()
+        .flat_map(|l| {
+            right
+                .iter()
+                .map(move |r| l.start.max(r.start)..l.end.min(r.end))
+        })
+        .filter(|lines| !lines.is_empty())
+        .collect()
+}
+
+/// Byte ranges of `source` spanned by `lines`, which are 1-based and end-exclusive.
+///
+/// Lines past the end of `source` span nothing.
+fn line_byte_ranges(source: &str, lines: &[Range<usize>]) -> Vec<Range<usize>> {
+    let starts = std::iter::once(0)
+        .chain(source.match_indices('\n').map(|(i, _)| i + 1))
+        .collect::<Vec<_>>();
+    let start_of = |line: usize| {
+        starts
+            .get(line.saturating_sub(1))
+            .copied()
+            .unwrap_or(source.len())
+    };
+
+    lines
+        .iter()
+        .map(|lines| start_of(lines.start)..start_of(lines.end))
+        .collect()
+}
+
+/// A replacement of the bytes `start..end` of some input by `text`.
+#[derive(Debug, serde::Serialize)]
+struct Edit<'a> {
+    start: usize,
+    end: usize,
+    text: &'a str,
+}
+
+/// Writes the name of a processed file to stdout, on a line of its own.
+fn write_path(path: &Path) -> io::Result<()> {
+    let path_repr = path.display().to_string();
+    let slices = &[path_repr.as_bytes(), b"\n"].map(IoSlice::new);
+
+    std::io::stdout().lock().write_vectored(slices).map(|_| ())
+}
+
+/// Writes the edit turning `source` into `result` as a JSON list, for `--edits`.
+///
+/// The list holds a single edit spanning all changes, or none if there are none.
+fn write_edits(destination: &mut impl io::Write, source: &str, result: &str) -> io::Result<()> {
+    let edits = if source == result {
+        Vec::new()
+    } else {
+        let (range, text) = lsp::difference(source, result);
+        vec![Edit {
+            start: range.start,
+            end: range.end,
+            text,
+        }]
+    };
+
+    serde_json::to_writer(&mut *destination, &edits)?;
+    writeln!(destination)
+}
+
+/// The (0-based) occurrences of matches to leave in scope per file, as selected by
+/// '--occurrence' and '--max-count'. [`None`] if all are.
+fn occurrences(options: &cli::GlobalOptions) -> Option<Range<usize>> {
+    select_occurrences(options.occurrence.as_ref(), options.max_count)
+}
+
+/// Like [`occurrences`], but for each part in scope of the language scoper.
+fn occurrences_per_scope(options: &cli::GlobalOptions) -> Option<Range<usize>> {
+    select_occurrences(
+        options.occurrence_per_scope.as_ref(),
+        options.max_count_per_scope,
+    )
+}
+
+/// Of the given `occurrence`, the first `max_count` ones.
+fn select_occurrences(
+    occurrence: Option<&cli::Occurrence>,
+    max_count: Option<usize>,
+) -> Option<Range<usize>> {
+    if occurrence.is_none() && max_count.is_none() {
+        return None;
+    }
+
+    let cli::Occurrence(range) = occurrence.cloned().unwrap_or_default();
+
+    Some(match max_count {
+        Some(max) => range.start..range.end.min(range.start.saturating_add(max)),
+        None => range,
+    })
+}
+
 #[derive(Debug)]
 enum ApplicationError {
     SomeInScope,
     NoneInScope,
     EmptyGlob(glob::Pattern),
+    MultipleLanguagesForStdin,
+    FilesExceededLimits(usize),
+    NotIdempotent,
 }
 
 impl fmt::Display for ApplicationError {
@@ -228,6 +1007,18 @@ impl fmt::Display for ApplicationError {
             ),
             Self::NoneInScope => write!(f, "Nothing in scope and explicit failure requested."),
             Self::EmptyGlob(p) => write!(f, "No files matched glob pattern: {:?}", p),
+            Self::MultipleLanguagesForStdin => write!(
+                f,
+                "Multiple language scopes are only supported when processing files."
+            ),
+            Self::FilesExceededLimits(n) => write!(
+                f,
+                "{n} file(s) exceeded time, memory or regex backtracking limits."
+            ),
+            Self::NotIdempotent => write!(
+                f,
+                "Applying actions again would change the result further (not idempotent)."
+            ),
         }
     }
 }
@@ -265,89 +1056,359 @@ impl fmt::Display for ScoperBuildError {
 
 impl Error for ScoperBuildError {}
 
+/// Pushes a scoper onto `$scopers` for each language of the table requested in
+/// `$args`, built from either its premade or custom query (the CLI allows only one).
+///
+/// Each row names the language's CLI arguments, then its language and query types.
+/// Premade queries of languages marked `filterable` are parsed into entire queries by
+/// the CLI already.
+macro_rules! language_scopers {
+    (@premade $query:ident, $given:ident) => {
+        $query::Premade($given)
+    };
+    (@premade $query:ident, $given:ident filterable) => {
+        $given
+    };
+    ($args:expr, $scopers:expr; $($premade:ident, $custom:ident: $language:ident($query:ident) $($filterable:ident)?;)*) => {
+        $(
+            if let Some(scope) = $args.languages_scopes.$premade.clone() {
+                let query = match (scope.$premade, scope.$custom) {
+                    (Some(given), _) => Some(language_scopers!(@premade $query, given $($filterable)?)),
+                    (None, Some(custom)) => Some($query::Custom(custom)),
+                    (None, None) => None,
+                };
+
+                if let Some(query) = query {
+                    $scopers.push((
+                        cli::LanguageName::$language,
+                        Box::new($language::new(query).with_overlaps($args.options.overlaps)),
+                    ));
+                }
+            }
+        )*
+    };
+}
+
+/// Assembles the requested language scopers, each alongside its language.
+///
+/// Any number of languages may be requested at once. Each applies to files in its
+/// language only.
+fn assemble_language_scopers(args: &cli::Cli) -> Vec<(cli::LanguageName, Box<dyn Scoper>)> {
+    let mut scopers: Vec<(cli::LanguageName, Box<dyn Scoper>)> = Vec::new();
+
+    language_scopers!(args, scopers;
+        bash, bash_query: Bash(BashQuery);
+        clojure, clojure_query: Clojure(ClojureQuery);
+        cmake, cmake_query: CMake(CMakeQuery) filterable;
+        csharp, csharp_query: CSharp(CSharpQuery);
+        css, css_query: Css(CssQuery);
+        dart, dart_query: Dart(DartQuery);
+        dockerfile, dockerfile_query: Dockerfile(DockerfileQuery);
+        elixir, elixir_query: Elixir(ElixirQuery);
+        erlang, erlang_query: Erlang(ErlangQuery);
+        go, go_query: Go(GoQuery) filterable;
+        graphql, graphql_query: GraphQL(GraphQLQuery);
+        groovy, groovy_query: Groovy(GroovyQuery) filterable;
+        haskell, haskell_query: Haskell(HaskellQuery);
+        html, html_query: Html(HtmlQuery) filterable;
+        java, java_query: Java(JavaQuery);
+        json, json_query: Json(JsonQuery);
+        julia, julia_query: Julia(JuliaQuery);
+        kotlin, kotlin_query: Kotlin(KotlinQuery);
+        lua, lua_query: Lua(LuaQuery);
+        makefile, makefile_query: Makefile(MakefileQuery);
+        markdown, markdown_query: Markdown(MarkdownQuery) filterable;
+        nix, nix_query: Nix(NixQuery);
+        objc, objc_query: ObjC(ObjCQuery);
+        ocaml, ocaml_query: OCaml(OCamlQuery);
+        perl, perl_query: Perl(PerlQuery);
+        php, php_query: Php(PhpQuery);
+        powershell, powershell_query: PowerShell(PowerShellQuery);
+        proto, proto_query: Proto(ProtoQuery);
+        python, python_query: Python(PythonQuery);
+        ruby, ruby_query: Ruby(RubyQuery);
+        rust, rust_query: Rust(RustQuery);
+        scala, scala_query: Scala(ScalaQuery);
+        scss, scss_query: Scss(ScssQuery);
+        solidity, solidity_query: Solidity(SolidityQuery);
+        sql, sql_query: Sql(SqlQuery);
+        svelte, svelte_query: Svelte(SvelteQuery);
+        swift, swift_query: Swift(SwiftQuery);
+        toml, toml_query: Toml(TomlQuery);
+        typescript, typescript_query: TypeScript(TypeScriptQuery);
+        vue, vue_query: Vue(VueQuery);
+        yaml, yaml_query: Yaml(YamlQuery);
+        zig, zig_query: Zig(ZigQuery);
+    );
+
+    scopers
+}
+
 fn assemble_scopers(args: &cli::Cli) -> Result<Vec<Box<dyn Scoper>>> {
     let mut scopers: Vec<Box<dyn Scoper>> = Vec::new();
 
-    if let Some(csharp) = args.languages_scopes.csharp.clone() {
-        if let Some(premade) = csharp.csharp {
-            let query = CSharpQuery::Premade(premade);
+    if args.options.literal_string {
+        scopers.push(Box::new(
+            literal_scope(args).context("Failed building literal string")?,
+        ));
+    } else {
+        let regex = if let Some(limit) = args.options.regex_backtrack_limit {
+            let pattern = RegexPatternBuilder::new(&regex_scope(args))
+                .backtrack_limit(limit)
+                .build()
+                .context("Failed building regex")?;
 
-            scopers.push(Box::new(CSharp::new(query)));
-        } else if let Some(custom) = csharp.csharp_query {
-            let query = CSharpQuery::Custom(custom);
+            Regex::new(pattern)
+        } else {
+            Regex::try_from(regex_scope(args)).context("Failed building regex")?
+        };
+
+        let replacement = args.composable_actions.replace.as_deref();
+        let refers_to_groups =
+            !args.options.replace_literal && replacement.is_some_and(refers_to_capture_groups);
+        // Occurrences count parts in scope, which have to be whole matches to count
+        // once.
+        let is_limited =
+            occurrences(&args.options).is_some() || occurrences_per_scope(&args.options).is_some();
+
+        let regex = match args.options.capture.clone() {
+            Some(group) => {
+                debug!("Scoping to capture group {group}");
+                regex
+                    .with_capture(group)
+                    .context("Failed narrowing regex to capture group")?
+            }
+            None => regex,
+        };
 
-            scopers.push(Box::new(CSharp::new(query)));
+        if refers_to_groups || is_limited {
+            debug!("Scoping to whole matches");
+            scopers.push(Box::new(regex.with_capture_groups()));
+        } else {
+            scopers.push(Box::new(regex));
         }
     }
 
-    if let Some(go) = args.languages_scopes.go.clone() {
-        if let Some(premade) = go.go {
-            let query = GoQuery::Premade(premade);
+    if let Some(occurrences) = occurrences_per_scope(&args.options) {
+        scopers = scopers
+            .into_iter()
+            .map(|scoper| {
+                Box::new(Occurrences::new(scoper, occurrences.clone())) as Box<dyn Scoper>
+            })
+            .collect();
+    }
 
-            scopers.push(Box::new(Go::new(query)));
-        } else if let Some(custom) = go.go_query {
-            let query = GoQuery::Custom(custom);
+    Ok(scopers)
+}
 
-            scopers.push(Box::new(Go::new(query)));
-        }
+/// Guards scopers by '--if' and '--unless', if given.
+///
+/// Guards check entire files by guarding language scopers, which see those. Without
+/// any, or if checking per scope, the first other scoper is guarded, which sees what
+/// language scopers left in scope (if any).
+#[allow(clippy::type_complexity)]
+fn assemble_guards(
+    args: &cli::Cli,
+    language_scopers: Vec<(cli::LanguageName, Box<dyn Scoper>)>,
+    mut scopers: Vec<Box<dyn Scoper>>,
+) -> Result<(
+    Vec<(cli::LanguageName, Box<dyn Scoper>)>,
+    Vec<Box<dyn Scoper>>,
+)> {
+    let options = &args.options;
+    if options.required.is_empty() && options.forbidden.is_empty() {
+        return Ok((language_scopers, scopers));
     }
 
-    if let Some(python) = args.languages_scopes.python.clone() {
-        if let Some(premade) = python.python {
-            let query = PythonQuery::Premade(premade);
+    let guard = |scoper: Box<dyn Scoper>| -> Result<Box<dyn Scoper>> {
+        let mut guarded = Guarded::new(scoper);
+        for pattern in &options.required {
+            let regex = Regex::try_from(pattern.clone())
+                .with_context(|| format!("Failed building '--if' regex: {pattern}"))?;
+            guarded = guarded.requiring(regex);
+        }
+        for pattern in &options.forbidden {
+            let regex = Regex::try_from(pattern.clone())
+                .with_context(|| format!("Failed building '--unless' regex: {pattern}"))?;
+            guarded = guarded.forbidding(regex);
+        }
 
-            scopers.push(Box::new(Python::new(query)));
-        } else if let Some(custom) = python.python_query {
-            let query = PythonQuery::Custom(custom);
+        Ok(Box::new(guarded))
+    };
 
-            scopers.push(Box::new(Python::new(query)));
+    if language_scopers.is_empty() || options.guard_per_scope {
+        debug!("Guarding first scoper");
+        if !scopers.is_empty() {
+            let first = scopers.remove(0);
+            scopers.insert(0, guard(first)?);
         }
+
+        return Ok((language_scopers, scopers));
     }
 
-    if let Some(rust) = args.languages_scopes.rust.clone() {
-        if let Some(premade) = rust.rust {
-            let query = RustQuery::Premade(premade);
+    debug!("Guarding language scopers");
+    let language_scopers = language_scopers
+        .into_iter()
+        .map(|(language, scoper)| Ok((language, guard(scoper)?)))
+        .collect::<Result<Vec<_>>>()?;
 
-            scopers.push(Box::new(Rust::new(query)));
-        } else if let Some(custom) = rust.rust_query {
-            let query = RustQuery::Custom(custom);
+    Ok((language_scopers, scopers))
+}
 
-            scopers.push(Box::new(Rust::new(query)));
-        }
+/// The scope as a regex pattern, with the flags requested (case-insensitive etc.).
+fn regex_scope(args: &cli::Cli) -> String {
+    let flags = [
+        (args.options.ignore_case, 'i'),
+        (args.options.multiline, 'm'),
+        (args.options.dotall, 's'),
+    ]
+    .into_iter()
+    .filter_map(|(is_set, flag)| is_set.then_some(flag))
+    .collect::<String>();
+
+    if flags.is_empty() {
+        args.scope.clone()
+    } else {
+        // Applies to the entire pattern, across all alternations.
+        format!("(?{flags}){}", args.scope)
     }
+}
 
-    if let Some(typescript) = args.languages_scopes.typescript.clone() {
-        if let Some(premade) = typescript.typescript {
-            let query = TypeScriptQuery::Premade(premade);
+/// The scope as a literal string, case-insensitive if requested.
+fn literal_scope(args: &cli::Cli) -> Result<Literal, LiteralError> {
+    let literal = Literal::try_from(args.scope.clone())?;
 
-            scopers.push(Box::new(TypeScript::new(query)));
-        } else if let Some(custom) = typescript.typescript_query {
-            let query = TypeScriptQuery::Custom(custom);
+    Ok(if args.options.ignore_case {
+        literal.case_insensitive()
+    } else {
+        literal
+    })
+}
 
-            scopers.push(Box::new(TypeScript::new(query)));
-        }
+/// Checks whether `replacement` refers to any capture groups, such as `$1` or
+/// `${name}`.
+///
+/// Regex scopes then need to be whole matches, with their capture groups at hand.
+fn refers_to_capture_groups(replacement: &str) -> bool {
+    Replacement::try_from(replacement.to_owned()).is_ok_and(|r| r.has_references())
+}
+
+/// Assembles a prefilter to skip files which cannot contain anything in scope, without
+/// any parsing.
+///
+/// Nothing in scope means files are left unchanged *unless* that is an error, so there
+/// is no prefilter for `--fail-none`.
+fn assemble_prefilter(args: &cli::Cli) -> Option<Prefilter> {
+    if args.options.fail_none {
+        return None;
     }
 
-    if args.options.literal_string {
-        scopers.push(Box::new(
-            Literal::try_from(args.scope.clone()).context("Failed building literal string")?,
-        ));
+    let prefilter = if args.options.literal_string {
+        literal_scope(args).ok()?.prefilter()
     } else {
-        scopers.push(Box::new(
-            Regex::try_from(args.scope.clone()).context("Failed building regex")?,
-        ));
+        Regex::try_from(regex_scope(args)).ok()?.prefilter()
+    };
+    debug!("Assembled prefilter: {:?}", prefilter);
+
+    prefilter
+}
+
+/// Checks whether the file at `path`, with the given `contents`, is written in
+/// `language`.
+///
+/// Files *not* in the language are to be skipped, as applying a scope for one language
+/// to source code of another is meaningless.
+///
+/// User-provided `mappings` take precedence, in order. Only if none of them match is
+/// the language's own detection consulted.
+fn is_in_language(
+    path: &Path,
+    contents: &str,
+    language: cli::LanguageName,
+    mappings: &[cli::LanguageMapping],
+) -> bool {
+    if let Some(mapping) = mappings.iter().find(|m| m.matches(path)) {
+        debug!("Path {:?} matched by language mapping {:?}", path, mapping);
+        return mapping.language == language;
     }
 
-    Ok(scopers)
+    match language {
+        cli::LanguageName::Bash => Bash::is_valid_file(path, contents),
+        cli::LanguageName::Clojure => Clojure::is_valid_file(path, contents),
+        cli::LanguageName::CMake => CMake::is_valid_file(path, contents),
+        cli::LanguageName::CSharp => CSharp::is_valid_file(path, contents),
+        cli::LanguageName::Css => Css::is_valid_file(path, contents),
+        cli::LanguageName::Dart => Dart::is_valid_file(path, contents),
+        cli::LanguageName::Dockerfile => Dockerfile::is_valid_file(path, contents),
+        cli::LanguageName::Elixir => Elixir::is_valid_file(path, contents),
+        cli::LanguageName::Erlang => Erlang::is_valid_file(path, contents),
+        cli::LanguageName::Go => Go::is_valid_file(path, contents),
+        cli::LanguageName::GraphQL => GraphQL::is_valid_file(path, contents),
+        cli::LanguageName::Groovy => Groovy::is_valid_file(path, contents),
+        cli::LanguageName::Haskell => Haskell::is_valid_file(path, contents),
+        cli::LanguageName::Html => Html::is_valid_file(path, contents),
+        cli::LanguageName::Java => Java::is_valid_file(path, contents),
+        cli::LanguageName::Json => Json::is_valid_file(path, contents),
+        cli::LanguageName::Julia => Julia::is_valid_file(path, contents),
+        cli::LanguageName::Kotlin => Kotlin::is_valid_file(path, contents),
+        cli::LanguageName::Lua => Lua::is_valid_file(path, contents),
+        cli::LanguageName::Makefile => Makefile::is_valid_file(path, contents),
+        cli::LanguageName::Markdown => Markdown::is_valid_file(path, contents),
+        cli::LanguageName::Nix => Nix::is_valid_file(path, contents),
+        cli::LanguageName::ObjC => ObjC::is_valid_file(path, contents),
+        cli::LanguageName::OCaml => OCaml::is_valid_file(path, contents),
+        cli::LanguageName::Perl => Perl::is_valid_file(path, contents),
+        cli::LanguageName::Php => Php::is_valid_file(path, contents),
+        cli::LanguageName::PowerShell => PowerShell::is_valid_file(path, contents),
+        cli::LanguageName::Proto => Proto::is_valid_file(path, contents),
+        cli::LanguageName::Python => Python::is_valid_file(path, contents),
+        cli::LanguageName::Ruby => Ruby::is_valid_file(path, contents),
+        cli::LanguageName::Rust => Rust::is_valid_file(path, contents),
+        cli::LanguageName::Scala => Scala::is_valid_file(path, contents),
+        cli::LanguageName::Scss => Scss::is_valid_file(path, contents),
+        cli::LanguageName::Solidity => Solidity::is_valid_file(path, contents),
+        cli::LanguageName::Sql => Sql::is_valid_file(path, contents),
+        cli::LanguageName::Svelte => Svelte::is_valid_file(path, contents),
+        cli::LanguageName::Swift => Swift::is_valid_file(path, contents),
+        cli::LanguageName::Toml => Toml::is_valid_file(path, contents),
+        cli::LanguageName::TypeScript => TypeScript::is_valid_file(path, contents),
+        cli::LanguageName::Vue => Vue::is_valid_file(path, contents),
+        cli::LanguageName::Yaml => Yaml::is_valid_file(path, contents),
+        cli::LanguageName::Zig => Zig::is_valid_file(path, contents),
+    }
 }
 
 fn assemble_actions(args: &cli::Cli) -> Result<Vec<Box<dyn Action>>> {
     let mut actions: Vec<Box<dyn Action>> = Vec::new();
 
     if let Some(replacement) = args.composable_actions.replace.clone() {
-        actions.push(Box::new(
-            Replacement::try_from(replacement).context("Failed building replacement string")?,
-        ));
+        let replacement =
+            Replacement::try_from(replacement).context("Failed building replacement string")?;
+
+        let replacement = if args.options.replace_literal {
+            replacement.without_references()
+        } else {
+            replacement
+        };
+
+        let replacement = if args.options.reindent {
+            replacement.reindented()
+        } else {
+            replacement
+        };
+
+        let replacement = if args.options.preserve_case {
+            replacement.preserving_case()
+        } else {
+            replacement
+        };
+
+        // Multiline replacements of comments would otherwise end them prematurely.
+        if args.languages_scopes.are_comments() {
+            actions.push(Box::new(replacement.continuing_comments()));
+        } else {
+            actions.push(Box::new(replacement));
+        }
         debug!("Loaded action: Replacement");
     }
 
@@ -397,7 +1458,10 @@ fn assemble_actions(args: &cli::Cli) -> Result<Vec<Box<dyn Action>>> {
         debug!("Loaded action: Normalization");
     }
 
-    if actions.is_empty() && !(args.options.fail_any || args.options.fail_none) {
+    if actions.is_empty()
+        && !(args.options.fail_any || args.options.fail_none)
+        && args.options.output.is_none()
+    {
         // Doesn't hurt, but warn loudly
         error!("No actions loaded, will return input unchanged");
     }
@@ -428,328 +1492,6 @@ fn level_filter_from_env_and_verbosity(additional_verbosity: u8) -> LevelFilter
     })
 }
 
-mod cli {
-    use clap::{builder::ArgPredicate, ArgAction, Command, CommandFactory, Parser};
-    use clap_complete::{generate, Generator, Shell};
-    use srgn::{
-        scoping::langs::{
-            csharp::{CustomCSharpQuery, PremadeCSharpQuery},
-            go::{CustomGoQuery, PremadeGoQuery},
-            python::{CustomPythonQuery, PremadePythonQuery},
-            rust::{CustomRustQuery, PremadeRustQuery},
-            typescript::{CustomTypeScriptQuery, PremadeTypeScriptQuery},
-        },
-        GLOBAL_SCOPE,
-    };
-
-    /// Main CLI entrypoint.
-    ///
-    /// Using `verbatim_doc_comment` a lot as otherwise lines wouldn't wrap neatly. I
-    /// format them narrowly manually anyway, so can just use them verbatim.
-    #[derive(Parser, Debug)]
-    #[command(author, version, about, verbatim_doc_comment, long_about = None)]
-    pub(super) struct Cli {
-        /// Scope to apply to, as a regular expression pattern
-        ///
-        /// If string literal mode is requested, will be interpreted as a literal string.
-        ///
-        /// Actions will apply their transformations within this scope only.
-        ///
-        /// The default is the global scope, matching the entire input.
-        ///
-        /// Where that default is meaningless (e.g., deletion), this argument is
-        /// _required_.
-        #[arg(
-            value_name = "SCOPE",
-            default_value = GLOBAL_SCOPE,
-            verbatim_doc_comment,
-            default_value_if("literal_string", ArgPredicate::IsPresent, None)
-        )]
-        pub scope: String,
-
-        /// Print shell completions for the given shell
-        // This thing needs to live up here to show up within `Options` next to `--help`
-        // and `--version`. Further down, it'd show up in the wrong section because we
-        // alter `next_help_heading`.
-        #[arg(long = "completions", value_enum, verbatim_doc_comment)]
-        pub shell: Option<Shell>,
-
-        #[command(flatten)]
-        pub composable_actions: ComposableActions,
-
-        #[command(flatten)]
-        pub standalone_actions: StandaloneActions,
-
-        #[command(flatten)]
-        pub options: GlobalOptions,
-
-        #[command(flatten)]
-        pub languages_scopes: LanguageScopes,
-
-        #[cfg(feature = "german")]
-        #[command(flatten)]
-        pub german_options: GermanOptions,
-    }
-
-    /// https://github.com/clap-rs/clap/blob/f65d421607ba16c3175ffe76a20820f123b6c4cb/clap_complete/examples/completion-derive.rs#L69
-    pub(super) fn print_completions<G: Generator>(gen: G, cmd: &mut Command) {
-        generate(gen, cmd, cmd.get_name().to_string(), &mut std::io::stdout());
-    }
-
-    #[derive(Parser, Debug)]
-    #[group(required = false, multiple = true)]
-    #[command(next_help_heading = "Options (global)")]
-    pub(super) struct GlobalOptions {
-        /// Glob of files to work on (instead of reading stdin).
-        ///
-        /// If processing occurs, it is done in-place, overwriting originals.
-        ///
-        /// For supported glob syntax, see:
-        /// https://docs.rs/glob/0.3.1/glob/struct.Pattern.html
-        ///
-        /// Names of processed files are written to stdout.
-        #[arg(long, verbatim_doc_comment)]
-        pub files: Option<glob::Pattern>,
-        /// Fail if file globbing is requested but returns no matches.
-        #[arg(long, verbatim_doc_comment, requires = "files")]
-        pub fail_empty_glob: bool,
-        /// Undo the effects of passed actions, where applicable
-        ///
-        /// Requires a 1:1 mapping (bijection) between replacements and original, which
-        /// is currently available for:
-        ///
-        /// - symbols: '≠' <-> '!=' etc.
-        ///
-        /// Other actions:
-        ///
-        /// - german: inverting e.g. 'Ä' is ambiguous (can be 'Ae' or 'AE')
-        ///
-        /// - upper, lower, deletion, squeeze: inversion is impossible as information is
-        ///   lost
-        ///
-        /// These may still be passed, but will be ignored for inversion and applied
-        /// normally
-        #[cfg(feature = "symbols")]
-        #[arg(short, long, env, requires = "symbols", verbatim_doc_comment)]
-        pub invert: bool,
-        /// Do not interpret the scope as a regex. Instead, interpret it as a literal
-        /// string. Will require a scope to be passed.
-        #[arg(short('L'), long, env, verbatim_doc_comment)]
-        pub literal_string: bool,
-        /// If anything at all is found to be in scope, fail.
-        ///
-        /// The default is to continue processing normally.
-        #[arg(long, verbatim_doc_comment)]
-        pub fail_any: bool,
-        /// If nothing is found to be in scope, fail.
-        ///
-        /// The default is to return the input unchanged (without failure).
-        #[arg(long, verbatim_doc_comment)]
-        pub fail_none: bool,
-        /// Increase log verbosity level
-        ///
-        /// The base log level to use is read from the `RUST_LOG` environment variable
-        /// (if missing, 'error'), and increased according to the number of times this
-        /// flag is given.
-        #[arg(
-            short = 'v',
-            long = "verbose",
-            action = ArgAction::Count,
-            verbatim_doc_comment
-        )]
-        pub additional_verbosity: u8,
-    }
-
-    #[derive(Parser, Debug)]
-    #[group(required = false, multiple = true)]
-    #[command(next_help_heading = "Composable Actions")]
-    pub(super) struct ComposableActions {
-        /// Replace scope by this (fixed) value
-        ///
-        /// Specially treated action for ergonomics and compatibility with `tr`.
-        ///
-        /// If given, will run before any other action.
-        #[arg(value_name = "REPLACEMENT", env, verbatim_doc_comment)]
-        pub replace: Option<String>,
-        /// Uppercase scope
-        #[arg(short, long, env, verbatim_doc_comment)]
-        pub upper: bool,
-        /// Lowercase scope
-        #[arg(short, long, env, verbatim_doc_comment)]
-        pub lower: bool,
-        /// Titlecase scope
-        #[arg(short, long, env, verbatim_doc_comment)]
-        pub titlecase: bool,
-        /// Normalize (Normalization Form D) scope, and throw away marks
-        #[arg(short, long, env, verbatim_doc_comment)]
-        pub normalize: bool,
-        /// Perform substitutions on German words, such as 'Abenteuergruesse' to
-        /// 'Abenteuergrüße'
-        ///
-        /// ASCII spellings for Umlauts (ae, oe, ue) and Eszett (ss) are replaced by
-        /// their respective native Unicode (ä, ö, ü, ß).
-        ///
-        /// Arbitrary compound words are supported.
-        ///
-        /// Words legally containing alternative spellings are not modified.
-        ///
-        /// Words require correct spelling to be detected.
-        #[cfg(feature = "german")]
-        #[arg(
-            short,
-            long,
-            verbatim_doc_comment,
-            // `true` as string is very ugly, but there's no other way?
-            default_value_if("german-opts", ArgPredicate::IsPresent, "true")
-        )]
-        pub german: bool,
-        /// Perform substitutions on symbols, such as '!=' to '≠', '->' to '→'
-        ///
-        /// Helps translate 'ASCII art' into native Unicode representations.
-        #[cfg(feature = "symbols")]
-        #[arg(short = 'S', long, verbatim_doc_comment)]
-        pub symbols: bool,
-    }
-
-    #[derive(Parser, Debug)]
-    #[group(required = false, multiple = false)]
-    #[command(next_help_heading = "Standalone Actions (only usable alone)")]
-    pub(super) struct StandaloneActions {
-        /// Delete scope
-        ///
-        /// Cannot be used with any other action: no point in deleting and performing any
-        /// other action. Sibling actions would either receive empty input or have their
-        /// work wiped.
-        #[arg(
-            short,
-            long,
-            requires = "scope",
-            conflicts_with = stringify!(ComposableActions),
-            verbatim_doc_comment
-        )]
-        pub delete: bool,
-        /// Squeeze consecutive occurrences of scope into one
-        #[arg(
-            short,
-            long,
-            visible_alias("squeeze-repeats"),
-            env,
-            requires = "scope",
-            verbatim_doc_comment
-        )]
-        pub squeeze: bool,
-    }
-
-    #[derive(Parser, Debug)]
-    #[group(required = false, multiple = false)]
-    #[command(next_help_heading = "Language scopes")]
-    pub(super) struct LanguageScopes {
-        #[command(flatten)]
-        pub csharp: Option<CSharpScope>,
-        #[command(flatten)]
-        pub go: Option<GoScope>,
-        #[command(flatten)]
-        pub python: Option<PythonScope>,
-        #[command(flatten)]
-        pub rust: Option<RustScope>,
-        #[command(flatten)]
-        pub typescript: Option<TypeScriptScope>,
-    }
-
-    #[derive(Parser, Debug, Clone)]
-    #[group(required = false, multiple = false)]
-    pub(super) struct CSharpScope {
-        /// Scope CSharp code using a premade query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub csharp: Option<PremadeCSharpQuery>,
-
-        /// Scope CSharp code using a custom tree-sitter query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub csharp_query: Option<CustomCSharpQuery>,
-    }
-
-    #[derive(Parser, Debug, Clone)]
-    #[group(required = false, multiple = false)]
-    pub(super) struct GoScope {
-        /// Scope Go code using a premade query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub go: Option<PremadeGoQuery>,
-
-        /// Scope Go code using a custom tree-sitter query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub go_query: Option<CustomGoQuery>,
-    }
-
-    #[derive(Parser, Debug, Clone)]
-    #[group(required = false, multiple = false)]
-    pub(super) struct PythonScope {
-        /// Scope Python code using a premade query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub python: Option<PremadePythonQuery>,
-
-        /// Scope Python code using a custom tree-sitter query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub python_query: Option<CustomPythonQuery>,
-    }
-
-    #[derive(Parser, Debug, Clone)]
-    #[group(required = false, multiple = false)]
-    pub(super) struct RustScope {
-        /// Scope Rust code using a premade query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub rust: Option<PremadeRustQuery>,
-
-        /// Scope Rust code using a custom tree-sitter query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub rust_query: Option<CustomRustQuery>,
-    }
-
-    #[derive(Parser, Debug, Clone)]
-    #[group(required = false, multiple = false)]
-    pub(super) struct TypeScriptScope {
-        /// Scope TypeScript code using a premade query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub typescript: Option<PremadeTypeScriptQuery>,
-
-        /// Scope TypeScript code using a custom tree-sitter query.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub typescript_query: Option<CustomTypeScriptQuery>,
-    }
-
-    #[cfg(feature = "german")]
-    #[derive(Parser, Debug)]
-    #[group(required = false, multiple = true, id("german-opts"))]
-    #[command(next_help_heading = "Options (german)")]
-    pub(super) struct GermanOptions {
-        /// When some original version and its replacement are equally legal, prefer the
-        /// original and do not modify.
-        ///
-        /// For example, "Busse" (original) and "Buße" (replacement) are equally legal
-        /// words: by default, the tool would prefer the latter.
-        // More fine-grained control is not available. We are not in the business of
-        // natural language processing or LLMs, so that's all we can offer...
-        #[arg(long, env, verbatim_doc_comment)]
-        pub german_prefer_original: bool,
-        /// Always perform any possible replacement ('ae' -> 'ä', 'ss' -> 'ß', etc.),
-        /// regardless of legality of the resulting word
-        ///
-        /// Useful for names, which are otherwise not modifiable as they do not occur in
-        /// dictionaries. Called 'naive' as this does not perform legal checks.
-        #[arg(long, env, verbatim_doc_comment)]
-        pub german_naive: bool,
-    }
-
-    impl Cli {
-        pub(super) fn init() -> Self {
-            Self::parse()
-        }
-
-        pub(super) fn command() -> clap::Command {
-            <Self as CommandFactory>::command()
-        }
-    }
-}
-
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -829,4 +1571,173 @@ mod tests {
         let result = level_filter_from_env_and_verbosity(additional_verbosity);
         assert_eq!(result, expected);
     }
+
+    #[rstest]
+    #[case(b"abc", None, "abc")]
+    #[case(b"", None, "")]
+    #[case(b"\xEF\xBB\xBFabc", Some(encoding::Bom::Utf8), "abc")]
+    #[case(b"\xFF\xFEa\x00b\x00", Some(encoding::Bom::Utf16Le), "ab")]
+    #[case(b"\xFE\xFF\x00a\x00b", Some(encoding::Bom::Utf16Be), "ab")]
+    #[case(b"\xFF\xFE=\xD8\x00\xDE", Some(encoding::Bom::Utf16Le), "😀")]
+    fn test_encoding_roundtrip(
+        #[case] bytes: &[u8],
+        #[case] expected_bom: Option<encoding::Bom>,
+        #[case] expected_text: &str,
+    ) {
+        let (bom, text) = encoding::decode(bytes.to_vec()).unwrap();
+        assert_eq!(bom, expected_bom);
+        assert_eq!(text, expected_text);
+
+        assert_eq!(encoding::encode(&text, bom), bytes);
+    }
+
+    #[rstest]
+    #[case(b"invalid utf8 \xFF")]
+    #[case(b"\xFF\xFEa\x00b")] // Odd length
+    #[case(b"\xFF\xFE\x00\xD8")] // Lone surrogate
+    fn test_encoding_invalid(#[case] bytes: &[u8]) {
+        assert!(encoding::decode(bytes.to_vec()).is_err());
+    }
+
+    #[rstest]
+    #[case("*.pyi=python", "stubs/foo.pyi", Some(true))]
+    #[case("*.pyi=python", "foo.py", Some(false))]
+    #[case("Jenkinsfile=go", "ci/Jenkinsfile", Some(true))]
+    #[case("ci/*=go", "ci/Jenkinsfile", Some(true))]
+    #[case("ci/*=go", "other/ci/Jenkinsfile", Some(false))]
+    #[case("*.gotmpl=GO", "a.gotmpl", Some(true))]
+    #[case("*.gotmpl=klingon", "a.gotmpl", None)]
+    #[case("*.gotmpl", "a.gotmpl", None)]
+    fn test_language_mapping(
+        #[case] mapping: &str,
+        #[case] path: &str,
+        #[case] expected: Option<bool>,
+    ) {
+        use std::str::FromStr;
+
+        let result = cli::LanguageMapping::from_str(mapping)
+            .ok()
+            .map(|m| m.matches(Path::new(path)));
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    #[case("0", Some(0))]
+    #[case("1024", Some(1024))]
+    #[case("1K", Some(1024))]
+    #[case("512m", Some(512 * 1024 * 1024))]
+    #[case("2GiB", Some(2 * 1024 * 1024 * 1024))]
+    #[case("", None)]
+    #[case("K", None)]
+    #[case("1T", None)]
+    #[case("-1", None)]
+    #[case("1.5G", None)]
+    fn test_byte_size(#[case] input: &str, #[case] expected: Option<usize>) {
+        use std::str::FromStr;
+
+        let result = cli::ByteSize::from_str(input).ok().map(|size| size.0);
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    #[case("0s", Some(Duration::ZERO))]
+    #[case("500ms", Some(Duration::from_millis(500)))]
+    #[case("2s", Some(Duration::from_secs(2)))]
+    #[case("3m", Some(Duration::from_secs(180)))]
+    #[case("", None)]
+    #[case("2", None)]
+    #[case("s", None)]
+    #[case("1h", None)]
+    #[case("1.5s", None)]
+    fn test_timeout(#[case] input: &str, #[case] expected: Option<Duration>) {
+        use std::str::FromStr;
+
+        let result = cli::Timeout::from_str(input).ok().map(|timeout| timeout.0);
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    #[case("1", Some(0..1))]
+    #[case("2", Some(1..2))]
+    #[case("2..4", Some(1..4))]
+    #[case("3..", Some(2..usize::MAX))]
+    #[case("..2", Some(0..2))]
+    #[case("2..2", Some(1..2))]
+    #[case("0", None)]
+    #[case("0..2", None)]
+    #[case("3..2", None)]
+    #[case("..", None)]
+    #[case("", None)]
+    #[case("-1", None)]
+    #[case("1...2", None)]
+    fn test_occurrence(#[case] input: &str, #[case] expected: Option<Range<usize>>) {
+        use std::str::FromStr;
+
+        let result = cli::Occurrence::from_str(input)
+            .ok()
+            .map(|occurrence| occurrence.0);
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    #[case("1", Some(1..2))]
+    #[case("2..4", Some(2..5))]
+    #[case("3..", Some(3..usize::MAX))]
+    #[case("2..2", Some(2..3))]
+    #[case("..2", None)]
+    #[case("0", None)]
+    #[case("3..2", None)]
+    #[case("", None)]
+    #[case("1:2", None)]
+    fn test_line_range(#[case] input: &str, #[case] expected: Option<Range<usize>>) {
+        use std::str::FromStr;
+
+        let result = cli::LineRange::from_str(input).ok().map(|lines| lines.0);
+        assert_eq!(result, expected);
+    }
+
+    #[rstest]
+    #[case(None, None, None)]
+    #[case(Some("2.."), None, Some(1..usize::MAX))]
+    #[case(None, Some(2), Some(0..2))]
+    #[case(Some("2.."), Some(2), Some(1..3))]
+    #[case(Some("2..3"), Some(5), Some(1..3))]
+    fn test_select_occurrences(
+        #[case] occurrence: Option<&str>,
+        #[case] max_count: Option<usize>,
+        #[case] expected: Option<Range<usize>>,
+    ) {
+        use std::str::FromStr;
+
+        let occurrence = occurrence.map(|o| cli::Occurrence::from_str(o).unwrap());
+        assert_eq!(select_occurrences(occurrence.as_ref(), max_count), expected);
+    }
+
+    #[rstest]
+    #[case("a\nb\nc\n", vec![], vec![])]
+    #[case("a\nb\nc\n", vec![1..2], vec![0..2])]
+    #[case("a\nb\nc\n", vec![2..4], vec![2..6])]
+    #[case("a\nb\nc", vec![3..4], vec![4..5])]
+    #[case("a\nb\nc\n", vec![1..usize::MAX], vec![0..6])]
+    #[case("a\n", vec![5..6], vec![2..2])]
+    fn test_line_byte_ranges(
+        #[case] source: &str,
+        #[case] lines: Vec<Range<usize>>,
+        #[case] expected: Vec<Range<usize>>,
+    ) {
+        assert_eq!(line_byte_ranges(source, &lines), expected);
+    }
+
+    #[rstest]
+    #[case(&[1..3], &[], vec![])]
+    #[case(&[1..3], &[2..5], vec![2..3])]
+    #[case(&[1..3, 4..6], &[2..5], vec![2..3, 4..5])]
+    #[case(&[1..usize::MAX], &[2..3, 7..9], vec![2..3, 7..9])]
+    fn test_intersect_lines(
+        #[case] left: &[Range<usize>],
+        #[case] right: &[Range<usize>],
+        #[case] expected: Vec<Range<usize>>,
+    ) {
+        assert_eq!(intersect_lines(left, right), expected);
+    }
 }
@@ -0,0 +1,254 @@
+//! A [Model Context Protocol](https://modelcontextprotocol.io/) server, run via
+//! `srgn mcp`.
+//!
+//! Lets agents (and other clients) use srgn as a tool, over stdin and stdout, with
+//! one JSON-RPC message per line. Tools take the keys of a single
+//! [recipe](super::recipe) stage as arguments:
+//!
+//! - `search`: lists parts in scope, leaving files unchanged
+//! - `apply`: applies actions to parts in scope, writing changed files
+//!
+//! Failures of tools, such as invalid arguments, are reported as results for the
+//! client to act on, not as protocol errors.
+
+use super::{cli::LanguageName, recipe};
+use anyhow::{Context, Result};
+use clap::ValueEnum;
+use log::{debug, info, warn};
+use serde::Deserialize;
+use serde_json::{json, Value};
+use std::io::{self, BufRead, Write};
+
+/// Version of the protocol implemented.
+const PROTOCOL_VERSION: &str = "2024-11-05";
+
+/// JSON-RPC error code for requests of unknown methods.
+const METHOD_NOT_FOUND: i64 = -32601;
+
+/// JSON-RPC error code for requests with invalid parameters.
+const INVALID_PARAMS: i64 = -32602;
+
+/// An incoming request, notification or response.
+#[derive(Debug, Deserialize)]
+struct Message {
+    id: Option<Value>,
+    method: Option<String>,
+    #[serde(default)]
+    params: Value,
+}
+
+#[derive(Debug, Deserialize)]
+struct Call {
+    name: String,
+    #[serde(default)]
+    arguments: Value,
+}
+
+/// Serves tools over stdin and stdout, until the client disconnects.
+pub(super) fn serve() -> Result<()> {
+    let mut output = io::stdout().lock();
+
+    for line in io::stdin().lock().lines() {
+        let line = line.context("Failed reading from client")?;
+        if line.trim().is_empty() {
+            continue;
+        }
+
+        let message: Message = match serde_json::from_str(&line) {
+            Ok(message) => message,
+            Err(e) => {
+                warn!("Ignoring malformed message: {e}");
+                continue;
+            }
+        };
+        let Some(method) = message.method else {
+            debug!("Ignoring response from client");
+            continue;
+        };
+        debug!("Received {method}");
+
+        let Some(id) = message.id else {
+            debug!("Ignoring notification: {method}");
+            continue;
+        };
+
+        let response = match handle(&method, message.params) {
+            Ok(result) => json!({ "jsonrpc": "2.0", "id": id, "result": result }),
+            Err((code, message)) => json!({
+                "jsonrpc": "2.0",
+                "id": id,
+                "error": { "code": code, "message": message },
+            }),
+        };
+
+        // Compact, so always a single line.
+        serde_json::to_writer(&mut output, &response)?;
+        writeln!(output)?;
+        output.flush().context("Failed writing to client")?;
+    }
+
+    info!("Client disconnected");
+    Ok(())
+}
+
+/// Handles a single request, returning its result or an error code and message.
+fn handle(method: &str, params: Value) -> Result<Value, (i64, String)> {
+    match method {
+        "initialize" => Ok(json!({
+            "protocolVersion": PROTOCOL_VERSION,
+            "capabilities": { "tools": {} },
+            "serverInfo": {
+                "name": env!("CARGO_PKG_NAME"),
+                "version": env!("CARGO_PKG_VERSION"),
+            },
+        })),
+        "ping" => Ok(json!({})),
+        "tools/list" => Ok(json!({ "tools": tools() })),
+        "tools/call" => {
+            let call: Call = serde_json::from_value(params)
+                .map_err(|e| (INVALID_PARAMS, format!("Invalid tool call: {e}")))?;
+
+            let result = match call.name.as_str() {
+                "search" => search(call.arguments),
+                "apply" => apply(call.arguments),
+                name => return Err((INVALID_PARAMS, format!("Unknown tool: {name}"))),
+            };
+
+            Ok(match result {
+                Ok(value) => json!({
+                    "content": [{ "type": "text", "text": value.to_string() }],
+                    "isError": false,
+                }),
+                Err(e) => json!({
+                    "content": [{ "type": "text", "text": format!("{e:#}") }],
+                    "isError": true,
+                }),
+            })
+        }
+        _ => Err((METHOD_NOT_FOUND, format!("Method not supported: {method}"))),
+    }
+}
+
+fn search(arguments: Value) -> Result<Value> {
+    let stage: recipe::Stage = serde_json::from_value(arguments).context("Invalid arguments")?;
+    let found = recipe::search(&stage)?;
+
+    Ok(json!(found))
+}
+
+fn apply(arguments: Value) -> Result<Value> {
+    let stage: recipe::Stage = serde_json::from_value(arguments).context("Invalid arguments")?;
+    let changed: Vec<_> = recipe::run_stage(&stage, None)?
+        .into_iter()
+        .filter_map(|(path, changed)| changed.then_some(path))
+        .collect();
+
+    Ok(json!({ "changed": changed }))
+}
+
+/// Descriptions of all tools, alongside the JSON schemas of their arguments.
+fn tools() -> Value {
+    let languages: Vec<_> = LanguageName::value_variants()
+        .iter()
+        .filter_map(ValueEnum::to_possible_value)
+        .map(|value| value.get_name().to_owned())
+        .collect();
+
+    let mut simple_actions = vec!["delete", "upper", "lower", "titlecase", "normalize"];
+    if cfg!(feature = "german") {
+        simple_actions.push("german");
+    }
+    if cfg!(feature = "symbols") {
+        simple_actions.push("symbols");
+    }
+
+    let scoping = json!({
+        "files": {
+            "type": "string",
+            "description": "Glob of files to work on, relative to the working directory, e.g. 'src/**/*.py'",
+        },
+        "language": {
+            "type": "string",
+            "enum": languages,
+            "description": "Language to scope to, using 'query' or 'custom-query'. Files not in this language are skipped.",
+        },
+        "query": {
+            "type": "string",
+            "description": "Name of a premade query of the language, e.g. 'comments' or 'strings'",
+        },
+        "custom-query": {
+            "type": "string",
+            "description": "A tree-sitter query over the language, with captures in scope",
+        },
+        "scope": {
+            "type": "string",
+            "description": "Regular expression to scope to, within what the language query (if any) found. Defaults to everything.",
+        },
+        "literal-string": {
+            "type": "boolean",
+            "description": "Interpret 'scope' as a literal string instead of a regular expression",
+        },
+    });
+
+    let mut applying = scoping.clone();
+    let properties = applying.as_object_mut().expect("Properties are an object");
+    properties.insert(
+        "actions".into(),
+        json!({
+            "type": "array",
+            "description": "Actions to apply to parts in scope, in order",
+            "items": {
+                "oneOf": [
+                    { "type": "string", "enum": simple_actions },
+                    {
+                        "type": "object",
+                        "properties": {
+                            "replace": {
+                                "type": "string",
+                                "description": "Replacement, which can refer to capture groups of 'scope' as '$1'",
+                            },
+                        },
+                        "required": ["replace"],
+                        "additionalProperties": false,
+                    },
+                ],
+            },
+        }),
+    );
+    properties.insert(
+        "squeeze".into(),
+        json!({
+            "type": "boolean",
+            "description": "Squeeze consecutive occurrences of 'scope' into one",
+        }),
+    );
+    properties.insert(
+        "preconditions".into(),
+        json!({
+            "type": "array",
+            "description": "Conditions checked across all files before any of them is written to",
+            "items": { "type": "string", "enum": ["files-matched", "any-in-scope", "none-in-scope"] },
+        }),
+    );
+
+    json!([
+        {
+            "name": "search",
+            "description": "Find parts of files in scope, for example only inside of comments of some language, leaving files unchanged. Returns path, line, column (1-based, in bytes) and text of each part.",
+            "inputSchema": {
+                "type": "object",
+                "properties": scoping,
+                "required": ["files"],
+            },
+        },
+        {
+            "name": "apply",
+            "description": "Apply actions (such as replacements) to parts of files in scope only, for example only inside of comments of some language, writing changed files. Returns the paths of changed files.",
+            "inputSchema": {
+                "type": "object",
+                "properties": applying,
+                "required": ["files", "actions"],
+            },
+        },
+    ])
+}
@@ -0,0 +1,441 @@
+//! Declarative, multi-step pipelines ("recipes"), run via `srgn run`.
+//!
+//! A recipe is a YAML file holding an ordered list of stages. Each stage works on
+//! its own set of files, with its own scopes and actions, same as a single
+//! invocation of the CLI would. For example:
+//!
+//! ```yaml
+//! stages:
+//!   - name: Mark old TODOs as done
+//!     files: "**/*.py"
+//!     language: python
+//!     query: comments
+//!     scope: TODO
+//!     actions:
+//!       - replace: DONE
+//!     preconditions:
+//!       - any-in-scope
+//! ```
+
+use super::{apply, cli::LanguageName, encoding, is_in_language, report, write_atomically};
+use anyhow::{anyhow, bail, Context, Result};
+use clap::ValueEnum;
+use log::{debug, info};
+use serde::{Deserialize, Serialize};
+#[cfg(feature = "german")]
+use srgn::actions::German;
+#[cfg(feature = "symbols")]
+use srgn::actions::Symbols;
+use srgn::{
+    actions::{Action, Deletion, Lower, Normalization, Replacement, Titlecase, Upper},
+    scoping::{
+        langs::{self, NodeScoper, RawQuery},
+        literal::Literal,
+        regex::Regex,
+        Scoper,
+    },
+    GLOBAL_SCOPE,
+};
+use std::{
+    fmt, fs,
+    io::Write,
+    path::{Path, PathBuf},
+    sync::Arc,
+};
+
+/// An entire recipe, as read from a file.
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields, rename_all = "kebab-case")]
+struct Recipe {
+    stages: Vec<Stage>,
+}
+
+/// A single step of a recipe.
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields, rename_all = "kebab-case")]
+pub(super) struct Stage {
+    /// Human-readable name, for logging and error messages.
+    name: Option<String>,
+    /// Glob of files to work on, relative to the working directory.
+    files: String,
+    /// Name of the language to scope to, if any.
+    ///
+    /// Files not in this language are skipped.
+    language: Option<String>,
+    /// Name of a premade query of `language`.
+    query: Option<String>,
+    /// A custom tree-sitter query over `language`.
+    custom_query: Option<String>,
+    /// Scope to apply to, as a regular expression pattern.
+    #[serde(default = "global_scope")]
+    scope: String,
+    /// Interpret `scope` as a literal string instead.
+    #[serde(default)]
+    literal_string: bool,
+    /// Squeeze consecutive occurrences of the scope into one.
+    #[serde(default)]
+    squeeze: bool,
+    /// Actions to apply, in order.
+    #[serde(default)]
+    actions: Vec<ActionSpec>,
+    /// Conditions which need to hold for the stage to be applied.
+    #[serde(default)]
+    preconditions: Vec<Precondition>,
+}
+
+fn global_scope() -> String {
+    GLOBAL_SCOPE.to_string()
+}
+
+impl fmt::Display for Stage {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match &self.name {
+            Some(name) => write!(f, "'{}'", name),
+            None => write!(f, "on '{}'", self.files),
+        }
+    }
+}
+
+/// An action as spelled in a recipe, e.g. `upper`, or `replace: value`.
+#[derive(Debug, Deserialize)]
+#[serde(rename_all = "kebab-case")]
+enum ActionSpec {
+    Replace(String),
+    #[cfg(feature = "german")]
+    German,
+    #[cfg(feature = "symbols")]
+    Symbols,
+    Delete,
+    Upper,
+    Lower,
+    Titlecase,
+    Normalize,
+}
+
+impl ActionSpec {
+    fn build(&self) -> Result<Box<dyn Action>> {
+        let action: Box<dyn Action> = match self {
+            Self::Replace(replacement) => Box::new(
+                Replacement::try_from(replacement.clone())
+                    .context("Failed building replacement string")?,
+            ),
+            #[cfg(feature = "german")]
+            Self::German => Box::<German>::default(),
+            #[cfg(feature = "symbols")]
+            Self::Symbols => Box::<Symbols>::default(),
+            Self::Delete => Box::<Deletion>::default(),
+            Self::Upper => Box::<Upper>::default(),
+            Self::Lower => Box::<Lower>::default(),
+            Self::Titlecase => Box::<Titlecase>::default(),
+            Self::Normalize => Box::<Normalization>::default(),
+        };
+
+        Ok(action)
+    }
+}
+
+/// Conditions checked across all files of a stage, before any of them is written
+/// to.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Deserialize)]
+#[serde(rename_all = "kebab-case")]
+enum Precondition {
+    /// The stage's glob matches at least one file (in its language, if any).
+    FilesMatched,
+    /// At least one file has something in scope.
+    AnyInScope,
+    /// No file has anything in scope.
+    NoneInScope,
+}
+
+/// Runs the recipe at `path`, stage by stage, returning how many files were
+/// changed.
+///
+/// Stages run in order, each seeing the results of all previous ones. If a stage
+/// fails (including unmet preconditions), the run is aborted: files of that stage
+/// are left unchanged, while changes of previous stages remain.
+///
+/// If `files` are given, stages work on those of them their glob matches, instead
+/// of on all files it matches. Stages matching none of them are skipped.
+pub(super) fn run(path: &Path, files: Option<&[PathBuf]>) -> Result<usize> {
+    let recipe = read(path)?;
+    info!("Loaded recipe with {} stage(s)", recipe.stages.len());
+
+    let mut n_changed = 0;
+    for (i, stage) in recipe.stages.iter().enumerate() {
+        info!("Running stage {} {}", i + 1, stage);
+        let processed =
+            run_stage(stage, files).with_context(|| format!("Stage {} {} failed", i + 1, stage))?;
+
+        for (path, changed) in processed {
+            writeln!(std::io::stdout().lock(), "{}", path.display())
+                .context("Failed writing processed file's name to stdout")?;
+            n_changed += usize::from(changed);
+        }
+    }
+
+    Ok(n_changed)
+}
+
+/// Runs the recipe at `path` on `files`, as a pre-commit hook.
+///
+/// Fails if any file was changed, so that changes can be reviewed (and staged) before
+/// committing. Nothing is staged here.
+pub(super) fn hook(path: &Path, files: &[PathBuf]) -> Result<()> {
+    let n_changed = run(path, Some(files))?;
+
+    if n_changed > 0 {
+        bail!("Changed {n_changed} file(s), review and stage them before committing");
+    }
+
+    Ok(())
+}
+
+/// A stage, ready to be applied to inputs.
+pub(super) struct Compiled {
+    /// How to refer to the stage in messages.
+    pub name: String,
+    pub files: glob::Pattern,
+    pub language: Option<(LanguageName, Box<dyn Scoper>)>,
+    /// The language's scoper once more, for [sessions][`srgn::session::Session`]
+    /// keeping syntax trees around.
+    pub syntax: Option<Arc<dyn NodeScoper>>,
+    pub scopers: Vec<Box<dyn Scoper>>,
+    pub actions: Vec<Box<dyn Action>>,
+    pub squeeze: bool,
+}
+
+/// Reads and compiles all stages of the recipe at `path`.
+pub(super) fn compile_all(path: &Path) -> Result<Vec<Compiled>> {
+    read(path)?
+        .stages
+        .iter()
+        .enumerate()
+        .map(|(i, stage)| {
+            compile(stage).with_context(|| format!("Stage {} {} is invalid", i + 1, stage))
+        })
+        .collect()
+}
+
+fn read(path: &Path) -> Result<Recipe> {
+    let contents =
+        fs::read_to_string(path).with_context(|| format!("Failed to read recipe: {:?}", path))?;
+
+    serde_yaml::from_str(&contents).with_context(|| format!("Failed to parse recipe: {:?}", path))
+}
+
+fn compile(stage: &Stage) -> Result<Compiled> {
+    let language = stage
+        .language
+        .as_deref()
+        .map(|name| {
+            let language = LanguageName::from_str(name, true).map_err(|e| anyhow!(e))?;
+            let query = match (&stage.query, &stage.custom_query) {
+                (Some(premade), None) => RawQuery::Premade(premade),
+                (None, Some(custom)) => RawQuery::Custom(custom),
+                (Some(_), Some(_)) => {
+                    bail!("Only one of `query` and `custom-query` can be given")
+                }
+                (None, None) => bail!("A language requires either `query` or `custom-query`"),
+            };
+            let scoper: Arc<dyn NodeScoper> = Arc::from(langs::by_name(name, query)?);
+
+            Ok::<_, anyhow::Error>((language, scoper))
+        })
+        .transpose()?;
+    let syntax = language.as_ref().map(|(_, scoper)| Arc::clone(scoper));
+    let language = language.map(|(name, scoper)| (name, Box::new(scoper) as Box<dyn Scoper>));
+
+    if language.is_none() && (stage.query.is_some() || stage.custom_query.is_some()) {
+        bail!("A query requires a language");
+    }
+
+    let scopers: Vec<Box<dyn Scoper>> = if stage.literal_string {
+        vec![Box::new(
+            Literal::try_from(stage.scope.clone()).context("Failed building literal string")?,
+        )]
+    } else {
+        let regex = Regex::try_from(stage.scope.clone()).context("Failed building regex")?;
+        let refers_to_capture_groups = stage.actions.iter().any(|action| match action {
+            ActionSpec::Replace(replacement) => super::refers_to_capture_groups(replacement),
+            _ => false,
+        });
+
+        if refers_to_capture_groups {
+            vec![Box::new(regex.with_capture_groups())]
+        } else {
+            vec![Box::new(regex)]
+        }
+    };
+
+    let actions = stage
+        .actions
+        .iter()
+        .map(ActionSpec::build)
+        .collect::<Result<Vec<_>>>()?;
+
+    Ok(Compiled {
+        name: stage.to_string(),
+        files: glob::Pattern::new(&stage.files).context("Invalid glob pattern")?,
+        language,
+        syntax,
+        scopers,
+        actions,
+        squeeze: stage.squeeze,
+    })
+}
+
+/// A part in scope of some stage, as found by [`search`].
+#[derive(Debug, Serialize)]
+pub(super) struct Found {
+    path: PathBuf,
+    /// Number (1-based) of the line the part starts on.
+    line: usize,
+    /// Byte offset (1-based) of the part within its line.
+    column: usize,
+    text: String,
+}
+
+/// All parts in scope of `stage` across its files, without applying any actions
+/// or checking preconditions.
+pub(super) fn search(stage: &Stage) -> Result<Vec<Found>> {
+    let compiled = compile(stage)?;
+    let language_scoper = compiled.language.as_ref().map(|(_, scoper)| scoper);
+
+    let mut found = Vec::new();
+    for (path, _, source) in inputs(&compiled, None)? {
+        let builder = super::scope(&source, language_scoper, &compiled.scopers);
+
+        found.extend(
+            report::matches(&source, builder)
+                .into_iter()
+                .map(|m| Found {
+                    path: path.clone(),
+                    line: m.line,
+                    column: m.column + 1,
+                    text: source[m.range].to_owned(),
+                }),
+        );
+    }
+
+    Ok(found)
+}
+
+/// Files of a `compiled` stage to work on alongside their contents: those its glob
+/// matches (of `files`, if given), and in its language if any.
+fn inputs(
+    compiled: &Compiled,
+    files: Option<&[PathBuf]>,
+) -> Result<Vec<(PathBuf, Option<encoding::Bom>, String)>> {
+    let Compiled {
+        files: pattern,
+        language,
+        ..
+    } = compiled;
+
+    let paths = match files {
+        Some(files) => files
+            .iter()
+            .filter(|path| pattern.matches_path(path))
+            .cloned()
+            .collect::<Vec<_>>(),
+        None => glob::glob(pattern.as_str())
+            .context("Invalid glob pattern")?
+            .collect::<Result<Vec<_>, _>>()
+            .context("Failed to glob")?,
+    };
+
+    let mut inputs = Vec::new();
+    for path in paths {
+        let (bom, source) = {
+            let bytes =
+                fs::read(&path).with_context(|| format!("Failed to read file: {:?}", path))?;
+
+            encoding::decode(bytes).with_context(|| format!("Failed to decode file: {:?}", path))?
+        };
+
+        if let Some((language, _)) = language {
+            if !is_in_language(&path, &source, *language, &[]) {
+                debug!("Skipping file not in language {:?}: {:?}", language, path);
+                continue;
+            }
+        }
+
+        inputs.push((path, bom, source));
+    }
+
+    Ok(inputs)
+}
+
+/// Runs a single `stage`, returning the files it processed, each alongside whether
+/// it was changed.
+///
+/// If `files` are given, the stage works on those of them its glob matches, and
+/// is skipped if there are none.
+pub(super) fn run_stage(stage: &Stage, files: Option<&[PathBuf]>) -> Result<Vec<(PathBuf, bool)>> {
+    let compiled = compile(stage)?;
+    let inputs = inputs(&compiled, files)?;
+    let Compiled {
+        language,
+        scopers,
+        actions,
+        ..
+    } = compiled;
+
+    if files.is_some() && inputs.is_empty() {
+        info!("None of the given files are for stage {}, skipping", stage);
+        return Ok(Vec::new());
+    }
+
+    let language_scoper = language.as_ref().map(|(_, scoper)| scoper);
+
+    for precondition in &stage.preconditions {
+        let any_in_scope = || {
+            inputs.iter().any(|(_, _, source)| {
+                super::scope(source, language_scoper, &scopers)
+                    .build()
+                    .has_any_in_scope()
+            })
+        };
+
+        let holds = match precondition {
+            Precondition::FilesMatched => !inputs.is_empty(),
+            Precondition::AnyInScope => any_in_scope(),
+            Precondition::NoneInScope => !any_in_scope(),
+        };
+
+        if !holds {
+            bail!("Precondition not met: {:?}", precondition);
+        }
+    }
+
+    let mut processed = Vec::new();
+    for (path, bom, source) in inputs {
+        let mut contents = Vec::new();
+        let applied = apply(
+            &source,
+            bom,
+            &mut contents,
+            language_scoper,
+            &scopers,
+            &actions,
+            false,
+            false,
+            stage.squeeze,
+            false,
+            None,
+            None,
+        )
+        .with_context(|| format!("Failed to process file contents: {:?}", path))?;
+
+        if applied.changed {
+            write_atomically(&path, &contents, false)
+                .with_context(|| format!("Failed to write to file: {:?}", path))?;
+        } else {
+            debug!("File contents unchanged, not writing: {:?}", path);
+        }
+
+        processed.push((path, applied.changed));
+    }
+
+    Ok(processed)
+}
@@ -0,0 +1,565 @@
+//! Reporting on parts in scope, instead of processing them.
+
+use super::cli::OutputFormat;
+use serde::{Deserialize, Serialize, Serializer};
+use srgn::{
+    actions::Action,
+    scoping::{view::ScopedViewBuilder, Scoper},
+};
+use std::{
+    io::{self, Write},
+    ops::{AddAssign, Range},
+    sync::Mutex,
+    time::{Duration, Instant},
+};
+
+/// A contiguous part of some input found to be in scope.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(super) struct Match<'viewee> {
+    /// Byte range of the match within the entire input.
+    pub range: Range<usize>,
+    /// Number (1-based) of the line the match starts on.
+    pub line: usize,
+    /// Byte offset (0-based) of the start of the line the match starts on.
+    pub line_start: usize,
+    /// Byte offset (0-based) of the match start within its line.
+    pub column: usize,
+    /// The entire line the match starts on, excluding its line ending.
+    pub line_text: &'viewee str,
+}
+
+/// Collects all matches found in the scopes of `builder`, built over `source`.
+///
+/// Adjacent parts in scope are merged into a single match.
+pub(super) fn matches<'viewee>(
+    source: &'viewee str,
+    builder: ScopedViewBuilder<'viewee>,
+) -> Vec<Match<'viewee>> {
+    builder
+        .matches()
+        .into_iter()
+        .map(|m| {
+            let line_start = m.range.start - m.column;
+            let line_end = source[line_start..]
+                .find('\n')
+                .map_or(source.len(), |i| line_start + i);
+            let line_text = &source[line_start..line_end];
+
+            Match {
+                range: m.range,
+                line: m.line,
+                line_start,
+                column: m.column,
+                line_text: line_text.strip_suffix('\r').unwrap_or(line_text),
+            }
+        })
+        .collect()
+}
+
+/// Statistics on reported inputs, as found in ripgrep's JSON output.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub(super) struct Stats {
+    /// Not restored from cache entries: no time is spent on those.
+    #[serde(skip_deserializing)]
+    elapsed: Elapsed,
+    searches: u64,
+    searches_with_match: u64,
+    bytes_searched: u64,
+    bytes_printed: u64,
+    matched_lines: u64,
+    matches: u64,
+}
+
+impl Stats {
+    /// Statistics for searching `source` without finding anything.
+    pub(super) fn unmatched(source: &str) -> Self {
+        Self {
+            searches: 1,
+            bytes_searched: source.len() as u64,
+            ..Default::default()
+        }
+    }
+}
+
+impl AddAssign for Stats {
+    fn add_assign(&mut self, rhs: Self) {
+        self.elapsed.0 += rhs.elapsed.0;
+        self.searches += rhs.searches;
+        self.searches_with_match += rhs.searches_with_match;
+        self.bytes_searched += rhs.bytes_searched;
+        self.bytes_printed += rhs.bytes_printed;
+        self.matched_lines += rhs.matched_lines;
+        self.matches += rhs.matches;
+    }
+}
+
+/// A duration, serialized the way ripgrep does.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+struct Elapsed(Duration);
+
+impl Serialize for Elapsed {
+    fn serialize<S: Serializer>(&self, serializer: S) -> Result<S::Ok, S::Error> {
+        #[derive(Serialize)]
+        struct Repr {
+            secs: u64,
+            nanos: u32,
+            human: String,
+        }
+
+        Repr {
+            secs: self.0.as_secs(),
+            nanos: self.0.subsec_nanos(),
+            human: format!("{:.6}s", self.0.as_secs_f64()),
+        }
+        .serialize(serializer)
+    }
+}
+
+/// A single message of ripgrep's JSON Lines output.
+///
+/// See <https://docs.rs/grep-printer/latest/grep_printer/struct.JSON.html> for the
+/// format.
+#[derive(Debug, Serialize)]
+#[serde(tag = "type", content = "data", rename_all = "snake_case")]
+enum Event<'a> {
+    Begin {
+        path: Text<'a>,
+    },
+    Match {
+        path: Text<'a>,
+        lines: Text<'a>,
+        line_number: usize,
+        absolute_offset: usize,
+        submatches: Vec<SubMatch<'a>>,
+    },
+    End {
+        path: Text<'a>,
+        binary_offset: Option<u64>,
+        stats: Stats,
+    },
+    Summary {
+        elapsed_total: Elapsed,
+        stats: Stats,
+    },
+}
+
+/// Arbitrary data; always valid UTF-8 here, so never needs ripgrep's `bytes`
+/// (base64) alternative.
+#[derive(Debug, Serialize)]
+struct Text<'a> {
+    text: &'a str,
+}
+
+#[derive(Debug, Serialize)]
+struct SubMatch<'a> {
+    #[serde(rename = "match")]
+    text: Text<'a>,
+    /// Byte offset relative to the start of the containing `lines`.
+    start: usize,
+    end: usize,
+}
+
+/// Reports all parts of `source` in scope to `destination`, in the given `format`.
+///
+/// `name` identifies the source (such as a file path) in the report. Only matches
+/// `within` the given byte ranges, and of those only the ones at `occurrences`, are
+/// reported, if given.
+#[allow(clippy::too_many_arguments)]
+pub(super) fn write(
+    source: &str,
+    name: &str,
+    destination: &mut impl io::Write,
+    language_scoper: Option<&Box<dyn Scoper>>,
+    scopers: &[Box<dyn Scoper>],
+    format: OutputFormat,
+    actions: &[Box<dyn Action>],
+    squeeze: bool,
+    within: Option<&[Range<usize>]>,
+    occurrences: Option<Range<usize>>,
+) -> io::Result<Stats> {
+    let start = Instant::now();
+    let mut builder = super::scope(source, language_scoper, scopers);
+    if let Some(within) = within {
+        builder.within(within);
+    }
+    if let Some(occurrences) = occurrences {
+        builder.occurrences(occurrences);
+    }
+    let suggest = (format == OutputFormat::Rdjson).then(|| Suggest {
+        builder: builder.clone(),
+        actions,
+        squeeze,
+    });
+    let matches = matches(source, builder);
+
+    // Results of cancelled runs are incomplete, and must not end up anywhere.
+    if let Some(reason) = cancel::current().and_then(|token| token.reason()) {
+        return Err(io::Error::new(io::ErrorKind::Other, reason));
+    }
+
+    match format {
+        OutputFormat::Vimgrep => vimgrep(destination, name, &matches).map(|()| Stats {
+            elapsed: Elapsed(start.elapsed()),
+            searches: 1,
+            bytes_searched: source.len() as u64,
+            ..Default::default()
+        }),
+        OutputFormat::Json => json(destination, name, source, &matches, start),
+        OutputFormat::Rdjson => {
+            let suggest = suggest.expect("Suggestions are prepared for rdjson");
+            rdjson(destination, name, source, &matches, &suggest).map(|()| Stats {
+                elapsed: Elapsed(start.elapsed()),
+                searches: 1,
+                bytes_searched: source.len() as u64,
+                ..Default::default()
+            })
+        }
+    }
+}
+
+/// Where finished reports on single inputs go.
+#[derive(Debug, Default)]
+pub(super) struct Output {
+    /// Reports held back until all inputs are done, for formats which are a single
+    /// document.
+    held: Mutex<Vec<u8>>,
+}
+
+impl Output {
+    /// Writes a finished `report` in some `format` on a single input to stdout, in
+    /// one go so that reports on different inputs do not interleave.
+    ///
+    /// Reports in formats which are a single document are held back for [`finish`]
+    /// instead.
+    pub(super) fn write(&self, format: OutputFormat, report: &[u8]) -> io::Result<()> {
+        match format {
+            OutputFormat::Vimgrep | OutputFormat::Json => io::stdout().lock().write_all(report),
+            OutputFormat::Rdjson => {
+                self.held
+                    .lock()
+                    .expect("No panics while holding lock")
+                    .extend_from_slice(report);
+                Ok(())
+            }
+        }
+    }
+}
+
+/// Bundles a finished `report` in some `format` with its `stats`, for storage in a
+/// [cache](super::cache).
+pub(super) fn to_cache_entry(stats: Stats, report: &[u8]) -> Vec<u8> {
+    let mut entry = serde_json::to_vec(&stats).expect("Stats are always serializable");
+    entry.push(b'\n');
+    entry.extend_from_slice(report);
+
+    entry
+}
+
+/// Inverse of [`to_cache_entry`].
+pub(super) fn from_cache_entry(entry: &[u8]) -> Option<(Stats, &[u8])> {
+    let newline = entry.iter().position(|&b| b == b'\n')?;
+    let (stats, report) = entry.split_at(newline);
+    let stats = serde_json::from_slice(stats).ok()?;
+
+    Some((stats, &report[1..]))
+}
+
+/// Concludes a report on possibly many inputs, with `stats` summed up across all of
+/// them.
+pub(super) fn finish(
+    destination: &mut impl io::Write,
+    format: OutputFormat,
+    stats: Stats,
+    elapsed: Duration,
+    output: Output,
+) -> io::Result<()> {
+    match format {
+        OutputFormat::Vimgrep => Ok(()),
+        OutputFormat::Rdjson => {
+            let held = output
+                .held
+                .into_inner()
+                .expect("No panics while holding lock");
+            let source = serde_json::to_string(&RdjsonSource::default())?;
+
+            // Diagnostics are held one per line.
+            write!(destination, r#"{{"source":{source},"diagnostics":["#)?;
+            for (i, diagnostic) in held
+                .split(|&b| b == b'\n')
+                .filter(|d| !d.is_empty())
+                .enumerate()
+            {
+                if i > 0 {
+                    destination.write_all(b",")?;
+                }
+                destination.write_all(diagnostic)?;
+            }
+            writeln!(destination, "]}}")
+        }
+        OutputFormat::Json => {
+            let summary = Event::Summary {
+                elapsed_total: Elapsed(elapsed),
+                stats,
+            };
+
+            write_event(destination, &summary).map(|_| ())
+        }
+    }
+}
+
+/// Writes `matches` as `file:line:column:text`, which Vim's default `grepformat`
+/// understands, for use in quickfix lists.
+fn vimgrep(destination: &mut impl io::Write, name: &str, matches: &[Match]) -> io::Result<()> {
+    for m in matches {
+        // Vim's columns are 1-based byte offsets.
+        writeln!(
+            destination,
+            "{}:{}:{}:{}",
+            name,
+            m.line,
+            m.column + 1,
+            m.line_text
+        )?;
+    }
+
+    Ok(())
+}
+
+/// Writes `matches` as ripgrep's `--json` would, so tooling built around it can
+/// consume srgn's output unchanged.
+///
+/// As with ripgrep, no `begin` and `end` messages are written if nothing matched.
+/// Matches sharing (some of) their lines are reported in a single `match` message.
+fn json(
+    destination: &mut impl io::Write,
+    name: &str,
+    source: &str,
+    matches: &[Match],
+    start: Instant,
+) -> io::Result<Stats> {
+    let path = || Text { text: name };
+    let mut stats = Stats {
+        searches: 1,
+        bytes_searched: source.len() as u64,
+        ..Default::default()
+    };
+
+    if !matches.is_empty() {
+        stats.searches_with_match = 1;
+        stats.bytes_printed += write_event(destination, &Event::Begin { path: path() })?;
+    }
+
+    let mut remaining = matches;
+    while let Some(first) = remaining.first() {
+        let mut lines = first.line_start..end_of_line(source, &first.range);
+        let n_grouped = remaining
+            .iter()
+            .take_while(|m| {
+                let is_grouped = m.range.start < lines.end;
+                if is_grouped {
+                    lines.end = lines.end.max(end_of_line(source, &m.range));
+                }
+                is_grouped
+            })
+            .count();
+        let (group, rest) = remaining.split_at(n_grouped);
+        remaining = rest;
+
+        let event = Event::Match {
+            path: path(),
+            lines: Text {
+                text: &source[lines.clone()],
+            },
+            line_number: first.line,
+            absolute_offset: lines.start,
+            submatches: group
+                .iter()
+                .map(|m| SubMatch {
+                    text: Text {
+                        text: &source[m.range.clone()],
+                    },
+                    start: m.range.start - lines.start,
+                    end: m.range.end - lines.start,
+                })
+                .collect(),
+        };
+
+        stats.bytes_printed += write_event(destination, &event)?;
+        stats.matched_lines += source[lines].lines().count() as u64;
+        stats.matches += group.len() as u64;
+    }
+
+    stats.elapsed = Elapsed(start.elapsed());
+
+    if !matches.is_empty() {
+        let end = Event::End {
+            path: path(),
+            binary_offset: None,
+            stats,
+        };
+        write_event(destination, &end)?;
+    }
+
+    Ok(stats)
+}
+
+/// What is needed to work out suggested fixes for matches, as would-be results of
+/// applying actions to them.
+struct Suggest<'viewee, 'a> {
+    builder: ScopedViewBuilder<'viewee>,
+    actions: &'a [Box<dyn Action>],
+    squeeze: bool,
+}
+
+impl Suggest<'_, '_> {
+    /// The byte range of `source` to replace, and what with, to apply actions to
+    /// the match at `range` only, or [`None`] if that changes nothing.
+    fn fix(&self, source: &str, range: &Range<usize>) -> Option<(Range<usize>, String)> {
+        if self.actions.is_empty() && !self.squeeze {
+            return None;
+        }
+
+        let mut builder = self.builder.clone();
+        builder.within(std::slice::from_ref(range));
+        let mut view = builder.build();
+        if self.squeeze {
+            view.squeeze();
+        }
+        for action in self.actions {
+            view.map(action);
+        }
+
+        let result = view.to_string();
+        if result == source {
+            return None;
+        }
+
+        let (range, text) = super::lsp::difference(source, &result);
+        Some((range, text.to_owned()))
+    }
+}
+
+/// The tool reporting diagnostics, in reviewdog's format.
+#[derive(Debug, Serialize)]
+struct RdjsonSource {
+    name: &'static str,
+    url: &'static str,
+}
+
+impl Default for RdjsonSource {
+    fn default() -> Self {
+        Self {
+            name: env!("CARGO_PKG_NAME"),
+            url: env!("CARGO_PKG_REPOSITORY"),
+        }
+    }
+}
+
+/// A single diagnostic, in reviewdog's format.
+#[derive(Debug, Serialize)]
+struct RdjsonDiagnostic<'a> {
+    message: String,
+    location: RdjsonLocation<'a>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    suggestions: Vec<RdjsonSuggestion>,
+}
+
+#[derive(Debug, Serialize)]
+struct RdjsonLocation<'a> {
+    path: &'a str,
+    range: RdjsonRange,
+}
+
+#[derive(Debug, Serialize)]
+struct RdjsonSuggestion {
+    range: RdjsonRange,
+    text: String,
+}
+
+#[derive(Debug, Serialize)]
+struct RdjsonRange {
+    start: RdjsonPosition,
+    end: RdjsonPosition,
+}
+
+/// Lines and columns are 1-based, columns counting bytes.
+#[derive(Debug, Serialize)]
+struct RdjsonPosition {
+    line: usize,
+    column: usize,
+}
+
+impl RdjsonRange {
+    fn new(source: &str, range: &Range<usize>) -> Self {
+        let position = |offset: usize| {
+            let before = &source[..offset];
+            let line_start = before.rfind('\n').map_or(0, |i| i + 1);
+
+            RdjsonPosition {
+                line: before.matches('\n').count() + 1,
+                column: offset - line_start + 1,
+            }
+        };
+
+        Self {
+            start: position(range.start),
+            end: position(range.end),
+        }
+    }
+}
+
+/// Writes `matches` as diagnostics of reviewdog's Diagnostic Format, one per line,
+/// to be assembled into a single document in [`finish`].
+///
+/// Each diagnostic comes with the would-be result of applying actions to its match
+/// as a suggested fix, if that changes anything.
+fn rdjson(
+    destination: &mut impl io::Write,
+    name: &str,
+    source: &str,
+    matches: &[Match],
+    suggest: &Suggest,
+) -> io::Result<()> {
+    for m in matches {
+        let diagnostic = RdjsonDiagnostic {
+            message: format!("Found {:?}", &source[m.range.clone()]),
+            location: RdjsonLocation {
+                path: name,
+                range: RdjsonRange::new(source, &m.range),
+            },
+            suggestions: suggest
+                .fix(source, &m.range)
+                .map(|(range, text)| RdjsonSuggestion {
+                    range: RdjsonRange::new(source, &range),
+                    text,
+                })
+                .into_iter()
+                .collect(),
+        };
+
+        serde_json::to_writer(&mut *destination, &diagnostic)?;
+        writeln!(destination)?;
+    }
+
+    Ok(())
+}
+
+/// Byte offset just past the end (including the line ending) of the line `range`
+/// ends on.
+fn end_of_line(source: &str, range: &Range<usize>) -> usize {
+    let last = range.end.saturating_sub(1).max(range.start);
+
+    source[last..]
+        .find('\n')
+        .map_or(source.len(), |i| last + i + 1)
+}
+
+/// Writes a single `event` as one line of JSON, returning the number of bytes
+/// written.
+fn write_event(destination: &mut impl io::Write, event: &Event) -> io::Result<u64> {
+    let mut line = serde_json::to_vec(event)?;
+    line.push(b'\n');
+    destination.write_all(&line)?;
+
+    Ok(line.len() as u64)
+}
@@ -106,6 +106,21 @@ Duebel
 
     #[rstest]
     #[case("**/*.py", "tests/files-option/basic-python/in", ["foo", "baz"].as_slice())]
+    #[case(
+        "*",
+        "tests/files-option/language-detection/in",
+        ["--python", "comments", "foo", "baz"].as_slice()
+    )]
+    #[case(
+        "*",
+        "tests/files-option/language-mapping/in",
+        ["--map-language", "*.pyi=python", "--python", "comments", "foo", "baz"].as_slice()
+    )]
+    #[case(
+        "*",
+        "tests/files-option/multiple-languages/in",
+        ["--python", "comments", "--go", "comments", "foo", "baz"].as_slice()
+    )]
     fn test_cli_files(#[case] glob: &str, #[case] left: PathBuf, #[case] add_args: &[&str]) {
         // Arrange
         let mut cmd = get_cmd();
@@ -137,6 +152,1300 @@ Duebel
         }
     }
 
+    #[test]
+    fn test_cli_run_recipe() {
+        let base = Path::new("tests/recipes/basic");
+        let recipe = base.join("recipe.yaml").canonicalize().unwrap();
+        let left = copy_to_tmp(&base.join("in"));
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(&left);
+        cmd.arg("run").arg(recipe);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "Binary execution itself failed");
+
+        if let Err(e) = compare_directories(left.path().to_owned(), base.join("out")) {
+            panic!("{}", format!("Directory comparison failed: {}.", e));
+        }
+    }
+
+    #[test]
+    fn test_cli_run_recipe_unmet_precondition() {
+        let base = Path::new("tests/recipes/basic");
+        let recipe = base.join("recipe.yaml").canonicalize().unwrap();
+        // The second stage finds no files, so fails. The first stage's results remain.
+        let left = copy_to_tmp(&base.join("in"));
+        std::fs::remove_file(left.path().join("notes.txt")).unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(&left);
+        cmd.arg("run").arg(recipe);
+
+        cmd.assert().failure();
+        assert_eq!(
+            std::fs::read_to_string(left.path().join("module.py")).unwrap(),
+            std::fs::read_to_string(base.join("out/module.py")).unwrap()
+        );
+    }
+
+    #[test]
+    fn test_cli_hook() {
+        let dir = TempDir::new().unwrap();
+        let recipe = r#"
+stages:
+  - files: "*.py"
+    language: python
+    query: comments
+    scope: TODO
+    actions:
+      - replace: DONE
+  - files: "*.txt"
+    actions:
+      - upper
+    preconditions:
+      - files-matched
+"#;
+        std::fs::write(dir.path().join(".srgn.yaml"), recipe).unwrap();
+        std::fs::write(dir.path().join("a.py"), "x = 1  # TODO\n").unwrap();
+        std::fs::write(dir.path().join("b.txt"), "todo\n").unwrap();
+
+        let hook = || {
+            let mut cmd = get_cmd();
+            cmd.current_dir(dir.path()).args(["hook", "a.py"]);
+            cmd
+        };
+
+        // Changing files fails the hook. The second stage is not for any of the given
+        // files, so is skipped instead of failing its precondition.
+        hook().assert().failure();
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.py"), "x = 1  # DONE\n");
+        assert_eq!(read("b.txt"), "todo\n");
+
+        // Nothing left to change.
+        hook().assert().success();
+    }
+
+    #[test]
+    fn test_cli_lsp() {
+        let dir = TempDir::new().unwrap();
+        let recipe = r#"
+stages:
+  - name: Resolve TODOs
+    files: "*.py"
+    language: python
+    query: comments
+    scope: TODO
+    actions:
+      - replace: DONE
+"#;
+        std::fs::write(dir.path().join(".srgn.yaml"), recipe).unwrap();
+        // Resolves symlinks, as the working directory of the server will.
+        let uri = format!(
+            "file://{}/a.py",
+            dir.path().canonicalize().unwrap().display()
+        );
+
+        let messages = [
+            serde_json::json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}),
+            serde_json::json!({"jsonrpc": "2.0", "method": "initialized", "params": {}}),
+            serde_json::json!({"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {
+                "textDocument": {
+                    "uri": uri,
+                    "languageId": "python",
+                    "version": 1,
+                    "text": "x = 'TODO'  # TODO\n# TODO\n",
+                },
+            }}),
+            serde_json::json!({"jsonrpc": "2.0", "id": 2, "method": "textDocument/codeAction", "params": {
+                "textDocument": {"uri": uri},
+                "range": {"start": {"line": 1, "character": 3}, "end": {"line": 1, "character": 3}},
+                "context": {"diagnostics": []},
+            }}),
+            serde_json::json!({"jsonrpc": "2.0", "id": 3, "method": "shutdown"}),
+            serde_json::json!({"jsonrpc": "2.0", "method": "exit"}),
+        ];
+        let stdin: String = messages
+            .iter()
+            .map(|message| {
+                let content = message.to_string();
+                format!("Content-Length: {}\r\n\r\n{}", content.len(), content)
+            })
+            .collect();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path()).arg("lsp").write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let stdout = String::from_utf8(output.stdout).unwrap();
+        let responses: Vec<serde_json::Value> = stdout
+            .split("Content-Length: ")
+            .filter(|part| !part.is_empty())
+            .map(|part| serde_json::from_str(part.split_once("\r\n\r\n").unwrap().1).unwrap())
+            .collect();
+        assert_eq!(responses.len(), 4, "{responses:#?}");
+
+        assert_eq!(responses[0]["id"], 1);
+        assert_eq!(
+            responses[0]["result"]["capabilities"]["codeActionProvider"],
+            true
+        );
+
+        let range = |(line, start): (u32, u32), (end_line, end): (u32, u32)| {
+            serde_json::json!({
+                "start": {"line": line, "character": start},
+                "end": {"line": end_line, "character": end},
+            })
+        };
+        let diagnostics = &responses[1]["params"]["diagnostics"];
+        assert_eq!(responses[1]["method"], "textDocument/publishDiagnostics");
+        assert_eq!(responses[1]["params"]["uri"], uri.as_str());
+        assert_eq!(diagnostics.as_array().unwrap().len(), 2, "{diagnostics:#?}");
+        assert_eq!(diagnostics[0]["range"], range((0, 14), (0, 18)));
+        assert_eq!(diagnostics[1]["range"], range((1, 2), (1, 6)));
+        assert_eq!(
+            diagnostics[1]["message"],
+            "In scope of stage 'Resolve TODOs'"
+        );
+
+        let actions = responses[2]["result"].as_array().unwrap();
+        assert_eq!(responses[2]["id"], 2);
+        assert_eq!(actions.len(), 2, "{actions:#?}");
+        assert_eq!(actions[0]["title"], "Apply stage 'Resolve TODOs'");
+        assert_eq!(
+            actions[0]["edit"]["changes"][uri.as_str()],
+            serde_json::json!([{"range": range((1, 2), (1, 6)), "newText": "DONE"}])
+        );
+        assert_eq!(
+            actions[1]["title"],
+            "Apply stage 'Resolve TODOs' to entire file"
+        );
+        assert_eq!(
+            actions[1]["edit"]["changes"][uri.as_str()],
+            serde_json::json!([{"range": range((0, 14), (1, 6)), "newText": "DONE\n# DONE"}])
+        );
+
+        assert_eq!(
+            responses[3],
+            serde_json::json!({"jsonrpc": "2.0", "id": 3, "result": null})
+        );
+    }
+
+    #[test]
+    fn test_cli_mcp() {
+        let dir = TempDir::new().unwrap();
+        std::fs::write(dir.path().join("a.py"), "x = 'TODO'  # TODO\n").unwrap();
+        std::fs::write(dir.path().join("b.txt"), "TODO\n").unwrap();
+
+        let call = |id: u64, name: &str, arguments: serde_json::Value| {
+            serde_json::json!({"jsonrpc": "2.0", "id": id, "method": "tools/call", "params": {
+                "name": name,
+                "arguments": arguments,
+            }})
+        };
+        let comments = serde_json::json!({
+            "files": "*.py",
+            "language": "python",
+            "query": "comments",
+            "scope": "TODO",
+        });
+        let mut replace = comments.clone();
+        replace["actions"] = serde_json::json!([{"replace": "DONE"}, "lower"]);
+
+        let messages = [
+            serde_json::json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {
+                "protocolVersion": "2024-11-05",
+                "capabilities": {},
+                "clientInfo": {"name": "test", "version": "0.0.0"},
+            }}),
+            serde_json::json!({"jsonrpc": "2.0", "method": "notifications/initialized"}),
+            serde_json::json!({"jsonrpc": "2.0", "id": 2, "method": "tools/list"}),
+            call(3, "search", comments),
+            call(4, "apply", replace),
+            call(
+                5,
+                "apply",
+                serde_json::json!({"files": "*.py", "language": "cobol"}),
+            ),
+            serde_json::json!({"jsonrpc": "2.0", "id": 6, "method": "unknown"}),
+        ];
+        let stdin: String = messages
+            .iter()
+            .map(|message| format!("{message}\n"))
+            .collect();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path()).arg("mcp").write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let responses: Vec<serde_json::Value> = std::str::from_utf8(&output.stdout)
+            .unwrap()
+            .lines()
+            .map(|line| serde_json::from_str(line).unwrap())
+            .collect();
+        // No responses to notifications.
+        assert_eq!(responses.len(), 6, "{responses:#?}");
+        let ids = responses
+            .iter()
+            .map(|r| r["id"].clone())
+            .collect::<Vec<_>>();
+        assert_eq!(ids, [1, 2, 3, 4, 5, 6]);
+
+        assert_eq!(responses[0]["result"]["protocolVersion"], "2024-11-05");
+
+        let tools = responses[1]["result"]["tools"].as_array().unwrap();
+        let names = tools.iter().map(|t| t["name"].clone()).collect::<Vec<_>>();
+        assert_eq!(names, ["search", "apply"]);
+
+        let text = |response: &serde_json::Value| {
+            assert_eq!(response["result"]["content"][0]["type"], "text");
+            let text = response["result"]["content"][0]["text"].as_str().unwrap();
+            (response["result"]["isError"].clone(), text.to_owned())
+        };
+
+        let (is_error, found) = text(&responses[2]);
+        assert_eq!(is_error, false);
+        assert_eq!(
+            serde_json::from_str::<serde_json::Value>(&found).unwrap(),
+            serde_json::json!([{"path": "a.py", "line": 1, "column": 15, "text": "TODO"}])
+        );
+
+        let (is_error, changed) = text(&responses[3]);
+        assert_eq!(is_error, false);
+        assert_eq!(
+            serde_json::from_str::<serde_json::Value>(&changed).unwrap(),
+            serde_json::json!({"changed": ["a.py"]})
+        );
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.py"), "x = 'TODO'  # done\n");
+        assert_eq!(read("b.txt"), "TODO\n");
+
+        // Failing tools are not protocol errors, but results.
+        let (is_error, message) = text(&responses[4]);
+        assert_eq!(is_error, true);
+        assert!(message.contains("cobol"), "{message}");
+
+        assert_eq!(responses[5]["error"]["code"], -32601);
+    }
+
+    #[rstest]
+    #[case(&["b+"], "abc\nxbbx\n", "<stdin>:1:2:abc\n<stdin>:2:2:xbbx\n")]
+    #[case(&["(b)"], "abbc\r\n", "<stdin>:1:2:abbc\n")]
+    #[case(&["z"], "abc\n", "")]
+    #[case(
+        &["--python", "comments", "TODO"],
+        "x = 1  # TODO\n\n# TODO: more\n",
+        "<stdin>:1:10:x = 1  # TODO\n<stdin>:3:3:# TODO: more\n"
+    )]
+    fn test_cli_output_vimgrep(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+
+        cmd.args(["--output", "vimgrep"])
+            .args(args)
+            .write_stdin(stdin);
+
+        cmd.assert().success().stdout(expected.to_owned());
+    }
+
+    #[test]
+    fn test_cli_output_json() {
+        let mut cmd = get_cmd();
+
+        cmd.args(["--output", "json", "b+|y\nz"])
+            .write_stdin("abc\nxbbx\ny\nz\n");
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "Binary execution itself failed");
+
+        // Timings aren't reproducible, drop them.
+        fn strip_elapsed(value: &mut serde_json::Value) {
+            if let Some(object) = value.as_object_mut() {
+                object.remove("elapsed");
+                object.remove("elapsed_total");
+                object.values_mut().for_each(strip_elapsed);
+            }
+        }
+
+        let lines = std::str::from_utf8(&output.stdout)
+            .unwrap()
+            .lines()
+            .collect::<Vec<_>>();
+        // Everything up to and including the last match message.
+        let bytes_printed = lines[..4].iter().map(|l| l.len() + 1).sum::<usize>();
+
+        let messages = lines
+            .into_iter()
+            .map(|line| {
+                let mut value: serde_json::Value = serde_json::from_str(line).unwrap();
+                strip_elapsed(&mut value);
+                value
+            })
+            .collect::<Vec<_>>();
+
+        let path = serde_json::json!({"text": "<stdin>"});
+        let stats = serde_json::json!({
+            "searches": 1,
+            "searches_with_match": 1,
+            "bytes_searched": 13,
+            "bytes_printed": bytes_printed,
+            "matched_lines": 4,
+            "matches": 3,
+        });
+        let expected = vec![
+            serde_json::json!({"type": "begin", "data": {"path": path}}),
+            serde_json::json!({"type": "match", "data": {
+                "path": path,
+                "lines": {"text": "abc\n"},
+                "line_number": 1,
+                "absolute_offset": 0,
+                "submatches": [{"match": {"text": "b"}, "start": 1, "end": 2}],
+            }}),
+            serde_json::json!({"type": "match", "data": {
+                "path": path,
+                "lines": {"text": "xbbx\n"},
+                "line_number": 2,
+                "absolute_offset": 4,
+                "submatches": [{"match": {"text": "bb"}, "start": 1, "end": 3}],
+            }}),
+            serde_json::json!({"type": "match", "data": {
+                "path": path,
+                "lines": {"text": "y\nz\n"},
+                "line_number": 3,
+                "absolute_offset": 9,
+                "submatches": [{"match": {"text": "y\nz"}, "start": 0, "end": 3}],
+            }}),
+            serde_json::json!({"type": "end", "data": {
+                "path": path,
+                "binary_offset": null,
+                "stats": stats,
+            }}),
+            serde_json::json!({"type": "summary", "data": {"stats": stats}}),
+        ];
+
+        assert_eq!(messages, expected);
+    }
+
+    #[rstest]
+    #[case(&["TODO"], "x = 1  # TODO\n", serde_json::json!([{
+        "message": "Found \"TODO\"",
+        "location": {"path": "<stdin>", "range": {
+            "start": {"line": 1, "column": 10},
+            "end": {"line": 1, "column": 14},
+        }},
+    }]))]
+    #[case(&["--python", "comments", "TODO", "DONE"], "x = 'TODO'  # TODO\n", serde_json::json!([{
+        "message": "Found \"TODO\"",
+        "location": {"path": "<stdin>", "range": {
+            "start": {"line": 1, "column": 15},
+            "end": {"line": 1, "column": 19},
+        }},
+        "suggestions": [{"range": {
+            "start": {"line": 1, "column": 15},
+            "end": {"line": 1, "column": 19},
+        }, "text": "DONE"}],
+    }]))]
+    #[case(&["--squeeze", "a+b", "ab"], "ababab\nxab\n", serde_json::json!([
+        {
+            "message": "Found \"ababab\"",
+            "location": {"path": "<stdin>", "range": {
+                "start": {"line": 1, "column": 1},
+                "end": {"line": 1, "column": 7},
+            }},
+            "suggestions": [{"range": {
+                "start": {"line": 1, "column": 3},
+                "end": {"line": 1, "column": 7},
+            }, "text": ""}],
+        },
+        {
+            "message": "Found \"ab\"",
+            "location": {"path": "<stdin>", "range": {
+                "start": {"line": 2, "column": 2},
+                "end": {"line": 2, "column": 4},
+            }},
+        },
+    ]))]
+    #[case(&["z"], "abc\n", serde_json::json!([]))]
+    fn test_cli_output_rdjson(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: serde_json::Value,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(["--output", "rdjson"])
+            .args(args)
+            .write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let report: serde_json::Value = serde_json::from_slice(&output.stdout).unwrap();
+        assert_eq!(
+            report,
+            serde_json::json!({
+                "source": {"name": "srgn", "url": "https://github.com/alexpovel/srgn"},
+                "diagnostics": expected,
+            })
+        );
+    }
+
+    #[test]
+    fn test_cli_output_rdjson_files() {
+        let dir = TempDir::new().unwrap();
+        std::fs::write(dir.path().join("a.txt"), "a\na\n").unwrap();
+        std::fs::write(dir.path().join("b.txt"), "a\n").unwrap();
+        std::fs::write(dir.path().join("c.txt"), "c\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path())
+            .args(["--files", "*.txt", "--output", "rdjson", "a", "b"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        // A single document across all files, in whichever order they were done.
+        let report: serde_json::Value = serde_json::from_slice(&output.stdout).unwrap();
+        let mut paths = report["diagnostics"]
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(|d| d["location"]["path"].as_str().unwrap().to_owned())
+            .collect::<Vec<_>>();
+        paths.sort();
+        assert_eq!(paths, ["a.txt", "a.txt", "b.txt"]);
+
+        // Suggested only, not applied.
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("a.txt")).unwrap(),
+            "a\na\n"
+        );
+    }
+
+    #[rstest]
+    #[case("vimgrep")]
+    #[case("json")]
+    fn test_cli_output_with_actions_fails(#[case] format: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(["--output", format, "a", "b"]).write_stdin("a\n");
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(!output.status.success());
+        let stderr = String::from_utf8(output.stderr).unwrap();
+        assert!(stderr.contains("--output rdjson"), "{stderr}");
+    }
+
+    #[rstest]
+    #[case(&["--memory-limit", "1M", "b", "X"], true)]
+    #[case(&["--memory-limit", "1", "b", "X"], false)]
+    #[case(&["--memory-limit", "1", "--output", "vimgrep", "b"], false)]
+    fn test_cli_memory_limit(#[case] args: &[&str], #[case] success: bool) {
+        let mut cmd = get_cmd();
+        cmd.args(args);
+        cmd.write_stdin("abc\n");
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert_eq!(output.status.success(), success);
+
+        if success {
+            assert_eq!(output.stdout, b"aXc\n");
+        } else {
+            // Nothing half-done is written out.
+            assert!(output.stdout.is_empty());
+            assert!(String::from_utf8_lossy(&output.stderr).contains("memory limit"));
+        }
+    }
+
+    #[rstest]
+    #[case(&["b", "X"], &["a.txt"])]
+    #[case(&["--literal-string", "b", "X"], &["a.txt"])]
+    // No prefilter possible, so all files are processed.
+    #[case(&[r"\w", "X"], &["a.txt", "c.txt"])]
+    fn test_cli_files_prefilter(#[case] args: &[&str], #[case] processed: &[&str]) {
+        let dir = TempDir::new().unwrap();
+        std::fs::write(dir.path().join("a.txt"), "abc\n").unwrap();
+        std::fs::write(dir.path().join("c.txt"), "cde\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt"]);
+        cmd.args(args);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success());
+
+        // Skipped files are not reported as processed...
+        let stdout = String::from_utf8(output.stdout).unwrap();
+        let mut names: Vec<_> = stdout.lines().collect();
+        names.sort_unstable();
+        assert_eq!(names, processed);
+
+        // ...and left alone.
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("c.txt")).unwrap(),
+            if processed.contains(&"c.txt") {
+                "XXX\n"
+            } else {
+                "cde\n"
+            }
+        );
+    }
+
+    #[rstest]
+    // Replacing with the same leaves the file unchanged, so the second run skips it,
+    // listing it all the same.
+    #[case(&["b", "b"], &["a.txt\n", "a.txt\n"])]
+    // Reports are replayed from cache.
+    #[case(&["--output", "vimgrep", "b"], &["a.txt:1:2:abc\n", "a.txt:1:2:abc\n"])]
+    fn test_cli_cache_dir(#[case] args: &[&str], #[case] stdouts: &[&str]) {
+        let dir = TempDir::new().unwrap();
+        std::fs::write(dir.path().join("a.txt"), "abc\n").unwrap();
+        let cache = TempDir::new().unwrap();
+
+        for expected in stdouts {
+            let mut cmd = get_cmd();
+            cmd.current_dir(dir.path());
+            cmd.args(["--files", "*.txt", "--cache-dir"]);
+            cmd.arg(cache.path());
+            cmd.args(args);
+
+            let output = cmd.output().expect("failed to execute binary under test");
+            assert!(output.status.success());
+            assert_eq!(String::from_utf8(output.stdout).unwrap(), *expected);
+        }
+
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("a.txt")).unwrap(),
+            "abc\n"
+        );
+        assert_eq!(std::fs::read_dir(cache.path()).unwrap().count(), 1);
+    }
+
+    #[test]
+    fn test_cli_files_unchanged_are_not_written() {
+        let dir = TempDir::new().unwrap();
+        let past = std::time::SystemTime::UNIX_EPOCH + std::time::Duration::from_secs(1_000);
+        for (name, contents) in [("changed.txt", "abc\n"), ("unchanged.txt", "xyz\n")] {
+            let path = dir.path().join(name);
+            std::fs::write(&path, contents).unwrap();
+            std::fs::File::options()
+                .write(true)
+                .open(&path)
+                .unwrap()
+                .set_modified(past)
+                .unwrap();
+        }
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt", "b", "X"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success());
+
+        let modified = |name: &str| {
+            std::fs::metadata(dir.path().join(name))
+                .unwrap()
+                .modified()
+                .unwrap()
+        };
+        assert_ne!(modified("changed.txt"), past);
+        assert_eq!(modified("unchanged.txt"), past);
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("changed.txt")).unwrap(),
+            "aXc\n"
+        );
+    }
+
+    #[rstest]
+    #[case(&["b", "X"], "abc\nxbbx\r\ny", Some("aXc\nxXXx\r\ny"))]
+    #[case(&["--squeeze", "b"], "abbc\nbb\n", Some("abc\nb\n"))]
+    // Scopes cannot span lines.
+    #[case(&["c\nx", "X"], "abc\nxbbx\n", Some("abc\nxbbx\n"))]
+    #[case(&["--fail-none", "z"], "abc\nxbbx\n", None)]
+    #[case(&["--fail-none", "x"], "abc\nxbbx\n", Some("abc\nxbbx\n"))]
+    #[case(&["--fail-any", "x"], "abc\nxbbx\n", None)]
+    #[case(&["--python", "comments", "b"], "abc\n", None)]
+    // Only a leading BOM is one, later ones are text.
+    #[case(&["-d", "\u{feff}"], "a\n\u{feff}b\n", Some("a\nb\n"))]
+    #[case(&["-d", "\u{feff}"], "\u{feff}a\n\u{feff}b\n", Some("\u{feff}a\nb\n"))]
+    fn test_cli_stream(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: Option<&str>) {
+        let mut cmd = get_cmd();
+        cmd.arg("--stream").args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        match expected {
+            Some(expected) => {
+                assert!(output.status.success());
+                assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+            }
+            None => assert!(!output.status.success()),
+        }
+    }
+
+    #[test]
+    fn test_cli_regex_backtrack_limit() {
+        let dir = TempDir::new().unwrap();
+        let pathological = format!("x{}\n", "ab".repeat(30));
+        std::fs::write(dir.path().join("a.txt"), &pathological).unwrap();
+        std::fs::write(dir.path().join("b.txt"), "xabc\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt", "--regex-backtrack-limit", "100"]);
+        cmd.args(["x(a|b|ab)*(?=c)", "Y"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        // Failing overall, but only the pathological file is skipped.
+        assert!(!output.status.success());
+        assert!(String::from_utf8_lossy(&output.stderr).contains("backtracking"));
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("a.txt")).unwrap(),
+            pathological
+        );
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("b.txt")).unwrap(),
+            "Yc\n"
+        );
+    }
+
+    #[test]
+    fn test_cli_stats() {
+        let dir = TempDir::new().unwrap();
+        std::fs::write(dir.path().join("a.py"), "x = 1  # b\n").unwrap();
+        std::fs::write(dir.path().join("b.txt"), "b\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*", "--stats", "--python", "comments", "b", "X"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success());
+
+        let stderr = String::from_utf8(output.stderr).unwrap();
+        for expected in [
+            "Files walked: 2\n",
+            "Files skipped: 1\n",
+            "Files parsed: 1\n",
+            "Bytes written: 11\n",
+            "Time parsing: ",
+            "Time total: ",
+        ] {
+            assert!(
+                stderr.contains(expected),
+                "Missing {expected:?} in {stderr}"
+            );
+        }
+    }
+
+    #[rstest]
+    #[case(&[r"(\w+) (\w+)", "$2 $1"], "hello world\n", "world hello\n")]
+    #[case(&[r"(?P<first>\w+) (?P<second>\w+)", "${second}_$first"], "a b\n", "b_a\n")]
+    #[case(&[r"\d+", "<$0>"], "a 12 b 3\n", "a <12> b <3>\n")]
+    #[case(&[r"(\d)(x)?", "$1$2."], "1x 2\n", "1x. 2.\n")]
+    #[case(&["b", "$$"], "abc\n", "a$c\n")]
+    #[case(&["b", "$"], "abc\n", "a$c\n")]
+    #[case(&["--python", "comments", "hi", "$0!"], "# hi\nhi\n", "# hi!\nhi\n")]
+    #[case(&["--replace-literal", "b", "$1 ${{ x }} $$"], "abc\n", "a$1 ${{ x }} $$c\n")]
+    #[case(&["--replace-literal", r"(b)", "$1"], "abc\n", "a$1c\n")]
+    fn test_cli_replacement_capture_group_references(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: &str,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["-L", "f(x[0])", "g"], "y = f(x[0]) * 2\n", "y = g * 2\n")]
+    #[case(&["-F", "f(x[0])", "g"], "y = f(x[0]) * 2\n", "y = g * 2\n")]
+    #[case(&["--fixed-strings", ".*", "+"], "a.*b\n", "a+b\n")]
+    fn test_cli_fixed_strings(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["-i", "hello", "bye"], "Hello HELLO hello\n", "bye bye bye\n")]
+    #[case(&["--ignore-case", "a|b", "x"], "AbC\n", "xxC\n")]
+    #[case(&["-i", "-L", "a.", "x"], "A. a. Ab\n", "x x Ab\n")]
+    #[case(&["-i", r"(\w)ELLO", "${1}i"], "hello\n", "hi\n")]
+    #[case(&["hello", "bye"], "Hello hello\n", "Hello bye\n")]
+    fn test_cli_ignore_case(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["--max-count", "1", "a", "b"], "aaa\n", "baa\n")]
+    #[case(&["--max-count", "2", "-d", "a"], "a a a\n", "  a\n")]
+    #[case(&["--max-count", "0", "a", "b"], "aaa\n", "aaa\n")]
+    #[case(&["--max-count", "1", "(a)(b)", "x"], "abab\n", "xab\n")]
+    #[case(&["--max-count", "2", "--stream", "a", "b"], "aa\naa\n", "bb\naa\n")]
+    #[case(&["--python", "comments", "--max-count", "1", "a", "b"], "# aa\n# aa\n", "# ba\n# aa\n")]
+    #[case(&["--python", "comments", "--max-count-per-scope", "1", "a", "b"], "# aa\n# aa\n", "# ba\n# ba\n")]
+    #[case(&["--max-count-per-scope", "1", "a", "b"], "aa\naa\n", "ba\naa\n")]
+    fn test_cli_max_count(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["--occurrence", "2", "a", "b"], "aaa\n", "aba\n")]
+    #[case(&["--occurrence", "2..", "a", "b"], "aaa\n", "abb\n")]
+    #[case(&["--occurrence", "..2", "a", "b"], "aaa\n", "bba\n")]
+    #[case(&["--occurrence", "2..3", "a", "b"], "aaaa\n", "abba\n")]
+    #[case(&["--occurrence", "2..", "--max-count", "1", "a", "b"], "aaa\n", "aba\n")]
+    #[case(&["--occurrence", "2", "--stream", "a", "b"], "a\na\na\n", "a\nb\na\n")]
+    #[case(&["--python", "comments", "--occurrence", "2", "a", "b"], "# aa\n# aa\n", "# ab\n# aa\n")]
+    #[case(&["--python", "comments", "--occurrence-per-scope", "2", "a", "b"], "# aa\n# aa\n", "# ab\n# ab\n")]
+    fn test_cli_occurrence(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(None, Some("DEF F():\n    X = 1\n"))]
+    #[case(Some("merge"), Some("DEF F():\n    X = 1\n"))]
+    #[case(Some("outermost"), Some("DEF F():\n    X = 1\n"))]
+    #[case(Some("innermost"), Some("def f():\n    X = 1\n"))]
+    #[case(Some("error"), None)]
+    fn test_cli_overlaps(#[case] overlaps: Option<&str>, #[case] expected: Option<&str>) {
+        let mut cmd = get_cmd();
+        cmd.args([
+            "--python-query",
+            "(function_definition) @f (assignment) @a",
+            "--upper",
+        ]);
+        if let Some(overlaps) = overlaps {
+            cmd.args(["--overlaps", overlaps]);
+        }
+        cmd.write_stdin("def f():\n    x = 1\n");
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        match expected {
+            Some(expected) => {
+                assert!(output.status.success());
+                assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+            }
+            None => {
+                assert!(!output.status.success());
+                let stderr = String::from_utf8(output.stderr).unwrap();
+                assert!(stderr.contains("overlapping"), "{stderr}");
+            }
+        }
+    }
+
+    #[rstest]
+    #[case("merge")]
+    #[case("innermost")]
+    #[case("outermost")]
+    #[case("error")]
+    fn test_cli_duplicate_captures(#[case] overlaps: &str) {
+        let mut cmd = get_cmd();
+        cmd.args([
+            "--python-query",
+            "(comment) @a (comment) @b",
+            "--overlaps",
+            overlaps,
+            "#",
+            "##",
+        ]);
+        cmd.write_stdin("x = 1  # a\n");
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), "x = 1  ## a\n");
+    }
+
+    #[rstest]
+    #[case(&["^b", "c"], "a\nb\n", "a\nb\n")]
+    #[case(&["--multiline", "^b", "c"], "a\nb\n", "a\nc\n")]
+    #[case(&["--multiline", "a$", "c"], "a\nb\n", "c\nb\n")]
+    #[case(&["a.b", "x"], "a\nb\n", "a\nb\n")]
+    #[case(&["--dotall", "a.b", "x"], "a\nb\n", "x\n")]
+    #[case(&["--multiline", "--dotall", "-i", "^A.B$", "x"], "c\na\nb\n", "c\nx\n")]
+    // Anchors are relative to each part in scope of the language scoper.
+    #[case(&["--python", "strings", "^x", "y"], "x = \"\"\"x\nx\"\"\"\n", "x = \"\"\"x\nx\"\"\"\n")]
+    #[case(&["--python", "strings", "--multiline", "^x", "y"], "x = \"\"\"x\nx\"\"\"\n", "x = \"\"\"x\ny\"\"\"\n")]
+    #[case(&["--python", "comments", "--dotall", "a.*", "b"], "# a\n# a\n", "# b\n# b\n")]
+    // `\A` and `\z` anchor to the part in scope, no matter `--multiline`.
+    #[case(&["--python", "doc-strings", r#"\A""""#, r#""""Note: "#], "def f():\n    \"\"\"Do.\n\n    More.\n    \"\"\"\n", "def f():\n    \"\"\"Note: Do.\n\n    More.\n    \"\"\"\n")]
+    #[case(&["--python", "doc-strings", "--multiline", r#"\s*"""\z"#, r#"""""#], "def f():\n    \"\"\"Do.\n\n    More.\n    \"\"\"\n", "def f():\n    \"\"\"Do.\n\n    More.\"\"\"\n")]
+    #[case(&["--python", "doc-strings", "--multiline", r"\A\S+", "x"], "def f():\n    \"\"\"Do.\n\"\"\"\n", "def f():\n    x\n\"\"\"\n")]
+    fn test_cli_multiline_dotall(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: &str,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["x = 1", "y = 1\\nz = 2"], "def f():\n    x = 1\n", "def f():\n    y = 1\nz = 2\n")]
+    #[case(&["--reindent", "x = 1", "y = 1\\nz = 2"], "def f():\n    x = 1\n", "def f():\n    y = 1\n    z = 2\n")]
+    #[case(&["--reindent", "x = 1", "if a:\\n    z = 2"], "def f():\n    x = 1\n", "def f():\n    if a:\n        z = 2\n")]
+    #[case(&["--reindent", "--python", "comments", ".+", "# a\\n# b"], "def f():\n    # x\n", "def f():\n    # a\n    # b\n")]
+    fn test_cli_reindent(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["--python", "comments", "TODO", "a\\nb"], "def f():\n    # TODO\n", "def f():\n    # a\n    # b\n")]
+    #[case(&["--rust", "doc-comments", "TODO", "a\\n\\nb"], "/// TODO\nfn f() {}\n", "/// a\n///\n/// b\nfn f() {}\n")]
+    #[case(&["--go", "comments", "TODO", "a\\nb"], "/* TODO */\n", "/* a\n * b */\n")]
+    // Not comments only: left alone.
+    #[case(&["--python", "strings", "TODO", "a\\nb"], "x = '# TODO'\n", "x = '# a\nb'\n")]
+    fn test_cli_continues_comments(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: &str,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["--html", "attributes~data-.*", "x", "y"], "<p class=\"x\" data-x=\"x\">x</p>\n", Some("<p class=\"x\" data-y=\"y\">x</p>\n"))]
+    #[case(&["--html", "elements~b|i", "x", "y"], "<p>x<b>x</b><i>x</i></p>\n", Some("<p>x<b>y</b><i>y</i></p>\n"))]
+    #[case(&["--go", "struct-tags~json", "Name", "name"], "type U struct {\n\tName string `json:\"Name\" db:\"Name\"`\n}\n", Some("type U struct {\n\tName string `json:\"name\" db:\"Name\"`\n}\n"))]
+    // Filters have to match entirely.
+    #[case(&["--html", "elements~b", "x", "y"], "<p>x<br>x</p>\n", Some("<p>x<br>x</p>\n"))]
+    #[case(&["--html", "comments~foo", "x", "y"], "<!-- x -->\n", None)]
+    #[case(&["--html", "attributes~(", "x", "y"], "<p class=\"x\"></p>\n", None)]
+    fn test_cli_filtered_premade_query(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: Option<&str>,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert_eq!(output.status.success(), expected.is_some());
+        if let Some(expected) = expected {
+            assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+        }
+    }
+
+    #[rstest]
+    #[case(&["foo", "bar"], "foo\n", Some("bar\n"))]
+    #[case(&["foo", "foofoo"], "foo\n", None)]
+    #[case(&["--upper", "foo"], "foo\n", Some("FOO\n"))]
+    #[case(&["--squeeze", " "], "a   b\n", Some("a b\n"))]
+    // Unchanged input is trivially fine.
+    #[case(&["baz", "foofoo"], "foo\n", Some("foo\n"))]
+    #[case(&["--python", "strings", "a", "aa"], "x = 'a'\n", None)]
+    fn test_cli_verify_idempotent(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: Option<&str>,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.arg("--verify-idempotent").args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        match expected {
+            Some(expected) => {
+                assert!(output.status.success());
+                assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+            }
+            None => {
+                assert!(!output.status.success());
+                assert!(output.stdout.is_empty());
+                let stderr = String::from_utf8(output.stderr).unwrap();
+                assert!(stderr.contains("not idempotent"), "{stderr}");
+            }
+        }
+    }
+
+    #[rstest]
+    #[case(&["--if", "import os", "foo", "bar"], "import os\nfoo\n", "import os\nbar\n")]
+    #[case(&["--if", "import os", "foo", "bar"], "foo\n", "foo\n")]
+    #[case(&["--if", "a", "--if", "b", "foo", "bar"], "a\nfoo\n", "a\nfoo\n")]
+    #[case(&["--if", "a", "--if", "b", "foo", "bar"], "a b\nfoo\n", "a b\nbar\n")]
+    #[case(&["--unless", "import bar", "foo", "bar"], "import bar\nfoo\n", "import bar\nfoo\n")]
+    #[case(&["--unless", "import bar", "foo", "bar"], "foo\n", "bar\n")]
+    // Checked against entire files, not only what is in scope of the language scoper.
+    #[case(&["--python", "strings", "--unless", "import new", "old", "new"], "x = 'old'\n", "x = 'new'\n")]
+    #[case(&["--python", "strings", "--unless", "import new", "old", "new"], "import new\nx = 'old'\n", "import new\nx = 'old'\n")]
+    // Unless checked per scope.
+    #[case(&["--python", "comments", "--guard-per-scope", "--if", "TODO", "fix", "FIX"], "# TODO fix\n# fix\n", "# TODO FIX\n# fix\n")]
+    #[case(&["--python", "comments", "--if", "TODO", "fix", "FIX"], "# TODO fix\n# fix\n", "# TODO FIX\n# FIX\n")]
+    fn test_cli_guards(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["--capture", "1", r#"key = "(\w+)""#, "--upper"], "key = \"value\"\n", Some("key = \"VALUE\"\n"))]
+    #[case(&["--capture", "v", r#"key = "(?P<v>\w+)""#, "x"], "key = \"value\", other = \"value\"\n", Some("key = \"x\", other = \"value\"\n"))]
+    #[case(&["--capture", "2", r"(\w+)=(\d+)?", "[$1]"], "a=1 b=\n", Some("a=[a] b=\n"))]
+    #[case(&["--capture", "2", r"(a)", "x"], "a\n", None)]
+    #[case(&["--capture", "b", r"(?P<a>a)", "x"], "a\n", None)]
+    fn test_cli_capture(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: Option<&str>,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        match expected {
+            Some(expected) => {
+                assert!(output.status.success());
+                assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+            }
+            None => {
+                assert!(!output.status.success());
+                let stderr = String::from_utf8(output.stderr).unwrap();
+                assert!(stderr.contains("No such capture group"), "{stderr}");
+            }
+        }
+    }
+
+    #[rstest]
+    #[case(&["--preserve-case", "-i", "foo", "bar"], "FOO Foo foo fOo\n", "BAR Bar bar bar\n")]
+    #[case(&["--preserve-case", "foo", "bar"], "FOO Foo foo\n", "FOO Foo bar\n")]
+    #[case(&["--preserve-case", "-i", r"(\w+)_id", "${1}_key"], "USER_ID User_id\n", "USER_KEY User_key\n")]
+    fn test_cli_preserve_case(#[case] args: &[&str], #[case] stdin: &str, #[case] expected: &str) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success());
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["--stdin-filepath", "a.py", "--python", "comments", "TODO", "DONE"], "x = 'TODO'  # TODO\n", "x = 'TODO'  # DONE\n")]
+    #[case(&["--stdin-filepath", "a.rs", "--python", "comments", "TODO", "DONE"], "// TODO\n", "// TODO\n")]
+    #[case(&["--stdin-filepath", "a.rs", "--python", "comments", "--rust", "comments", "TODO", "DONE"], "// TODO\n", "// DONE\n")]
+    #[case(&["--stdin-filepath", "a.py", "--output", "vimgrep", "TODO"], "TODO\n", "a.py:1:1:TODO\n")]
+    #[case(&["--range", "2", "TODO", "DONE"], "TODO\nTODO\nTODO\n", "TODO\nDONE\nTODO\n")]
+    #[case(&["--range", "3", "--range", "1..1", "TODO", "DONE"], "TODO\nTODO\nTODO\n", "DONE\nTODO\nDONE\n")]
+    #[case(&["--range", "2..", "TODO", "DONE"], "TODO\nTODO\nTODO", "TODO\nDONE\nDONE")]
+    fn test_cli_stdin_filepath_and_range(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: &str,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+
+        assert!(output.status.success(), "{output:?}");
+        assert_eq!(String::from_utf8(output.stdout).unwrap(), expected);
+    }
+
+    #[rstest]
+    #[case(&["TODO", "DONE"], "TODO\nTODO\n", serde_json::json!([{"start": 0, "end": 9, "text": "DONE\nDONE"}]))]
+    #[case(&["--range", "2", "TODO", "DONE"], "TODO\nTODO\n", serde_json::json!([{"start": 5, "end": 9, "text": "DONE"}]))]
+    #[case(&["--range", "2", "TODO", "TO"], "TODO\nTODO\n", serde_json::json!([{"start": 7, "end": 9, "text": ""}]))]
+    #[case(&["TODO", "TODO"], "TODO\n", serde_json::json!([]))]
+    #[case(&["--stdin-filepath", "a.rs", "--python", "comments", "TODO", "DONE"], "// TODO\n", serde_json::json!([]))]
+    fn test_cli_edits(
+        #[case] args: &[&str],
+        #[case] stdin: &str,
+        #[case] expected: serde_json::Value,
+    ) {
+        let mut cmd = get_cmd();
+        cmd.arg("--edits").args(args).write_stdin(stdin);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let edits: serde_json::Value = serde_json::from_slice(&output.stdout).unwrap();
+        assert_eq!(edits, expected);
+    }
+
+    /// Runs git with `args` in `dir`, asserting success and returning its stdout.
+    fn git(dir: &Path, args: &[&str]) -> String {
+        let mut cmd = std::process::Command::new("git");
+        cmd.current_dir(dir);
+        for config in [
+            "user.name=srgn",
+            "user.email=srgn@example.com",
+            "commit.gpgsign=false",
+            "init.defaultBranch=main",
+        ] {
+            cmd.args(["-c", config]);
+        }
+
+        let output = cmd.args(args).output().expect("failed to run git");
+
+        assert!(output.status.success(), "{output:?}");
+        String::from_utf8(output.stdout).unwrap()
+    }
+
+    /// A git repository with `files` (names and contents) committed.
+    fn git_repo(files: &[(&str, &str)]) -> TempDir {
+        let dir = TempDir::new().unwrap();
+        git(dir.path(), &["init", "--quiet"]);
+        for (name, contents) in files {
+            std::fs::write(dir.path().join(name), contents).unwrap();
+        }
+        git(dir.path(), &["add", "--all"]);
+        git(dir.path(), &["commit", "--quiet", "-m", "Initial"]);
+
+        dir
+    }
+
+    #[rstest]
+    #[case(&["--changed-lines"], "x\n2\nb\n")]
+    #[case(&["--changed-lines=HEAD"], "x\n2\nb\n")]
+    #[case(&["--changed-lines=HEAD~1"], "x\n2\nx\n")]
+    #[case(&["--changed-lines=main..."], "x\n2\nx\n")]
+    fn test_cli_changed_lines(#[case] args: &[&str], #[case] expected: &str) {
+        let dir = git_repo(&[("a.txt", "1\n2\n3\n"), ("b.txt", "b\n")]);
+        git(dir.path(), &["checkout", "--quiet", "-b", "feature"]);
+        std::fs::write(dir.path().join("a.txt"), "1\n2\nb\n").unwrap();
+        git(dir.path(), &["commit", "--quiet", "--all", "-m", "Feature"]);
+        // Uncommitted changes.
+        std::fs::write(dir.path().join("a.txt"), "b\n2\nb\n").unwrap();
+        std::fs::write(dir.path().join("c.txt"), "b\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt"]).args(args).args(["b", "x"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.txt"), expected);
+        // Unchanged since, so left alone.
+        assert_eq!(read("b.txt"), "b\n");
+        // Untracked, so changed entirely.
+        assert_eq!(read("c.txt"), "x\n");
+    }
+
+    #[rstest]
+    #[case(&["--staged"], "x\n2\nx\n")]
+    #[case(&["--staged=files"], "x\n2\nx\n")]
+    #[case(&["--staged=hunks"], "x\n2\nb\n")]
+    fn test_cli_staged(#[case] args: &[&str], #[case] expected: &str) {
+        let dir = git_repo(&[("a.txt", "1\n2\n3\n"), ("b.txt", "b\n")]);
+        std::fs::write(dir.path().join("a.txt"), "b\n2\n3\n").unwrap();
+        git(dir.path(), &["add", "a.txt"]);
+        // Unstaged changes, to staged and unchanged files, and untracked files.
+        std::fs::write(dir.path().join("a.txt"), "b\n2\nb\n").unwrap();
+        std::fs::write(dir.path().join("b.txt"), "b\nb\n").unwrap();
+        std::fs::write(dir.path().join("c.txt"), "b\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt"]).args(args).args(["b", "x"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.txt"), expected);
+        assert_eq!(read("b.txt"), "b\nb\n");
+        assert_eq!(read("c.txt"), "b\n");
+    }
+
+    #[test]
+    fn test_cli_cache_dir_staged() {
+        let dir = git_repo(&[("a.txt", "1\n2\n3\n")]);
+        std::fs::write(dir.path().join("a.txt"), "b\n2\n3\n").unwrap();
+        git(dir.path(), &["add", "a.txt"]);
+        std::fs::write(dir.path().join("a.txt"), "b\n2\nb\n").unwrap();
+        let cache = TempDir::new().unwrap();
+
+        let run = || {
+            let mut cmd = get_cmd();
+            cmd.current_dir(dir.path());
+            cmd.args(["--files", "*.txt", "--staged=hunks", "--cache-dir"]);
+            cmd.arg(cache.path());
+            cmd.args(["--output", "vimgrep", "b"]);
+
+            let output = cmd.output().expect("failed to execute binary under test");
+            assert!(output.status.success(), "{output:?}");
+            String::from_utf8(output.stdout).unwrap()
+        };
+
+        assert_eq!(run(), "a.txt:1:1:b\n");
+        // Contents are left as they are, only what is staged changes.
+        git(dir.path(), &["add", "a.txt"]);
+        assert_eq!(run(), "a.txt:1:1:b\na.txt:3:1:b\n");
+    }
+
+    #[rstest]
+    #[case(&["--blame-author", "New"], "a\nxx\naaa\n")]
+    #[case(&["--blame-author", "old@example"], "x\naa\naaa\n")]
+    #[case(&["--blame-author", "^Not Committed Yet"], "a\naa\nxxx\n")]
+    #[case(&["--blame-since", "2021-01-01"], "a\nxx\nxxx\n")]
+    #[case(&["--blame-since", "2021-01-01", "--blame-author", "New"], "a\nxx\naaa\n")]
+    #[case(&["--blame-since", "2019-01-01"], "x\nxx\nxxx\n")]
+    fn test_cli_blame(#[case] args: &[&str], #[case] expected: &str) {
+        let dir = TempDir::new().unwrap();
+        git(dir.path(), &["init", "--quiet"]);
+        std::fs::write(dir.path().join("a.txt"), "a\na\na\n").unwrap();
+        git(dir.path(), &["add", "a.txt"]);
+        // Committed long ago, by someone else.
+        let output = std::process::Command::new("git")
+            .current_dir(dir.path())
+            .env("GIT_COMMITTER_DATE", "2020-01-01T00:00:00")
+            .env("GIT_COMMITTER_NAME", "Old")
+            .env("GIT_COMMITTER_EMAIL", "old@example.com")
+            .args([
+                "-c",
+                "commit.gpgsign=false",
+                "commit",
+                "--quiet",
+                "-m",
+                "Old",
+            ])
+            .args(["--author", "Old <old@example.com>"])
+            .args(["--date", "2020-01-01T00:00:00"])
+            .output()
+            .unwrap();
+        assert!(output.status.success(), "{output:?}");
+        std::fs::write(dir.path().join("a.txt"), "a\naa\na\n").unwrap();
+        let author = "--author=New <new@example.com>";
+        git(
+            dir.path(),
+            &["commit", "--quiet", "--all", "-m", "New", author],
+        );
+        std::fs::write(dir.path().join("a.txt"), "a\naa\naaa\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt"]).args(args).args(["a", "x"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.txt"), expected);
+    }
+
+    #[rstest]
+    #[case(&[], "x\n", "x\n", "x\n")]
+    #[case(&["--skip-generated"], "x\n", "a\n", "a\n")]
+    fn test_cli_skips_generated(
+        #[case] args: &[&str],
+        #[case] expected: &str,
+        #[case] expected_generated: &str,
+        #[case] expected_vendored: &str,
+    ) {
+        let dir = TempDir::new().unwrap();
+        git(dir.path(), &["init", "--quiet"]);
+        std::fs::write(
+            dir.path().join(".gitattributes"),
+            "gen/** linguist-generated\nvendor.txt linguist-vendored\n",
+        )
+        .unwrap();
+        std::fs::create_dir(dir.path().join("gen")).unwrap();
+        std::fs::write(dir.path().join("a.txt"), "a\n").unwrap();
+        std::fs::write(dir.path().join("gen/a.txt"), "a\n").unwrap();
+        std::fs::write(dir.path().join("vendor.txt"), "a\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "**/*.txt"])
+            .args(args)
+            .args(["a", "x"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.txt"), expected);
+        assert_eq!(read("gen/a.txt"), expected_generated);
+        assert_eq!(read("vendor.txt"), expected_vendored);
+    }
+
+    #[rstest]
+    #[case(&["--since", "HEAD~1"], "a\n", "x\n")]
+    #[case(&["--rev-range", "HEAD~1..HEAD"], "a\n", "x\n")]
+    #[case(&["--rev-range", "HEAD~1"], "x\n", "b\n")]
+    #[case(&["--since", "HEAD"], "a\n", "b\n")]
+    fn test_cli_rev_range(#[case] args: &[&str], #[case] a: &str, #[case] b: &str) {
+        let dir = git_repo(&[("a.txt", "a\n")]);
+        std::fs::write(dir.path().join("b.txt"), "a\n").unwrap();
+        git(dir.path(), &["add", "b.txt"]);
+        git(dir.path(), &["commit", "--quiet", "-m", "Second"]);
+        // Uncommitted changes do not count.
+        std::fs::write(dir.path().join("b.txt"), "b\n").unwrap();
+
+        let mut cmd = get_cmd();
+        cmd.current_dir(dir.path());
+        cmd.args(["--files", "*.txt"])
+            .args(args)
+            .args(["[ab]", "x"]);
+
+        let output = cmd.output().expect("failed to execute binary under test");
+        assert!(output.status.success(), "{output:?}");
+
+        let read = |name: &str| std::fs::read_to_string(dir.path().join(name)).unwrap();
+        assert_eq!(read("a.txt"), a);
+        assert_eq!(read("b.txt"), b);
+    }
+
     #[test]
     fn test_cli_on_invalid_utf8() {
         let mut cmd = get_cmd();
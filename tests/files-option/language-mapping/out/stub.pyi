@@ -0,0 +1 @@
+def f() -> None: ...  # baz
@@ -0,0 +1 @@
+x = "foo"  # baz
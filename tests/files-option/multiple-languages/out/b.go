@@ -0,0 +1,3 @@
+package main
+
+var x = "foo" // baz
@@ -0,0 +1,3 @@
+# __T__
+__T__x=1 # __T__
+echo "# __T__"
@@ -0,0 +1,9 @@
+__T__f() {
+    echo __T__
+}
+
+function __T__g {
+    :
+}
+
+echo __T__
@@ -0,0 +1,4 @@
+cat <<EOF
+__T__ $__T__x
+EOF
+echo __T__
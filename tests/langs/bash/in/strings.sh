@@ -0,0 +1,3 @@
+__T__a="__T__ $__T__b"
+__T__c='__T__'
+echo __T__
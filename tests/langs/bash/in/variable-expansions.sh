@@ -0,0 +1,2 @@
+echo $__T__x ${__T__y} "__T__ $__T__z"
+__T__a=1
@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::bash::{Bash, BashQuery, PremadeBashQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.sh", BashQuery::Premade(PremadeBashQuery::Comments))]
+#[case("strings.sh", BashQuery::Premade(PremadeBashQuery::Strings))]
+#[case("heredocs.sh", BashQuery::Premade(PremadeBashQuery::Heredocs))]
+#[case("functions.sh", BashQuery::Premade(PremadeBashQuery::Functions))]
+#[case(
+    "variable-expansions.sh",
+    BashQuery::Premade(PremadeBashQuery::VariableExpansions)
+)]
+fn test_bash_nuke(#[case] file: &str, #[case] query: BashQuery) {
+    let lang = Bash::new(query);
+
+    let (input, output) = get_input_output("bash", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,3 @@
+# 
+__T__x=1 # 
+echo "# __T__"
@@ -0,0 +1,9 @@
+f() {
+    echo 
+}
+
+function g {
+    :
+}
+
+echo __T__
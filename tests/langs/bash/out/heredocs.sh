@@ -0,0 +1,4 @@
+cat <<EOF
+ $x
+EOF
+echo __T__
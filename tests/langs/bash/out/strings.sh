@@ -0,0 +1,3 @@
+__T__a=" $__T__b"
+__T__c=''
+echo __T__
@@ -0,0 +1,2 @@
+echo $x ${y} "__T__ $z"
+__T__a=1
@@ -0,0 +1,2 @@
+; __T__
+(def __T__ "__T__") ;; __T__
@@ -0,0 +1,4 @@
+; __T__
+(defn __T__ [__T__a] (inc __T__a))
+(defn- __T__b [] nil)
+(def __T__c "__T__")
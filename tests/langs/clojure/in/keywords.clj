@@ -0,0 +1,2 @@
+; __T__
+(def __T__ {:__T__a 1 ::__T__b "__T__"})
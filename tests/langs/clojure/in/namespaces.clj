@@ -0,0 +1,5 @@
+; __T__
+(ns __T__.core
+  (:require [__T__.util :as __T__]))
+
+(def __T__ 1)
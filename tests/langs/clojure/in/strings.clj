@@ -0,0 +1,3 @@
+; __T__
+(def __T__ "__T__")
+(println "__T__ again" :__T__)
@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::clojure::{Clojure, ClojureQuery, PremadeClojureQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.clj", ClojureQuery::Premade(PremadeClojureQuery::Comments))]
+#[case("strings.clj", ClojureQuery::Premade(PremadeClojureQuery::Strings))]
+#[case("keywords.clj", ClojureQuery::Premade(PremadeClojureQuery::Keywords))]
+#[case("defns.clj", ClojureQuery::Premade(PremadeClojureQuery::Defns))]
+#[case(
+    "namespaces.clj",
+    ClojureQuery::Premade(PremadeClojureQuery::Namespaces)
+)]
+fn test_clojure_nuke(#[case] file: &str, #[case] query: ClojureQuery) {
+    let lang = Clojure::new(query);
+
+    let (input, output) = get_input_output("clojure", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
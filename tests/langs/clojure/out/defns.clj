@@ -0,0 +1,4 @@
+; __T__
+(defn  [a] (inc a))
+(defn- b [] nil)
+(def __T__c "__T__")
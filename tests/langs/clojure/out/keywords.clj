@@ -0,0 +1,2 @@
+; __T__
+(def __T__ {:a 1 ::b "__T__"})
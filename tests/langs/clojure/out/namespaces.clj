@@ -0,0 +1,5 @@
+; __T__
+(ns .core
+  (:require [.util :as ]))
+
+(def __T__ 1)
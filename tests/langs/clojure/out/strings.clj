@@ -0,0 +1,3 @@
+; __T__
+(def __T__ "")
+(println " again" :__T__)
@@ -0,0 +1,5 @@
+# __T__
+project(__T__)
+target_link_libraries(__T__ PRIVATE __T__b)
+TARGET_LINK_LIBRARIES(__T__ PUBLIC __T__c)
+add_library(__T__ STATIC __T__.c)
@@ -0,0 +1,6 @@
+# __T__
+project(__T__)
+add_library(__T__ STATIC __T__.c)
+if(__T__)
+  message("__T__")
+endif()
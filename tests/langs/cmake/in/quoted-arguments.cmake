@@ -0,0 +1,3 @@
+# __T__
+message(STATUS "__T__ ${__T__}" __T__)
+set(__T__ [[__T__]])
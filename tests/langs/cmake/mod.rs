@@ -0,0 +1,48 @@
+use rstest::rstest;
+use srgn::scoping::langs::{
+    cmake::{CMake, CMakeQuery, PremadeCMakeQuery},
+    Filterable,
+};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.cmake", CMakeQuery::Premade(PremadeCMakeQuery::Comments))]
+#[case("commands.cmake", CMakeQuery::Premade(PremadeCMakeQuery::Commands))]
+#[case(
+    "quoted-arguments.cmake",
+    CMakeQuery::Premade(PremadeCMakeQuery::QuotedArguments)
+)]
+fn test_cmake_nuke(#[case] file: &str, #[case] query: CMakeQuery) {
+    let lang = CMake::new(query);
+
+    let (input, output) = get_input_output("cmake", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+#[case(
+    "commands-filtered.cmake",
+    PremadeCMakeQuery::Commands,
+    "target_link_libraries"
+)]
+fn test_cmake_filtered_nuke(
+    #[case] file: &str,
+    #[case] premade: PremadeCMakeQuery,
+    #[case] pattern: &str,
+) {
+    let source = premade.filtered(pattern).unwrap();
+    let lang = CMake::new(CMakeQuery::Custom(source.parse().unwrap()));
+
+    let (input, output) = get_input_output("cmake", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[test]
+fn test_cmake_filtered_unfilterable() {
+    assert_eq!(PremadeCMakeQuery::Comments.filtered("foo"), None);
+}
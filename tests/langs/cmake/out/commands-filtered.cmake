@@ -0,0 +1,5 @@
+# __T__
+project(__T__)
+target_link_libraries( PRIVATE b)
+TARGET_LINK_LIBRARIES( PUBLIC c)
+add_library(__T__ STATIC __T__.c)
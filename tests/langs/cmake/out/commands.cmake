@@ -0,0 +1,6 @@
+# __T__
+project()
+add_library( STATIC .c)
+if(__T__)
+  message("")
+endif()
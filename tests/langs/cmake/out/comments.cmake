@@ -0,0 +1,2 @@
+# 
+project(__T__) #[[  ]]
@@ -0,0 +1,28 @@
+use rstest::rstest;
+use srgn::scoping::langs::css::{Css, CssQuery, PremadeCssQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.css", CssQuery::Premade(PremadeCssQuery::Comments))]
+#[case("selectors.css", CssQuery::Premade(PremadeCssQuery::Selectors))]
+#[case(
+    "property-names.css",
+    CssQuery::Premade(PremadeCssQuery::PropertyNames)
+)]
+#[case(
+    "property-values.css",
+    CssQuery::Premade(PremadeCssQuery::PropertyValues)
+)]
+#[case(
+    "custom-properties.css",
+    CssQuery::Premade(PremadeCssQuery::CustomProperties)
+)]
+fn test_css_nuke(#[case] file: &str, #[case] query: CssQuery) {
+    let lang = Css::new(query);
+
+    let (input, output) = get_input_output("css", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
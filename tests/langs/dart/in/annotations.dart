@@ -0,0 +1,5 @@
+@__T__override
+void __T__foo() {}
+
+@__T__Deprecated('__T__')
+class __T__Bar {}
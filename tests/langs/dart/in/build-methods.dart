@@ -0,0 +1,8 @@
+class __T__Foo extends __T__StatelessWidget {
+  @__T__override
+  Widget build(BuildContext __T__context) {
+    return __T__Text('__T__');
+  }
+
+  void __T__other() {}
+}
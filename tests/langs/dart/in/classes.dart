@@ -0,0 +1,5 @@
+class __T__Foo extends __T__Bar {
+  int __T__x = 1;
+}
+
+void __T__main() {}
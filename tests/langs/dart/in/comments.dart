@@ -0,0 +1,5 @@
+// __T__
+/// __T__
+class __T__Foo {
+  /* __T__ */
+}
@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::dart::{Dart, DartQuery, PremadeDartQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.dart", DartQuery::Premade(PremadeDartQuery::Comments))]
+#[case("strings.dart", DartQuery::Premade(PremadeDartQuery::Strings))]
+#[case("classes.dart", DartQuery::Premade(PremadeDartQuery::Classes))]
+#[case(
+    "build-methods.dart",
+    DartQuery::Premade(PremadeDartQuery::BuildMethods)
+)]
+#[case("annotations.dart", DartQuery::Premade(PremadeDartQuery::Annotations))]
+fn test_dart_nuke(#[case] file: &str, #[case] query: DartQuery) {
+    let lang = Dart::new(query);
+
+    let (input, output) = get_input_output("dart", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
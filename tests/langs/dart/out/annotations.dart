@@ -0,0 +1,5 @@
+@override
+void __T__foo() {}
+
+@Deprecated('')
+class __T__Bar {}
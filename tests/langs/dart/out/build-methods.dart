@@ -0,0 +1,8 @@
+class __T__Foo extends __T__StatelessWidget {
+  @__T__override
+  Widget build(BuildContext context) {
+    return Text('');
+  }
+
+  void __T__other() {}
+}
@@ -0,0 +1,5 @@
+class Foo extends Bar {
+  int x = 1;
+}
+
+void __T__main() {}
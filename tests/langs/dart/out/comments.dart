@@ -0,0 +1,5 @@
+// 
+/// 
+class __T__Foo {
+  /*  */
+}
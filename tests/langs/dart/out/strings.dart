@@ -0,0 +1,2 @@
+var __T__a = ' $__T__b';
+var __T__c = "";
@@ -0,0 +1,49 @@
+use rstest::rstest;
+use srgn::scoping::langs::{
+    dockerfile::{Dockerfile, DockerfileQuery, PremadeDockerfileQuery},
+    LanguageScoper,
+};
+use std::path::Path;
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case(
+    "comments.dockerfile",
+    DockerfileQuery::Premade(PremadeDockerfileQuery::Comments)
+)]
+#[case(
+    "images.dockerfile",
+    DockerfileQuery::Premade(PremadeDockerfileQuery::Images)
+)]
+#[case(
+    "run-commands.dockerfile",
+    DockerfileQuery::Premade(PremadeDockerfileQuery::RunCommands)
+)]
+#[case(
+    "variables.dockerfile",
+    DockerfileQuery::Premade(PremadeDockerfileQuery::Variables)
+)]
+#[case(
+    "labels.dockerfile",
+    DockerfileQuery::Premade(PremadeDockerfileQuery::Labels)
+)]
+fn test_dockerfile_nuke(#[case] file: &str, #[case] query: DockerfileQuery) {
+    let lang = Dockerfile::new(query);
+
+    let (input, output) = get_input_output("dockerfile", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+#[case("Dockerfile", true)]
+#[case("Dockerfile.dev", true)]
+#[case("Containerfile", true)]
+#[case("app.dockerfile", true)]
+#[case("Makefile", false)]
+#[case("dockerfile.txt", false)]
+fn test_dockerfile_is_valid_file(#[case] path: &str, #[case] expected: bool) {
+    assert_eq!(Dockerfile::is_valid_file(Path::new(path), ""), expected);
+}
@@ -0,0 +1,5 @@
+# __T__
+defmodule __T__Foo do
+  # __T__
+  def __T__bar, do: 1 # __T__
+end
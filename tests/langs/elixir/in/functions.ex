@@ -0,0 +1,9 @@
+defmodule __T__Foo do
+  @__T__x 1
+
+  def __T__bar(__T__y) do
+    __T__y
+  end
+
+  defp __T__baz, do: 1
+end
@@ -0,0 +1,6 @@
+defmodule __T__Foo do
+  @moduledoc "__T__"
+  @__T__x 1
+
+  def __T__bar, do: @__T__x
+end
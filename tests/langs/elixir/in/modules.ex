@@ -0,0 +1,5 @@
+defmodule __T__Foo do
+  def __T__bar, do: 1
+end
+
+__T__baz = 1
@@ -0,0 +1,4 @@
+__T__a = "__T__ #{__T__b}"
+__T__c = '__T__'
+__T__d = ~r/__T__/
+__T__e = :__T__atom
@@ -0,0 +1,23 @@
+use rstest::rstest;
+use srgn::scoping::langs::elixir::{Elixir, ElixirQuery, PremadeElixirQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.ex", ElixirQuery::Premade(PremadeElixirQuery::Comments))]
+#[case("strings.ex", ElixirQuery::Premade(PremadeElixirQuery::Strings))]
+#[case("modules.ex", ElixirQuery::Premade(PremadeElixirQuery::Modules))]
+#[case("functions.ex", ElixirQuery::Premade(PremadeElixirQuery::Functions))]
+#[case(
+    "module-attributes.ex",
+    ElixirQuery::Premade(PremadeElixirQuery::ModuleAttributes)
+)]
+#[case("docs.ex", ElixirQuery::Premade(PremadeElixirQuery::Docs))]
+fn test_elixir_nuke(#[case] file: &str, #[case] query: ElixirQuery) {
+    let lang = Elixir::new(query);
+
+    let (input, output) = get_input_output("elixir", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,9 @@
+defmodule __T__Foo do
+  @moduledoc ""
+  @__T__x 1
+
+  @doc """
+  
+  """
+  def __T__bar, do: @__T__x
+end
@@ -0,0 +1,9 @@
+defmodule __T__Foo do
+  @__T__x 1
+
+  def bar(y) do
+    y
+  end
+
+  defp baz, do: 1
+end
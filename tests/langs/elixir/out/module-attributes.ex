@@ -0,0 +1,6 @@
+defmodule __T__Foo do
+  @moduledoc ""
+  @x 1
+
+  def __T__bar, do: @x
+end
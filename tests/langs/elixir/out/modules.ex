@@ -0,0 +1,5 @@
+defmodule Foo do
+  def bar, do: 1
+end
+
+__T__baz = 1
@@ -0,0 +1,3 @@
+% __T__
+-module(__T__).
+__T__(X) -> {__T__a, '__T__ b', "__T__"}.
@@ -0,0 +1,6 @@
+% __T__
+-module(__T__).
+-export([__T__/1]).
+
+__T__(0) -> __T__a;
+__T__(N) when N > 0 -> N * __T__(N - 1).
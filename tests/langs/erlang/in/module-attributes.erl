@@ -0,0 +1,8 @@
+% __T__
+-module(__T__).
+-behaviour(__T__b).
+-export([__T__/0]).
+-__T__c(true).
+
+-spec __T__() -> ok.
+__T__() -> ok.
@@ -0,0 +1,3 @@
+% __T__
+-module(__T__).
+__T__() -> "__T__".
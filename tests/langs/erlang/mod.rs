@@ -0,0 +1,26 @@
+use rstest::rstest;
+use srgn::scoping::langs::erlang::{Erlang, ErlangQuery, PremadeErlangQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.erl", ErlangQuery::Premade(PremadeErlangQuery::Comments))]
+#[case("strings.erl", ErlangQuery::Premade(PremadeErlangQuery::Strings))]
+#[case("atoms.erl", ErlangQuery::Premade(PremadeErlangQuery::Atoms))]
+#[case(
+    "function-clauses.erl",
+    ErlangQuery::Premade(PremadeErlangQuery::FunctionClauses)
+)]
+#[case(
+    "module-attributes.erl",
+    ErlangQuery::Premade(PremadeErlangQuery::ModuleAttributes)
+)]
+#[case("specs.erl", ErlangQuery::Premade(PremadeErlangQuery::Specs))]
+fn test_erlang_nuke(#[case] file: &str, #[case] query: ErlangQuery) {
+    let lang = Erlang::new(query);
+
+    let (input, output) = get_input_output("erlang", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,3 @@
+% __T__
+-module().
+(X) -> {a, ' b', "__T__"}.
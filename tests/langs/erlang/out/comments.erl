@@ -0,0 +1,3 @@
+% 
+-module(__T__).
+__T__() -> "__T__". %% 
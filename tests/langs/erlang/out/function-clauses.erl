@@ -0,0 +1,6 @@
+% __T__
+-module(__T__).
+-export([__T__/1]).
+
+(0) -> a;
+(N) when N > 0 -> N * (N - 1).
@@ -0,0 +1,8 @@
+% __T__
+-module().
+-behaviour(b).
+-export([/0]).
+-c(true).
+
+-spec __T__() -> ok.
+__T__() -> ok.
@@ -0,0 +1,18 @@
+package main
+
+// __T__Testable is implemented by test cases.
+type Testable interface {
+	__T__Run(t *testing.T) error
+	Name() __T__string
+}
+
+type (
+	__T__Setup func()
+	Named interface{ __T__Name() string }
+)
+
+type TestCase struct {
+	__T__name string
+}
+
+func (tc *TestCase) __T__Run(t *testing.T) error { return nil }
@@ -0,0 +1,8 @@
+package main
+
+type User struct {
+	Name string `json:"__T__name" db:"__T__name" validate:"required"`
+	Age  int    `db:"__T__age" json:"__T__age,omitempty"`
+	// __T__
+	ID   string `xml:"__T__id"`
+}
@@ -1,5 +1,9 @@
 use rstest::rstest;
-use srgn::scoping::langs::go::{Go, GoQuery, PremadeGoQuery};
+use srgn::cancel::CancellationToken;
+use srgn::scoping::langs::{
+    go::{CustomGoQuery, Go, GoQuery, PremadeGoQuery},
+    Filterable, NodeScoper, Overlaps,
+};
 
 use super::{get_input_output, nuke_target};
 
@@ -8,6 +12,8 @@ use super::{get_input_output, nuke_target};
 #[case("strings.go", GoQuery::Premade(PremadeGoQuery::Strings))]
 #[case("imports.go", GoQuery::Premade(PremadeGoQuery::Imports))]
 #[case("struct-tags.go", GoQuery::Premade(PremadeGoQuery::StructTags))]
+#[case("interface.go", GoQuery::Premade(PremadeGoQuery::Interface))]
+#[case("receiver.go", GoQuery::Premade(PremadeGoQuery::Receiver))]
 fn test_go_nuke(#[case] file: &str, #[case] query: GoQuery) {
     let lang = Go::new(query);
 
@@ -16,3 +22,72 @@ fn test_go_nuke(#[case] file: &str, #[case] query: GoQuery) {
 
     assert_eq!(result, output);
 }
+
+#[rstest]
+#[case("struct-tags-filtered.go", PremadeGoQuery::StructTags, "json")]
+#[case("interface-filtered.go", PremadeGoQuery::Interface, "Testable")]
+fn test_go_filtered_nuke(
+    #[case] file: &str,
+    #[case] premade: PremadeGoQuery,
+    #[case] pattern: &str,
+) {
+    let source = premade.filtered(pattern).unwrap();
+    let lang = Go::new(GoQuery::Custom(source.parse().unwrap()));
+
+    let (input, output) = get_input_output("go", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+#[case("interface.go", None)]
+#[case("interface-filtered.go", Some("Testable"))]
+fn test_go_interface_overlaps(
+    #[case] file: &str,
+    #[case] pattern: Option<&str>,
+    #[values(
+        Overlaps::Merge,
+        Overlaps::Innermost,
+        Overlaps::Outermost,
+        Overlaps::Error
+    )]
+    overlaps: Overlaps,
+) {
+    let query = match pattern {
+        Some(pattern) => {
+            let source = PremadeGoQuery::Interface.filtered(pattern).unwrap();
+            GoQuery::Custom(source.parse().unwrap())
+        }
+        None => GoQuery::Premade(PremadeGoQuery::Interface),
+    };
+    let lang = Go::new(query).with_overlaps(overlaps);
+
+    let (input, output) = get_input_output("go", file);
+    let result = CancellationToken::new().run(|| nuke_target(&input, &lang));
+
+    assert_eq!(result, Ok(output));
+}
+
+#[test]
+fn test_go_filtered_captures() {
+    let input = "package main\n\ntype User struct {\n\tName string `db:\"name\" json:\"id\"`\n}\n";
+    let source = PremadeGoQuery::StructTags.filtered("json").unwrap();
+    let lang = Go::new(GoQuery::Custom(source.parse().unwrap()));
+
+    let captures = lang.captures(input);
+    let texts = captures
+        .iter()
+        .map(|c| &input[c.range.clone()])
+        .collect::<Vec<_>>();
+
+    assert_eq!(texts, ["id"]);
+    assert_eq!(captures[0].kind, "raw_string_literal");
+}
+
+#[test]
+fn test_go_filtered_invalid_tag_key() {
+    let source = PremadeGoQuery::StructTags.filtered("(").unwrap();
+
+    assert!(source.parse::<CustomGoQuery>().is_err());
+}
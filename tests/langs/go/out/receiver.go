@@ -0,0 +1,16 @@
+package main
+
+type TestCase struct {
+	__T__name string
+}
+
+func (tc *TestCase) Run(__T__t *testing.T) error { return nil }
+
+func (TestCase) Name() string { return "__T__" }
+
+func (tc TestCase) String() string {
+	__T__tc := tc.name
+	return __T__tc
+}
+
+func __T__helper(tc *TestCase) {}
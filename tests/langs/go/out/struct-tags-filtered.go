@@ -0,0 +1,8 @@
+package main
+
+type User struct {
+	Name string `json:"name" db:"__T__name" validate:"required"`
+	Age  int    `db:"__T__age" json:"age,omitempty"`
+	// __T__
+	ID   string `xml:"__T__id"`
+}
@@ -3,4 +3,6 @@ package main
 type User struct {
 	Name     string `json:"name" xml:"nameElement" validate:"required"`
 	Password string `json:"-" xml:""`
+	// __T__ is left alone, as are field names and types.
+	__T__ID  __T__Type `db:"id"`
 }
@@ -0,0 +1,34 @@
+use rstest::rstest;
+use srgn::scoping::langs::graphql::{GraphQL, GraphQLQuery, PremadeGraphQLQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case(
+    "comments.graphql",
+    GraphQLQuery::Premade(PremadeGraphQLQuery::Comments)
+)]
+#[case(
+    "descriptions.graphql",
+    GraphQLQuery::Premade(PremadeGraphQLQuery::Descriptions)
+)]
+#[case(
+    "type-definitions.graphql",
+    GraphQLQuery::Premade(PremadeGraphQLQuery::TypeDefinitions)
+)]
+#[case(
+    "field-definitions.graphql",
+    GraphQLQuery::Premade(PremadeGraphQLQuery::FieldDefinitions)
+)]
+#[case(
+    "directives.graphql",
+    GraphQLQuery::Premade(PremadeGraphQLQuery::Directives)
+)]
+fn test_graphql_nuke(#[case] file: &str, #[case] query: GraphQLQuery) {
+    let lang = GraphQL::new(query);
+
+    let (input, output) = get_input_output("graphql", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,6 @@
+// __T__
+dependencies {
+    implementation '__T__:core:1.0'
+    testImplementation '__T__:test:1.0'
+    implementation("__T__:extra:1.0")
+}
@@ -0,0 +1,4 @@
+// __T__
+def __T__ = 1
+println(__T__)
+implementation '__T__:core:1.0'
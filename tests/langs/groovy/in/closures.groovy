@@ -0,0 +1,3 @@
+// __T__
+def __T__ = { __T__a -> __T__a * 2 }
+[1, 2].each { println __T__ }
@@ -0,0 +1,42 @@
+use rstest::rstest;
+use srgn::scoping::langs::{
+    groovy::{Groovy, GroovyQuery, PremadeGroovyQuery},
+    Filterable,
+};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.groovy", GroovyQuery::Premade(PremadeGroovyQuery::Comments))]
+#[case("strings.groovy", GroovyQuery::Premade(PremadeGroovyQuery::Strings))]
+#[case("closures.groovy", GroovyQuery::Premade(PremadeGroovyQuery::Closures))]
+#[case("calls.groovy", GroovyQuery::Premade(PremadeGroovyQuery::Calls))]
+fn test_groovy_nuke(#[case] file: &str, #[case] query: GroovyQuery) {
+    let lang = Groovy::new(query);
+
+    let (input, output) = get_input_output("groovy", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+#[case("calls-filtered.groovy", PremadeGroovyQuery::Calls, "implementation")]
+fn test_groovy_filtered_nuke(
+    #[case] file: &str,
+    #[case] premade: PremadeGroovyQuery,
+    #[case] pattern: &str,
+) {
+    let source = premade.filtered(pattern).unwrap();
+    let lang = Groovy::new(GroovyQuery::Custom(source.parse().unwrap()));
+
+    let (input, output) = get_input_output("groovy", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[test]
+fn test_groovy_filtered_unfilterable() {
+    assert_eq!(PremadeGroovyQuery::Closures.filtered("foo"), None);
+}
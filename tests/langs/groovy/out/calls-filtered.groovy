@@ -0,0 +1,6 @@
+// __T__
+dependencies {
+    implementation ':core:1.0'
+    testImplementation '__T__:test:1.0'
+    implementation(":extra:1.0")
+}
@@ -0,0 +1,4 @@
+// __T__
+def __T__ = 1
+println()
+implementation ':core:1.0'
@@ -0,0 +1,3 @@
+// __T__
+def __T__ = { a -> a * 2 }
+[1, 2].each { println  }
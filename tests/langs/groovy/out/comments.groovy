@@ -0,0 +1,2 @@
+// 
+def __T__ = "__T__" /*  */
@@ -0,0 +1,3 @@
+// __T__
+def __T__a = ''
+def __T__b = " ${__T__}"
@@ -0,0 +1,5 @@
+-- __T__
+module Main where
+
+{- __T__ -}
+__T__x = 1 -- __T__
@@ -0,0 +1,4 @@
+__T__f :: Int -> Int
+__T__f __T__x = __T__x
+
+__T__y = 1
@@ -0,0 +1,3 @@
+{-# LANGUAGE __T__Foo #-}
+-- __T__
+module Main where
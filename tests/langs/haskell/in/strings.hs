@@ -0,0 +1,2 @@
+__T__x = "__T__"
+__T__y = 'a'
@@ -0,0 +1,2 @@
+__T__f :: Int -> __T__t
+__T__f __T__x = __T__x
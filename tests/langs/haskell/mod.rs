@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::haskell::{Haskell, HaskellQuery, PremadeHaskellQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.hs", HaskellQuery::Premade(PremadeHaskellQuery::Comments))]
+#[case("strings.hs", HaskellQuery::Premade(PremadeHaskellQuery::Strings))]
+#[case(
+    "type-signatures.hs",
+    HaskellQuery::Premade(PremadeHaskellQuery::TypeSignatures)
+)]
+#[case("functions.hs", HaskellQuery::Premade(PremadeHaskellQuery::Functions))]
+#[case("pragmas.hs", HaskellQuery::Premade(PremadeHaskellQuery::Pragmas))]
+fn test_haskell_nuke(#[case] file: &str, #[case] query: HaskellQuery) {
+    let lang = Haskell::new(query);
+
+    let (input, output) = get_input_output("haskell", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
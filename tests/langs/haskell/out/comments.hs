@@ -0,0 +1,5 @@
+-- 
+module Main where
+
+{-  -}
+__T__x = 1 -- 
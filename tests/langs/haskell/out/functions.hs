@@ -0,0 +1,4 @@
+__T__f :: Int -> Int
+f x = x
+
+y = 1
@@ -0,0 +1,3 @@
+{-# LANGUAGE Foo #-}
+-- __T__
+module Main where
@@ -0,0 +1,2 @@
+__T__x = ""
+__T__y = 'a'
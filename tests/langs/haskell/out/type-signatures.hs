@@ -0,0 +1,2 @@
+f :: Int -> t
+__T__f __T__x = __T__x
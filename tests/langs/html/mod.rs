@@ -0,0 +1,72 @@
+use rstest::rstest;
+use srgn::cancel::CancellationToken;
+use srgn::scoping::langs::{
+    html::{Html, HtmlQuery, PremadeHtmlQuery},
+    Filterable, Overlaps,
+};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.html", HtmlQuery::Premade(PremadeHtmlQuery::Comments))]
+#[case("text.html", HtmlQuery::Premade(PremadeHtmlQuery::Text))]
+#[case("attributes.html", HtmlQuery::Premade(PremadeHtmlQuery::Attributes))]
+#[case(
+    "attribute-values.html",
+    HtmlQuery::Premade(PremadeHtmlQuery::AttributeValues)
+)]
+#[case("elements.html", HtmlQuery::Premade(PremadeHtmlQuery::Elements))]
+fn test_html_nuke(#[case] file: &str, #[case] query: HtmlQuery) {
+    let lang = Html::new(query);
+
+    let (input, output) = get_input_output("html", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+#[case("attributes-filtered.html", PremadeHtmlQuery::Attributes, "data-.*")]
+#[case(
+    "attribute-values-filtered.html",
+    PremadeHtmlQuery::AttributeValues,
+    "class"
+)]
+#[case("elements-filtered.html", PremadeHtmlQuery::Elements, "p")]
+fn test_html_filtered_nuke(
+    #[case] file: &str,
+    #[case] premade: PremadeHtmlQuery,
+    #[case] pattern: &str,
+) {
+    let source = premade.filtered(pattern).unwrap();
+    let lang = Html::new(HtmlQuery::Custom(source.parse().unwrap()));
+
+    let (input, output) = get_input_output("html", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+fn test_html_filtered_overlaps(
+    #[values(
+        Overlaps::Merge,
+        Overlaps::Innermost,
+        Overlaps::Outermost,
+        Overlaps::Error
+    )]
+    overlaps: Overlaps,
+) {
+    let source = PremadeHtmlQuery::Elements.filtered("p").unwrap();
+    let lang = Html::new(HtmlQuery::Custom(source.parse().unwrap())).with_overlaps(overlaps);
+
+    let (input, output) = get_input_output("html", "elements-filtered.html");
+    let result = CancellationToken::new().run(|| nuke_target(&input, &lang));
+
+    assert_eq!(result, Ok(output));
+}
+
+#[test]
+fn test_html_filtered_unfilterable() {
+    assert_eq!(PremadeHtmlQuery::Comments.filtered("foo"), None);
+}
@@ -0,0 +1,5 @@
+@__T__Deprecated
+class __T__Foo {
+    @SuppressWarnings("__T__")
+    void __T__bar() {}
+}
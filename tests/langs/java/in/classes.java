@@ -0,0 +1,10 @@
+package __T__pkg;
+
+// __T__
+public class __T__Foo {
+    int __T__x;
+
+    class __T__Bar {}
+}
+
+interface __T__Baz {}
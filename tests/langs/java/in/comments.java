@@ -0,0 +1,5 @@
+// __T__ line
+class Foo {
+    /* __T__ block */
+    int __T__x; /** __T__ doc */
+}
@@ -0,0 +1,7 @@
+package __T__pkg;
+
+import java.__T__util.List;
+import static org.__T__junit.Assert.assertEquals;
+import java.__T__io.*;
+
+class __T__Foo {}
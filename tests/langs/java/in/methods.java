@@ -0,0 +1,9 @@
+class __T__Foo {
+    int __T__x;
+
+    void __T__bar() {
+        int __T__y = 1;
+    }
+
+    __T__Foo() {}
+}
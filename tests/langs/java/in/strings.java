@@ -0,0 +1,4 @@
+class Foo {
+    String __T__s = "__T__";
+    char __T__c = 'a';
+}
@@ -0,0 +1,20 @@
+use rstest::rstest;
+use srgn::scoping::langs::java::{Java, JavaQuery, PremadeJavaQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.java", JavaQuery::Premade(PremadeJavaQuery::Comments))]
+#[case("strings.java", JavaQuery::Premade(PremadeJavaQuery::Strings))]
+#[case("imports.java", JavaQuery::Premade(PremadeJavaQuery::Imports))]
+#[case("classes.java", JavaQuery::Premade(PremadeJavaQuery::Classes))]
+#[case("methods.java", JavaQuery::Premade(PremadeJavaQuery::Methods))]
+#[case("annotations.java", JavaQuery::Premade(PremadeJavaQuery::Annotations))]
+fn test_java_nuke(#[case] file: &str, #[case] query: JavaQuery) {
+    let lang = Java::new(query);
+
+    let (input, output) = get_input_output("java", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,5 @@
+@Deprecated
+class __T__Foo {
+    @SuppressWarnings("")
+    void __T__bar() {}
+}
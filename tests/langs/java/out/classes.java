@@ -0,0 +1,10 @@
+package __T__pkg;
+
+// __T__
+public class Foo {
+    int x;
+
+    class Bar {}
+}
+
+interface __T__Baz {}
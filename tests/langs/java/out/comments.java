@@ -0,0 +1,5 @@
+//  line
+class Foo {
+    /*  block */
+    int __T__x; /**  doc */
+}
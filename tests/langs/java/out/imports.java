@@ -0,0 +1,7 @@
+package __T__pkg;
+
+import java.util.List;
+import static org.junit.Assert.assertEquals;
+import java.io.*;
+
+class __T__Foo {}
@@ -0,0 +1,9 @@
+class __T__Foo {
+    int __T__x;
+
+    void bar() {
+        int y = 1;
+    }
+
+    __T__Foo() {}
+}
@@ -0,0 +1,4 @@
+class Foo {
+    String __T__s = "";
+    char __T__c = 'a';
+}
@@ -0,0 +1,17 @@
+use rstest::rstest;
+use srgn::scoping::langs::json::{Json, JsonQuery, PremadeJsonQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("keys.json", JsonQuery::Premade(PremadeJsonQuery::Keys))]
+#[case("strings.json", JsonQuery::Premade(PremadeJsonQuery::Strings))]
+#[case("comments.jsonc", JsonQuery::Premade(PremadeJsonQuery::Comments))]
+fn test_json_nuke(#[case] file: &str, #[case] query: JsonQuery) {
+    let lang = Json::new(query);
+
+    let (input, output) = get_input_output("json", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
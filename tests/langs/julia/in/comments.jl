@@ -0,0 +1,5 @@
+# __T__
+function __T__f()
+    #= __T__ =#
+    __T__x = 1 # __T__
+end
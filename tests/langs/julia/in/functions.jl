@@ -0,0 +1,5 @@
+function __T__f(__T__x)
+    return __T__x
+end
+
+__T__g = 1
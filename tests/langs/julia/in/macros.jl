@@ -0,0 +1,2 @@
+@__T__time __T__f()
+__T__y = 1
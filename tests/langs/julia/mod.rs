@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::julia::{Julia, JuliaQuery, PremadeJuliaQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.jl", JuliaQuery::Premade(PremadeJuliaQuery::Comments))]
+#[case("docstrings.jl", JuliaQuery::Premade(PremadeJuliaQuery::DocStrings))]
+#[case("strings.jl", JuliaQuery::Premade(PremadeJuliaQuery::Strings))]
+#[case("macros.jl", JuliaQuery::Premade(PremadeJuliaQuery::Macros))]
+#[case("functions.jl", JuliaQuery::Premade(PremadeJuliaQuery::Functions))]
+fn test_julia_nuke(#[case] file: &str, #[case] query: JuliaQuery) {
+    let lang = Julia::new(query);
+
+    let (input, output) = get_input_output("julia", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,5 @@
+# 
+function __T__f()
+    #=  =#
+    __T__x = 1 # 
+end
@@ -0,0 +1,6 @@
+"""
+    
+"""
+function __T__f()
+    __T__x = "__T__"
+end
@@ -0,0 +1,5 @@
+function f(x)
+    return x
+end
+
+__T__g = 1
@@ -0,0 +1,2 @@
+@time f()
+__T__y = 1
@@ -0,0 +1,2 @@
+__T__a = " $__T__b"
+__T__c = 'a'
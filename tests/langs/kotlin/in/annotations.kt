@@ -0,0 +1,5 @@
+@__T__Deprecated("__T__")
+class __T__Foo {
+    @__T__JvmField
+    val __T__x = 1
+}
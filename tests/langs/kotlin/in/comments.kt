@@ -0,0 +1,5 @@
+// __T__ line
+fun __T__foo() {
+    /* __T__ block */
+    val __T__x = 1 // __T__
+}
@@ -0,0 +1,9 @@
+fun __T__top() {}
+
+class __T__Foo {
+    val __T__x = 1
+
+    fun __T__bar(__T__y: Int): Int {
+        return __T__y
+    }
+}
@@ -0,0 +1,25 @@
+use rstest::rstest;
+use srgn::scoping::langs::kotlin::{Kotlin, KotlinQuery, PremadeKotlinQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.kt", KotlinQuery::Premade(PremadeKotlinQuery::Comments))]
+#[case("strings.kt", KotlinQuery::Premade(PremadeKotlinQuery::Strings))]
+#[case("functions.kt", KotlinQuery::Premade(PremadeKotlinQuery::Functions))]
+#[case(
+    "companion-objects.kt",
+    KotlinQuery::Premade(PremadeKotlinQuery::CompanionObjects)
+)]
+#[case(
+    "annotations.kt",
+    KotlinQuery::Premade(PremadeKotlinQuery::Annotations)
+)]
+fn test_kotlin_nuke(#[case] file: &str, #[case] query: KotlinQuery) {
+    let lang = Kotlin::new(query);
+
+    let (input, output) = get_input_output("kotlin", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
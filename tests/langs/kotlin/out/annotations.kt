@@ -0,0 +1,5 @@
+@Deprecated("")
+class __T__Foo {
+    @JvmField
+    val __T__x = 1
+}
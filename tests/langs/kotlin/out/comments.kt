@@ -0,0 +1,5 @@
+//  line
+fun __T__foo() {
+    /*  block */
+    val __T__x = 1 // 
+}
@@ -0,0 +1,7 @@
+class __T__Foo {
+    companion object {
+        const val X = 1
+    }
+
+    fun __T__bar() {}
+}
@@ -0,0 +1,9 @@
+fun top() {}
+
+class __T__Foo {
+    val __T__x = 1
+
+    fun bar(y: Int): Int {
+        return y
+    }
+}
@@ -0,0 +1,4 @@
+fun __T__foo(__T__b: Int, __T__c: Int) {
+    val __T__x = " $__T__b"
+    val __T__y = "${__T__c} "
+}
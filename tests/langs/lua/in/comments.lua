@@ -0,0 +1,4 @@
+-- __T__
+local __T__x = 1 -- __T__
+--[[ __T__
+__T__ ]]
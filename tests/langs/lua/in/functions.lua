@@ -0,0 +1,8 @@
+function __T__foo(__T__x)
+  return __T__x
+end
+
+local function __T__bar() end
+
+local __T__baz = function() return __T__x end
+local __T__y = 1
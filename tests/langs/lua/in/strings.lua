@@ -0,0 +1,3 @@
+local __T__a = "__T__"
+local __T__b = '__T__'
+local __T__c = [[__T__]]
@@ -0,0 +1,2 @@
+local __T__t = { __T__a = 1, [2] = "__T__" }
+local __T__u = 1
@@ -0,0 +1,18 @@
+use rstest::rstest;
+use srgn::scoping::langs::lua::{Lua, LuaQuery, PremadeLuaQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.lua", LuaQuery::Premade(PremadeLuaQuery::Comments))]
+#[case("strings.lua", LuaQuery::Premade(PremadeLuaQuery::Strings))]
+#[case("functions.lua", LuaQuery::Premade(PremadeLuaQuery::Functions))]
+#[case("tables.lua", LuaQuery::Premade(PremadeLuaQuery::Tables))]
+fn test_lua_nuke(#[case] file: &str, #[case] query: LuaQuery) {
+    let lang = Lua::new(query);
+
+    let (input, output) = get_input_output("lua", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
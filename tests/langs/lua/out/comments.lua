@@ -0,0 +1,4 @@
+-- 
+local __T__x = 1 -- 
+--[[ 
+ ]]
@@ -0,0 +1,8 @@
+function foo(x)
+  return x
+end
+
+local function bar() end
+
+local __T__baz = function() return x end
+local __T__y = 1
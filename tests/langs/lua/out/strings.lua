@@ -0,0 +1,3 @@
+local __T__a = ""
+local __T__b = ''
+local __T__c = [[]]
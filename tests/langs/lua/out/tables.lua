@@ -0,0 +1,2 @@
+local __T__t = { a = 1, [2] = "" }
+local __T__u = 1
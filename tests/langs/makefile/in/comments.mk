@@ -0,0 +1,5 @@
+# __T__
+__T__: __T__.c
+	cc __T__.c
+
+# __T__ again
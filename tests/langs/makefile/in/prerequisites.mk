@@ -0,0 +1,3 @@
+# __T__
+__T__: __T__.c __T__.h
+	cc __T__.c
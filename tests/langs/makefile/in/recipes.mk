@@ -0,0 +1,4 @@
+# __T__
+__T__: __T__.c
+	cc -o __T__ __T__.c
+	@echo __T__
@@ -0,0 +1,3 @@
+# __T__
+__T__ __T__b: __T__.c
+	cc __T__.c
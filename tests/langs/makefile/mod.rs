@@ -0,0 +1,25 @@
+use rstest::rstest;
+use srgn::scoping::langs::makefile::{Makefile, MakefileQuery, PremadeMakefileQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.mk", MakefileQuery::Premade(PremadeMakefileQuery::Comments))]
+#[case("targets.mk", MakefileQuery::Premade(PremadeMakefileQuery::Targets))]
+#[case(
+    "prerequisites.mk",
+    MakefileQuery::Premade(PremadeMakefileQuery::Prerequisites)
+)]
+#[case("recipes.mk", MakefileQuery::Premade(PremadeMakefileQuery::Recipes))]
+#[case(
+    "variables.mk",
+    MakefileQuery::Premade(PremadeMakefileQuery::Variables)
+)]
+fn test_makefile_nuke(#[case] file: &str, #[case] query: MakefileQuery) {
+    let lang = Makefile::new(query);
+
+    let (input, output) = get_input_output("makefile", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
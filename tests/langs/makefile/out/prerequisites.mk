@@ -0,0 +1,3 @@
+# __T__
+__T__: .c .h
+	cc __T__.c
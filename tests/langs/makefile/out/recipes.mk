@@ -0,0 +1,4 @@
+# __T__
+__T__: __T__.c
+	cc -o  .c
+	@echo 
@@ -0,0 +1,3 @@
+# __T__
+ b: __T__.c
+	cc __T__.c
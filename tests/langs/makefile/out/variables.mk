@@ -0,0 +1,5 @@
+# __T__
+CC := cc
+FLAGS += -O2
+__T__: __T__.c
+	$(__T__CC) __T__.c
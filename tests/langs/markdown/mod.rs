@@ -0,0 +1,52 @@
+use rstest::rstest;
+use srgn::scoping::langs::{
+    markdown::{Markdown, MarkdownQuery, PremadeMarkdownQuery},
+    Filterable,
+};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case(
+    "code-blocks.md",
+    MarkdownQuery::Premade(PremadeMarkdownQuery::CodeBlocks)
+)]
+#[case(
+    "inline-code.md",
+    MarkdownQuery::Premade(PremadeMarkdownQuery::InlineCode)
+)]
+#[case("links.md", MarkdownQuery::Premade(PremadeMarkdownQuery::Links))]
+#[case("headings.md", MarkdownQuery::Premade(PremadeMarkdownQuery::Headings))]
+fn test_markdown_nuke(#[case] file: &str, #[case] query: MarkdownQuery) {
+    let lang = Markdown::new(query);
+
+    let (input, output) = get_input_output("markdown", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[rstest]
+#[case(
+    "code-blocks-filtered.md",
+    PremadeMarkdownQuery::CodeBlocks,
+    "py|python"
+)]
+fn test_markdown_filtered_nuke(
+    #[case] file: &str,
+    #[case] premade: PremadeMarkdownQuery,
+    #[case] pattern: &str,
+) {
+    let source = premade.filtered(pattern).unwrap();
+    let lang = Markdown::new(MarkdownQuery::Custom(source.parse().unwrap()));
+
+    let (input, output) = get_input_output("markdown", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
+
+#[test]
+fn test_markdown_filtered_unfilterable() {
+    assert_eq!(PremadeMarkdownQuery::InlineCode.filtered("foo"), None);
+}
@@ -1,10 +1,52 @@
+mod bash;
+mod clojure;
+mod cmake;
 mod csharp;
+mod css;
+mod dart;
+mod dockerfile;
+mod elixir;
+mod erlang;
 mod go;
+mod graphql;
+mod groovy;
+mod haskell;
+mod html;
+mod java;
+mod json;
+mod julia;
+mod kotlin;
+mod lua;
+mod makefile;
+mod markdown;
+mod nix;
+mod objc;
+mod ocaml;
+mod perl;
+mod php;
+mod powershell;
+mod proto;
 mod python;
+mod ruby;
 mod rust;
+mod scala;
+mod scss;
+mod solidity;
+mod sql;
+mod svelte;
+mod swift;
+mod toml;
 mod typescript;
+mod vue;
+mod yaml;
+mod zig;
 
-use srgn::scoping::{langs::LanguageScoper, regex::Regex, view::ScopedViewBuilder};
+use srgn::scoping::{
+    langs::{by_name, LanguageScoper, RawQuery, NAMES},
+    regex::Regex,
+    view::ScopedViewBuilder,
+    Scoper,
+};
 use std::{fs::read_to_string, path::Path};
 
 fn get_input_output(lang: &str, file: &str) -> (String, String) {
@@ -36,3 +78,16 @@ fn nuke_target(input: &str, lang: &impl LanguageScoper) -> String {
 
     view.to_string()
 }
+
+/// Grammars are built against some tree-sitter ABI version, which is only checked once
+/// they are loaded. Load them all, so grammar crates incompatible with the tree-sitter
+/// version in use are caught here, not by users.
+#[test]
+fn test_grammars_load() {
+    for name in NAMES {
+        let scoper = by_name(name, RawQuery::Custom("(_) @any"))
+            .unwrap_or_else(|e| panic!("Grammar of {name} does not load: {e}"));
+        // Parsing panics if the grammar is incompatible.
+        scoper.scope("");
+    }
+}
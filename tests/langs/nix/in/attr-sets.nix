@@ -0,0 +1,3 @@
+# __T__
+let __T__ = 1; in
+{ __T__a = __T__; __T__b = rec { __T__c = 2; }; }
@@ -0,0 +1,7 @@
+# __T__
+{
+  a = fetchurl { url = "https://__T__.example/a.tar.gz"; hash = "__T__"; };
+  b = builtins.fetchTarball { url = "https://__T__.example/b.tar.gz"; };
+  c = pkgs.fetchzip { urls = [ "https://__T__.example/c.zip" ]; };
+  d = __T__ { url = "https://__T__.example/d"; };
+}
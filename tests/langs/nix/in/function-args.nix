@@ -0,0 +1,2 @@
+# __T__
+{ __T__, __T__b ? 1, ... }: __T__c: __T__ + __T__c
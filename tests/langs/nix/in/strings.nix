@@ -0,0 +1,7 @@
+# __T__
+{
+  __T__a = "__T__ ${__T__} __T__";
+  __T__b = ''
+    __T__
+  '';
+}
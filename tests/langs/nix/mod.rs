@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::nix::{Nix, NixQuery, PremadeNixQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.nix", NixQuery::Premade(PremadeNixQuery::Comments))]
+#[case("strings.nix", NixQuery::Premade(PremadeNixQuery::Strings))]
+#[case("attr-sets.nix", NixQuery::Premade(PremadeNixQuery::AttrSets))]
+#[case("function-args.nix", NixQuery::Premade(PremadeNixQuery::FunctionArgs))]
+#[case("fetch-urls.nix", NixQuery::Premade(PremadeNixQuery::FetchUrls))]
+fn test_nix_nuke(#[case] file: &str, #[case] query: NixQuery) {
+    let lang = Nix::new(query);
+
+    let (input, output) = get_input_output("nix", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,3 @@
+# __T__
+let __T__ = 1; in
+{ a = ; b = rec { c = 2; }; }
@@ -0,0 +1,7 @@
+# __T__
+{
+  a = fetchurl { url = "https://.example/a.tar.gz"; hash = "__T__"; };
+  b = builtins.fetchTarball { url = "https://.example/b.tar.gz"; };
+  c = pkgs.fetchzip { urls = [ "https://.example/c.zip" ]; };
+  d = __T__ { url = "https://__T__.example/d"; };
+}
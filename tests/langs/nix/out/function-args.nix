@@ -0,0 +1,2 @@
+# __T__
+{ , b ? 1, ... }: c: __T__ + __T__c
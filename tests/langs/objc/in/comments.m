@@ -0,0 +1,4 @@
+// __T__
+@implementation __T__
+/* __T__ */
+@end
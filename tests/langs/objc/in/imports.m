@@ -0,0 +1,6 @@
+// __T__
+#import <__T__/__T__.h>
+#import "__T__.h"
+
+@implementation __T__
+@end
@@ -0,0 +1,11 @@
+// __T__
+@interface __T__ : NSObject
+- (void)__T__;
++ (instancetype)__T__With:(int)__T__;
+@end
+
+@implementation __T__
+- (void)__T__ {
+    __T__();
+}
+@end
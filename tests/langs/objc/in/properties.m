@@ -0,0 +1,5 @@
+// __T__
+@interface __T__ : NSObject
+@property (nonatomic, copy) NSString *__T__;
+- (void)__T__;
+@end
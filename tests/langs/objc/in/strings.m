@@ -0,0 +1,6 @@
+// __T__
+@implementation __T__
+- (NSString *)__T__ {
+    return @"__T__";
+}
+@end
@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::objc::{ObjC, ObjCQuery, PremadeObjCQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.m", ObjCQuery::Premade(PremadeObjCQuery::Comments))]
+#[case("strings.m", ObjCQuery::Premade(PremadeObjCQuery::Strings))]
+#[case("methods.m", ObjCQuery::Premade(PremadeObjCQuery::Methods))]
+#[case("imports.m", ObjCQuery::Premade(PremadeObjCQuery::Imports))]
+#[case("properties.m", ObjCQuery::Premade(PremadeObjCQuery::Properties))]
+fn test_objc_nuke(#[case] file: &str, #[case] query: ObjCQuery) {
+    let lang = ObjC::new(query);
+
+    let (input, output) = get_input_output("objc", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,4 @@
+// 
+@implementation __T__
+/*  */
+@end
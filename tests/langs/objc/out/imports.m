@@ -0,0 +1,6 @@
+// __T__
+#import </.h>
+#import ".h"
+
+@implementation __T__
+@end
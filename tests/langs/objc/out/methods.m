@@ -0,0 +1,11 @@
+// __T__
+@interface __T__ : NSObject
+- (void);
++ (instancetype)With:(int);
+@end
+
+@implementation __T__
+- (void) {
+    ();
+}
+@end
@@ -0,0 +1,5 @@
+// __T__
+@interface __T__ : NSObject
+@property (nonatomic, copy) NSString *;
+- (void)__T__;
+@end
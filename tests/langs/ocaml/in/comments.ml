@@ -0,0 +1,2 @@
+(* __T__ *)
+let __T__x = 1 (* __T__ *)
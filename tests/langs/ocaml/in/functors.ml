@@ -0,0 +1,7 @@
+module __T__F (__T__X : __T__S) = struct
+  let __T__y = 1
+end
+
+module __T__G = functor (__T__X : __T__S) -> struct end
+
+module __T__M = struct end
@@ -0,0 +1,5 @@
+let __T__x = 1
+
+let rec __T__f __T__y = __T__y
+
+type __T__t = int
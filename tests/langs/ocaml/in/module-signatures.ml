@@ -0,0 +1,7 @@
+module type __T__S = sig
+  val __T__x : int
+end
+
+module __T__M = struct
+  let __T__x = 1
+end
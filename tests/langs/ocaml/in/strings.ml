@@ -0,0 +1,3 @@
+let __T__a = "__T__"
+let __T__b = {|__T__|}
+let __T__c = 'a'
@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::ocaml::{OCaml, OCamlQuery, PremadeOCamlQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.ml", OCamlQuery::Premade(PremadeOCamlQuery::Comments))]
+#[case("strings.ml", OCamlQuery::Premade(PremadeOCamlQuery::Strings))]
+#[case("lets.ml", OCamlQuery::Premade(PremadeOCamlQuery::Lets))]
+#[case(
+    "module-signatures.ml",
+    OCamlQuery::Premade(PremadeOCamlQuery::ModuleSignatures)
+)]
+#[case("functors.ml", OCamlQuery::Premade(PremadeOCamlQuery::Functors))]
+fn test_ocaml_nuke(#[case] file: &str, #[case] query: OCamlQuery) {
+    let lang = OCaml::new(query);
+
+    let (input, output) = get_input_output("ocaml", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
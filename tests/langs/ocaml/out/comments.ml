@@ -0,0 +1,2 @@
+(*  *)
+let __T__x = 1 (*  *)
@@ -0,0 +1,7 @@
+module F (X : S) = struct
+  let y = 1
+end
+
+module __T__G = functor (X : S) -> struct end
+
+module __T__M = struct end
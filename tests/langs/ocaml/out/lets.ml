@@ -0,0 +1,5 @@
+let x = 1
+
+let rec f y = y
+
+type __T__t = int
@@ -0,0 +1,3 @@
+let __T__a = ""
+let __T__b = {||}
+let __T__c = 'a'
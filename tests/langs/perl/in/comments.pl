@@ -0,0 +1,2 @@
+# __T__
+my $__T__ = "__T__"; # __T__
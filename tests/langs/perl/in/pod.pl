@@ -0,0 +1,10 @@
+# __T__
+my $__T__ = 1;
+
+=head1 __T__
+
+__T__ docs.
+
+=cut
+
+print $__T__;
@@ -0,0 +1,5 @@
+# __T__
+my $__T__ = "__T__";
+$__T__ =~ /__T__+/;
+$__T__ =~ s/__T__/x/g;
+my $__T__re = qr/__T__/i;
@@ -0,0 +1,6 @@
+# __T__
+my $__T__a = '__T__';
+my $__T__b = "__T__";
+print <<EOT;
+__T__
+EOT
@@ -0,0 +1,7 @@
+# __T__
+sub __T__ {
+    my ($__T__a) = @_;
+    return $__T__a;
+}
+
+__T__(1);
@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::perl::{Perl, PerlQuery, PremadePerlQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.pl", PerlQuery::Premade(PremadePerlQuery::Comments))]
+#[case("strings.pl", PerlQuery::Premade(PremadePerlQuery::Strings))]
+#[case("regexes.pl", PerlQuery::Premade(PremadePerlQuery::Regexes))]
+#[case("pod.pl", PerlQuery::Premade(PremadePerlQuery::Pod))]
+#[case("subroutines.pl", PerlQuery::Premade(PremadePerlQuery::Subroutines))]
+fn test_perl_nuke(#[case] file: &str, #[case] query: PerlQuery) {
+    let lang = Perl::new(query);
+
+    let (input, output) = get_input_output("perl", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
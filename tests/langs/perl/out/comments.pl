@@ -0,0 +1,2 @@
+# 
+my $__T__ = "__T__"; # 
@@ -0,0 +1,10 @@
+# __T__
+my $__T__ = 1;
+
+=head1 
+
+ docs.
+
+=cut
+
+print $__T__;
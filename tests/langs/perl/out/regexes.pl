@@ -0,0 +1,5 @@
+# __T__
+my $__T__ = "__T__";
+$__T__ =~ /+/;
+$__T__ =~ s//x/g;
+my $__T__re = qr//i;
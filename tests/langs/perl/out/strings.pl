@@ -0,0 +1,6 @@
+# __T__
+my $__T__a = '';
+my $__T__b = "";
+print <<EOT;
+
+EOT
@@ -0,0 +1,7 @@
+# __T__
+sub  {
+    my ($a) = @_;
+    return $a;
+}
+
+__T__(1);
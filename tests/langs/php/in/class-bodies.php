@@ -0,0 +1,7 @@
+<?php
+
+class __T__Foo extends __T__Bar {
+    public $__T__x;
+}
+
+function __T__baz() {}
@@ -0,0 +1,8 @@
+<?php
+
+// __T__
+# __T__
+/** __T__ docblock */
+function __T__foo() {
+    /* __T__ */
+}
@@ -0,0 +1,9 @@
+<?php
+
+/**
+ * __T__
+ */
+function __T__foo() {
+    // __T__
+    /* __T__ */
+}
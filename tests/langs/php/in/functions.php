@@ -0,0 +1,9 @@
+<?php
+
+function __T__foo() {
+    $__T__x = 1;
+}
+
+class __T__Bar {
+    public function __T__baz() {}
+}
@@ -0,0 +1,4 @@
+<?php
+
+$__T__a = '__T__';
+$__T__b = "__T__ $__T__c";
@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::php::{Php, PhpQuery, PremadePhpQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.php", PhpQuery::Premade(PremadePhpQuery::Comments))]
+#[case("docblocks.php", PhpQuery::Premade(PremadePhpQuery::DocBlocks))]
+#[case("strings.php", PhpQuery::Premade(PremadePhpQuery::Strings))]
+#[case("functions.php", PhpQuery::Premade(PremadePhpQuery::Functions))]
+#[case("class-bodies.php", PhpQuery::Premade(PremadePhpQuery::ClassBodies))]
+fn test_php_nuke(#[case] file: &str, #[case] query: PhpQuery) {
+    let lang = Php::new(query);
+
+    let (input, output) = get_input_output("php", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
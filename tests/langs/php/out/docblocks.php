@@ -0,0 +1,9 @@
+<?php
+
+/**
+ * 
+ */
+function __T__foo() {
+    // __T__
+    /* __T__ */
+}
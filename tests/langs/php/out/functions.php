@@ -0,0 +1,9 @@
+<?php
+
+function foo() {
+    $x = 1;
+}
+
+class __T__Bar {
+    public function baz() {}
+}
@@ -0,0 +1,4 @@
+<?php
+
+$__T__a = '';
+$__T__b = " $__T__c";
@@ -0,0 +1,5 @@
+function __T__Get-Foo {
+    Write-Output "__T__"
+}
+
+$__T__x = 1
@@ -0,0 +1,2 @@
+$__T__a = "__T__"
+$__T__c = '__T__'
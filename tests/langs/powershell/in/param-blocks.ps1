@@ -0,0 +1,6 @@
+function __T__Get-Foo {
+    param(
+        [string]$__T__Name
+    )
+    Write-Output $__T__Name
+}
@@ -0,0 +1,34 @@
+use rstest::rstest;
+use srgn::scoping::langs::powershell::{PowerShell, PowerShellQuery, PremadePowerShellQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case(
+    "comments.ps1",
+    PowerShellQuery::Premade(PremadePowerShellQuery::Comments)
+)]
+#[case(
+    "expandable-strings.ps1",
+    PowerShellQuery::Premade(PremadePowerShellQuery::ExpandableStrings)
+)]
+#[case(
+    "literal-strings.ps1",
+    PowerShellQuery::Premade(PremadePowerShellQuery::LiteralStrings)
+)]
+#[case(
+    "functions.ps1",
+    PowerShellQuery::Premade(PremadePowerShellQuery::Functions)
+)]
+#[case(
+    "param-blocks.ps1",
+    PowerShellQuery::Premade(PremadePowerShellQuery::ParamBlocks)
+)]
+fn test_powershell_nuke(#[case] file: &str, #[case] query: PowerShellQuery) {
+    let lang = PowerShell::new(query);
+
+    let (input, output) = get_input_output("powershell", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
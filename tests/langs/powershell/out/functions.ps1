@@ -0,0 +1,5 @@
+function Get-Foo {
+    Write-Output ""
+}
+
+$__T__x = 1
@@ -0,0 +1,29 @@
+use rstest::rstest;
+use srgn::scoping::langs::proto::{PremadeProtoQuery, Proto, ProtoQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.proto", ProtoQuery::Premade(PremadeProtoQuery::Comments))]
+#[case(
+    "definitions.proto",
+    ProtoQuery::Premade(PremadeProtoQuery::Definitions)
+)]
+#[case(
+    "field-names.proto",
+    ProtoQuery::Premade(PremadeProtoQuery::FieldNames)
+)]
+#[case(
+    "field-options.proto",
+    ProtoQuery::Premade(PremadeProtoQuery::FieldOptions)
+)]
+#[case("package.proto", ProtoQuery::Premade(PremadeProtoQuery::Package))]
+#[case("imports.proto", ProtoQuery::Premade(PremadeProtoQuery::Imports))]
+fn test_proto_nuke(#[case] file: &str, #[case] query: ProtoQuery) {
+    let lang = Proto::new(query);
+
+    let (input, output) = get_input_output("proto", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
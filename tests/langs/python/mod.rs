@@ -1,5 +1,11 @@
 use rstest::rstest;
-use srgn::scoping::langs::python::{PremadePythonQuery, Python, PythonQuery};
+use srgn::cancel::{CancellationToken, Cancelled};
+use srgn::scoping::langs::{
+    python::{CustomPythonQuery, PremadePythonQuery, Python, PythonQuery},
+    LanguageScoper, Overlaps, TSQuery,
+};
+use srgn::scoping::view::ScopedViewBuilder;
+use std::str::FromStr;
 
 use super::{get_input_output, nuke_target};
 
@@ -25,3 +31,49 @@ fn test_python_nuke(#[case] file: &str, #[case] query: PythonQuery) {
 
     assert_eq!(result, output);
 }
+
+#[rstest]
+#[case(PythonQuery::Premade(PremadePythonQuery::Comments))]
+#[case(PythonQuery::Custom(CustomPythonQuery::from_str("(comment) @comment").unwrap()))]
+fn test_python_query_compiled_once(#[case] query: PythonQuery) {
+    let lang = Python::new(query);
+    // Raw pointers are not `Send`, addresses are.
+    let address = |query: &TSQuery| query as *const TSQuery as usize;
+    let compiled = address(lang.query());
+
+    std::thread::scope(|s| {
+        for _ in 0..4 {
+            s.spawn(|| {
+                assert_eq!(nuke_target("x = 1  # __T__\n", &lang), "x = 1  # \n");
+                assert_eq!(address(lang.query()), compiled);
+            });
+        }
+    });
+}
+
+#[rstest]
+#[case(Overlaps::Merge, Ok(vec!["def f():\n    x = 1\n    y = 2", "z = 3"]))]
+#[case(Overlaps::Outermost, Ok(vec!["def f():\n    x = 1\n    y = 2", "z = 3"]))]
+#[case(Overlaps::Innermost, Ok(vec!["x = 1", "y = 2", "z = 3"]))]
+#[case(Overlaps::Error, Err(Cancelled::OverlappingScopes))]
+fn test_python_overlaps(
+    #[case] overlaps: Overlaps,
+    #[case] expected: Result<Vec<&str>, Cancelled>,
+) {
+    let input = "def f():\n    x = 1\n    y = 2\n\nz = 3\n";
+    let query = CustomPythonQuery::from_str("(function_definition) @f (assignment) @a").unwrap();
+    let lang = Python::new(PythonQuery::Custom(query)).with_overlaps(overlaps);
+
+    let result = CancellationToken::new().run(|| {
+        let mut builder = ScopedViewBuilder::new(input);
+        builder.explode(&lang);
+
+        builder
+            .matches()
+            .into_iter()
+            .map(|m| m.text)
+            .collect::<Vec<_>>()
+    });
+
+    assert_eq!(result, expected);
+}
@@ -0,0 +1,5 @@
+[1].each { |__T__x| puts __T__x }
+[1].map do |__T__y|
+  __T__y
+end
+__T__z = 1
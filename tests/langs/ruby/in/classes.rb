@@ -0,0 +1,7 @@
+class __T__Foo < __T__Base
+  __T__x = 1
+end
+
+module __T__Mod
+  class __T__Bar; end
+end
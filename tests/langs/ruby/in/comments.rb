@@ -0,0 +1,5 @@
+# __T__
+def __T__foo
+  # __T__
+  __T__x = 1 # __T__
+end
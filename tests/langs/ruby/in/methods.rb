@@ -0,0 +1,7 @@
+def __T__foo
+  __T__x = 1
+end
+
+def self.__T__bar; end
+
+__T__y = 2
@@ -0,0 +1,5 @@
+module __T__Mod
+  class __T__Bar; end
+end
+
+class __T__Foo; end
@@ -0,0 +1,7 @@
+__T__a = "__T__ #{__T__b}"
+__T__c = '__T__'
+__T__d = :__T__sym
+__T__e = { __T__key: 1 }
+__T__f = <<~EOS
+  __T__ heredoc
+EOS
@@ -0,0 +1,20 @@
+use rstest::rstest;
+use srgn::scoping::langs::ruby::{PremadeRubyQuery, Ruby, RubyQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.rb", RubyQuery::Premade(PremadeRubyQuery::Comments))]
+#[case("strings.rb", RubyQuery::Premade(PremadeRubyQuery::Strings))]
+#[case("methods.rb", RubyQuery::Premade(PremadeRubyQuery::Methods))]
+#[case("classes.rb", RubyQuery::Premade(PremadeRubyQuery::Classes))]
+#[case("modules.rb", RubyQuery::Premade(PremadeRubyQuery::Modules))]
+#[case("blocks.rb", RubyQuery::Premade(PremadeRubyQuery::Blocks))]
+fn test_ruby_nuke(#[case] file: &str, #[case] query: RubyQuery) {
+    let lang = Ruby::new(query);
+
+    let (input, output) = get_input_output("ruby", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
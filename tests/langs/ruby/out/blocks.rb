@@ -0,0 +1,5 @@
+[1].each { |x| puts x }
+[1].map do |y|
+  y
+end
+__T__z = 1
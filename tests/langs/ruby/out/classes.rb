@@ -0,0 +1,7 @@
+class Foo < Base
+  x = 1
+end
+
+module __T__Mod
+  class Bar; end
+end
@@ -0,0 +1,5 @@
+# 
+def __T__foo
+  # 
+  __T__x = 1 # 
+end
@@ -0,0 +1,7 @@
+def foo
+  x = 1
+end
+
+def self.bar; end
+
+__T__y = 2
@@ -0,0 +1,5 @@
+module Mod
+  class Bar; end
+end
+
+class __T__Foo; end
@@ -0,0 +1,7 @@
+__T__a = " #{__T__b}"
+__T__c = ''
+__T__d = :sym
+__T__e = { __T__key: 1 }
+__T__f = <<~EOS
+   heredoc
+EOS
@@ -0,0 +1,3 @@
+case class __T__Point(__T__x: Int)
+
+class __T__Other(__T__y: Int)
@@ -0,0 +1,5 @@
+// __T__
+object __T__Foo {
+  /* __T__ */
+  val __T__x = 1 // __T__
+}
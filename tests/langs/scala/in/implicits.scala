@@ -0,0 +1,5 @@
+object __T__Foo {
+  implicit val __T__x: Int = 1
+
+  def __T__bar(implicit __T__y: Int): Int = __T__y
+}
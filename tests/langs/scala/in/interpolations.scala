@@ -0,0 +1,3 @@
+object __T__Foo {
+  val __T__b = s"__T__ $__T__a ${__T__a + 1}"
+}
@@ -0,0 +1,5 @@
+object __T__Foo {
+  val __T__x = 1
+}
+
+class __T__Bar
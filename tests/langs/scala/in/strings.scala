@@ -0,0 +1,4 @@
+object __T__Foo {
+  val __T__a = "__T__"
+  val __T__b = s"__T__ $__T__a"
+}
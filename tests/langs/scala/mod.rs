@@ -0,0 +1,26 @@
+use rstest::rstest;
+use srgn::scoping::langs::scala::{PremadeScalaQuery, Scala, ScalaQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.scala", ScalaQuery::Premade(PremadeScalaQuery::Comments))]
+#[case("strings.scala", ScalaQuery::Premade(PremadeScalaQuery::Strings))]
+#[case(
+    "interpolations.scala",
+    ScalaQuery::Premade(PremadeScalaQuery::Interpolations)
+)]
+#[case(
+    "case-classes.scala",
+    ScalaQuery::Premade(PremadeScalaQuery::CaseClasses)
+)]
+#[case("objects.scala", ScalaQuery::Premade(PremadeScalaQuery::Objects))]
+#[case("implicits.scala", ScalaQuery::Premade(PremadeScalaQuery::Implicits))]
+fn test_scala_nuke(#[case] file: &str, #[case] query: ScalaQuery) {
+    let lang = Scala::new(query);
+
+    let (input, output) = get_input_output("scala", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
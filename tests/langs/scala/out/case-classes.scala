@@ -0,0 +1,3 @@
+case class Point(x: Int)
+
+class __T__Other(__T__y: Int)
@@ -0,0 +1,5 @@
+object __T__Foo {
+  implicit val x: Int = 1
+
+  def __T__bar(implicit y: Int): Int = __T__y
+}
@@ -0,0 +1,5 @@
+object Foo {
+  val x = 1
+}
+
+class __T__Bar
@@ -0,0 +1,4 @@
+object __T__Foo {
+  val __T__a = ""
+  val __T__b = s" $__T__a"
+}
@@ -0,0 +1,28 @@
+use rstest::rstest;
+use srgn::scoping::langs::scss::{PremadeScssQuery, Scss, ScssQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.scss", ScssQuery::Premade(PremadeScssQuery::Comments))]
+#[case("selectors.scss", ScssQuery::Premade(PremadeScssQuery::Selectors))]
+#[case(
+    "property-names.scss",
+    ScssQuery::Premade(PremadeScssQuery::PropertyNames)
+)]
+#[case(
+    "property-values.scss",
+    ScssQuery::Premade(PremadeScssQuery::PropertyValues)
+)]
+#[case(
+    "custom-properties.scss",
+    ScssQuery::Premade(PremadeScssQuery::CustomProperties)
+)]
+fn test_scss_nuke(#[case] file: &str, #[case] query: ScssQuery) {
+    let lang = Scss::new(query);
+
+    let (input, output) = get_input_output("scss", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
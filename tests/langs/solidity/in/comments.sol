@@ -0,0 +1,5 @@
+// __T__
+contract __T__ {
+    /// __T__
+    uint __T__ = 1; /* __T__ */
+}
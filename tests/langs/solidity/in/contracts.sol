@@ -0,0 +1,12 @@
+// __T__
+pragma solidity ^0.8.0;
+
+contract __T__ {
+    uint __T__;
+}
+
+interface I__T__ {
+    function __T__() external;
+}
+
+library L__T__ {}
@@ -0,0 +1,8 @@
+// __T__
+contract __T__ {
+    event __T__(address indexed __T__);
+
+    function __T__b() public {
+        emit __T__(msg.sender);
+    }
+}
@@ -0,0 +1,8 @@
+// __T__
+contract __T__ {
+    modifier __T__() {
+        _;
+    }
+
+    function __T__b() public __T__ {}
+}
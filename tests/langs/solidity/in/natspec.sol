@@ -0,0 +1,10 @@
+// __T__
+contract __T__ {
+    /// @notice __T__
+    function __T__() public {}
+
+    /**
+     * @dev __T__
+     */
+    function __T__b() public {} /* __T__ */
+}
@@ -0,0 +1,26 @@
+use rstest::rstest;
+use srgn::scoping::langs::solidity::{PremadeSolidityQuery, Solidity, SolidityQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.sol", SolidityQuery::Premade(PremadeSolidityQuery::Comments))]
+#[case("natspec.sol", SolidityQuery::Premade(PremadeSolidityQuery::Natspec))]
+#[case("strings.sol", SolidityQuery::Premade(PremadeSolidityQuery::Strings))]
+#[case(
+    "contracts.sol",
+    SolidityQuery::Premade(PremadeSolidityQuery::Contracts)
+)]
+#[case(
+    "modifiers.sol",
+    SolidityQuery::Premade(PremadeSolidityQuery::Modifiers)
+)]
+#[case("events.sol", SolidityQuery::Premade(PremadeSolidityQuery::Events))]
+fn test_solidity_nuke(#[case] file: &str, #[case] query: SolidityQuery) {
+    let lang = Solidity::new(query);
+
+    let (input, output) = get_input_output("solidity", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
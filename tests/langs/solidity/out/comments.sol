@@ -0,0 +1,5 @@
+// 
+contract __T__ {
+    /// 
+    uint __T__ = 1; /*  */
+}
@@ -0,0 +1,12 @@
+// __T__
+pragma solidity ^0.8.0;
+
+contract  {
+    uint ;
+}
+
+interface I {
+    function () external;
+}
+
+library L {}
@@ -0,0 +1,8 @@
+// __T__
+contract __T__ {
+    event (address indexed );
+
+    function __T__b() public {
+        emit __T__(msg.sender);
+    }
+}
@@ -0,0 +1,8 @@
+// __T__
+contract __T__ {
+    modifier () {
+        _;
+    }
+
+    function __T__b() public  {}
+}
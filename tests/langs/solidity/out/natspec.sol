@@ -0,0 +1,10 @@
+// __T__
+contract __T__ {
+    /// @notice 
+    function __T__() public {}
+
+    /**
+     * @dev 
+     */
+    function __T__b() public {} /* __T__ */
+}
@@ -0,0 +1,5 @@
+// __T__
+contract __T__ {
+    string __T__ = "";
+    string __T__b = '';
+}
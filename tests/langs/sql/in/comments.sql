@@ -0,0 +1,3 @@
+-- __T__
+SELECT __T__a FROM __T__t; -- __T__
+/* __T__ */
@@ -0,0 +1 @@
+SELECT __T__a, __T__b FROM __T__t WHERE __T__c = 1;
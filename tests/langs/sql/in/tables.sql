@@ -0,0 +1 @@
+SELECT __T__a FROM __T__t JOIN __T__u ON __T__t.__T__id = __T__u.__T__id;
@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::sql::{PremadeSqlQuery, Sql, SqlQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.sql", SqlQuery::Premade(PremadeSqlQuery::Comments))]
+#[case("strings.sql", SqlQuery::Premade(PremadeSqlQuery::Strings))]
+#[case("identifiers.sql", SqlQuery::Premade(PremadeSqlQuery::Identifiers))]
+#[case(
+    "select-columns.sql",
+    SqlQuery::Premade(PremadeSqlQuery::SelectColumns)
+)]
+#[case("tables.sql", SqlQuery::Premade(PremadeSqlQuery::Tables))]
+fn test_sql_nuke(#[case] file: &str, #[case] query: SqlQuery) {
+    let lang = Sql::new(query);
+
+    let (input, output) = get_input_output("sql", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
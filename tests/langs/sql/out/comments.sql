@@ -0,0 +1,3 @@
+-- 
+SELECT __T__a FROM __T__t; -- 
+/*  */
@@ -0,0 +1,2 @@
+-- __T__
+SELECT a FROM t WHERE b = '__T__';
@@ -0,0 +1 @@
+SELECT a, b FROM __T__t WHERE __T__c = 1;
@@ -0,0 +1 @@
+SELECT __T__a FROM __T__t WHERE __T__b = '';
@@ -0,0 +1 @@
+SELECT __T__a FROM t JOIN u ON __T__t.__T__id = __T__u.__T__id;
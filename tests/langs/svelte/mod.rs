@@ -0,0 +1,33 @@
+use rstest::rstest;
+use srgn::scoping::langs::svelte::{PremadeSvelteQuery, Svelte, SvelteQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("markup.svelte", SvelteQuery::Premade(PremadeSvelteQuery::Markup))]
+#[case("script.svelte", SvelteQuery::Premade(PremadeSvelteQuery::Script))]
+#[case("style.svelte", SvelteQuery::Premade(PremadeSvelteQuery::Style))]
+#[case(
+    "expressions.svelte",
+    SvelteQuery::Premade(PremadeSvelteQuery::Expressions)
+)]
+#[case(
+    "script-comments.svelte",
+    SvelteQuery::Premade(PremadeSvelteQuery::ScriptComments)
+)]
+#[case(
+    "script-strings.svelte",
+    SvelteQuery::Premade(PremadeSvelteQuery::ScriptStrings)
+)]
+#[case(
+    "style-comments.svelte",
+    SvelteQuery::Premade(PremadeSvelteQuery::StyleComments)
+)]
+fn test_svelte_nuke(#[case] file: &str, #[case] query: SvelteQuery) {
+    let lang = Svelte::new(query);
+
+    let (input, output) = get_input_output("svelte", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,6 @@
+struct __T__Foo {
+    @__T__State var __T__x = 1
+}
+
+@__T__discardableResult
+func __T__bar() -> Int { 1 }
@@ -0,0 +1,7 @@
+class __T__Foo {
+    let __T__x = 1
+}
+
+struct __T__Bar {}
+
+let __T__y = 2
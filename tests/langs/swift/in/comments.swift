@@ -0,0 +1,5 @@
+// __T__
+func __T__foo() {
+    /* __T__ */
+    let __T__x = 1 // __T__
+}
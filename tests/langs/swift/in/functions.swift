@@ -0,0 +1,9 @@
+func __T__top() {}
+
+class __T__Foo {
+    let __T__x = 1
+
+    func __T__bar(__T__y: Int) -> Int {
+        return __T__y
+    }
+}
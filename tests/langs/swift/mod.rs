@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::swift::{PremadeSwiftQuery, Swift, SwiftQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.swift", SwiftQuery::Premade(PremadeSwiftQuery::Comments))]
+#[case("strings.swift", SwiftQuery::Premade(PremadeSwiftQuery::Strings))]
+#[case("functions.swift", SwiftQuery::Premade(PremadeSwiftQuery::Functions))]
+#[case("classes.swift", SwiftQuery::Premade(PremadeSwiftQuery::Classes))]
+#[case("attributes.swift", SwiftQuery::Premade(PremadeSwiftQuery::Attributes))]
+fn test_swift_nuke(#[case] file: &str, #[case] query: SwiftQuery) {
+    let lang = Swift::new(query);
+
+    let (input, output) = get_input_output("swift", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
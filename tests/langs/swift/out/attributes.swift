@@ -0,0 +1,6 @@
+struct __T__Foo {
+    @State var __T__x = 1
+}
+
+@discardableResult
+func __T__bar() -> Int { 1 }
@@ -0,0 +1,7 @@
+class Foo {
+    let x = 1
+}
+
+struct Bar {}
+
+let __T__y = 2
@@ -0,0 +1,5 @@
+// 
+func __T__foo() {
+    /*  */
+    let __T__x = 1 // 
+}
@@ -0,0 +1,9 @@
+func top() {}
+
+class __T__Foo {
+    let __T__x = 1
+
+    func bar(y: Int) -> Int {
+        return y
+    }
+}
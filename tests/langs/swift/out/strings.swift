@@ -0,0 +1,4 @@
+let __T__a = " \(__T__b)"
+let __T__c = """
+
+"""
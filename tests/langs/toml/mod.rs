@@ -0,0 +1,21 @@
+use rstest::rstest;
+use srgn::scoping::langs::toml::{PremadeTomlQuery, Toml, TomlQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.toml", TomlQuery::Premade(PremadeTomlQuery::Comments))]
+#[case(
+    "table-headers.toml",
+    TomlQuery::Premade(PremadeTomlQuery::TableHeaders)
+)]
+#[case("keys.toml", TomlQuery::Premade(PremadeTomlQuery::Keys))]
+#[case("strings.toml", TomlQuery::Premade(PremadeTomlQuery::Strings))]
+fn test_toml_nuke(#[case] file: &str, #[case] query: TomlQuery) {
+    let lang = Toml::new(query);
+
+    let (input, output) = get_input_output("toml", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
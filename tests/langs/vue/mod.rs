@@ -0,0 +1,29 @@
+use rstest::rstest;
+use srgn::scoping::langs::vue::{PremadeVueQuery, Vue, VueQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("template.vue", VueQuery::Premade(PremadeVueQuery::Template))]
+#[case("script.vue", VueQuery::Premade(PremadeVueQuery::Script))]
+#[case("style.vue", VueQuery::Premade(PremadeVueQuery::Style))]
+#[case(
+    "script-comments.vue",
+    VueQuery::Premade(PremadeVueQuery::ScriptComments)
+)]
+#[case(
+    "script-strings.vue",
+    VueQuery::Premade(PremadeVueQuery::ScriptStrings)
+)]
+#[case(
+    "style-comments.vue",
+    VueQuery::Premade(PremadeVueQuery::StyleComments)
+)]
+fn test_vue_nuke(#[case] file: &str, #[case] query: VueQuery) {
+    let lang = Vue::new(query);
+
+    let (input, output) = get_input_output("vue", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,22 @@
+use rstest::rstest;
+use srgn::scoping::langs::yaml::{PremadeYamlQuery, Yaml, YamlQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.yaml", YamlQuery::Premade(PremadeYamlQuery::Comments))]
+#[case("keys.yaml", YamlQuery::Premade(PremadeYamlQuery::Keys))]
+#[case("values.yaml", YamlQuery::Premade(PremadeYamlQuery::Values))]
+#[case(
+    "block-scalars.yaml",
+    YamlQuery::Premade(PremadeYamlQuery::BlockScalars)
+)]
+#[case("anchors.yaml", YamlQuery::Premade(PremadeYamlQuery::Anchors))]
+fn test_yaml_nuke(#[case] file: &str, #[case] query: YamlQuery) {
+    let lang = Yaml::new(query);
+
+    let (input, output) = get_input_output("yaml", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
@@ -0,0 +1,5 @@
+//! __T__ container doc
+/// __T__ doc
+fn __T__foo() void {
+    // __T__
+}
@@ -0,0 +1,5 @@
+comptime {
+    _ = __T__x;
+}
+
+const __T__x = 1;
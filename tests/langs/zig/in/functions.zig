@@ -0,0 +1,7 @@
+fn __T__foo(__T__x: u8) u8 {
+    return __T__x;
+}
+
+pub fn __T__bar() void {}
+
+const __T__y = 1;
@@ -0,0 +1,4 @@
+const __T__a = "__T__";
+const __T__b =
+    \\__T__
+;
@@ -0,0 +1,5 @@
+fn __T__foo() void {}
+
+test "__T__" {
+    __T__foo();
+}
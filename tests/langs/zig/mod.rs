@@ -0,0 +1,19 @@
+use rstest::rstest;
+use srgn::scoping::langs::zig::{PremadeZigQuery, Zig, ZigQuery};
+
+use super::{get_input_output, nuke_target};
+
+#[rstest]
+#[case("comments.zig", ZigQuery::Premade(PremadeZigQuery::Comments))]
+#[case("strings.zig", ZigQuery::Premade(PremadeZigQuery::Strings))]
+#[case("comptime.zig", ZigQuery::Premade(PremadeZigQuery::Comptime))]
+#[case("tests.zig", ZigQuery::Premade(PremadeZigQuery::Tests))]
+#[case("functions.zig", ZigQuery::Premade(PremadeZigQuery::Functions))]
+fn test_zig_nuke(#[case] file: &str, #[case] query: ZigQuery) {
+    let lang = Zig::new(query);
+
+    let (input, output) = get_input_output("zig", file);
+    let result = nuke_target(&input, &lang);
+
+    assert_eq!(result, output);
+}
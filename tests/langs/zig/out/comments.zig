@@ -0,0 +1,5 @@
+//!  container doc
+///  doc
+fn __T__foo() void {
+    // 
+}
@@ -0,0 +1,5 @@
+comptime {
+    _ = x;
+}
+
+const __T__x = 1;
@@ -0,0 +1,7 @@
+fn foo(x: u8) u8 {
+    return x;
+}
+
+pub fn bar() void {}
+
+const __T__y = 1;
@@ -0,0 +1,4 @@
+const __T__a = "";
+const __T__b =
+    \\
+;
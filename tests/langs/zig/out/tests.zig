@@ -0,0 +1,5 @@
+fn __T__foo() void {}
+
+test "" {
+    foo();
+}
@@ -0,0 +1,2 @@
+# TODO: fix
+x = "TODO"
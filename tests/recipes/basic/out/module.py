@@ -0,0 +1,2 @@
+# DONE: fix
+x = "TODO"